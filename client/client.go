@@ -0,0 +1,232 @@
+// Package client submits deployments to a Deployadactyl server from the command line.
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	C "github.com/compozed/deployadactyl/constants"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Exit codes of a deployment. ExitUsage is left to the command that parses the arguments.
+const (
+	ExitSuccess    = 0
+	ExitFailure    = 1
+	ExitUsage      = 2
+	ExitRolledBack = 3
+)
+
+// Deployment is what the deploy subcommand asks a server to deploy.
+type Deployment struct {
+	ServerURL   string
+	Environment string
+	Org         string
+	Space       string
+	AppName     string
+
+	Username string
+	Password string
+	Token    string
+
+	// ArtifactURL is deployed by the server. Otherwise Path, a directory or a zip file,
+	// is uploaded.
+	ArtifactURL string
+	Path        string
+
+	// Manifest replaces the manifest of the artifact when it is not empty.
+	Manifest             string
+	EnvironmentVariables map[string]string
+	HealthCheckEndpoint  string
+	EmergencyReason      string
+	DryRun               bool
+}
+
+// NewRequest returns the request that deploys a deployment. The response is a stream, so the
+// output can be reported while the deployment runs, or JSON from servers that cannot stream.
+// Either way the result of every foundation can be reported.
+//
+// Returns an error when the artifact cannot be read.
+func NewRequest(deployment Deployment) (*http.Request, error) {
+	if (deployment.ArtifactURL == "") == (deployment.Path == "") {
+		return nil, ArtifactError{}
+	}
+
+	var (
+		body        []byte
+		contentType string
+		err         error
+	)
+
+	if deployment.ArtifactURL != "" {
+		contentType = "application/json"
+		body, err = json.Marshal(S.DeploymentInfo{
+			ArtifactURL:          deployment.ArtifactURL,
+			Manifest:             base64.StdEncoding.EncodeToString([]byte(deployment.Manifest)),
+			EnvironmentVariables: deployment.EnvironmentVariables,
+			HealthCheckEndpoint:  deployment.HealthCheckEndpoint,
+		})
+	} else {
+		contentType = "application/zip"
+		body, err = Zip(deployment.Path, deployment.AppName, deployment.Manifest, deployment.EnvironmentVariables)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	requestURL := fmt.Sprintf("%s/v2/deploy/%s/%s/%s/%s",
+		strings.TrimSuffix(deployment.ServerURL, "/"),
+		url.QueryEscape(deployment.Environment),
+		url.QueryEscape(deployment.Org),
+		url.QueryEscape(deployment.Space),
+		url.QueryEscape(deployment.AppName),
+	)
+	if deployment.DryRun {
+		requestURL += "?dry_run=true"
+	}
+
+	req, err := http.NewRequest("POST", requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", C.StreamContentType+", application/json")
+
+	if deployment.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+deployment.Token)
+	} else if deployment.Username != "" {
+		req.SetBasicAuth(deployment.Username, deployment.Password)
+	}
+
+	if deployment.EmergencyReason != "" {
+		req.Header.Set(C.EmergencyReasonHeader, deployment.EmergencyReason)
+	}
+
+	return req, nil
+}
+
+// ReportResponse prints the result of a deployment from a deploy response with ReportStream
+// when it is streamed and Report otherwise.
+func ReportResponse(resp *http.Response, out io.Writer) int {
+	mediaType := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	if strings.EqualFold(mediaType, C.StreamContentType) {
+		return ReportStream(resp.Body, out)
+	}
+
+	return Report(resp.StatusCode, resp.Body, out)
+}
+
+// ReportStream prints the result of a deployment from the body of a streamed deploy response.
+// The output of the deployment is printed as it arrives, followed by the status of every
+// foundation once the deployment finishes.
+//
+// Returns the exit code of the deployment, the same as Report.
+func ReportStream(body io.Reader, out io.Writer) int {
+	decoder := json.NewDecoder(body)
+	endsLine := true
+
+	for {
+		var line S.DeployStreamLine
+		err := decoder.Decode(&line)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			if !endsLine {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintln(out, ResponseError{http.StatusOK, StreamError{err}})
+			return ExitFailure
+		}
+
+		if line.Result != nil {
+			if !endsLine {
+				fmt.Fprintln(out)
+			}
+			return report(line.Result.StatusCode, *line.Result, out)
+		}
+
+		if line.Output != "" {
+			fmt.Fprint(out, line.Output)
+			endsLine = strings.HasSuffix(line.Output, "\n")
+		}
+	}
+}
+
+// Report prints the result of a deployment from the body of a deploy response: the output
+// of the deployment followed by the status of every foundation.
+//
+// Returns the exit code of the deployment. A deployment that was rolled back on any
+// foundation has its own exit code, so scripts can tell it apart from other failures.
+func Report(statusCode int, body io.Reader, out io.Writer) int {
+	var result S.DeployResult
+
+	contents, err := ioutil.ReadAll(body)
+	if err == nil {
+		err = json.Unmarshal(contents, &result)
+	}
+	if err != nil {
+		if len(contents) > 0 {
+			fmt.Fprintln(out, strings.TrimSuffix(string(contents), "\n"))
+		}
+		fmt.Fprintln(out, ResponseError{statusCode, err})
+		return ExitFailure
+	}
+
+	fmt.Fprint(out, result.Logs)
+	if result.Logs != "" && !strings.HasSuffix(result.Logs, "\n") {
+		fmt.Fprintln(out)
+	}
+
+	return report(statusCode, result, out)
+}
+
+// report prints the status of every foundation and the result of a deployment.
+func report(statusCode int, result S.DeployResult, out io.Writer) int {
+	for _, fieldError := range result.FieldErrors {
+		fmt.Fprintf(out, "%s: %s\n", fieldError.Field, fieldError.Message)
+	}
+
+	for _, foundation := range result.Foundations {
+		if foundation.Error != "" {
+			fmt.Fprintf(out, "%s: %s: %s\n", foundation.FoundationURL, foundation.Status, foundation.Error)
+		} else {
+			fmt.Fprintf(out, "%s: %s\n", foundation.FoundationURL, foundation.Status)
+		}
+	}
+
+	if result.Error != "" {
+		fmt.Fprintf(out, "cannot deploy application: %s\n", result.Error)
+	}
+
+	name := "deployment"
+	if result.Status == "dry_run" {
+		name = "dry run"
+	}
+	if result.UUID != "" {
+		name = fmt.Sprintf("%s %s", name, result.UUID)
+	}
+
+	for _, foundation := range result.Foundations {
+		if foundation.Status == "rolled_back" {
+			fmt.Fprintf(out, "%s was rolled back\n", name)
+			return ExitRolledBack
+		}
+	}
+
+	if result.Status == "failure" || statusCode >= http.StatusBadRequest {
+		fmt.Fprintf(out, "%s failed\n", name)
+		return ExitFailure
+	}
+
+	fmt.Fprintf(out, "%s succeeded\n", name)
+	return ExitSuccess
+}
@@ -0,0 +1,365 @@
+package client_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/compozed/deployadactyl/client"
+	C "github.com/compozed/deployadactyl/constants"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+// recordingResponse is a deployment response that keeps the result of every foundation.
+type recordingResponse struct {
+	*bytes.Buffer
+	results []S.FoundationResult
+}
+
+func (r *recordingResponse) RecordFoundation(result S.FoundationResult) {
+	r.results = append(r.results, result)
+}
+
+var _ = Describe("Client", func() {
+	var deployment Deployment
+
+	BeforeEach(func() {
+		deployment = Deployment{
+			ServerURL:   "https://deployadactyl.example.com/",
+			Environment: "production",
+			Org:         "org",
+			Space:       "space",
+			AppName:     "app",
+		}
+	})
+
+	Describe("NewRequest", func() {
+		Context("when an artifact url is given", func() {
+			It("returns a JSON request with the manifest and environment variables", func() {
+				deployment.ArtifactURL = "https://example.com/artifact.jar"
+				deployment.Manifest = "applications:\n- name: app\n"
+				deployment.EnvironmentVariables = map[string]string{"PORT": "8080"}
+				deployment.HealthCheckEndpoint = "/health"
+
+				req, err := NewRequest(deployment)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(req.Method).To(Equal("POST"))
+				Expect(req.URL.String()).To(Equal("https://deployadactyl.example.com/v2/deploy/production/org/space/app"))
+				Expect(req.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(req.Header.Get("Accept")).To(Equal(C.StreamContentType + ", application/json"))
+
+				var body S.DeploymentInfo
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				Expect(body.ArtifactURL).To(Equal("https://example.com/artifact.jar"))
+				Expect(body.Manifest).To(Equal(base64.StdEncoding.EncodeToString([]byte(deployment.Manifest))))
+				Expect(body.EnvironmentVariables).To(Equal(map[string]string{"PORT": "8080"}))
+				Expect(body.HealthCheckEndpoint).To(Equal("/health"))
+			})
+		})
+
+		Context("when a path is given", func() {
+			It("returns a zip request", func() {
+				directory, err := ioutil.TempDir("", "client")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(directory)
+
+				Expect(ioutil.WriteFile(filepath.Join(directory, "index.html"), []byte("hello"), 0644)).To(Succeed())
+				deployment.Path = directory
+
+				req, err := NewRequest(deployment)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(req.Header.Get("Content-Type")).To(Equal("application/zip"))
+
+				body, err := ioutil.ReadAll(req.Body)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = zip.NewReader(bytes.NewReader(body), int64(len(body)))
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		It("returns an error when neither an artifact url nor a path is given", func() {
+			_, err := NewRequest(deployment)
+
+			Expect(err).To(MatchError(ArtifactError{}))
+		})
+
+		It("returns an error when both an artifact url and a path are given", func() {
+			deployment.ArtifactURL = "https://example.com/artifact.jar"
+			deployment.Path = "artifact.zip"
+
+			_, err := NewRequest(deployment)
+
+			Expect(err).To(MatchError(ArtifactError{}))
+		})
+
+		It("asks for a dry run", func() {
+			deployment.ArtifactURL = "https://example.com/artifact.jar"
+			deployment.DryRun = true
+
+			req, err := NewRequest(deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(req.URL.Query().Get("dry_run")).To(Equal("true"))
+		})
+
+		It("authenticates with a username and password", func() {
+			deployment.ArtifactURL = "https://example.com/artifact.jar"
+			deployment.Username = "user"
+			deployment.Password = "pwd"
+
+			req, err := NewRequest(deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			username, password, ok := req.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(username).To(Equal("user"))
+			Expect(password).To(Equal("pwd"))
+		})
+
+		It("authenticates with a token instead of a username and password", func() {
+			deployment.ArtifactURL = "https://example.com/artifact.jar"
+			deployment.Username = "user"
+			deployment.Token = "token"
+
+			req, err := NewRequest(deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer token"))
+		})
+
+		It("gives the emergency reason", func() {
+			deployment.ArtifactURL = "https://example.com/artifact.jar"
+			deployment.EmergencyReason = "outage"
+
+			req, err := NewRequest(deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(req.Header.Get(C.EmergencyReasonHeader)).To(Equal("outage"))
+		})
+	})
+
+	Describe("Report", func() {
+		var (
+			result S.DeployResult
+			out    *bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			out = &bytes.Buffer{}
+			result = S.DeployResult{
+				Status:     "success",
+				StatusCode: http.StatusOK,
+				UUID:       "uuid",
+				Foundations: []S.FoundationResult{
+					{FoundationURL: "https://api.foundation-1.example.com", Status: "success"},
+					{FoundationURL: "https://api.foundation-2.example.com", Status: "success"},
+				},
+				Logs: "deployment output",
+			}
+		})
+
+		report := func(statusCode int) int {
+			body, err := json.Marshal(result)
+			Expect(err).ToNot(HaveOccurred())
+
+			return Report(statusCode, bytes.NewReader(body), out)
+		}
+
+		It("prints the output and the status of every foundation", func() {
+			Expect(report(http.StatusOK)).To(Equal(ExitSuccess))
+
+			Expect(out.String()).To(Equal("deployment output\n" +
+				"https://api.foundation-1.example.com: success\n" +
+				"https://api.foundation-2.example.com: success\n" +
+				"deployment uuid succeeded\n"))
+		})
+
+		It("succeeds for a dry run", func() {
+			result.Status = "dry_run"
+
+			Expect(report(http.StatusOK)).To(Equal(ExitSuccess))
+			Expect(out.String()).To(ContainSubstring("dry run uuid succeeded"))
+		})
+
+		It("fails when the deployment fails", func() {
+			result.Status = "failure"
+			result.Error = "push failed"
+			result.Foundations[1] = S.FoundationResult{FoundationURL: "https://api.foundation-2.example.com", Status: "failure", Error: "push failed"}
+
+			Expect(report(http.StatusInternalServerError)).To(Equal(ExitFailure))
+
+			Expect(out.String()).To(ContainSubstring("https://api.foundation-2.example.com: failure: push failed\n"))
+			Expect(out.String()).To(ContainSubstring("cannot deploy application: push failed\n"))
+			Expect(out.String()).To(HaveSuffix("deployment uuid failed\n"))
+		})
+
+		It("has its own exit code when the deployment was rolled back", func() {
+			result.Status = "failure"
+			result.Error = "push failed"
+			result.Foundations[0].Status = "rolled_back"
+			result.Foundations[1] = S.FoundationResult{FoundationURL: "https://api.foundation-2.example.com", Status: "failure", Error: "push failed"}
+
+			Expect(report(http.StatusInternalServerError)).To(Equal(ExitRolledBack))
+			Expect(out.String()).To(HaveSuffix("deployment uuid was rolled back\n"))
+		})
+
+		It("has its own exit code when a push to a single foundation is rolled back", func() {
+			pusher := &mocks.Pusher{Response: &bytes.Buffer{}}
+			pusher.PushCall.Returns.Error = errors.New("push failed")
+			pusherCreator := &mocks.PusherCreator{}
+			pusherCreator.CreatePusherCall.Returns.Pushers = []I.Pusher{pusher}
+			pusherCreator.CreatePusherCall.Returns.Error = []error{nil}
+
+			blueGreen := bluegreen.BlueGreen{
+				PusherCreator: pusherCreator,
+				Log:           logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "client_test"),
+			}
+			environment := S.Environment{Foundations: []string{"https://api.foundation-1.example.com"}, EnableRollback: true}
+			recorder := &recordingResponse{Buffer: &bytes.Buffer{}}
+
+			err := blueGreen.Push(environment, "appPath", S.DeploymentInfo{AppName: "app", UUID: "uuid"}, recorder)
+			Expect(err).To(HaveOccurred())
+			Expect(pusher.UndoPushCall.Received.UndoPushWasCalled).To(BeTrue())
+
+			result = S.DeployResult{Status: "failure", UUID: "uuid", Error: err.Error(), Foundations: recorder.results}
+
+			Expect(report(http.StatusInternalServerError)).To(Equal(ExitRolledBack))
+			Expect(out.String()).To(ContainSubstring("https://api.foundation-1.example.com: rolled_back: push failed\n"))
+			Expect(out.String()).To(HaveSuffix("deployment uuid was rolled back\n"))
+		})
+
+		It("prints the problems with a request body that is not valid", func() {
+			result = S.DeployResult{
+				Status:      "failure",
+				Error:       "invalid request body",
+				FieldErrors: []S.FieldError{{Field: "artifact_url", Message: "is required"}},
+			}
+
+			Expect(report(http.StatusBadRequest)).To(Equal(ExitFailure))
+
+			Expect(out.String()).To(Equal("artifact_url: is required\n" +
+				"cannot deploy application: invalid request body\n" +
+				"deployment failed\n"))
+		})
+
+		It("prints the response and fails when it is not JSON", func() {
+			exitCode := Report(http.StatusUnauthorized, strings.NewReader("not authorized"), out)
+
+			Expect(exitCode).To(Equal(ExitFailure))
+			Expect(out.String()).To(HavePrefix("not authorized"))
+			Expect(out.String()).To(ContainSubstring("unexpected response from the server with status 401"))
+		})
+	})
+
+	Describe("ReportStream", func() {
+		var (
+			reader *io.PipeReader
+			writer *io.PipeWriter
+			out    *gbytes.Buffer
+			exit   chan int
+		)
+
+		BeforeEach(func() {
+			reader, writer = io.Pipe()
+			out = gbytes.NewBuffer()
+			exit = make(chan int, 1)
+
+			go func() {
+				exit <- ReportStream(reader, out)
+			}()
+		})
+
+		send := func(line S.DeployStreamLine) {
+			Expect(json.NewEncoder(writer).Encode(line)).To(Succeed())
+		}
+
+		It("prints the output as it arrives followed by the result", func() {
+			send(S.DeployStreamLine{Output: "fetching the artifact\n"})
+			Eventually(out).Should(gbytes.Say("fetching the artifact\n"))
+
+			send(S.DeployStreamLine{Output: "pushing"})
+			Eventually(out).Should(gbytes.Say("pushing"))
+			Consistently(exit).ShouldNot(Receive())
+
+			send(S.DeployStreamLine{Result: &S.DeployResult{
+				Status:      "failure",
+				StatusCode:  http.StatusInternalServerError,
+				UUID:        "uuid",
+				Error:       "push failed",
+				Foundations: []S.FoundationResult{{FoundationURL: "https://api.foundation-1.example.com", Status: "rolled_back", Error: "push failed"}},
+			}})
+			writer.Close()
+
+			Eventually(exit).Should(Receive(Equal(ExitRolledBack)))
+			Expect(string(out.Contents())).To(Equal("fetching the artifact\n" +
+				"pushing\n" +
+				"https://api.foundation-1.example.com: rolled_back: push failed\n" +
+				"cannot deploy application: push failed\n" +
+				"deployment uuid was rolled back\n"))
+		})
+
+		It("fails when the response ends before the result", func() {
+			send(S.DeployStreamLine{Output: "pushing"})
+			writer.Close()
+
+			Eventually(exit).Should(Receive(Equal(ExitFailure)))
+			Expect(string(out.Contents())).To(Equal("pushing\n" +
+				ResponseError{http.StatusOK, StreamError{io.ErrUnexpectedEOF}}.Error() + "\n"))
+		})
+	})
+
+	Describe("ReportResponse", func() {
+		var out *bytes.Buffer
+
+		BeforeEach(func() {
+			out = &bytes.Buffer{}
+		})
+
+		It("reports a streamed response", func() {
+			body, err := json.Marshal(S.DeployStreamLine{Result: &S.DeployResult{Status: "success", StatusCode: http.StatusOK, UUID: "uuid"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{C.StreamContentType}},
+				Body:       ioutil.NopCloser(bytes.NewReader(body)),
+			}
+
+			Expect(ReportResponse(resp, out)).To(Equal(ExitSuccess))
+			Expect(out.String()).To(Equal("deployment uuid succeeded\n"))
+		})
+
+		It("reports a JSON response from a server that does not stream", func() {
+			body, err := json.Marshal(S.DeployResult{Status: "failure", StatusCode: http.StatusInternalServerError, UUID: "uuid", Logs: "deployment output"})
+			Expect(err).ToNot(HaveOccurred())
+
+			resp := &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Header:     http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+				Body:       ioutil.NopCloser(bytes.NewReader(body)),
+			}
+
+			Expect(ReportResponse(resp, out)).To(Equal(ExitFailure))
+			Expect(out.String()).To(Equal("deployment output\ndeployment uuid failed\n"))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package client
+
+import "fmt"
+
+type ArtifactError struct{}
+
+func (e ArtifactError) Error() string {
+	return "either an artifact url or a path must be given"
+}
+
+type ReadArtifactError struct {
+	Path string
+	Err  error
+}
+
+func (e ReadArtifactError) Error() string {
+	return fmt.Sprintf("cannot read artifact %s: %s", e.Path, e.Err)
+}
+
+type ManifestError struct {
+	Err error
+}
+
+func (e ManifestError) Error() string {
+	return fmt.Sprintf("cannot add environment variables to the manifest: %s", e.Err)
+}
+
+type StreamError struct {
+	Err error
+}
+
+func (e StreamError) Error() string {
+	return fmt.Sprintf("the response ended before the deployment finished: %s", e.Err)
+}
+
+type ResponseError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e ResponseError) Error() string {
+	return fmt.Sprintf("unexpected response from the server with status %d: %s", e.StatusCode, e.Err)
+}
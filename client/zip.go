@@ -0,0 +1,201 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/compozed/deployadactyl/eventmanager/handlers/envvar"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/op/go-logging"
+)
+
+const manifestFile = "manifest.yml"
+
+// zipFile is a file that is copied into the uploaded zip.
+type zipFile struct {
+	name string
+	mode os.FileMode
+	open func() (io.ReadCloser, error)
+}
+
+// Zip returns the zip file the server deploys for a directory or a zip file. The manifest.yml
+// of the artifact is replaced by manifest when it is not empty. Environment variables are
+// added to the manifest, because a zip upload has no other way to set them.
+//
+// Returns an error when the artifact or its manifest cannot be read.
+func Zip(path, appName, manifest string, environmentVariables map[string]string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, ReadArtifactError{path, err}
+	}
+
+	var files []zipFile
+	if info.IsDir() {
+		files, err = directoryFiles(path)
+	} else {
+		files, err = zipFiles(path)
+	}
+	if err != nil {
+		return nil, ReadArtifactError{path, err}
+	}
+
+	if len(environmentVariables) > 0 {
+		if manifest == "" {
+			manifest, err = readManifest(files)
+			if err != nil {
+				return nil, ReadArtifactError{path, err}
+			}
+		}
+
+		manifest, err = addEnvironmentVariables(appName, manifest, environmentVariables)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+
+	for _, file := range files {
+		if manifest != "" && file.name == manifestFile {
+			continue
+		}
+
+		err = copyFile(writer, file)
+		if err != nil {
+			return nil, ReadArtifactError{path, err}
+		}
+	}
+
+	if manifest != "" {
+		w, err := writer.Create(manifestFile)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(w, manifest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// directoryFiles returns the files in a directory and its subdirectories.
+func directoryFiles(directory string) ([]zipFile, error) {
+	var files []zipFile
+
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		name, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, zipFile{
+			name: filepath.ToSlash(name),
+			mode: info.Mode(),
+			open: func() (io.ReadCloser, error) { return os.Open(path) },
+		})
+		return nil
+	})
+
+	return files, err
+}
+
+// zipFiles returns the files in a zip file.
+func zipFiles(path string) ([]zipFile, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []zipFile
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		files = append(files, zipFile{
+			name: file.Name,
+			mode: file.Mode(),
+			open: file.Open,
+		})
+	}
+
+	return files, nil
+}
+
+func copyFile(writer *zip.Writer, file zipFile) error {
+	header := &zip.FileHeader{Name: file.name, Method: zip.Deflate}
+	header.SetMode(file.mode)
+
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	contents, err := file.open()
+	if err != nil {
+		return err
+	}
+	defer contents.Close()
+
+	_, err = io.Copy(w, contents)
+	return err
+}
+
+// readManifest returns the manifest.yml of an artifact, or an empty string when it has none.
+func readManifest(files []zipFile) (string, error) {
+	for _, file := range files {
+		if file.name != manifestFile {
+			continue
+		}
+
+		contents, err := file.open()
+		if err != nil {
+			return "", err
+		}
+		defer contents.Close()
+
+		manifest, err := ioutil.ReadAll(contents)
+		return string(manifest), err
+	}
+
+	return "", nil
+}
+
+// addEnvironmentVariables adds environment variables to the first application of a manifest.
+// An empty manifest gets an application with the name of the app.
+func addEnvironmentVariables(appName, content string, environmentVariables map[string]string) (string, error) {
+	log := logger.DefaultLogger(ioutil.Discard, logging.ERROR, "client")
+
+	manifest, err := envvar.CreateManifest(appName, content, nil, log)
+	if err != nil {
+		return "", ManifestError{err}
+	}
+
+	_, err = manifest.AddEnvironmentVariables(environmentVariables)
+	if err != nil {
+		return "", ManifestError{err}
+	}
+
+	return manifest.Marshal(), nil
+}
@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/compozed/deployadactyl/client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// unzip returns the names and contents of the files in a zip file.
+func unzip(contents []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	Expect(err).ToNot(HaveOccurred())
+
+	files := map[string]string{}
+	for _, file := range reader.File {
+		r, err := file.Open()
+		Expect(err).ToNot(HaveOccurred())
+
+		body, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		r.Close()
+
+		files[file.Name] = string(body)
+	}
+
+	return files
+}
+
+var _ = Describe("Zip", func() {
+	var directory string
+
+	BeforeEach(func() {
+		var err error
+		directory, err = ioutil.TempDir("", "zip")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(directory, "app", "public"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(directory, "app", "index.html"), []byte("index"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(directory, "app", "public", "style.css"), []byte("style"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(directory, "app", "manifest.yml"), []byte("applications:\n- name: app\n  memory: 64M\n"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(directory)
+	})
+
+	Context("when the path is a directory", func() {
+		It("zips every file in the directory", func() {
+			contents, err := Zip(filepath.Join(directory, "app"), "app", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(unzip(contents)).To(Equal(map[string]string{
+				"index.html":       "index",
+				"public/style.css": "style",
+				"manifest.yml":     "applications:\n- name: app\n  memory: 64M\n",
+			}))
+		})
+
+		It("replaces the manifest", func() {
+			contents, err := Zip(filepath.Join(directory, "app"), "app", "applications:\n- name: other\n", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			files := unzip(contents)
+			Expect(files).To(HaveLen(3))
+			Expect(files["manifest.yml"]).To(Equal("applications:\n- name: other\n"))
+		})
+
+		It("adds the environment variables to the manifest", func() {
+			contents, err := Zip(filepath.Join(directory, "app"), "app", "", map[string]string{"PORT": "8080"})
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest := unzip(contents)["manifest.yml"]
+			Expect(manifest).To(ContainSubstring("memory: 64M"))
+			Expect(manifest).To(ContainSubstring("PORT: \"8080\""))
+		})
+
+		It("adds a manifest with the environment variables when there is none", func() {
+			Expect(os.Remove(filepath.Join(directory, "app", "manifest.yml"))).To(Succeed())
+
+			contents, err := Zip(filepath.Join(directory, "app"), "app", "", map[string]string{"PORT": "8080"})
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest := unzip(contents)["manifest.yml"]
+			Expect(manifest).To(ContainSubstring("name: app"))
+			Expect(manifest).To(ContainSubstring("PORT: \"8080\""))
+		})
+	})
+
+	Context("when the path is a zip file", func() {
+		It("copies the files of the zip file", func() {
+			original, err := Zip(filepath.Join(directory, "app"), "app", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(directory, "app.zip"), original, 0644)).To(Succeed())
+
+			contents, err := Zip(filepath.Join(directory, "app.zip"), "app", "applications:\n- name: other\n", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(unzip(contents)).To(Equal(map[string]string{
+				"index.html":       "index",
+				"public/style.css": "style",
+				"manifest.yml":     "applications:\n- name: other\n",
+			}))
+		})
+
+		It("returns an error when the file is not a zip file", func() {
+			path := filepath.Join(directory, "app", "index.html")
+
+			_, err := Zip(path, "app", "", nil)
+
+			Expect(err).To(BeAssignableToTypeOf(ReadArtifactError{}))
+			Expect(err.Error()).To(ContainSubstring("cannot read artifact " + path))
+		})
+	})
+
+	It("returns an error when the path does not exist", func() {
+		_, err := Zip(filepath.Join(directory, "missing"), "app", "", nil)
+
+		Expect(err).To(BeAssignableToTypeOf(ReadArtifactError{}))
+	})
+
+	It("returns an error when the manifest cannot be parsed", func() {
+		_, err := Zip(filepath.Join(directory, "app"), "app", "applications: [", map[string]string{"PORT": "8080"})
+
+		Expect(err).To(BeAssignableToTypeOf(ManifestError{}))
+	})
+})
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"time"
+
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultApprovalTimeout is how long a deployment waits for approval when the environment
+// does not set an approval_timeout.
+const DefaultApprovalTimeout = time.Hour
+
+// ApprovalTimeout returns how long deployments to an environment wait for approval.
+func ApprovalTimeout(environment s.Environment) (time.Duration, error) {
+	if environment.ApprovalTimeout == "" {
+		return DefaultApprovalTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(environment.ApprovalTimeout)
+	if err != nil || timeout <= 0 {
+		return 0, ApprovalTimeoutError{environment.Name, environment.ApprovalTimeout}
+	}
+
+	return timeout, nil
+}
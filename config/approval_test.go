@@ -0,0 +1,21 @@
+package config_test
+
+import (
+	. "github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ApprovalTimeout", func() {
+	It("defaults to an hour", func() {
+		Expect(ApprovalTimeout(S.Environment{})).To(Equal(DefaultApprovalTimeout))
+	})
+
+	It("returns an error when the timeout is not a positive duration", func() {
+		_, err := ApprovalTimeout(S.Environment{Name: "production", ApprovalTimeout: "-1m"})
+
+		Expect(err).To(MatchError(ApprovalTimeoutError{"production", "-1m"}))
+	})
+})
@@ -4,6 +4,7 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,34 +15,57 @@ import (
 	s "github.com/compozed/deployadactyl/structs"
 )
 
-const defaultConfigPath = "./config.yml"
+// DefaultConfigPath is the location of the config file when a custom one is not given.
+const DefaultConfigPath = "./config.yml"
 
 // Config is a representation of a config yaml. It can contain multiple Environments.
 type Config struct {
 	Username      string
 	Password      string
+	Credentials   map[string]s.Credentials
 	Environments  map[string]s.Environment
 	Port          int
+	BindAddress   string
+	TLS           s.TLS
 	ErrorMatchers []interfaces.ErrorMatcher
+	Policies      []s.Policy
+	OIDC          s.OIDC
+	Janitor       s.Janitor
 }
 
 type configYaml struct {
 	Environments       []s.Environment            `yaml:",flow"`
 	MatcherDescriptors []s.ErrorMatcherDescriptor `yaml:"error_matchers,flow"`
+	Credentials        []credentialsYaml          `yaml:",flow"`
+	Policies           []s.Policy                 `yaml:",flow"`
+	OIDC               s.OIDC                     `yaml:"oidc"`
+	Janitor            s.Janitor                  `yaml:"janitor"`
+}
+
+type foundationsYaml struct {
+	Environments []struct {
+		Foundations []interface{} `yaml:",flow"`
+	}
 }
 
 type foundationYaml struct {
-	Foundations []string
+	URL         string
+	Name        string
+	AppsDomain  string `yaml:"apps_domain"`
+	SkipSSL     *bool  `yaml:"skip_ssl"`
+	Credentials string
+	Timeout     int
+	Weight      int
 }
 
 // Default returns a new Config struct with information from environment variables and the default config file (./config.yml).
 func Default(getenv func(string) string) (Config, error) {
-	return Custom(getenv, defaultConfigPath)
+	return Custom(getenv, DefaultConfigPath)
 }
 
 // Custom returns a new Config struct with information from environment variables and a custom config file.
 func Custom(getenv func(string) string, configPath string) (Config, error) {
-	foundationConfig, err := parseConfig(configPath)
+	foundationConfig, err := parseConfig(getenv, configPath)
 	if err != nil {
 		return Config{}, err
 	}
@@ -51,39 +75,142 @@ func Custom(getenv func(string) string, configPath string) (Config, error) {
 		return Config{}, err
 	}
 
-	errormatchers := getErrorMatchersFromConfig(foundationConfig)
+	errormatchers, err := getErrorMatchersFromConfig(foundationConfig)
 	if err != nil {
 		return Config{}, err
 	}
 
-	return createConfig(getenv, environments, errormatchers)
-}
+	credentials, err := getCredentialsFromConfig(getenv, foundationConfig)
+	if err != nil {
+		return Config{}, err
+	}
 
-func createConfig(getenv func(string) string, environments map[string]s.Environment, errormatchers []interfaces.ErrorMatcher) (Config, error) {
-	getter := geterrors.WrapFunc(getenv)
+	err = checkCredentialReferences(environments, credentials)
+	if err != nil {
+		return Config{}, err
+	}
 
-	username := getter.Get("CF_USERNAME")
-	password := getter.Get("CF_PASSWORD")
+	for _, p := range foundationConfig.Policies {
+		if err := CheckPolicy(p); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if foundationConfig.OIDC != (s.OIDC{}) {
+		if err := CheckOIDC(foundationConfig.OIDC); err != nil {
+			return Config{}, err
+		}
+	}
 
-	if err := getter.Err("missing environment variables"); err != nil {
+	if len(foundationConfig.Janitor.Spaces) > 0 {
+		if err := CheckJanitor(foundationConfig.Janitor); err != nil {
+			return Config{}, err
+		}
+
+		for _, space := range foundationConfig.Janitor.Spaces {
+			if _, ok := environments[strings.ToLower(space.Environment)]; !ok {
+				return Config{}, JanitorEnvironmentNotFoundError{Environment: space.Environment}
+			}
+		}
+	}
+
+	config, err := createConfig(getenv, environments, errormatchers, credentials)
+	if err != nil {
 		return Config{}, err
 	}
+	config.Policies = foundationConfig.Policies
+	config.OIDC = foundationConfig.OIDC
+	config.Janitor = foundationConfig.Janitor
+
+	return config, nil
+}
+
+func createConfig(getenv func(string) string, environments map[string]s.Environment, errormatchers []interfaces.ErrorMatcher, credentials map[string]s.Credentials) (Config, error) {
+	var username, password string
+
+	// CF_USERNAME and CF_PASSWORD are only required as a fallback for environments
+	// that do not have their own credentials.
+	if needsDefaultCredentials(environments) {
+		getter := geterrors.WrapFunc(getenv)
+
+		username = getter.Get("CF_USERNAME")
+		password = getter.Get("CF_PASSWORD")
+
+		if err := getter.Err("missing environment variables"); err != nil {
+			return Config{}, err
+		}
+	} else {
+		username = getenv("CF_USERNAME")
+		password = getenv("CF_PASSWORD")
+	}
 
 	port, err := getPortFromEnv(getenv)
 	if err != nil {
 		return Config{}, err
 	}
 
+	tlsConfig, err := getTLSFromEnv(getenv)
+	if err != nil {
+		return Config{}, err
+	}
+
+	bindAddress := getenv("DEPLOYADACTYL_BIND_ADDRESS")
+	if bindAddress == "" {
+		bindAddress = "0.0.0.0"
+	}
+
 	config := Config{
 		Username:      username,
 		Password:      password,
+		Credentials:   credentials,
 		Port:          port,
+		BindAddress:   bindAddress,
+		TLS:           tlsConfig,
 		Environments:  environments,
 		ErrorMatchers: errormatchers,
 	}
 	return config, nil
 }
 
+// GetCredentials returns the credentials used to log into a foundation of an environment
+// when a request does not provide basic auth. Foundation credentials take precedence over
+// environment credentials, which take precedence over CF_USERNAME and CF_PASSWORD.
+func (c Config) GetCredentials(environment s.Environment, foundationURL string) s.Credentials {
+	if credentials, ok := c.Credentials[environment.GetFoundation(foundationURL).Credentials]; ok {
+		return credentials
+	}
+
+	if credentials, ok := c.Credentials[environment.Credentials]; ok {
+		return credentials
+	}
+
+	return s.Credentials{Username: c.Username, Password: c.Password}
+}
+
+// WithServiceAccounts returns a copy of the environment where every foundation has its
+// credentials resolved, so it is logged into with its service account.
+func (c Config) WithServiceAccounts(environment s.Environment) s.Environment {
+	foundations := make(map[string]s.Foundation, len(environment.Foundations))
+
+	for _, foundationURL := range environment.Foundations {
+		foundation := environment.GetFoundation(foundationURL)
+		foundation.ServiceAccount = c.GetCredentials(environment, foundationURL)
+		foundations[foundationURL] = foundation
+	}
+	environment.FoundationConfigs = foundations
+
+	return environment
+}
+
+func needsDefaultCredentials(environments map[string]s.Environment) bool {
+	for _, environment := range environments {
+		if environment.Credentials == "" {
+			return true
+		}
+	}
+	return false
+}
+
 func getPortFromEnv(getenv func(string) string) (int, error) {
 	envPort := getenv("PORT")
 	if envPort == "" {
@@ -98,20 +225,52 @@ func getPortFromEnv(getenv func(string) string) (int, error) {
 	return cfgPort, nil
 }
 
-func getErrorMatchersFromConfig(foundationConfig configYaml) []interfaces.ErrorMatcher {
+func getTLSFromEnv(getenv func(string) string) (s.TLS, error) {
+	tlsConfig := s.TLS{
+		CertFile:     getenv("DEPLOYADACTYL_TLS_CERT_FILE"),
+		KeyFile:      getenv("DEPLOYADACTYL_TLS_KEY_FILE"),
+		ClientCAFile: getenv("DEPLOYADACTYL_TLS_CLIENT_CA_FILE"),
+		ClientAuth:   getenv("DEPLOYADACTYL_TLS_CLIENT_AUTH"),
+	}
+
+	if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		return s.TLS{}, TLSKeyPairError{}
+	}
+
+	if tlsConfig.ClientCAFile != "" && tlsConfig.CertFile == "" {
+		return s.TLS{}, TLSClientCAError{}
+	}
+
+	if tlsConfig.ClientCAFile != "" && tlsConfig.ClientAuth == "" {
+		tlsConfig.ClientAuth = "require"
+	}
+
+	if tlsConfig.ClientAuth != "" && tlsConfig.ClientAuth != "require" && tlsConfig.ClientAuth != "verify_if_given" {
+		return s.TLS{}, TLSClientAuthError{tlsConfig.ClientAuth}
+	}
+
+	if tlsConfig.ClientAuth != "" && tlsConfig.ClientCAFile == "" {
+		return s.TLS{}, TLSClientCAError{}
+	}
+
+	return tlsConfig, nil
+}
+
+func getErrorMatchersFromConfig(foundationConfig configYaml) ([]interfaces.ErrorMatcher, error) {
 
 	matchers := make([]interfaces.ErrorMatcher, 0, 0)
 
 	if foundationConfig.MatcherDescriptors != nil || len(foundationConfig.MatcherDescriptors) > 0 {
 		factory := error_finder.ErrorMatcherFactory{}
-		for _, descriptor := range foundationConfig.MatcherDescriptors {
+		for i, descriptor := range foundationConfig.MatcherDescriptors {
 			matcher, err := factory.CreateErrorMatcher(descriptor)
-			if err == nil {
-				matchers = append(matchers, matcher)
+			if err != nil {
+				return nil, ErrorMatcherError{i + 1, err}
 			}
+			matchers = append(matchers, matcher)
 		}
 	}
-	return matchers
+	return matchers, nil
 }
 
 func getEnvironmentsFromConfig(foundationConfig configYaml) (map[string]s.Environment, error) {
@@ -126,6 +285,22 @@ func getEnvironmentsFromConfig(foundationConfig configYaml) (map[string]s.Enviro
 			return nil, MissingParameterError{}
 		}
 
+		for _, foundationURL := range environment.Foundations {
+			if foundationURL == "" {
+				return nil, MissingParameterError{}
+			}
+		}
+
+		for _, window := range environment.FreezeWindows {
+			if _, err := ParseFreezeWindow(window); err != nil {
+				return nil, FreezeWindowError{environment.Name, err}
+			}
+		}
+
+		if _, err := ApprovalTimeout(environment); err != nil {
+			return nil, err
+		}
+
 		if environment.Instances < 1 {
 			environment.Instances = 1
 		}
@@ -136,12 +311,17 @@ func getEnvironmentsFromConfig(foundationConfig configYaml) (map[string]s.Enviro
 	return environments, nil
 }
 
-func parseConfig(configPath string) (configYaml, error) {
+func parseConfig(getenv func(string) string, configPath string) (configYaml, error) {
 	file, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return configYaml{}, err
 	}
 
+	file, err = interpolate(getenv, file)
+	if err != nil {
+		return configYaml{}, err
+	}
+
 	foundationConfig, err := parseYamlFromBody(file)
 	if err != nil {
 		return configYaml{}, err
@@ -157,5 +337,95 @@ func parseYamlFromBody(data []byte) (configYaml, error) {
 		return configYaml{}, ParseYamlError{err}
 	}
 
+	var foundations foundationsYaml
+
+	err = candiedyaml.Unmarshal(data, &foundations)
+	if err != nil {
+		return configYaml{}, ParseYamlError{err}
+	}
+
+	for i := range foundationConfig.Environments {
+		if i >= len(foundations.Environments) {
+			break
+		}
+
+		err = parseFoundations(&foundationConfig.Environments[i], foundations.Environments[i].Foundations)
+		if err != nil {
+			return configYaml{}, err
+		}
+	}
+
 	return foundationConfig, nil
 }
+
+// parseFoundations sets the foundations of an environment. Each foundation can either be
+// a URL string or an object with a url and optional settings.
+func parseFoundations(environment *s.Environment, foundations []interface{}) error {
+	for _, f := range foundations {
+		switch value := f.(type) {
+		case string:
+			environment.Foundations = append(environment.Foundations, value)
+		case map[interface{}]interface{}:
+			foundation, err := parseFoundation(value, environment.SkipSSL)
+			if err != nil {
+				return err
+			}
+
+			if environment.FoundationConfigs == nil {
+				environment.FoundationConfigs = map[string]s.Foundation{}
+			}
+
+			environment.Foundations = append(environment.Foundations, foundation.URL)
+			environment.FoundationConfigs[foundation.URL] = foundation
+		default:
+			return InvalidFoundationError{Environment: environment.Name, Foundation: fmt.Sprint(f)}
+		}
+	}
+
+	sort.Stable(byWeight{*environment})
+
+	return nil
+}
+
+func parseFoundation(value map[interface{}]interface{}, skipSSL bool) (s.Foundation, error) {
+	var f foundationYaml
+
+	data, err := candiedyaml.Marshal(value)
+	if err != nil {
+		return s.Foundation{}, ParseYamlError{err}
+	}
+
+	err = candiedyaml.Unmarshal(data, &f)
+	if err != nil {
+		return s.Foundation{}, ParseYamlError{err}
+	}
+
+	if f.SkipSSL != nil {
+		skipSSL = *f.SkipSSL
+	}
+
+	return s.Foundation{
+		URL:         f.URL,
+		Name:        f.Name,
+		AppsDomain:  f.AppsDomain,
+		SkipSSL:     skipSSL,
+		Credentials: f.Credentials,
+		Timeout:     f.Timeout,
+		Weight:      f.Weight,
+	}, nil
+}
+
+// byWeight sorts the foundations of an environment from the highest weight to the lowest.
+type byWeight struct {
+	s.Environment
+}
+
+func (b byWeight) Len() int { return len(b.Foundations) }
+
+func (b byWeight) Swap(i, j int) {
+	b.Foundations[i], b.Foundations[j] = b.Foundations[j], b.Foundations[i]
+}
+
+func (b byWeight) Less(i, j int) bool {
+	return b.GetFoundation(b.Foundations[i]).Weight > b.GetFoundation(b.Foundations[j]).Weight
+}
@@ -149,6 +149,66 @@ var _ = Describe("Config", func() {
 		})
 	})
 
+	Context("when TLS is configured in the environment", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("listens on 0.0.0.0 without TLS by default", func() {
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.BindAddress).To(Equal("0.0.0.0"))
+			Expect(config.TLS).To(BeZero())
+		})
+
+		It("uses the bind address, certificate and client CA", func() {
+			env.GetCall.Returns.Values["DEPLOYADACTYL_BIND_ADDRESS"] = "127.0.0.1"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CERT_FILE"] = "cert.pem"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_KEY_FILE"] = "key.pem"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CLIENT_CA_FILE"] = "ca.pem"
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.BindAddress).To(Equal("127.0.0.1"))
+			Expect(config.TLS).To(Equal(S.TLS{
+				CertFile:     "cert.pem",
+				KeyFile:      "key.pem",
+				ClientCAFile: "ca.pem",
+				ClientAuth:   "require",
+			}))
+		})
+
+		It("returns an error when only the certificate is set", func() {
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CERT_FILE"] = "cert.pem"
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(MatchError(TLSKeyPairError{}))
+		})
+
+		It("returns an error when a client CA is set without a certificate", func() {
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CLIENT_CA_FILE"] = "ca.pem"
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(MatchError(TLSClientCAError{}))
+		})
+
+		It("returns an error when the client auth is unknown", func() {
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CERT_FILE"] = "cert.pem"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_KEY_FILE"] = "key.pem"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CLIENT_CA_FILE"] = "ca.pem"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CLIENT_AUTH"] = "optional"
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(MatchError(TLSClientAuthError{"optional"}))
+		})
+	})
+
 	Context("when an environment variable is missing", func() {
 		It("returns an error", func() {
 			env.GetCall.Returns.Values["CF_USERNAME"] = ""
@@ -248,6 +308,620 @@ environments:
 		})
 	})
 
+	Context("when foundations are configured as objects", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("returns the foundation settings alongside plain foundation urls", func() {
+			env.GetCall.Returns.Values["PROD_2_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["PROD_2_PASSWORD"] = cfPassword
+
+			testConfig := `---
+credentials:
+- name: production-2
+  username_env: PROD_2_USERNAME
+  password_env: PROD_2_PASSWORD
+environments:
+- name: production
+  domain: example.com
+  skip_ssl: true
+  foundations:
+  - https://api.foundation-1.example.com
+  - url: https://api.foundation-2.example.com
+    name: foundation-2
+    apps_domain: apps.foundation-2.example.com
+    skip_ssl: false
+    credentials: production-2
+    timeout: 30
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			environment := config.Environments["production"]
+			Expect(environment.Foundations).To(Equal([]string{"https://api.foundation-1.example.com", "https://api.foundation-2.example.com"}))
+			Expect(environment.GetFoundation("https://api.foundation-1.example.com")).To(Equal(S.Foundation{
+				URL:     "https://api.foundation-1.example.com",
+				SkipSSL: true,
+			}))
+			Expect(environment.GetFoundation("https://api.foundation-2.example.com")).To(Equal(S.Foundation{
+				URL:         "https://api.foundation-2.example.com",
+				Name:        "foundation-2",
+				AppsDomain:  "apps.foundation-2.example.com",
+				SkipSSL:     false,
+				Credentials: "production-2",
+				Timeout:     30,
+			}))
+		})
+
+		It("inherits skip_ssl from the environment when the foundation does not set it", func() {
+			testConfig := `---
+environments:
+- name: production
+  skip_ssl: true
+  foundations:
+  - url: https://api.foundation-1.example.com
+    name: foundation-1
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].GetFoundation("https://api.foundation-1.example.com").SkipSSL).To(BeTrue())
+		})
+
+		It("orders the foundations by weight", func() {
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - https://api.foundation-1.example.com
+  - url: https://api.foundation-2.example.com
+    weight: 10
+  - url: https://api.foundation-3.example.com
+    weight: 20
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].Foundations).To(Equal([]string{
+				"https://api.foundation-3.example.com",
+				"https://api.foundation-2.example.com",
+				"https://api.foundation-1.example.com",
+			}))
+		})
+
+		It("returns an error when a foundation object has no url", func() {
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - name: foundation-1
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(MatchError(MissingParameterError{}))
+		})
+	})
+
+	Context("when freeze windows are configured", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("returns the freeze windows of the environment", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+  allow_emergency_override: true
+  freeze_windows:
+  - name: year-end
+    start: 2026-12-24
+    end: 2027-01-02 08:00
+    time_zone: America/Chicago
+  - name: business-hours
+    cron: "0 9 * * 1-5"
+    duration: 8h
+`), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, badConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			production := config.Environments["production"]
+			Expect(production.AllowEmergencyOverride).To(BeTrue())
+			Expect(production.FreezeWindows).To(Equal([]S.FreezeWindow{
+				{Name: "year-end", Start: "2026-12-24", End: "2027-01-02 08:00", TimeZone: "America/Chicago"},
+				{Name: "business-hours", Cron: "0 9 * * 1-5", Duration: "8h"},
+			}))
+		})
+
+		It("returns an error when a freeze window is invalid", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+  freeze_windows:
+  - name: year-end
+    start: 2026-12-24
+`), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, badConfigPath)
+
+			Expect(err).To(MatchError(FreezeWindowError{"production", FreezeScheduleError{Window: "year-end"}}))
+		})
+	})
+
+	Context("when approvers are configured", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("returns the approvers and the approval timeout of the environment", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+  approvers:
+  - approver1
+  - approver2
+  approval_timeout: 30m
+`), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, badConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].Approvers).To(Equal([]string{"approver1", "approver2"}))
+			Expect(config.Environments["production"].ApprovalTimeout).To(Equal("30m"))
+		})
+
+		It("returns an error when the approval timeout is not a duration", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+  approvers:
+  - approver1
+  approval_timeout: soon
+`), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, badConfigPath)
+
+			Expect(err).To(MatchError(ApprovalTimeoutError{Environment: "production", Timeout: "soon"}))
+		})
+	})
+
+	Context("when policies are configured", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("returns the policies", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+policies:
+- name: release-managers
+  identities:
+  - user:bob
+  environments:
+  - production
+  spaces:
+  - prod-*
+  actions:
+  - deploy
+  - promote
+`), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, badConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Policies).To(Equal([]S.Policy{{
+				Name:         "release-managers",
+				Identities:   []string{"user:bob"},
+				Environments: []string{"production"},
+				Spaces:       []string{"prod-*"},
+				Actions:      []string{"deploy", "promote"},
+			}}))
+		})
+
+		It("returns an error when a policy is invalid", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+policies:
+- name: release-managers
+  identities:
+  - bob
+  actions:
+  - deploy
+`), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, badConfigPath)
+
+			Expect(err).To(MatchError(PolicyIdentityError{Policy: "release-managers", Identity: "bob"}))
+		})
+	})
+
+	Context("when an identity provider is configured", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("returns the identity provider", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+oidc:
+  issuer: https://idp.example.com
+  audience: deployadactyl
+  jwks: https://idp.example.com/jwks.json
+  groups_claim: roles
+`), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, badConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.OIDC).To(Equal(S.OIDC{
+				Issuer:      "https://idp.example.com",
+				Audience:    "deployadactyl",
+				JWKS:        "https://idp.example.com/jwks.json",
+				GroupsClaim: "roles",
+			}))
+		})
+
+		It("returns an error when the identity provider is incomplete", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+oidc:
+  issuer: https://idp.example.com
+  jwks: https://idp.example.com/jwks.json
+`), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, badConfigPath)
+
+			Expect(err).To(MatchError(OIDCMissingError{Key: "audience"}))
+		})
+	})
+
+	Context("when a janitor is configured", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("returns the janitor", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+janitor:
+  interval: 30m
+  older_than: 12h
+  dry_run: true
+  spaces:
+  - environment: Production
+    org: my-org
+    space: my-space
+`), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, badConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Janitor).To(Equal(S.Janitor{
+				Interval:  "30m",
+				OlderThan: "12h",
+				DryRun:    true,
+				Spaces:    []S.JanitorSpace{{Environment: "Production", Org: "my-org", Space: "my-space"}},
+			}))
+		})
+
+		It("returns an error when a space refers to an unknown environment", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte(`---
+environments:
+- name: production
+  foundations:
+  - https://api1.example.com
+janitor:
+  spaces:
+  - environment: staging
+    org: my-org
+    space: my-space
+`), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, badConfigPath)
+
+			Expect(err).To(MatchError(JanitorEnvironmentNotFoundError{Environment: "staging"}))
+		})
+	})
+
+	Context("when credentials are configured", func() {
+		const (
+			secretsFilePath   = "./test_secrets.yml"
+			credentialsConfig = `---
+credentials:
+- name: preproduction
+  username_env: PREPROD_CF_USERNAME
+  password_env: PREPROD_CF_PASSWORD
+- name: production
+  secrets_file: ./test_secrets.yml
+environments:
+- name: preproduction
+  credentials: preproduction
+  foundations:
+  - https://api.foundation-1.example.com
+- name: production
+  credentials: production
+  foundations:
+  - https://api.foundation-2.example.com
+  - url: https://api.foundation-3.example.com
+    credentials: preproduction
+`
+		)
+
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["PREPROD_CF_USERNAME"] = "preprod-username"
+			env.GetCall.Returns.Values["PREPROD_CF_PASSWORD"] = "preprod-password"
+
+			Expect(ioutil.WriteFile(secretsFilePath, []byte("username: prod-username\npassword: prod-password\n"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(customConfigPath, []byte(credentialsConfig), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(secretsFilePath)).To(Succeed())
+		})
+
+		It("resolves credentials from environment variables and secrets files", func() {
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Credentials).To(Equal(map[string]S.Credentials{
+				"preproduction": {Username: "preprod-username", Password: "preprod-password"},
+				"production":    {Username: "prod-username", Password: "prod-password"},
+			}))
+		})
+
+		It("does not require CF_USERNAME and CF_PASSWORD when every environment has credentials", func() {
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns the most specific credentials for a foundation", func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			production := config.Environments["production"]
+			Expect(config.GetCredentials(production, "https://api.foundation-2.example.com").Username).To(Equal("prod-username"))
+			Expect(config.GetCredentials(production, "https://api.foundation-3.example.com").Username).To(Equal("preprod-username"))
+			Expect(config.GetCredentials(S.Environment{}, "").Username).To(Equal(cfUsername))
+		})
+
+		It("returns an error when a credentials environment variable is missing", func() {
+			env.GetCall.Returns.Values["PREPROD_CF_PASSWORD"] = ""
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(MatchError("missing credentials environment variables: PREPROD_CF_PASSWORD"))
+		})
+
+		It("returns an error when the secrets file cannot be read", func() {
+			Expect(os.RemoveAll(secretsFilePath)).To(Succeed())
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(BeAssignableToTypeOf(SecretsFileError{}))
+		})
+
+		It("returns an error when an environment refers to unknown credentials", func() {
+			testConfig := `---
+environments:
+- name: production
+  credentials: does-not-exist
+  foundations:
+  - https://api.foundation-1.example.com
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(MatchError(UnknownCredentialsError{"production", "does-not-exist"}))
+		})
+	})
+
+	Context("when the config refers to environment variables", func() {
+		BeforeEach(func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+		})
+
+		It("expands the variables before parsing the config", func() {
+			env.GetCall.Returns.Values["PROD_FOUNDATION"] = "https://api.prod.example.com"
+			env.GetCall.Returns.Values["PROD_DOMAIN"] = "prod.example.com"
+
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - ${PROD_FOUNDATION}
+  domain: ${PROD_DOMAIN}
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].Foundations).To(Equal([]string{"https://api.prod.example.com"}))
+			Expect(config.Environments["production"].Domain).To(Equal("prod.example.com"))
+		})
+
+		It("uses the default when a variable is empty or not set", func() {
+			env.GetCall.Returns.Values["PROD_DOMAIN"] = ""
+
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - ${PROD_FOUNDATION:-https://api.default.example.com}
+  domain: ${PROD_DOMAIN:-default.example.com}
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].Foundations).To(Equal([]string{"https://api.default.example.com"}))
+			Expect(config.Environments["production"].Domain).To(Equal("default.example.com"))
+		})
+
+		It("does not expand an escaped reference", func() {
+			env.GetCall.Returns.Values["PROD_DOMAIN"] = "prod.example.com"
+
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - https://api.prod.example.com
+  domain: $${PROD_DOMAIN}
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].Domain).To(Equal("${PROD_DOMAIN}"))
+		})
+
+		It("returns an error listing every missing variable", func() {
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - ${PROD_FOUNDATION}
+  - ${PROD_FOUNDATION}
+  domain: ${PROD_DOMAIN}
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(MatchError("missing config environment variables: PROD_FOUNDATION, PROD_DOMAIN"))
+		})
+
+		It("does not expand references in comments", func() {
+			testConfig := `---
+# domain: ${UNSET_DOMAIN}
+environments:
+- name: production
+  foundations:
+  - https://api.prod.example.com # ${UNSET_FOUNDATION}
+  domain: "prod.example.com" # ${UNSET_DOMAIN}
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].Domain).To(Equal("prod.example.com"))
+		})
+
+		It("quotes values that would change the structure of the config", func() {
+			env.GetCall.Returns.Values["PROD_DOMAIN"] = "prod.example.com\n  authenticate: true"
+			env.GetCall.Returns.Values["PROD_HOST"] = "api.prod.example.com # comment"
+			env.GetCall.Returns.Values["PROD_PARAM"] = "key: value"
+
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - https://${PROD_HOST}
+  domain: ${PROD_DOMAIN}
+  custom_params:
+    param: ${PROD_PARAM}
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			production := config.Environments["production"]
+			Expect(production.Domain).To(Equal("prod.example.com\n  authenticate: true"))
+			Expect(production.Authenticate).To(BeFalse())
+			Expect(production.Foundations).To(Equal([]string{"https://api.prod.example.com # comment"}))
+			Expect(production.CustomParams["param"]).To(Equal("key: value"))
+		})
+
+		It("escapes values in quoted scalars", func() {
+			env.GetCall.Returns.Values["PROD_DOMAIN"] = `prod "example" \ com`
+			env.GetCall.Returns.Values["PROD_NAME"] = "prod's"
+
+			testConfig := `---
+environments:
+- name: '${PROD_NAME}'
+  foundations:
+  - https://api.prod.example.com
+  domain: "${PROD_DOMAIN}"
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments).To(HaveKey("prod's"))
+			Expect(config.Environments["prod's"].Domain).To(Equal(`prod "example" \ com`))
+		})
+
+		It("expands numbers and booleans without quoting them", func() {
+			env.GetCall.Returns.Values["PROD_INSTANCES"] = "3"
+			env.GetCall.Returns.Values["PROD_AUTHENTICATE"] = "true"
+
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - https://api.prod.example.com
+  instances: ${PROD_INSTANCES}
+  authenticate: ${PROD_AUTHENTICATE}
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			config, err := Custom(env.Get, customConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Environments["production"].Instances).To(Equal(uint16(3)))
+			Expect(config.Environments["production"].Authenticate).To(BeTrue())
+		})
+	})
+
 	Context("when no error matchers are present", func() {
 		It("has zero error matchers", func() {
 			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
@@ -300,5 +974,28 @@ error_matchers:
 			Expect(config.ErrorMatchers[0].Descriptor()).To(Equal("a matcher: ab: 12: an error code"))
 			Expect(config.ErrorMatchers[1].Descriptor()).To(Equal("another matcher: cd: 34: "))
 		})
+
+		It("returns an error when a pattern is not a valid regular expression", func() {
+			env.GetCall.Returns.Values["CF_USERNAME"] = cfUsername
+			env.GetCall.Returns.Values["CF_PASSWORD"] = cfPassword
+
+			testConfig := `---
+environments:
+- name: production
+  foundations:
+  - api1.example.com
+error_matchers:
+- description: a matcher
+  pattern: ab
+- description: a bad matcher
+  pattern: "cd["
+`
+			Expect(ioutil.WriteFile(customConfigPath, []byte(testConfig), 0644)).To(Succeed())
+
+			_, err := Custom(env.Get, customConfigPath)
+
+			Expect(err).To(BeAssignableToTypeOf(ErrorMatcherError{}))
+			Expect(err.(ErrorMatcherError).Number).To(Equal(2))
+		})
 	})
 })
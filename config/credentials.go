@@ -0,0 +1,98 @@
+package config
+
+import (
+	"io/ioutil"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	"github.com/compozed/deployadactyl/geterrors"
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+// credentialsYaml is a named reference to a Cloud Foundry username and password.
+// The values are read from the named environment variables or from a secrets file
+// containing username and password keys.
+type credentialsYaml struct {
+	Name        string
+	UsernameEnv string `yaml:"username_env"`
+	PasswordEnv string `yaml:"password_env"`
+	SecretsFile string `yaml:"secrets_file"`
+}
+
+type secretsFileYaml struct {
+	Username string
+	Password string
+}
+
+func getCredentialsFromConfig(getenv func(string) string, foundationConfig configYaml) (map[string]s.Credentials, error) {
+	getter := geterrors.WrapFunc(getenv)
+	credentials := map[string]s.Credentials{}
+
+	for _, c := range foundationConfig.Credentials {
+		if c.Name == "" {
+			return nil, MissingCredentialsParameterError{}
+		}
+
+		if c.SecretsFile != "" {
+			secrets, err := readSecretsFile(c.SecretsFile)
+			if err != nil {
+				return nil, SecretsFileError{c.Name, err}
+			}
+
+			credentials[c.Name] = s.Credentials{Username: secrets.Username, Password: secrets.Password}
+			continue
+		}
+
+		if c.UsernameEnv == "" || c.PasswordEnv == "" {
+			return nil, MissingCredentialsParameterError{c.Name}
+		}
+
+		credentials[c.Name] = s.Credentials{
+			Username: getter.Get(c.UsernameEnv),
+			Password: getter.Get(c.PasswordEnv),
+		}
+	}
+
+	if err := getter.Err("missing credentials environment variables"); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// checkCredentialReferences returns an error if an environment or foundation refers to
+// credentials that are not configured.
+func checkCredentialReferences(environments map[string]s.Environment, credentials map[string]s.Credentials) error {
+	for _, environment := range environments {
+		if _, ok := credentials[environment.Credentials]; environment.Credentials != "" && !ok {
+			return UnknownCredentialsError{environment.Name, environment.Credentials}
+		}
+
+		for _, foundation := range environment.FoundationConfigs {
+			if _, ok := credentials[foundation.Credentials]; foundation.Credentials != "" && !ok {
+				return UnknownCredentialsError{environment.Name, foundation.Credentials}
+			}
+		}
+	}
+
+	return nil
+}
+
+func readSecretsFile(path string) (secretsFileYaml, error) {
+	var secrets secretsFileYaml
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return secretsFileYaml{}, err
+	}
+
+	err = candiedyaml.Unmarshal(data, &secrets)
+	if err != nil {
+		return secretsFileYaml{}, err
+	}
+
+	if secrets.Username == "" || secrets.Password == "" {
+		return secretsFileYaml{}, MissingSecretsError{}
+	}
+
+	return secrets, nil
+}
@@ -21,3 +21,243 @@ type ParseYamlError struct {
 func (e ParseYamlError) Error() string {
 	return fmt.Sprintf("cannot parse yaml file: %s", e.Err)
 }
+
+type InvalidFoundationError struct {
+	Environment string
+	Foundation  string
+}
+
+func (e InvalidFoundationError) Error() string {
+	return fmt.Sprintf("foundations in environment %s must be a url or an object with a url: %s", e.Environment, e.Foundation)
+}
+
+type MissingCredentialsParameterError struct {
+	Name string
+}
+
+func (e MissingCredentialsParameterError) Error() string {
+	if e.Name == "" {
+		return "missing name in the credentials key"
+	}
+	return fmt.Sprintf("credentials %s must have username_env and password_env or a secrets_file", e.Name)
+}
+
+type UnknownCredentialsError struct {
+	Environment string
+	Credentials string
+}
+
+func (e UnknownCredentialsError) Error() string {
+	return fmt.Sprintf("environment %s refers to unknown credentials: %s", e.Environment, e.Credentials)
+}
+
+type SecretsFileError struct {
+	Name string
+	Err  error
+}
+
+func (e SecretsFileError) Error() string {
+	return fmt.Sprintf("cannot read secrets file for credentials %s: %s", e.Name, e.Err)
+}
+
+type MissingSecretsError struct{}
+
+func (e MissingSecretsError) Error() string {
+	return "secrets file must contain a username and password"
+}
+
+type ReloadError struct {
+	ConfigPath string
+	Err        error
+}
+
+func (e ReloadError) Error() string {
+	return fmt.Sprintf("cannot reload config file %s: keeping the current config: %s", e.ConfigPath, e.Err)
+}
+
+type ErrorMatcherError struct {
+	Number int
+	Err    error
+}
+
+func (e ErrorMatcherError) Error() string {
+	return fmt.Sprintf("error matcher %d is not valid: %s", e.Number, e.Err)
+}
+
+type InvalidConfigError struct {
+	Report ValidationReport
+}
+
+func (e InvalidConfigError) Error() string {
+	return fmt.Sprintf("config is not valid:\n%s", e.Report)
+}
+
+type FreezeWindowError struct {
+	Environment string
+	Err         error
+}
+
+func (e FreezeWindowError) Error() string {
+	return fmt.Sprintf("environment %s: %s", e.Environment, e.Err)
+}
+
+type TLSKeyPairError struct{}
+
+func (e TLSKeyPairError) Error() string {
+	return "DEPLOYADACTYL_TLS_CERT_FILE and DEPLOYADACTYL_TLS_KEY_FILE must be set together"
+}
+
+type TLSClientCAError struct{}
+
+func (e TLSClientCAError) Error() string {
+	return "client certificate verification requires DEPLOYADACTYL_TLS_CERT_FILE, DEPLOYADACTYL_TLS_KEY_FILE and DEPLOYADACTYL_TLS_CLIENT_CA_FILE"
+}
+
+type TLSClientAuthError struct {
+	ClientAuth string
+}
+
+func (e TLSClientAuthError) Error() string {
+	return fmt.Sprintf("DEPLOYADACTYL_TLS_CLIENT_AUTH must be require or verify_if_given: %s", e.ClientAuth)
+}
+
+type ApprovalTimeoutError struct {
+	Environment string
+	Timeout     string
+}
+
+func (e ApprovalTimeoutError) Error() string {
+	return fmt.Sprintf("environment %s has an approval_timeout that is not a positive duration: %s", e.Environment, e.Timeout)
+}
+
+type FreezeTimeZoneError struct {
+	Window   string
+	TimeZone string
+	Err      error
+}
+
+func (e FreezeTimeZoneError) Error() string {
+	return fmt.Sprintf("freeze window %s has an unknown time zone %s: %s", e.Window, e.TimeZone, e.Err)
+}
+
+type FreezeDateError struct {
+	Window string
+	Key    string
+	Value  string
+}
+
+func (e FreezeDateError) Error() string {
+	return fmt.Sprintf("freeze window %s has a malformed %s, expected YYYY-MM-DD or YYYY-MM-DD HH:MM: %s", e.Window, e.Key, e.Value)
+}
+
+type FreezeDateRangeError struct {
+	Window string
+}
+
+func (e FreezeDateRangeError) Error() string {
+	return fmt.Sprintf("freeze window %s must end after it starts", e.Window)
+}
+
+type FreezeCronError struct {
+	Window string
+	Cron   string
+	Reason string
+}
+
+func (e FreezeCronError) Error() string {
+	return fmt.Sprintf("freeze window %s has a malformed cron %q: %s", e.Window, e.Cron, e.Reason)
+}
+
+type FreezeDurationError struct {
+	Window   string
+	Duration string
+}
+
+func (e FreezeDurationError) Error() string {
+	return fmt.Sprintf("freeze window %s must have a duration between 1m and %s: %s", e.Window, maxFreezeDuration, e.Duration)
+}
+
+type FreezeScheduleError struct {
+	Window string
+}
+
+func (e FreezeScheduleError) Error() string {
+	return fmt.Sprintf("freeze window %s must have either start and end or cron and duration", e.Window)
+}
+
+type PolicyIdentityError struct {
+	Policy   string
+	Identity string
+}
+
+func (e PolicyIdentityError) Error() string {
+	return fmt.Sprintf("policy %s has an identity that is not anonymous, user:name, token:name, subject:name or group:name: %s", e.Policy, e.Identity)
+}
+
+type PolicyActionError struct {
+	Policy string
+	Action string
+}
+
+func (e PolicyActionError) Error() string {
+	return fmt.Sprintf("policy %s has an unknown action %s, expected one of %v or *", e.Policy, e.Action, PolicyActions)
+}
+
+type PolicyPatternError struct {
+	Policy  string
+	Pattern string
+}
+
+func (e PolicyPatternError) Error() string {
+	return fmt.Sprintf("policy %s has a malformed pattern: %s", e.Policy, e.Pattern)
+}
+
+type PolicyMissingError struct {
+	Policy string
+	Key    string
+}
+
+func (e PolicyMissingError) Error() string {
+	return fmt.Sprintf("policy %s has no %s", e.Policy, e.Key)
+}
+
+type OIDCMissingError struct {
+	Key string
+}
+
+func (e OIDCMissingError) Error() string {
+	return fmt.Sprintf("oidc is missing %s", e.Key)
+}
+
+type OIDCClockSkewError struct {
+	ClockSkew string
+}
+
+func (e OIDCClockSkewError) Error() string {
+	return fmt.Sprintf("oidc has a clock_skew that is not a duration of at least 0s: %s", e.ClockSkew)
+}
+
+type JanitorDurationError struct {
+	Key   string
+	Value string
+}
+
+func (e JanitorDurationError) Error() string {
+	return fmt.Sprintf("janitor %s must be a positive duration, eg: 24h: %s", e.Key, e.Value)
+}
+
+type JanitorSpaceError struct {
+	Index int
+}
+
+func (e JanitorSpaceError) Error() string {
+	return fmt.Sprintf("janitor space %d must have an environment, org and space", e.Index)
+}
+
+type JanitorEnvironmentNotFoundError struct {
+	Environment string
+}
+
+func (e JanitorEnvironmentNotFoundError) Error() string {
+	return fmt.Sprintf("janitor space refers to unknown environment: %s", e.Environment)
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+// maxFreezeDuration is the longest a cron freeze window can last. Checking a cron window
+// looks at every minute of its duration, so it is kept to a week. Longer freezes are date ranges.
+const maxFreezeDuration = 168 * time.Hour
+
+var freezeDateLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+// FreezeSchedule is when a freeze window freezes an environment.
+type FreezeSchedule struct {
+	start    time.Time
+	end      time.Time
+	cron     *cron
+	duration time.Duration
+	location *time.Location
+}
+
+// ParseFreezeWindow returns the schedule of a freeze window or an error when the window
+// cannot be used.
+func ParseFreezeWindow(window s.FreezeWindow) (FreezeSchedule, error) {
+	schedule := FreezeSchedule{location: time.UTC}
+
+	if window.TimeZone != "" {
+		location, err := time.LoadLocation(window.TimeZone)
+		if err != nil {
+			return FreezeSchedule{}, FreezeTimeZoneError{window.Name, window.TimeZone, err}
+		}
+		schedule.location = location
+	}
+
+	switch {
+	case window.Start != "" && window.End != "" && window.Cron == "" && window.Duration == "":
+		var err error
+
+		schedule.start, err = parseFreezeDate(window.Start, schedule.location)
+		if err != nil {
+			return FreezeSchedule{}, FreezeDateError{window.Name, "start", window.Start}
+		}
+
+		schedule.end, err = parseFreezeDate(window.End, schedule.location)
+		if err != nil {
+			return FreezeSchedule{}, FreezeDateError{window.Name, "end", window.End}
+		}
+
+		if !schedule.end.After(schedule.start) {
+			return FreezeSchedule{}, FreezeDateRangeError{window.Name}
+		}
+	case window.Cron != "" && window.Duration != "" && window.Start == "" && window.End == "":
+		c, err := parseCron(window.Cron)
+		if err != nil {
+			return FreezeSchedule{}, FreezeCronError{window.Name, window.Cron, err.Error()}
+		}
+		schedule.cron = c
+
+		schedule.duration, err = time.ParseDuration(window.Duration)
+		if err != nil || schedule.duration < time.Minute || schedule.duration > maxFreezeDuration {
+			return FreezeSchedule{}, FreezeDurationError{window.Name, window.Duration}
+		}
+	default:
+		return FreezeSchedule{}, FreezeScheduleError{window.Name}
+	}
+
+	return schedule, nil
+}
+
+func parseFreezeDate(value string, location *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range freezeDateLayouts {
+		var t time.Time
+		t, err = time.ParseInLocation(layout, value, location)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Active returns whether now is in the schedule and when the freeze that now is in ends.
+// A cron freeze is active when the cron matched any minute within the duration before now.
+func (schedule FreezeSchedule) Active(now time.Time) (time.Time, bool) {
+	if schedule.cron == nil {
+		return schedule.end, !now.Before(schedule.start) && now.Before(schedule.end)
+	}
+
+	now = now.In(schedule.location)
+	minute := now.Truncate(time.Minute)
+	for t := minute; now.Sub(t) < schedule.duration; t = t.Add(-time.Minute) {
+		if schedule.cron.matches(t) {
+			return t.Add(schedule.duration), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// cron is a parsed five field cron expression: minute, hour, day of month, month and day
+// of week. Each field is a set of the values it matches.
+type cron struct {
+	minute, hour, dom, month, dow map[int]bool
+
+	// domRestricted and dowRestricted follow cron: when both the day of month and the
+	// day of week are restricted, a day matches if either of them matches.
+	domRestricted, dowRestricted bool
+}
+
+func parseCron(expression string) (*cron, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, found %d", len(fields))
+	}
+
+	bounds := []struct {
+		name     string
+		min, max int
+	}{
+		{"minute", 0, 59},
+		{"hour", 0, 23},
+		{"day of month", 1, 31},
+		{"month", 1, 12},
+		{"day of week", 0, 7},
+	}
+
+	sets := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", bounds[i].name, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday is both 0 and 7.
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &cron{
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           sets[4],
+		domRestricted: !strings.HasPrefix(fields[2], "*"),
+		dowRestricted: !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField parses a comma separated list of *, values or ranges, each with an optional step.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := map[int]bool{}
+
+	for _, item := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %q", item[i+1:])
+			}
+			item, stepped = item[:i], true
+		}
+
+		low, high := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+
+			var err error
+			low, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", item)
+			}
+
+			high = low
+			if stepped {
+				high = max
+			}
+			if len(bounds) == 2 {
+				high, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", item)
+				}
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("%q is out of range (%d to %d)", item, min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
+
+func (c *cron) matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+
+	dom, dow := c.dom[t.Day()], c.dow[int(t.Weekday())]
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,61 @@
+package config_test
+
+import (
+	. "github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseFreezeWindow", func() {
+	It("accepts valid windows", func() {
+		_, err := ParseFreezeWindow(S.FreezeWindow{Name: "a", Start: "2026-01-01", End: "2026-01-02"})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = ParseFreezeWindow(S.FreezeWindow{Name: "b", Cron: "0 0 * * 7", Duration: "1h", TimeZone: "Europe/London"})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns an error for an unknown time zone", func() {
+		_, err := ParseFreezeWindow(S.FreezeWindow{Name: "a", Start: "2026-01-01", End: "2026-01-02", TimeZone: "Nowhere/City"})
+
+		Expect(err).To(BeAssignableToTypeOf(FreezeTimeZoneError{}))
+	})
+
+	It("returns an error for a malformed date", func() {
+		_, err := ParseFreezeWindow(S.FreezeWindow{Name: "a", Start: "01/01/2026", End: "2026-01-02"})
+
+		Expect(err).To(MatchError(FreezeDateError{"a", "start", "01/01/2026"}))
+	})
+
+	It("returns an error when the end is not after the start", func() {
+		_, err := ParseFreezeWindow(S.FreezeWindow{Name: "a", Start: "2026-01-02", End: "2026-01-01"})
+
+		Expect(err).To(MatchError(FreezeDateRangeError{"a"}))
+	})
+
+	It("returns an error for a malformed cron", func() {
+		for _, expression := range []string{"0 9 * *", "60 9 * * *", "0 9 * * mon"} {
+			_, err := ParseFreezeWindow(S.FreezeWindow{Name: "a", Cron: expression, Duration: "1h"})
+
+			Expect(err).To(BeAssignableToTypeOf(FreezeCronError{}))
+		}
+	})
+
+	It("returns an error for a duration that is out of range", func() {
+		for _, duration := range []string{"2w", "169h"} {
+			_, err := ParseFreezeWindow(S.FreezeWindow{Name: "a", Cron: "0 9 * * *", Duration: duration})
+
+			Expect(err).To(MatchError(FreezeDurationError{"a", duration}))
+		}
+	})
+
+	It("returns an error when a window is neither a date range nor a cron", func() {
+		_, err := ParseFreezeWindow(S.FreezeWindow{Name: "a", Start: "2026-01-01"})
+		Expect(err).To(MatchError(FreezeScheduleError{"a"}))
+
+		_, err = ParseFreezeWindow(S.FreezeWindow{Name: "a", Start: "2026-01-01", End: "2026-01-02", Cron: "0 9 * * *", Duration: "1h"})
+		Expect(err).To(MatchError(FreezeScheduleError{"a"}))
+	})
+})
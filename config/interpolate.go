@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/compozed/deployadactyl/geterrors"
+)
+
+// variablePattern matches ${VAR} and ${VAR:-default}. A reference can be escaped as $${VAR}.
+var variablePattern = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// blockPattern matches a line that starts a literal or folded block scalar.
+var blockPattern = regexp.MustCompile(`(^|\s)[|>][-+1-9]{0,2}\s*(#.*)?$`)
+
+// interpolate expands ${VAR} and ${VAR:-default} references in a config with values from getenv.
+// A default is used when the variable is empty or not set.
+//
+// References are expanded in the text of the config so they can be used for numbers and
+// booleans, but they are expanded as the YAML scalars they are in. References in comments are
+// not expanded, values are escaped in quoted scalars and a plain scalar is quoted when a value
+// would make it more than a single scalar, such as a value with ": ", " #" or a newline.
+//
+// Returns an error listing every variable without a default that is empty or not set.
+func interpolate(getenv func(string) string, data []byte) ([]byte, error) {
+	getter := geterrors.WrapFunc(getenv)
+	values := map[string]string{}
+
+	value := func(reference []byte) []byte {
+		groups := variablePattern.FindSubmatch(reference)
+		escaped, name, hasDefault, defaultValue := len(groups[1]) > 0, string(groups[2]), len(groups[3]) > 0, groups[4]
+
+		if escaped {
+			return reference[1:]
+		}
+
+		if value := getenv(name); value != "" {
+			return []byte(value)
+		}
+
+		if hasDefault {
+			return defaultValue
+		}
+
+		if _, found := values[name]; !found {
+			values[name] = getter.Get(name)
+		}
+		return nil
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	blockIndent := -1
+	for i, line := range lines {
+		indent := len(line) - len(bytes.TrimLeft(line, " "))
+		if blockIndent >= 0 && (len(bytes.TrimSpace(line)) == 0 || indent > blockIndent) {
+			lines[i] = interpolateBlock(line, indent, value)
+			continue
+		}
+
+		lines[i] = interpolateLine(line, value)
+
+		blockIndent = -1
+		if blockPattern.Match(line) {
+			blockIndent = keyIndent(line)
+		}
+	}
+
+	if err := getter.Err("missing config environment variables"); err != nil {
+		return nil, err
+	}
+
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// interpolateLine expands the references in the scalars of a line. The line is read as a
+// sequence of indicators, quoted scalars and plain scalars up to a comment.
+func interpolateLine(line []byte, value func([]byte) []byte) []byte {
+	result := make([]byte, 0, len(line))
+	flow := 0
+
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '#':
+			return append(result, line[i:]...)
+
+		case c == ' ' || c == '\t' || c == '\r':
+			result = append(result, c)
+			i++
+
+		case c == '[' || c == '{' || c == ']' || c == '}' || c == ',':
+			if c == '[' || c == '{' {
+				flow++
+			} else if c != ',' && flow > 0 {
+				flow--
+			}
+			result = append(result, c)
+			i++
+
+		case (c == '-' || c == '?' || c == ':') && (i+1 == len(line) || isSpace(line[i+1])):
+			result = append(result, c)
+			i++
+
+		case c == '"':
+			end := endOfQuoted(line, i+1, '"')
+			result = append(result, '"')
+			result = append(result, variablePattern.ReplaceAllFunc(line[i+1:end], func(reference []byte) []byte {
+				quoted := strconv.Quote(string(value(reference)))
+				return []byte(quoted[1 : len(quoted)-1])
+			})...)
+			i = end
+
+		case c == '\'':
+			end := endOfQuoted(line, i+1, '\'')
+			result = append(result, '\'')
+			result = append(result, variablePattern.ReplaceAllFunc(line[i+1:end], func(reference []byte) []byte {
+				return bytes.Replace(value(reference), []byte("'"), []byte("''"), -1)
+			})...)
+			i = end
+
+		default:
+			scalar := bytes.TrimRight(line[i:endOfPlain(line, i, flow > 0)], " \t\r")
+			result = append(result, interpolatePlain(scalar, flow > 0, value)...)
+			i += len(scalar)
+		}
+	}
+
+	return result
+}
+
+// interpolatePlain expands the references in a plain scalar. The scalar is quoted when it
+// would not be read as a single plain scalar after the references are expanded.
+func interpolatePlain(scalar []byte, flow bool, value func([]byte) []byte) []byte {
+	if !variablePattern.Match(scalar) {
+		return scalar
+	}
+
+	expanded := variablePattern.ReplaceAllFunc(scalar, value)
+	if isPlain(expanded, flow) {
+		return expanded
+	}
+
+	return []byte(strconv.Quote(string(expanded)))
+}
+
+// interpolateBlock expands the references in a line of a block scalar. The lines of a value
+// with newlines are indented like the line, so they stay in the block.
+func interpolateBlock(line []byte, indent int, value func([]byte) []byte) []byte {
+	newline := []byte("\n" + strings.Repeat(" ", indent))
+
+	return variablePattern.ReplaceAllFunc(line, func(reference []byte) []byte {
+		return bytes.Replace(value(reference), []byte("\n"), newline, -1)
+	})
+}
+
+// endOfQuoted returns the index after the closing quote of a quoted scalar that starts at
+// start, or the end of the line when the scalar continues on the next line.
+func endOfQuoted(line []byte, start int, quote byte) int {
+	for i := start; i < len(line); i++ {
+		switch {
+		case quote == '"' && line[i] == '\\':
+			i++
+		case quote == '\'' && line[i] == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			i++
+		case line[i] == quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// endOfPlain returns the index where a plain scalar that starts at start ends: at a ": " that
+// makes it a key, at a " #" comment, at a flow indicator in a flow collection or at the end
+// of the line. The braces of references are part of the scalar.
+func endOfPlain(line []byte, start int, flow bool) int {
+	for i := start; i < len(line); i++ {
+		if reference := variablePattern.FindIndex(line[i:]); reference != nil && reference[0] == 0 {
+			i += reference[1] - 1
+			continue
+		}
+
+		switch {
+		case line[i] == ':' && (i+1 == len(line) || isSpace(line[i+1]) || flow && isFlowIndicator(line[i+1])):
+			return i
+		case line[i] == '#' && i > start && isSpace(line[i-1]):
+			return i
+		case flow && isFlowIndicator(line[i]):
+			return i
+		}
+	}
+	return len(line)
+}
+
+// isPlain returns true when a value is read as the same single scalar without quotes.
+func isPlain(value []byte, flow bool) bool {
+	if len(value) == 0 {
+		return true
+	}
+
+	for _, c := range value {
+		if c < ' ' || c == 0x7f || flow && isFlowIndicator(c) {
+			return false
+		}
+	}
+
+	first, last := value[0], value[len(value)-1]
+	switch {
+	case first == ' ' || last == ' ' || last == ':':
+		return false
+	case strings.IndexByte("#&*!|>'\"%@`,[]{}", first) >= 0:
+		return false
+	case strings.IndexByte("-?:", first) >= 0 && (len(value) == 1 || value[1] == ' '):
+		return false
+	}
+
+	return !bytes.Contains(value, []byte(": ")) && !bytes.Contains(value, []byte(" #"))
+}
+
+// keyIndent returns the column of the key of a line, after any list item indicators.
+func keyIndent(line []byte) int {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '-' && i+1 < len(line) && line[i+1] == ' ') {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func isFlowIndicator(c byte) bool {
+	return c == ',' || c == '[' || c == ']' || c == '{' || c == '}'
+}
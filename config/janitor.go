@@ -0,0 +1,40 @@
+package config
+
+import (
+	"time"
+
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+// CheckJanitor returns an error when a janitor configuration cannot be used.
+func CheckJanitor(janitor s.Janitor) error {
+	if err := checkJanitorDuration("interval", janitor.Interval); err != nil {
+		return err
+	}
+
+	if err := checkJanitorDuration("older_than", janitor.OlderThan); err != nil {
+		return err
+	}
+
+	for i, space := range janitor.Spaces {
+		if space.Environment == "" || space.Org == "" || space.Space == "" {
+			return JanitorSpaceError{i + 1}
+		}
+	}
+
+	return nil
+}
+
+// checkJanitorDuration returns an error when a janitor duration is set and not positive.
+func checkJanitorDuration(key, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return JanitorDurationError{key, value}
+	}
+
+	return nil
+}
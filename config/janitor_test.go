@@ -0,0 +1,35 @@
+package config_test
+
+import (
+	. "github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckJanitor", func() {
+	var janitor S.Janitor
+
+	BeforeEach(func() {
+		janitor = S.Janitor{
+			Spaces: []S.JanitorSpace{{Environment: "preproduction", Org: "org", Space: "space"}},
+		}
+	})
+
+	It("accepts the defaults", func() {
+		Expect(CheckJanitor(janitor)).To(Succeed())
+	})
+
+	It("returns an error for a duration that is not positive", func() {
+		janitor.OlderThan = "-1h"
+
+		Expect(CheckJanitor(janitor)).To(MatchError(JanitorDurationError{"older_than", "-1h"}))
+	})
+
+	It("returns an error for a space without an org", func() {
+		janitor.Spaces[0].Org = ""
+
+		Expect(CheckJanitor(janitor)).To(MatchError(JanitorSpaceError{1}))
+	})
+})
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"time"
+
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+// CheckOIDC returns an error when an identity provider configuration cannot be used.
+func CheckOIDC(oidc s.OIDC) error {
+	for _, required := range []struct{ key, value string }{
+		{"issuer", oidc.Issuer},
+		{"audience", oidc.Audience},
+		{"jwks", oidc.JWKS},
+	} {
+		if required.value == "" {
+			return OIDCMissingError{required.key}
+		}
+	}
+
+	if oidc.ClockSkew != "" {
+		skew, err := time.ParseDuration(oidc.ClockSkew)
+		if err != nil || skew < 0 {
+			return OIDCClockSkewError{oidc.ClockSkew}
+		}
+	}
+
+	return nil
+}
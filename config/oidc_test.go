@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	. "github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckOIDC", func() {
+	var oidc S.OIDC
+
+	BeforeEach(func() {
+		oidc = S.OIDC{Issuer: "https://idp.example.com", Audience: "deployadactyl", JWKS: "/jwks.json", ClockSkew: "30s"}
+	})
+
+	It("accepts a complete configuration", func() {
+		Expect(CheckOIDC(oidc)).To(Succeed())
+	})
+
+	It("returns an error when a required setting is missing", func() {
+		Expect(CheckOIDC(S.OIDC{Issuer: "https://idp.example.com", JWKS: "/jwks.json"})).To(MatchError(OIDCMissingError{"audience"}))
+		Expect(CheckOIDC(S.OIDC{Issuer: "https://idp.example.com", Audience: "deployadactyl"})).To(MatchError(OIDCMissingError{"jwks"}))
+	})
+
+	It("returns an error when the clock skew is not a duration", func() {
+		oidc.ClockSkew = "a minute"
+
+		Expect(CheckOIDC(oidc)).To(MatchError(OIDCClockSkewError{"a minute"}))
+	})
+})
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"path"
+	"strings"
+
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+// Actions that policies allow.
+const (
+	DeployAction   = "deploy"
+	RollbackAction = "rollback"
+	PromoteAction  = "promote"
+	ApproveAction  = "approve"
+	ReadAction     = "read"
+)
+
+// PolicyActions are every action that policies allow.
+var PolicyActions = []string{DeployAction, RollbackAction, PromoteAction, ApproveAction, ReadAction}
+
+// Kinds of the identities of policies.
+const (
+	AnonymousIdentity = "anonymous"
+	UserIdentity      = "user"
+	TokenIdentity     = "token"
+	SubjectIdentity   = "subject"
+	GroupIdentity     = "group"
+)
+
+// ParsePolicyIdentity splits a policy identity, such as user:name, into its kind and name.
+func ParsePolicyIdentity(identity string) (string, string) {
+	parts := strings.SplitN(identity, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// CheckPolicy returns an error when a policy cannot be used.
+func CheckPolicy(p s.Policy) error {
+	if len(p.Identities) == 0 {
+		return PolicyMissingError{p.Name, "identities"}
+	}
+
+	for _, identity := range p.Identities {
+		kind, name := ParsePolicyIdentity(identity)
+		if !validIdentity(kind, name) {
+			return PolicyIdentityError{p.Name, identity}
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return PolicyPatternError{p.Name, identity}
+		}
+	}
+
+	if len(p.Actions) == 0 {
+		return PolicyMissingError{p.Name, "actions"}
+	}
+
+	for _, action := range p.Actions {
+		if action != "*" && !contains(PolicyActions, action) {
+			return PolicyActionError{p.Name, action}
+		}
+	}
+
+	for _, patterns := range [][]string{p.Environments, p.Orgs, p.Spaces, p.Apps} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return PolicyPatternError{p.Name, pattern}
+			}
+		}
+	}
+
+	return nil
+}
+
+func validIdentity(kind, name string) bool {
+	switch kind {
+	case AnonymousIdentity:
+		return name == ""
+	case UserIdentity, TokenIdentity, SubjectIdentity, GroupIdentity:
+		return name != ""
+	}
+	return false
+}
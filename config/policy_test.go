@@ -0,0 +1,41 @@
+package config_test
+
+import (
+	. "github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckPolicy", func() {
+	It("accepts valid policies", func() {
+		Expect(CheckPolicy(S.Policy{Name: "p", Identities: []string{"anonymous"}, Actions: []string{"*"}})).To(Succeed())
+		Expect(CheckPolicy(S.Policy{
+			Name:         "p",
+			Identities:   []string{"user:*@example.com", "token:ci", "subject:alice", "group:release-*"},
+			Environments: []string{"prod*"},
+			Spaces:       []string{"dev-?"},
+			Actions:      PolicyActions,
+		})).To(Succeed())
+	})
+
+	It("returns an error for a policy without identities or actions", func() {
+		Expect(CheckPolicy(S.Policy{Name: "p", Actions: []string{DeployAction}})).To(MatchError(PolicyMissingError{"p", "identities"}))
+		Expect(CheckPolicy(S.Policy{Name: "p", Identities: []string{"anonymous"}})).To(MatchError(PolicyMissingError{"p", "actions"}))
+	})
+
+	It("returns an error for an unknown identity", func() {
+		for _, identity := range []string{"alice", "user:", "token:", "group:"} {
+			Expect(CheckPolicy(S.Policy{Name: "p", Identities: []string{identity}, Actions: []string{DeployAction}})).To(MatchError(PolicyIdentityError{"p", identity}))
+		}
+	})
+
+	It("returns an error for an unknown action", func() {
+		Expect(CheckPolicy(S.Policy{Name: "p", Identities: []string{"anonymous"}, Actions: []string{"delete"}})).To(MatchError(PolicyActionError{"p", "delete"}))
+	})
+
+	It("returns an error for a malformed pattern", func() {
+		Expect(CheckPolicy(S.Policy{Name: "p", Identities: []string{"anonymous"}, Actions: []string{DeployAction}, Spaces: []string{"dev-["}})).To(MatchError(PolicyPatternError{"p", "dev-["}))
+	})
+})
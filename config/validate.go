@@ -0,0 +1,679 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+const (
+	// SeverityError is a problem that stops the config from being loaded or used.
+	SeverityError = "error"
+
+	// SeverityWarning is a problem that does not stop the config from being loaded.
+	SeverityWarning = "warning"
+)
+
+// The keys of each section are the keys the structs it is loaded into are read from, so
+// they cannot drift apart. Foundations are parsed separately from their environment.
+var (
+	configKeys       = yamlKeys(configYaml{})
+	environmentKeys  = append(yamlKeys(s.Environment{}), "foundations")
+	freezeKeys       = yamlKeys(s.FreezeWindow{})
+	foundationKeys   = yamlKeys(foundationYaml{})
+	matcherKeys      = yamlKeys(s.ErrorMatcherDescriptor{})
+	policyKeys       = yamlKeys(s.Policy{})
+	credentialsKeys  = yamlKeys(credentialsYaml{})
+	oidcKeys         = yamlKeys(s.OIDC{})
+	janitorKeys      = yamlKeys(s.Janitor{})
+	janitorSpaceKeys = yamlKeys(s.JanitorSpace{})
+)
+
+// Problem is a single problem found in a config file. Line is the 1-based line of the
+// config file the problem was found on, or 0 when it is not known.
+type Problem struct {
+	Line     int
+	Severity string
+	Message  string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Severity, p.Message)
+}
+
+// ValidationReport has every Problem found in a config file.
+type ValidationReport struct {
+	Problems []Problem
+}
+
+// HasErrors returns true if the report has a problem with SeverityError.
+func (r ValidationReport) HasErrors() bool {
+	for _, problem := range r.Problems {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ValidationReport) String() string {
+	lines := []string{}
+	for _, problem := range r.Problems {
+		lines = append(lines, problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidateFile reads a config file, expands environment variable references with getenv
+// and returns a report of every problem in it.
+func ValidateFile(getenv func(string) string, configPath string) (ValidationReport, error) {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return ValidationReport{}, err
+	}
+
+	data, err = interpolate(getenv, data)
+	if err != nil {
+		return ValidationReport{Problems: []Problem{{0, SeverityError, err.Error()}}}, nil
+	}
+
+	return Validate(data), nil
+}
+
+// Validate returns a report of every problem in a config. Unlike loading a config, it does
+// not stop at the first problem and it reports problems that loading silently ignores,
+// such as unknown keys.
+func Validate(data []byte) ValidationReport {
+	v := &validator{lines: strings.Split(string(data), "\n")}
+
+	var config map[interface{}]interface{}
+	err := candiedyaml.Unmarshal(data, &config)
+	if err != nil {
+		v.errorf(0, "%s", ParseYamlError{err})
+		return v.report
+	}
+
+	v.checkKeys(config, configKeys, 0, "config")
+
+	credentials := v.validateCredentials(config["credentials"])
+	v.validateEnvironments(config["environments"], credentials)
+	v.validateErrorMatchers(config["error_matchers"])
+	v.validatePolicies(config["policies"])
+	v.validateOIDC(config["oidc"])
+	v.validateJanitor(config["janitor"], config["environments"])
+
+	sort.Stable(byLine(v.report.Problems))
+
+	return v.report
+}
+
+// byLine sorts problems by line, keeping problems without a line first.
+type byLine []Problem
+
+func (b byLine) Len() int           { return len(b) }
+func (b byLine) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byLine) Less(i, j int) bool { return b[i].Line < b[j].Line }
+
+type validator struct {
+	lines  []string
+	report ValidationReport
+}
+
+func (v *validator) errorf(line int, format string, a ...interface{}) {
+	v.report.Problems = append(v.report.Problems, Problem{line, SeverityError, fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) warnf(line int, format string, a ...interface{}) {
+	v.report.Problems = append(v.report.Problems, Problem{line, SeverityWarning, fmt.Sprintf(format, a...)})
+}
+
+// find returns the first line at or after start that matches the pattern, or 0 if
+// there is none. start and the returned line are 1-based.
+func (v *validator) find(start int, pattern string) int {
+	re := regexp.MustCompile(pattern)
+
+	if start < 1 {
+		start = 1
+	}
+
+	for i := start - 1; i < len(v.lines); i++ {
+		if re.MatchString(v.lines[i]) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// findKey returns the line of a key, optionally with a value, at or after start.
+func (v *validator) findKey(start int, key, value string) int {
+	valuePattern := `.*`
+	if value != "" {
+		valuePattern = `["']?` + regexp.QuoteMeta(value) + `["']?\s*(#.*)?$`
+	}
+	return v.find(start, `^\s*(-\s+)?`+regexp.QuoteMeta(key)+`:\s*`+valuePattern)
+}
+
+// findItem returns the line of a list item with a scalar value at or after start.
+func (v *validator) findItem(start int, value string) int {
+	return v.find(start, `^\s*-\s+["']?`+regexp.QuoteMeta(value)+`["']?\s*(#.*)?$`)
+}
+
+// findMap returns the first line of an object at or after start, using the keys that
+// have string values to tell it apart from other objects.
+func (v *validator) findMap(start int, m map[interface{}]interface{}) int {
+	first := 0
+	for key, value := range m {
+		if _, isString := value.(string); !isString {
+			continue
+		}
+
+		line := v.findKey(start, fmt.Sprint(key), toString(value))
+		if line != 0 && (first == 0 || line < first) {
+			first = line
+		}
+	}
+	return first
+}
+
+func (v *validator) checkKeys(m map[interface{}]interface{}, known []string, start int, parent string) {
+	unknown := []string{}
+	for key := range m {
+		name := fmt.Sprint(key)
+		if !contains(known, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		v.warnf(v.findKey(start, key, ""), "unknown key %s in %s", key, parent)
+	}
+}
+
+func (v *validator) validateCredentials(value interface{}) []string {
+	names := []string{}
+	if value == nil {
+		return names
+	}
+
+	start := v.find(1, `^credentials:`)
+
+	list, ok := value.([]interface{})
+	if !ok {
+		v.errorf(start, "credentials must be a list")
+		return names
+	}
+
+	for i, item := range list {
+		c, ok := item.(map[interface{}]interface{})
+		if !ok {
+			v.errorf(start, "credentials %d must be an object", i+1)
+			continue
+		}
+
+		name := toString(c["name"])
+		if name == "" {
+			v.errorf(start, "credentials %d is missing a name", i+1)
+		} else {
+			if line := v.findKey(start+1, "name", name); line != 0 {
+				start = line
+			}
+
+			if contains(names, name) {
+				v.errorf(start, "duplicate credentials name: %s", name)
+			}
+			names = append(names, name)
+		}
+
+		v.checkKeys(c, credentialsKeys, start, fmt.Sprintf("credentials %s", name))
+
+		if toString(c["secrets_file"]) == "" && (toString(c["username_env"]) == "" || toString(c["password_env"]) == "") {
+			v.errorf(start, "credentials %s must have a secrets_file or both username_env and password_env", name)
+		}
+	}
+
+	return names
+}
+
+func (v *validator) validateEnvironments(value interface{}, credentials []string) {
+	start := v.find(1, `^environments:`)
+
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		v.errorf(start, "%s", EnvironmentsNotSpecifiedError{})
+		return
+	}
+
+	names := map[string]int{}
+	for i, item := range list {
+		environment, ok := item.(map[interface{}]interface{})
+		if !ok {
+			v.errorf(start, "environment %d must be an object", i+1)
+			continue
+		}
+
+		name := toString(environment["name"])
+		label := fmt.Sprintf("environment %s", name)
+
+		if name == "" {
+			label = fmt.Sprintf("environment %d", i+1)
+			if line := v.findMap(start+1, environment); line != 0 {
+				start = line
+			}
+			v.errorf(start, "%s is missing a name", label)
+		} else {
+			if line := v.findKey(start+1, "name", name); line != 0 {
+				start = line
+			}
+
+			if line, found := names[strings.ToLower(name)]; found {
+				v.errorf(start, "duplicate environment name: %s is also defined on line %d", name, line)
+			} else {
+				names[strings.ToLower(name)] = start
+			}
+		}
+
+		v.checkKeys(environment, environmentKeys, start, label)
+		v.validateInstances(environment["instances"], start, label)
+		v.validateCredentialsReference(environment["credentials"], credentials, start, label)
+		v.validateFoundations(environment["foundations"], credentials, start, label)
+		v.validateFreezeWindows(environment["freeze_windows"], start, label)
+		v.validateApprovals(environment, start, label)
+	}
+}
+
+func (v *validator) validateInstances(value interface{}, start int, label string) {
+	if value == nil {
+		return
+	}
+
+	line := v.findKey(start, "instances", "")
+
+	instances, ok := toInt(value)
+	if !ok {
+		v.errorf(line, "%s has instances that is not a whole number: %v", label, value)
+	} else if instances < 0 || instances > 65535 {
+		v.errorf(line, "%s has instances out of range (0 to 65535): %d", label, instances)
+	} else if instances == 0 {
+		v.warnf(line, "%s has 0 instances and will be deployed with 1 instance", label)
+	}
+}
+
+func (v *validator) validateCredentialsReference(value interface{}, credentials []string, start int, label string) {
+	name := toString(value)
+	if name != "" && !contains(credentials, name) {
+		v.errorf(v.findKey(start, "credentials", name), "%s refers to unknown credentials: %s", label, name)
+	}
+}
+
+func (v *validator) validateFoundations(value interface{}, credentials []string, start int, label string) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		v.errorf(start, "%s has no foundations", label)
+		return
+	}
+
+	for _, item := range list {
+		switch foundation := item.(type) {
+		case string:
+			v.validateFoundationURL(foundation, v.findItem(start, foundation), label)
+		case map[interface{}]interface{}:
+			foundationURL := toString(foundation["url"])
+			line := v.findKey(start, "url", foundationURL)
+
+			if foundationURL == "" {
+				v.errorf(start, "%s has a foundation without a url", label)
+			} else {
+				v.validateFoundationURL(foundationURL, line, label)
+			}
+
+			v.checkKeys(foundation, foundationKeys, line, fmt.Sprintf("foundation %s", foundationURL))
+			v.validateCredentialsReference(foundation["credentials"], credentials, line, fmt.Sprintf("foundation %s", foundationURL))
+		default:
+			v.errorf(start, "%s", InvalidFoundationError{label, fmt.Sprint(item)})
+		}
+	}
+}
+
+func (v *validator) validateFreezeWindows(value interface{}, start int, label string) {
+	if value == nil {
+		return
+	}
+
+	line := v.findKey(start, "freeze_windows", "")
+
+	list, ok := value.([]interface{})
+	if !ok {
+		v.errorf(line, "%s has freeze_windows that is not a list", label)
+		return
+	}
+
+	for i, item := range list {
+		w, ok := item.(map[interface{}]interface{})
+		if !ok {
+			v.errorf(line, "%s has freeze window %d that is not an object", label, i+1)
+			continue
+		}
+
+		window := s.FreezeWindow{
+			Name:     toString(w["name"]),
+			Start:    toString(w["start"]),
+			End:      toString(w["end"]),
+			Cron:     toString(w["cron"]),
+			Duration: toString(w["duration"]),
+			TimeZone: toString(w["time_zone"]),
+		}
+		if window.Name == "" {
+			window.Name = strconv.Itoa(i + 1)
+		}
+
+		if found := v.findMap(line+1, w); found != 0 {
+			line = found
+		}
+
+		v.checkKeys(w, freezeKeys, line, fmt.Sprintf("freeze window %s", window.Name))
+
+		if _, err := ParseFreezeWindow(window); err != nil {
+			v.errorf(line, "%s: %s", label, err)
+		}
+	}
+}
+
+func (v *validator) validateApprovals(environment map[interface{}]interface{}, start int, label string) {
+	if value, ok := environment["approvers"]; ok {
+		approvers, isList := value.([]interface{})
+		if !isList || len(approvers) == 0 {
+			v.errorf(v.findKey(start, "approvers", ""), "%s has approvers that is not a list of usernames", label)
+		}
+	}
+
+	if value, ok := environment["approval_timeout"]; ok {
+		if _, err := ApprovalTimeout(s.Environment{Name: toString(environment["name"]), ApprovalTimeout: toString(value)}); err != nil {
+			v.errorf(v.findKey(start, "approval_timeout", ""), "%s", err)
+		}
+
+		if environment["approvers"] == nil {
+			v.warnf(v.findKey(start, "approval_timeout", ""), "%s has an approval_timeout but no approvers", label)
+		}
+	}
+}
+
+func (v *validator) validateFoundationURL(foundationURL string, line int, label string) {
+	u, err := url.Parse(foundationURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.errorf(line, "%s has a malformed foundation url, expected http(s)://host: %s", label, foundationURL)
+	}
+}
+
+func (v *validator) validatePolicies(value interface{}) {
+	if value == nil {
+		return
+	}
+
+	start := v.find(1, `^policies:`)
+
+	list, ok := value.([]interface{})
+	if !ok {
+		v.errorf(start, "policies must be a list")
+		return
+	}
+
+	for i, item := range list {
+		p, ok := item.(map[interface{}]interface{})
+		if !ok {
+			v.errorf(start, "policy %d must be an object", i+1)
+			continue
+		}
+
+		name := toString(p["name"])
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+
+		if line := v.findMap(start+1, p); line != 0 {
+			start = line
+		}
+
+		v.checkKeys(p, policyKeys, start, fmt.Sprintf("policy %s", name))
+
+		for _, key := range policyKeys[1:] {
+			if _, isList := p[key].([]interface{}); p[key] != nil && !isList {
+				v.errorf(v.findKey(start, key, ""), "policy %s has %s that is not a list", name, key)
+			}
+		}
+
+		err := CheckPolicy(s.Policy{
+			Name:         name,
+			Identities:   toStrings(p["identities"]),
+			Environments: toStrings(p["environments"]),
+			Orgs:         toStrings(p["orgs"]),
+			Spaces:       toStrings(p["spaces"]),
+			Apps:         toStrings(p["apps"]),
+			Actions:      toStrings(p["actions"]),
+		})
+		if err != nil {
+			v.errorf(start, "%s", err)
+		}
+	}
+}
+
+func (v *validator) validateOIDC(value interface{}) {
+	if value == nil {
+		return
+	}
+
+	start := v.find(1, `^oidc:`)
+
+	o, ok := value.(map[interface{}]interface{})
+	if !ok {
+		v.errorf(start, "oidc must be an object")
+		return
+	}
+
+	v.checkKeys(o, oidcKeys, start, "oidc")
+
+	err := CheckOIDC(s.OIDC{
+		Issuer:       toString(o["issuer"]),
+		Audience:     toString(o["audience"]),
+		JWKS:         toString(o["jwks"]),
+		SubjectClaim: toString(o["subject_claim"]),
+		GroupsClaim:  toString(o["groups_claim"]),
+		ClockSkew:    toString(o["clock_skew"]),
+	})
+	if err != nil {
+		line := start
+		if e, ok := err.(OIDCClockSkewError); ok {
+			line = v.findKey(start, "clock_skew", e.ClockSkew)
+		}
+		v.errorf(line, "%s", err)
+	}
+}
+
+func (v *validator) validateJanitor(value, environments interface{}) {
+	if value == nil {
+		return
+	}
+
+	start := v.find(1, `^janitor:`)
+
+	j, ok := value.(map[interface{}]interface{})
+	if !ok {
+		v.errorf(start, "janitor must be an object")
+		return
+	}
+
+	v.checkKeys(j, janitorKeys, start, "janitor")
+
+	if err := checkJanitorDuration("interval", toString(j["interval"])); err != nil {
+		v.errorf(v.findKey(start, "interval", ""), "%s", err)
+	}
+
+	if err := checkJanitorDuration("older_than", toString(j["older_than"])); err != nil {
+		v.errorf(v.findKey(start, "older_than", ""), "%s", err)
+	}
+
+	if _, isBool := j["dry_run"].(bool); j["dry_run"] != nil && !isBool {
+		v.errorf(v.findKey(start, "dry_run", ""), "janitor dry_run must be true or false")
+	}
+
+	if j["spaces"] == nil {
+		return
+	}
+
+	list, ok := j["spaces"].([]interface{})
+	if !ok {
+		v.errorf(v.findKey(start, "spaces", ""), "janitor spaces must be a list")
+		return
+	}
+
+	names := map[string]bool{}
+	environmentList, _ := environments.([]interface{})
+	for _, item := range environmentList {
+		if environment, ok := item.(map[interface{}]interface{}); ok {
+			names[strings.ToLower(toString(environment["name"]))] = true
+		}
+	}
+
+	for i, item := range list {
+		space, ok := item.(map[interface{}]interface{})
+		if !ok {
+			v.errorf(start, "janitor space %d must be an object", i+1)
+			continue
+		}
+
+		if line := v.find(start+1, `^\s*-\s`); line != 0 {
+			start = line
+		}
+
+		v.checkKeys(space, janitorSpaceKeys, start, fmt.Sprintf("janitor space %d", i+1))
+
+		if toString(space["environment"]) == "" || toString(space["org"]) == "" || toString(space["space"]) == "" {
+			v.errorf(start, "%s", JanitorSpaceError{Index: i + 1})
+			continue
+		}
+
+		if !names[strings.ToLower(toString(space["environment"]))] {
+			v.errorf(v.findKey(start, "environment", toString(space["environment"])), "%s", JanitorEnvironmentNotFoundError{Environment: toString(space["environment"])})
+		}
+	}
+}
+
+func (v *validator) validateErrorMatchers(value interface{}) {
+	if value == nil {
+		return
+	}
+
+	start := v.find(1, `^error_matchers:`)
+
+	list, ok := value.([]interface{})
+	if !ok {
+		v.errorf(start, "error_matchers must be a list")
+		return
+	}
+
+	for i, item := range list {
+		matcher, ok := item.(map[interface{}]interface{})
+		if !ok {
+			v.errorf(start, "error matcher %d must be an object", i+1)
+			continue
+		}
+
+		pattern := toString(matcher["pattern"])
+		if pattern == "" {
+			if line := v.findMap(start+1, matcher); line != 0 {
+				start = line
+			}
+			v.errorf(start, "error matcher %d is missing a pattern", i+1)
+		} else {
+			if line := v.findKey(start+1, "pattern", pattern); line != 0 {
+				start = line
+			}
+
+			_, err := regexp.Compile(pattern)
+			if err != nil {
+				v.errorf(start, "error matcher %d has an invalid pattern: %s", i+1, err)
+			}
+		}
+
+		v.checkKeys(matcher, matcherKeys, start, fmt.Sprintf("error matcher %d", i+1))
+	}
+}
+
+// yamlKeys returns the keys the yaml package reads the exported fields of a struct from:
+// the name of the yaml tag, or the lowercase field name when the tag has no name. Fields
+// tagged with "-" are skipped.
+func yamlKeys(value interface{}) []string {
+	t := reflect.TypeOf(value)
+
+	keys := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		keys = append(keys, name)
+	}
+
+	return keys
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func toInt(value interface{}) (int64, bool) {
+	switch n := value.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	case float64:
+		return int64(n), float64(int64(n)) == n
+	}
+	return 0, false
+}
+
+// toStrings returns the items of a list as strings.
+func toStrings(value interface{}) []string {
+	list, _ := value.([]interface{})
+
+	strings := make([]string, 0, len(list))
+	for _, item := range list {
+		strings = append(strings, toString(item))
+	}
+	return strings
+}
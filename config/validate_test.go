@@ -0,0 +1,352 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/compozed/deployadactyl/config"
+	"github.com/compozed/deployadactyl/mocks"
+)
+
+var _ = Describe("Validate", func() {
+	Context("when the config is valid", func() {
+		It("returns no problems", func() {
+			report := Validate([]byte(`---
+credentials:
+- name: production
+  username_env: PROD_CF_USERNAME
+  password_env: PROD_CF_PASSWORD
+environments:
+- name: preproduction
+  domain: preproduction.example.com
+  foundations:
+  - https://api.foundation-1.example.com
+  - url: https://api.foundation-2.example.com
+    name: foundation-2
+    timeout: 30
+  instances: 2
+- name: production
+  credentials: production
+  foundations:
+  - https://production.foundation-1.example.com
+error_matchers:
+- description: access denied
+  pattern: access[- ]denied
+  solution: check the permissions
+  code: ACCESS
+`))
+
+			Expect(report.Problems).To(BeEmpty())
+			Expect(report.HasErrors()).To(BeFalse())
+		})
+	})
+
+	Context("when the yaml cannot be parsed", func() {
+		It("returns an error", func() {
+			report := Validate([]byte("environments: [\n"))
+
+			Expect(report.HasErrors()).To(BeTrue())
+			Expect(report.Problems[0].Message).To(ContainSubstring("cannot parse yaml file"))
+		})
+	})
+
+	Context("when there are no environments", func() {
+		It("returns an error", func() {
+			report := Validate([]byte("---\nerror_matchers: []\n"))
+
+			Expect(report.Problems).To(ConsistOf(Problem{0, SeverityError, EnvironmentsNotSpecifiedError{}.Error()}))
+		})
+	})
+
+	Context("when an environment is broken", func() {
+		It("reports every problem with its line", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+- name: Test
+  foundations:
+  - api.foundation-2.example.com
+  - https://
+  instances: -1
+- name: prod
+  domian: example.com
+  foundations: []
+  instances: 0
+- domain: nameless.example.com
+  credentials: unknown
+  foundations:
+  - https://api.foundation-3.example.com
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{6, SeverityError, "duplicate environment name: Test is also defined on line 3"},
+				{8, SeverityError, "environment Test has a malformed foundation url, expected http(s)://host: api.foundation-2.example.com"},
+				{9, SeverityError, "environment Test has a malformed foundation url, expected http(s)://host: https://"},
+				{10, SeverityError, "environment Test has instances out of range (0 to 65535): -1"},
+				{11, SeverityError, "environment prod has no foundations"},
+				{12, SeverityWarning, "unknown key domian in environment prod"},
+				{14, SeverityWarning, "environment prod has 0 instances and will be deployed with 1 instance"},
+				{15, SeverityError, "environment 4 is missing a name"},
+				{16, SeverityError, "environment 4 refers to unknown credentials: unknown"},
+			}))
+			Expect(report.HasErrors()).To(BeTrue())
+		})
+	})
+
+	Context("when a foundation object is broken", func() {
+		It("reports the problems with the foundation", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - url: https://api.foundation-1.example.com
+    credentials: missing
+    wieght: 2
+  - name: no-url
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{3, SeverityError, "environment test has a foundation without a url"},
+				{6, SeverityError, "foundation https://api.foundation-1.example.com refers to unknown credentials: missing"},
+				{7, SeverityWarning, "unknown key wieght in foundation https://api.foundation-1.example.com"},
+			}))
+		})
+	})
+
+	Context("when freeze windows are broken", func() {
+		It("reports the problems with each freeze window", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+  freeze_windows:
+  - name: year-end
+    start: 2026-12-24
+    end: 2026-12-01
+  - name: nightly
+    cron: "0 25 * * *"
+    duration: 1h
+    timezone: UTC
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{7, SeverityError, "environment test: freeze window year-end must end after it starts"},
+				{10, SeverityError, `environment test: freeze window nightly has a malformed cron "0 25 * * *": hour: "25" is out of range (0 to 23)`},
+				{13, SeverityWarning, "unknown key timezone in freeze window nightly"},
+			}))
+		})
+	})
+
+	Context("when approvals are broken", func() {
+		It("reports the problems with the approvers and the approval timeout", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+  approvers: approver1
+  approval_timeout: 0s
+- name: prod
+  foundations:
+  - https://api.foundation-2.example.com
+  approval_timeout: 1h
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{6, SeverityError, "environment test has approvers that is not a list of usernames"},
+				{7, SeverityError, "environment test has an approval_timeout that is not a positive duration: 0s"},
+				{11, SeverityWarning, "environment prod has an approval_timeout but no approvers"},
+			}))
+		})
+	})
+
+	Context("when policies are broken", func() {
+		It("reports the problems with each policy", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+policies:
+- name: developers
+  identities:
+  - user:alice
+  actions:
+  - deploy
+  - destroy
+- name: testers
+  identities: user:bob
+  actions:
+  - deploy
+  enviroments:
+  - test
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{7, SeverityError, "policy developers has an unknown action destroy, expected one of [deploy rollback promote approve read] or *"},
+				{13, SeverityError, "policy testers has no identities"},
+				{14, SeverityError, "policy testers has identities that is not a list"},
+				{17, SeverityWarning, "unknown key enviroments in policy testers"},
+			}))
+		})
+	})
+
+	Context("when the identity provider is broken", func() {
+		It("reports the problems with it", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+oidc:
+  issuer: https://idp.example.com
+  audience: deployadactyl
+  jwks: /etc/deployadactyl/jwks.json
+  clock_skew: a minute
+  groups: roles
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{10, SeverityError, "oidc has a clock_skew that is not a duration of at least 0s: a minute"},
+				{11, SeverityWarning, "unknown key groups in oidc"},
+			}))
+		})
+	})
+
+	Context("when the janitor is broken", func() {
+		It("reports the problems with it", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+janitor:
+  interval: hourly
+  dry_run: yes please
+  spaces:
+  - environment: staging
+    org: my-org
+    space: my-space
+  - environment: test
+    org: my-org
+    spcae: my-space
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{7, SeverityError, "janitor interval must be a positive duration, eg: 24h: hourly"},
+				{8, SeverityError, "janitor dry_run must be true or false"},
+				{10, SeverityError, "janitor space refers to unknown environment: staging"},
+				{13, SeverityError, "janitor space 2 must have an environment, org and space"},
+				{15, SeverityWarning, "unknown key spcae in janitor space 2"},
+			}))
+		})
+	})
+
+	Context("when error matchers are broken", func() {
+		It("reports invalid patterns and missing patterns", func() {
+			report := Validate([]byte(`---
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+error_matchers:
+- description: good
+  pattern: good
+- description: bad
+  pattern: bad(
+- description: missing
+  colour: red
+`))
+
+			Expect(report.Problems).To(HaveLen(3))
+			Expect(report.Problems[0].Line).To(Equal(10))
+			Expect(report.Problems[0].Message).To(ContainSubstring("error matcher 2 has an invalid pattern"))
+			Expect(report.Problems[1]).To(Equal(Problem{11, SeverityError, "error matcher 3 is missing a pattern"}))
+			Expect(report.Problems[2]).To(Equal(Problem{12, SeverityWarning, "unknown key colour in error matcher 3"}))
+		})
+	})
+
+	Context("when credentials are broken", func() {
+		It("reports duplicate and incomplete credentials", func() {
+			report := Validate([]byte(`---
+credentials:
+- name: production
+  secrets_file: /etc/production.yml
+- name: production
+  username_env: CF_USERNAME
+environments:
+- name: test
+  credentials: production
+  foundations:
+  - https://api.foundation-1.example.com
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{
+				{5, SeverityError, "duplicate credentials name: production"},
+				{5, SeverityError, "credentials production must have a secrets_file or both username_env and password_env"},
+			}))
+		})
+	})
+
+	Context("when there are unknown top level keys", func() {
+		It("returns a warning", func() {
+			report := Validate([]byte(`---
+enviroments: []
+environments:
+- name: test
+  foundations:
+  - https://api.foundation-1.example.com
+`))
+
+			Expect(report.Problems).To(Equal([]Problem{{2, SeverityWarning, "unknown key enviroments in config"}}))
+			Expect(report.HasErrors()).To(BeFalse())
+		})
+	})
+
+	Describe("printing a report", func() {
+		It("prints each problem with its line", func() {
+			report := ValidationReport{Problems: []Problem{
+				{0, SeverityError, "first"},
+				{3, SeverityWarning, "second"},
+			}}
+
+			Expect(report.String()).To(Equal("error: first\nline 3: warning: second"))
+		})
+	})
+
+	Describe("validating a file", func() {
+		It("returns an error when the file cannot be read", func() {
+			_, err := ValidateFile(os.Getenv, "./does_not_exist.yml")
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("validates the contents of the file", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte("---\nenvironments: []\n"), 0644)).To(Succeed())
+			defer os.Remove(badConfigPath)
+
+			report, err := ValidateFile(os.Getenv, badConfigPath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.HasErrors()).To(BeTrue())
+		})
+
+		It("reports environment variables that are referenced but not set", func() {
+			Expect(ioutil.WriteFile(badConfigPath, []byte("---\nenvironments:\n- name: production\n  foundations:\n  - ${PROD_FOUNDATION}\n"), 0644)).To(Succeed())
+			defer os.Remove(badConfigPath)
+
+			env := &mocks.Env{}
+
+			report, err := ValidateFile(env.Get, badConfigPath)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.String()).To(Equal("error: missing config environment variables: PROD_FOUNDATION"))
+		})
+	})
+})
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/compozed/deployadactyl/interfaces"
+	s "github.com/compozed/deployadactyl/structs"
+)
+
+// DefaultWatchInterval is how often the config file is checked for changes.
+const DefaultWatchInterval = 5 * time.Second
+
+// Watcher holds the current Config and reloads it from the config file when the file
+// changes or Reload is called. A config that fails validation is never swapped in.
+type Watcher struct {
+	getenv     func(string) string
+	configPath string
+	log        interfaces.Logger
+
+	mutex    sync.RWMutex
+	config   Config
+	modTime  time.Time
+	onReload []func(Config)
+}
+
+// NewWatcher returns a Watcher for a config file that was already loaded into config.
+func NewWatcher(getenv func(string) string, configPath string, config Config, log interfaces.Logger) *Watcher {
+	w := &Watcher{
+		getenv:     getenv,
+		configPath: configPath,
+		log:        log,
+		config:     config,
+	}
+
+	if info, err := os.Stat(configPath); err == nil {
+		w.modTime = info.ModTime()
+	}
+
+	return w
+}
+
+// Config returns the current Config.
+func (w *Watcher) Config() Config {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return w.config
+}
+
+// OnReload registers a function that is called with the new Config after every successful reload.
+func (w *Watcher) OnReload(f func(Config)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.onReload = append(w.onReload, f)
+}
+
+// Reload reads and validates the config file. If it is valid, the environments and error
+// matchers of the new config are used for subsequent deployments and the differences are logged.
+// The config file is also checked like the validate-config command checks it, so a reload
+// does not silently drop error matchers that loading ignores.
+//
+// Returns a ReloadError and keeps the current config if the new config is not valid.
+func (w *Watcher) Reload() error {
+	w.log.Infof("reloading config file %s", w.configPath)
+
+	newConfig, err := Custom(w.getenv, w.configPath)
+	if err == nil {
+		var report ValidationReport
+		report, err = ValidateFile(w.getenv, w.configPath)
+		if err == nil && report.HasErrors() {
+			err = InvalidConfigError{report}
+		}
+	}
+	if err != nil {
+		err = ReloadError{w.configPath, err}
+		w.log.Error(err)
+		return err
+	}
+
+	w.mutex.Lock()
+	oldConfig := w.config
+	if newConfig.Port != oldConfig.Port {
+		w.log.Errorf("port cannot be changed without a restart: keeping port %d", oldConfig.Port)
+		newConfig.Port = oldConfig.Port
+	}
+	if newConfig.BindAddress != oldConfig.BindAddress || newConfig.TLS != oldConfig.TLS {
+		w.log.Errorf("bind address and TLS settings cannot be changed without a restart: keeping %s", oldConfig.BindAddress)
+		newConfig.BindAddress = oldConfig.BindAddress
+		newConfig.TLS = oldConfig.TLS
+	}
+	w.config = newConfig
+	onReload := w.onReload
+	w.mutex.Unlock()
+
+	changes := Diff(oldConfig, newConfig)
+	if len(changes) == 0 {
+		w.log.Info("reloaded config: no changes")
+	}
+	for _, change := range changes {
+		w.log.Infof("reloaded config: %s", change)
+	}
+
+	for _, f := range onReload {
+		f(newConfig)
+	}
+
+	return nil
+}
+
+// Watch checks the config file for changes every interval and reloads it when it has
+// been modified. It returns when stop is closed.
+func (w *Watcher) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(w.configPath)
+			if err != nil {
+				w.log.Errorf("cannot watch config file: %s", err)
+				continue
+			}
+
+			if info.ModTime().Equal(w.modTime) {
+				continue
+			}
+			w.modTime = info.ModTime()
+
+			w.Reload()
+		}
+	}
+}
+
+// Diff returns a description of every environment that was added, removed or changed
+// and whether the error matchers or credentials changed between two configs.
+func Diff(oldConfig, newConfig Config) []string {
+	changes := []string{}
+
+	for _, name := range sortedNames(newConfig.Environments) {
+		oldEnvironment, found := oldConfig.Environments[name]
+		if !found {
+			changes = append(changes, fmt.Sprintf("added environment %s", name))
+		} else if !reflect.DeepEqual(oldEnvironment, newConfig.Environments[name]) {
+			changes = append(changes, fmt.Sprintf("changed environment %s", name))
+		}
+	}
+
+	for _, name := range sortedNames(oldConfig.Environments) {
+		if _, found := newConfig.Environments[name]; !found {
+			changes = append(changes, fmt.Sprintf("removed environment %s", name))
+		}
+	}
+
+	if !reflect.DeepEqual(oldConfig.ErrorMatchers, newConfig.ErrorMatchers) {
+		changes = append(changes, fmt.Sprintf("changed error matchers: %d to %d", len(oldConfig.ErrorMatchers), len(newConfig.ErrorMatchers)))
+	}
+
+	if !reflect.DeepEqual(oldConfig.Credentials, newConfig.Credentials) {
+		changes = append(changes, "changed credentials")
+	}
+
+	return changes
+}
+
+func sortedNames(environments map[string]s.Environment) []string {
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,198 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+
+	. "github.com/compozed/deployadactyl/config"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	logging "github.com/op/go-logging"
+)
+
+const (
+	watchedConfigPath = "./test_watched_config.yml"
+	watchedConfig     = `---
+environments:
+- name: Test
+  domain: test.example.com
+  foundations:
+  - https://api1.example.com
+  - https://api2.example.com
+  skip_ssl: true
+  instances: 3
+- name: Prod
+  domain: example.com
+  foundations:
+  - https://api3.example.com
+  - https://api4.example.com
+  skip_ssl: false
+`
+	reloadedConfig = `---
+environments:
+- name: Test
+  domain: test.example.com
+  foundations:
+  - https://api1.example.com
+  - https://api2.example.com
+  skip_ssl: true
+  instances: 3
+- name: Staging
+  domain: staging.example.com
+  foundations:
+  - https://api5.example.com
+`
+)
+
+var _ = Describe("Watcher", func() {
+	var (
+		env       *mocks.Env
+		logBuffer *Buffer
+		watcher   *Watcher
+	)
+
+	BeforeEach(func() {
+		env = &mocks.Env{}
+		env.GetCall.Returns.Values = map[string]string{
+			"CF_USERNAME": "username",
+			"CF_PASSWORD": "password",
+			"PORT":        "8080",
+		}
+
+		Expect(ioutil.WriteFile(watchedConfigPath, []byte(watchedConfig), 0644)).To(Succeed())
+
+		config, err := Custom(env.Get, watchedConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		logBuffer = NewBuffer()
+		watcher = NewWatcher(env.Get, watchedConfigPath, config, logger.DefaultLogger(logBuffer, logging.DEBUG, "watcher_test"))
+	})
+
+	AfterEach(func() {
+		Expect(os.Remove(watchedConfigPath)).To(Succeed())
+	})
+
+	Describe("reloading the config", func() {
+		It("swaps in the new environments and logs the differences", func() {
+			Expect(ioutil.WriteFile(watchedConfigPath, []byte(reloadedConfig), 0644)).To(Succeed())
+
+			Expect(watcher.Reload()).To(Succeed())
+
+			Expect(watcher.Config().Environments).To(HaveKey("staging"))
+			Expect(watcher.Config().Environments).ToNot(HaveKey("prod"))
+
+			Eventually(logBuffer).Should(Say("reloaded config: added environment staging"))
+			Eventually(logBuffer).Should(Say("reloaded config: removed environment prod"))
+		})
+
+		It("calls the reload functions with the new config", func() {
+			var reloaded Config
+			watcher.OnReload(func(c Config) { reloaded = c })
+
+			Expect(ioutil.WriteFile(watchedConfigPath, []byte(reloadedConfig), 0644)).To(Succeed())
+
+			Expect(watcher.Reload()).To(Succeed())
+
+			Expect(reloaded.Environments).To(HaveKey("staging"))
+		})
+
+		It("keeps the port of the running server", func() {
+			env.GetCall.Returns.Values["PORT"] = "9090"
+
+			Expect(watcher.Reload()).To(Succeed())
+
+			Expect(watcher.Config().Port).To(Equal(8080))
+			Eventually(logBuffer).Should(Say("port cannot be changed without a restart"))
+		})
+
+		It("keeps the bind address and TLS settings of the running server", func() {
+			env.GetCall.Returns.Values["DEPLOYADACTYL_BIND_ADDRESS"] = "127.0.0.1"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_CERT_FILE"] = "cert.pem"
+			env.GetCall.Returns.Values["DEPLOYADACTYL_TLS_KEY_FILE"] = "key.pem"
+
+			Expect(watcher.Reload()).To(Succeed())
+
+			Expect(watcher.Config().BindAddress).To(Equal("0.0.0.0"))
+			Expect(watcher.Config().TLS).To(BeZero())
+			Eventually(logBuffer).Should(Say("TLS settings cannot be changed without a restart"))
+		})
+
+		Context("when the new config is not valid", func() {
+			It("keeps the current config and returns an error", func() {
+				Expect(ioutil.WriteFile(watchedConfigPath, []byte("---\nenvironments: []\n"), 0644)).To(Succeed())
+
+				err := watcher.Reload()
+
+				Expect(err).To(MatchError(ReloadError{watchedConfigPath, EnvironmentsNotSpecifiedError{}}))
+				Expect(watcher.Config().Environments).To(HaveKey("prod"))
+				Eventually(logBuffer).Should(Say("keeping the current config"))
+			})
+		})
+
+		Context("when the new config has an error matcher with an invalid pattern", func() {
+			It("keeps the current config and logs the problem", func() {
+				Expect(ioutil.WriteFile(watchedConfigPath, []byte(reloadedConfig+`error_matchers:
+- description: a matcher
+  pattern: "bad ["
+  solution: a solution
+  code: code
+`), 0644)).To(Succeed())
+
+				err := watcher.Reload()
+
+				Expect(err).To(BeAssignableToTypeOf(ReloadError{}))
+				Expect(watcher.Config().Environments).To(HaveKey("prod"))
+				Expect(watcher.Config().Environments).ToNot(HaveKey("staging"))
+				Eventually(logBuffer).Should(Say("keeping the current config: error matcher 1 is not valid"))
+			})
+		})
+
+		Context("when the new config has a problem that only validation finds", func() {
+			It("keeps the current config and logs the problem", func() {
+				Expect(ioutil.WriteFile(watchedConfigPath, []byte(reloadedConfig+`- name: Sandbox
+  foundations:
+  - api6.example.com
+`), 0644)).To(Succeed())
+
+				err := watcher.Reload()
+
+				Expect(err).To(BeAssignableToTypeOf(ReloadError{}))
+				Expect(err.(ReloadError).Err).To(BeAssignableToTypeOf(InvalidConfigError{}))
+				Expect(watcher.Config().Environments).ToNot(HaveKey("sandbox"))
+				Eventually(logBuffer).Should(Say("environment Sandbox has a malformed foundation url"))
+			})
+		})
+	})
+
+	Describe("watching the config file", func() {
+		It("reloads the config when the file changes", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+
+			go watcher.Watch(10*time.Millisecond, stop)
+
+			Expect(ioutil.WriteFile(watchedConfigPath, []byte(reloadedConfig), 0644)).To(Succeed())
+			later := time.Now().Add(time.Minute)
+			Expect(os.Chtimes(watchedConfigPath, later, later)).To(Succeed())
+
+			Eventually(func() map[string]bool {
+				names := map[string]bool{}
+				for name := range watcher.Config().Environments {
+					names[name] = true
+				}
+				return names
+			}).Should(HaveKey("staging"))
+		})
+	})
+
+	Describe("diffing configs", func() {
+		It("returns no changes for the same config", func() {
+			Expect(Diff(watcher.Config(), watcher.Config())).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,8 @@
+package constants
+
+// DryRunHeader is set by the controller when a deployment is a dry run, so no changes are
+// made to the foundations.
+const DryRunHeader = "X-Deployadactyl-Dry-Run"
+
+// DryRunPrefix starts every line of the output that reports a command a dry run did not run.
+const DryRunPrefix = "dry run:"
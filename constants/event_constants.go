@@ -7,4 +7,8 @@ const (
 	DeployFailureEvent = "deploy.failure"
 	DeployErrorEvent   = "deploy.error"
 	PushFinishedEvent  = "push.finished"
+	FinishPushEvent    = "push.finishing"
+
+	DeployFrozenEvent         = "deploy.frozen"
+	DeployFreezeOverrideEvent = "deploy.freezeOverride"
 )
@@ -0,0 +1,4 @@
+package constants
+
+// EmergencyReasonHeader is the request header with the reason for deploying during a freeze window.
+const EmergencyReasonHeader = "X-Deployadactyl-Emergency-Reason"
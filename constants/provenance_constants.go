@@ -0,0 +1,21 @@
+package constants
+
+// Environment variables that record which deployment produced an application. They are
+// only set when the foundation does not support metadata on applications.
+const (
+	DeploymentUUIDEnvVar   = "DEPLOYADACTYL_DEPLOYMENT_UUID"
+	ArtifactURLEnvVar      = "DEPLOYADACTYL_ARTIFACT_URL"
+	ArtifactChecksumEnvVar = "DEPLOYADACTYL_ARTIFACT_CHECKSUM"
+	DeployedByEnvVar       = "DEPLOYADACTYL_DEPLOYED_BY"
+	DeployedAtEnvVar       = "DEPLOYADACTYL_DEPLOYED_AT"
+)
+
+// Metadata annotations that record which deployment produced an application.
+// The deployment UUID is also added as a label so applications can be selected by it.
+const (
+	DeploymentUUIDAnnotation   = "deployadactyl/deployment-uuid"
+	ArtifactURLAnnotation      = "deployadactyl/artifact-url"
+	ArtifactChecksumAnnotation = "deployadactyl/artifact-checksum"
+	DeployedByAnnotation       = "deployadactyl/deployed-by"
+	DeployedAtAnnotation       = "deployadactyl/deployed-at"
+)
@@ -0,0 +1,5 @@
+package constants
+
+// StreamContentType is the media type of a deploy response that is streamed while the
+// deployment runs, one JSON object per line.
+const StreamContentType = "application/x-ndjson"
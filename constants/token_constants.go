@@ -0,0 +1,9 @@
+package constants
+
+// APITokenHeader is set by the controller to the name of the API token a deployment was
+// authenticated with, so the deployment logs in with the service account of the environment.
+const APITokenHeader = "X-Deployadactyl-API-Token"
+
+// SubjectHeader is set by the controller to the subject of the JWT a deployment was
+// authenticated with, so the deployment is recorded as deployed by the subject.
+const SubjectHeader = "X-Deployadactyl-Subject"
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// GetApp writes the state of an application on every foundation of an environment as JSON,
+// flagging drift between the foundations.
+func (c *Controller) GetApp(g *gin.Context) {
+	environment, deploymentInfo, statusCode, err := c.getApp(g)
+	if err != nil {
+		g.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Log.Debugf("getting %s from every foundation of %s", deploymentInfo.AppName, deploymentInfo.Environment)
+
+	g.JSON(http.StatusOK, c.Inventory.Get(environment, deploymentInfo))
+}
+
+// ReconcileApp deploys the latest successful deployment of an application again to the
+// foundations of an environment that have drifted from it.
+func (c *Controller) ReconcileApp(g *gin.Context) {
+	environment, deploymentInfo, statusCode, err := c.getApp(g)
+	if err != nil {
+		g.Writer.WriteHeader(statusCode)
+		fmt.Fprintf(g.Writer, "cannot reconcile application: %s\n", err)
+		return
+	}
+
+	c.Log.Debugf("reconciling %s on every foundation of %s", deploymentInfo.AppName, deploymentInfo.Environment)
+
+	response := &bytes.Buffer{}
+	defer io.Copy(g.Writer, response)
+
+	reconcileResponse := c.Reconciler.Reconcile(environment, deploymentInfo, response)
+
+	g.Writer.WriteHeader(reconcileResponse.StatusCode)
+	if reconcileResponse.Error != nil {
+		fmt.Fprintf(response, "cannot reconcile application: %s\n", reconcileResponse.Error)
+	}
+}
+
+// getApp returns the environment and the application of a request with the credentials
+// used to log into the foundations.
+//
+// Basic auth is used when it is given. Otherwise the credentials of the config are used,
+// unless the environment requires authentication and the request was not authenticated with
+// an API token or JWT by Authorize.
+func (c *Controller) getApp(g *gin.Context) (S.Environment, S.DeploymentInfo, int, error) {
+	cfg := c.getConfig()
+	name := g.Param("environment")
+
+	environment, ok := cfg.Environments[strings.ToLower(name)]
+	if !ok {
+		return S.Environment{}, S.DeploymentInfo{}, http.StatusNotFound, EnvironmentNotFoundError{name}
+	}
+
+	deploymentInfo := S.DeploymentInfo{
+		Environment: name,
+		Org:         g.Param("org"),
+		Space:       g.Param("space"),
+		AppName:     g.Param("appName"),
+	}
+
+	username, password, ok := g.Request.BasicAuth()
+	if ok {
+		deploymentInfo.Username, deploymentInfo.Password = username, password
+		return environment, deploymentInfo, http.StatusOK, nil
+	}
+
+	authorization := getAuthorization(g)
+	if environment.Authenticate && authorization.Token == "" && authorization.Subject == "" {
+		return S.Environment{}, S.DeploymentInfo{}, http.StatusUnauthorized, BasicAuthError{}
+	}
+
+	credentials := cfg.GetCredentials(environment, "")
+	deploymentInfo.Username, deploymentInfo.Password = credentials.Username, credentials.Password
+	if authorization.Subject != "" {
+		deploymentInfo.Username, deploymentInfo.Password = authorization.Subject, ""
+	}
+
+	return cfg.WithServiceAccounts(environment), deploymentInfo, http.StatusOK, nil
+}
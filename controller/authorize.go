@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/compozed/deployadactyl/controller/policy"
+	"github.com/compozed/deployadactyl/controller/token"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// Keys of the gin context for what a request was authenticated with.
+const (
+	apiTokenKey = "apiToken"
+	claimsKey   = "claims"
+)
+
+// Authorize returns middleware that stops a request unless the policies of the config
+// allow the identity of the request to perform the action on the application of the request.
+// Requests are stopped with http.StatusUnauthorized when they have no identity or an invalid
+// bearer token and http.StatusForbidden otherwise. Basic auth users are anonymous on
+// environments that do not authenticate. API tokens are also stopped for environments
+// they are not scoped to.
+func (c *Controller) Authorize(action string) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		identity, err := c.identify(g)
+		if err != nil {
+			c.deny(g, http.StatusUnauthorized, action, err)
+			return
+		}
+
+		resource, ok := c.getResource(g)
+		if !ok {
+			g.Next()
+			return
+		}
+
+		if identity.Kind == policy.UserIdentity && action != policy.ApproveAction && !c.authenticates(resource.Environment) {
+			c.Log.Debugf("environment %s does not authenticate, %s is anonymous", resource.Environment, identity)
+			identity = S.Identity{Kind: policy.AnonymousIdentity}
+		}
+
+		if apiToken, ok := getToken(g); ok && !token.InScope(apiToken, resource.Environment) {
+			c.deny(g, http.StatusForbidden, action, TokenScopeError{apiToken.Name, resource.Environment})
+			return
+		}
+
+		if policy.Allowed(c.getConfig().Policies, identity, action, resource) {
+			g.Next()
+			return
+		}
+
+		statusCode := http.StatusForbidden
+		if identity.Kind == policy.AnonymousIdentity {
+			statusCode = http.StatusUnauthorized
+		}
+
+		c.deny(g, statusCode, action, ForbiddenError{identity.String(), action, resource.String()})
+	}
+}
+
+func (c *Controller) deny(g *gin.Context, statusCode int, action string, err error) {
+	c.Log.Errorf("%s", err)
+
+	g.Writer.WriteHeader(statusCode)
+	fmt.Fprintf(g.Writer, "cannot %s application: %s\n", action, err)
+	g.Abort()
+}
+
+// identify returns the identity of a request and keeps what it was authenticated with in the
+// gin context. A bearer token is an API token when it has the API token prefix and a JWT
+// otherwise. Requests without a bearer token are identified by the user of basic auth or
+// are anonymous.
+func (c *Controller) identify(g *gin.Context) (S.Identity, error) {
+	secret, ok := bearerToken(g.Request)
+	if !ok {
+		if username, _, ok := g.Request.BasicAuth(); ok {
+			return S.Identity{Kind: policy.UserIdentity, Name: username}, nil
+		}
+		return S.Identity{Kind: policy.AnonymousIdentity}, nil
+	}
+
+	if strings.HasPrefix(secret, token.Prefix) {
+		if c.Tokens == nil {
+			return S.Identity{}, token.InvalidTokenError{}
+		}
+
+		apiToken, err := c.Tokens.Verify(secret)
+		if err != nil {
+			return S.Identity{}, err
+		}
+
+		g.Set(apiTokenKey, apiToken)
+		return S.Identity{Kind: policy.TokenIdentity, Name: apiToken.Name}, nil
+	}
+
+	if c.JWTVerifier == nil {
+		return S.Identity{}, token.InvalidTokenError{}
+	}
+
+	claims, err := c.JWTVerifier.Verify(secret)
+	if err != nil {
+		return S.Identity{}, err
+	}
+
+	g.Set(claimsKey, claims)
+	return S.Identity{Kind: policy.SubjectIdentity, Name: claims.Subject, Groups: claims.Groups}, nil
+}
+
+// authenticates returns true when an environment logs into its foundations with the basic
+// auth of a request. The basic auth user of a request is only known to be who they say they
+// are on those environments, so they are anonymous everywhere else. Approvals are the exception
+// because the approval gate logs in with the basic auth of the approver itself.
+func (c *Controller) authenticates(environment string) bool {
+	return c.getConfig().Environments[strings.ToLower(environment)].Authenticate
+}
+
+// bearerToken returns the token of an Authorization: Bearer header.
+func bearerToken(req *http.Request) (string, bool) {
+	header := req.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), true
+}
+
+// getToken returns the API token a request was authenticated with by Authorize.
+func getToken(g *gin.Context) (S.APIToken, bool) {
+	value, ok := g.Get(apiTokenKey)
+	if !ok {
+		return S.APIToken{}, false
+	}
+	apiToken, ok := value.(S.APIToken)
+	return apiToken, ok
+}
+
+// getClaims returns the claims of the JWT a request was authenticated with by Authorize.
+func getClaims(g *gin.Context) (S.Claims, bool) {
+	value, ok := g.Get(claimsKey)
+	if !ok {
+		return S.Claims{}, false
+	}
+	claims, ok := value.(S.Claims)
+	return claims, ok
+}
+
+// getAuthorization returns the authorization a request deploys with.
+func getAuthorization(g *gin.Context) I.Authorization {
+	username, password, _ := g.Request.BasicAuth()
+	authorization := I.Authorization{Username: username, Password: password}
+
+	if apiToken, ok := getToken(g); ok {
+		authorization.Token = apiToken.Name
+	}
+	if claims, ok := getClaims(g); ok {
+		authorization.Subject = claims.Subject
+	}
+
+	return authorization
+}
+
+// getResource returns the application a request acts on. A request for a deployment that
+// is pending approval acts on the application of that deployment. Returns false when the
+// pending deployment does not exist, which the handler reports.
+func (c *Controller) getResource(g *gin.Context) (policy.Resource, bool) {
+	if uuid := g.Param("uuid"); uuid != "" {
+		for _, deployment := range c.ApprovalGate.Pending() {
+			if deployment.UUID == uuid {
+				return policy.Resource{
+					Environment: deployment.Environment,
+					Org:         deployment.Org,
+					Space:       deployment.Space,
+					AppName:     deployment.AppName,
+				}, true
+			}
+		}
+		return policy.Resource{}, false
+	}
+
+	environment := g.Param("environment")
+	if environment == "" {
+		environment = g.Param("toEnvironment")
+	}
+
+	return policy.Resource{
+		Environment: environment,
+		Org:         g.Param("org"),
+		Space:       g.Param("space"),
+		AppName:     g.Param("appName"),
+	}, true
+}
@@ -10,10 +10,16 @@ import (
 	"io/ioutil"
 
 	"os"
+	"strconv"
+	"strings"
 
 	"encoding/base64"
 
+	"github.com/compozed/deployadactyl/config"
+	C "github.com/compozed/deployadactyl/constants"
+	"github.com/compozed/deployadactyl/controller/openapi"
 	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,9 +29,19 @@ type Controller struct {
 	Deployer       I.Deployer
 	SilentDeployer I.Deployer
 	Log            I.Logger
+	Inventory      I.Inventory
+	Reconciler     I.Reconciler
+	History        I.History
+	ApprovalGate   I.ApprovalGate
+	Tokens         I.TokenStore
+	JWTVerifier    I.JWTVerifier
+	AdminToken     string
+	Config         config.Config
+	ConfigWatcher  *config.Watcher
+	Routes         Routes
 }
 
-func (c *Controller) RunDeployment(deployment *I.Deployment, response *bytes.Buffer) I.DeployResponse {
+func (c *Controller) RunDeployment(deployment *I.Deployment, response io.ReadWriter) I.DeployResponse {
 
 	bodyNotSilent := ioutil.NopCloser(bytes.NewBuffer(*deployment.Body))
 	bodySilent := ioutil.NopCloser(bytes.NewBuffer(*deployment.Body))
@@ -38,6 +54,22 @@ func (c *Controller) RunDeployment(deployment *I.Deployment, response *bytes.Buf
 		headers["Authorization"] = []string{}
 	}
 
+	if deployment.Authorization.Token != "" {
+		headers.Set(C.APITokenHeader, deployment.Authorization.Token)
+	}
+
+	if deployment.Authorization.Subject != "" {
+		headers.Set(C.SubjectHeader, deployment.Authorization.Subject)
+	}
+
+	if deployment.EmergencyReason != "" {
+		headers.Set(C.EmergencyReasonHeader, deployment.EmergencyReason)
+	}
+
+	if deployment.DryRun {
+		headers.Set(C.DryRunHeader, "true")
+	}
+
 	request1 := &http.Request{
 		Header: headers,
 		Body:   bodyNotSilent,
@@ -57,7 +89,7 @@ func (c *Controller) RunDeployment(deployment *I.Deployment, response *bytes.Buf
 	go c.Deployer.Deploy(request1, cf.Environment, cf.Organization, cf.Space, cf.Application, cf.UUID, deployment.Type, response, reqChannel1)
 
 	silentResponse := &bytes.Buffer{}
-	if cf.Environment == os.Getenv("SILENT_DEPLOY_ENVIRONMENT") {
+	if !deployment.DryRun && cf.Environment == os.Getenv("SILENT_DEPLOY_ENVIRONMENT") {
 		go c.SilentDeployer.Deploy(request2, cf.Environment, cf.Organization, cf.Space, cf.Application, cf.UUID, deployment.Type, silentResponse, reqChannel2)
 		<-reqChannel2
 	}
@@ -68,6 +100,7 @@ func (c *Controller) RunDeployment(deployment *I.Deployment, response *bytes.Buf
 }
 
 // RunDeploymentViaHttp checks the request content type and passes it to the Deployer.
+// Clients that accept a stream get the output of the deployment while it runs.
 func (c *Controller) RunDeploymentViaHttp(g *gin.Context) {
 	c.Log.Debugf("Request originated from: %+v", g.Request.RemoteAddr)
 
@@ -78,29 +111,49 @@ func (c *Controller) RunDeploymentViaHttp(g *gin.Context) {
 		Application:  g.Param("appName"),
 	}
 
-	user, pwd, _ := g.Request.BasicAuth()
-	authorization := I.Authorization{
-		Username: user,
-		Password: pwd,
-	}
+	authorization := getAuthorization(g)
 
 	deploymentType := I.DeploymentType{
 		JSON: isJSON(g.Request.Header.Get("Content-Type")),
 		ZIP:  isZip(g.Request.Header.Get("Content-Type")),
 	}
 	response := &bytes.Buffer{}
+	streamResponse := accepts(g.Request.Header.Get("Accept"), C.StreamContentType)
+	jsonResponse := streamResponse || accepts(g.Request.Header.Get("Accept"), "application/json")
+
+	dryRun, err := getDryRun(g)
+	if err != nil && jsonResponse {
+		g.JSON(http.StatusBadRequest, newDeployResult(I.DeployResponse{StatusCode: http.StatusBadRequest, Error: err}, ""))
+		return
+	} else if err != nil {
+		g.Writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(g.Writer, "cannot deploy application: %s\n", err)
+		return
+	}
 
 	deployment := I.Deployment{
-		Authorization: authorization,
-		CFContext:     cfContext,
-		Type:          deploymentType,
+		Authorization:   authorization,
+		CFContext:       cfContext,
+		Type:            deploymentType,
+		EmergencyReason: g.Request.Header.Get(C.EmergencyReasonHeader),
+		DryRun:          dryRun,
 	}
 	bodyBuffer, _ := ioutil.ReadAll(g.Request.Body)
 	g.Request.Body.Close()
 	deployment.Body = &bodyBuffer
 
+	if streamResponse {
+		c.streamDeployment(g, &deployment)
+		return
+	}
+
 	deployResponse := c.RunDeployment(&deployment, response)
 
+	if jsonResponse {
+		g.JSON(deployResponse.StatusCode, newDeployResult(deployResponse, response.String()))
+		return
+	}
+
 	defer io.Copy(g.Writer, response)
 
 	if deployResponse.Error != nil {
@@ -112,6 +165,23 @@ func (c *Controller) RunDeploymentViaHttp(g *gin.Context) {
 	g.Writer.WriteHeader(deployResponse.StatusCode)
 }
 
+// getDryRun returns true when the dry_run query parameter of a request is true.
+//
+// Returns a DryRunError when the parameter is not a boolean.
+func getDryRun(g *gin.Context) (bool, error) {
+	value := g.Query("dry_run")
+	if value == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, DryRunError{value}
+	}
+
+	return dryRun, nil
+}
+
 func isZip(contentType string) bool {
 	return contentType == "application/zip"
 }
@@ -119,3 +189,68 @@ func isZip(contentType string) bool {
 func isJSON(contentType string) bool {
 	return contentType == "application/json"
 }
+
+// accepts returns true when a media type is one of the media types of an Accept header.
+// Deploy requests get the text response unless they accept application/json or a stream,
+// including requests that accept */*.
+func accepts(accept, mediaType string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		if strings.EqualFold(strings.TrimSpace(strings.Split(mediaRange, ";")[0]), mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// newDeployResult returns the JSON body of a deploy response with the output of the deployment.
+func newDeployResult(deployResponse I.DeployResponse, logs string) S.DeployResult {
+	result := S.DeployResult{
+		Status:        "success",
+		StatusCode:    deployResponse.StatusCode,
+		Foundations:   deployResponse.Foundations,
+		MatchedErrors: []S.MatchedError{},
+		Logs:          logs,
+	}
+
+	if info := deployResponse.DeploymentInfo; info != nil {
+		result.UUID = info.UUID
+		result.Parameters = S.DeployParameters{
+			ArtifactURL:      info.ArtifactURL,
+			ArtifactChecksum: info.ArtifactChecksum,
+			Username:         info.Username,
+			Environment:      info.Environment,
+			Org:              info.Org,
+			Space:            info.Space,
+			AppName:          info.AppName,
+			Instances:        info.Instances,
+			DryRun:           info.DryRun,
+		}
+		if info.DryRun {
+			result.Status = "dry_run"
+		}
+	}
+
+	if deployResponse.Error != nil {
+		result.Status = "failure"
+		result.Error = deployResponse.Error.Error()
+	}
+
+	if validationError, ok := deployResponse.Error.(openapi.ValidationError); ok {
+		result.FieldErrors = validationError.FieldErrors
+	}
+
+	if result.Foundations == nil {
+		result.Foundations = []S.FoundationResult{}
+	}
+
+	for _, matchedError := range deployResponse.MatchedErrors {
+		result.MatchedErrors = append(result.MatchedErrors, S.MatchedError{
+			Code:        matchedError.Code(),
+			Description: matchedError.Error(),
+			Details:     matchedError.Details(),
+			Solution:    matchedError.Solution(),
+		})
+	}
+
+	return result
+}
@@ -2,6 +2,7 @@ package controller_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -11,11 +12,15 @@ import (
 
 	"os"
 
+	C "github.com/compozed/deployadactyl/constants"
 	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
+	"github.com/compozed/deployadactyl/controller/openapi"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/logger"
 	"github.com/compozed/deployadactyl/mocks"
 	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -104,6 +109,47 @@ var _ = Describe("Controller", func() {
 				Eventually(deployer.DeployCall.Received.AppName).Should(Equal(appName))
 			})
 
+			It("passes the emergency reason to the deployer", func() {
+				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set(C.EmergencyReasonHeader, "outage")
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+				router.ServeHTTP(resp, req)
+
+				Expect(deployer.DeployCall.Received.Request.Header.Get(C.EmergencyReasonHeader)).To(Equal("outage"))
+			})
+
+			It("tells the deployer when the deployment is a dry run", func() {
+				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s?dry_run=true", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+				router.ServeHTTP(resp, req)
+
+				Expect(deployer.DeployCall.Received.Request.Header.Get(C.DryRunHeader)).To(Equal("true"))
+			})
+
+			It("does not accept the dry run header from the client", func() {
+				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set(C.DryRunHeader, "true")
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+				router.ServeHTTP(resp, req)
+
+				Expect(deployer.DeployCall.Received.Request.Header.Get(C.DryRunHeader)).To(BeEmpty())
+			})
+
 			It("does not run silent deploy when environment other than non-prop", func() {
 				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s", environment, org, "not-non-prod", appName)
 
@@ -140,6 +186,237 @@ var _ = Describe("Controller", func() {
 			})
 		})
 
+		Context("when the client accepts JSON", func() {
+			BeforeEach(func() {
+				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s", environment, org, space, appName)
+			})
+
+			It("returns the result of the deployment as JSON", func() {
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", "application/json")
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+				deployer.DeployCall.Write.Output = "deploy output"
+				deployer.DeployCall.Returns.DeploymentInfo = &S.DeploymentInfo{
+					UUID:        "uuid",
+					ArtifactURL: "https://example.com/artifact.jar",
+					Username:    "username",
+					Password:    "password",
+					Environment: environment,
+					AppName:     appName,
+					Instances:   2,
+				}
+				deployer.DeployCall.Returns.Foundations = []S.FoundationResult{
+					{FoundationURL: "https://api.foundation-1.example.com", Status: "success", Logs: "pushed"},
+				}
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Header().Get("Content-Type")).To(ContainSubstring("application/json"))
+
+				var result S.DeployResult
+				Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+				Expect(result.Status).To(Equal("success"))
+				Expect(result.StatusCode).To(Equal(http.StatusOK))
+				Expect(result.UUID).To(Equal("uuid"))
+				Expect(result.Parameters.ArtifactURL).To(Equal("https://example.com/artifact.jar"))
+				Expect(result.Parameters.Username).To(Equal("username"))
+				Expect(result.Parameters.Instances).To(Equal(uint16(2)))
+				Expect(result.Foundations).To(Equal(deployer.DeployCall.Returns.Foundations))
+				Expect(result.MatchedErrors).To(BeEmpty())
+				Expect(result.Logs).To(Equal("deploy output"))
+				Expect(resp.Body.String()).ToNot(ContainSubstring("password"))
+			})
+
+			It("returns the errors that were found in the output", func() {
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", "text/plain, application/json;q=0.9")
+
+				matchedError := error_finder.CreateLogMatchedError("out of memory", []string{"insufficient resources: memory"}, "lower the memory", "InsufficientMemory")
+				deployer.DeployCall.Returns.StatusCode = http.StatusInternalServerError
+				deployer.DeployCall.Returns.Error = matchedError
+				deployer.DeployCall.Returns.MatchedErrors = []I.LogMatchedError{matchedError}
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+
+				var result S.DeployResult
+				Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+				Expect(result.Status).To(Equal("failure"))
+				Expect(result.Error).To(Equal("out of memory"))
+				Expect(result.Foundations).To(BeEmpty())
+				Expect(result.MatchedErrors).To(Equal([]S.MatchedError{{
+					Code:        "InsufficientMemory",
+					Description: "out of memory",
+					Details:     []string{"insufficient resources: memory"},
+					Solution:    "lower the memory",
+				}}))
+			})
+
+			It("reports dry runs", func() {
+				req, err := http.NewRequest("POST", foundationURL+"?dry_run=true", jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", "application/json")
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+				deployer.DeployCall.Returns.DeploymentInfo = &S.DeploymentInfo{DryRun: true}
+
+				router.ServeHTTP(resp, req)
+
+				var result S.DeployResult
+				Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+				Expect(result.Status).To(Equal("dry_run"))
+				Expect(result.Parameters.DryRun).To(BeTrue())
+			})
+
+			It("returns JSON when dry_run is not a boolean", func() {
+				req, err := http.NewRequest("POST", foundationURL+"?dry_run=maybe", jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", "application/json")
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+
+				var result S.DeployResult
+				Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+				Expect(result.Status).To(Equal("failure"))
+				Expect(result.Error).To(Equal(DryRunError{"maybe"}.Error()))
+			})
+
+			It("returns the problems with the fields of a body that is not valid", func() {
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", "application/json")
+
+				fieldErrors := []S.FieldError{{Field: "artifact_url", Message: "is required"}}
+				deployer.DeployCall.Returns.StatusCode = http.StatusBadRequest
+				deployer.DeployCall.Returns.Error = openapi.ValidationError{FieldErrors: fieldErrors}
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+
+				var result S.DeployResult
+				Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+				Expect(result.Error).To(Equal("invalid request body: artifact_url is required"))
+				Expect(result.FieldErrors).To(Equal(fieldErrors))
+			})
+
+			It("returns text to clients that accept anything", func() {
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", "*/*")
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+				deployer.DeployCall.Write.Output = "deploy success"
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Body.String()).To(Equal("deploy success"))
+			})
+		})
+
+		Context("when the client accepts a stream", func() {
+			BeforeEach(func() {
+				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s", environment, org, space, appName)
+			})
+
+			streamLines := func() []S.DeployStreamLine {
+				var lines []S.DeployStreamLine
+				decoder := json.NewDecoder(resp.Body)
+				for decoder.More() {
+					var line S.DeployStreamLine
+					Expect(decoder.Decode(&line)).To(Succeed())
+					lines = append(lines, line)
+				}
+				return lines
+			}
+
+			It("streams the output followed by the result of the deployment", func() {
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", C.StreamContentType+", application/json")
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusInternalServerError
+				deployer.DeployCall.Returns.Error = errors.New("push failed")
+				deployer.DeployCall.Write.Output = "deploy output"
+				deployer.DeployCall.Returns.DeploymentInfo = &S.DeploymentInfo{UUID: "uuid"}
+				deployer.DeployCall.Returns.Foundations = []S.FoundationResult{
+					{FoundationURL: "https://api.foundation-1.example.com", Status: "rolled_back", Error: "push failed"},
+				}
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Header().Get("Content-Type")).To(Equal(C.StreamContentType))
+				Expect(resp.Flushed).To(BeTrue())
+
+				lines := streamLines()
+				Expect(lines).To(HaveLen(2))
+				Expect(lines[0]).To(Equal(S.DeployStreamLine{Output: "deploy output"}))
+				Expect(lines[1].Result).ToNot(BeNil())
+				Expect(lines[1].Result.Status).To(Equal("failure"))
+				Expect(lines[1].Result.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(lines[1].Result.Error).To(Equal("push failed"))
+				Expect(lines[1].Result.UUID).To(Equal("uuid"))
+				Expect(lines[1].Result.Foundations).To(Equal(deployer.DeployCall.Returns.Foundations))
+				Expect(lines[1].Result.Logs).To(BeEmpty())
+			})
+
+			It("flushes every write to the client as it happens", func() {
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", C.StreamContentType)
+
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+				deployer.DeployCall.Write.Output = "deploy output"
+
+				recorder := &flushesRecorder{ResponseRecorder: httptest.NewRecorder()}
+				router.ServeHTTP(recorder, req)
+
+				Expect(recorder.flushes).To(HaveLen(3))
+				Expect(recorder.flushes[0]).To(BeEmpty())
+				Expect(recorder.flushes[1]).To(MatchJSON(`{"output": "deploy output"}`))
+				Expect(recorder.flushes[2]).To(ContainSubstring(`"result"`))
+
+				contents, err := ioutil.ReadAll(deployer.DeployCall.Received.Response)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(BeEmpty())
+			})
+
+			It("returns http.StatusBadRequest as JSON before streaming when dry_run is not a boolean", func() {
+				req, err := http.NewRequest("POST", foundationURL+"?dry_run=maybe", jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Accept", C.StreamContentType)
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Header().Get("Content-Type")).To(ContainSubstring("application/json"))
+				Expect(deployer.DeployCall.Called).To(Equal(0))
+			})
+		})
+
+		Context("when dry_run is not a boolean", func() {
+			It("returns http.StatusBadRequest without deploying", func() {
+				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s?dry_run=maybe", environment, org, space, appName)
+
+				req, err := http.NewRequest("POST", foundationURL, jsonBuffer)
+				Expect(err).ToNot(HaveOccurred())
+
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Body).To(ContainSubstring(DryRunError{"maybe"}.Error()))
+				Expect(deployer.DeployCall.Called).To(Equal(0))
+			})
+		})
+
 		Context("when parameters are added to the url", func() {
 			It("does not return an error", func() {
 				foundationURL = fmt.Sprintf("/v2/deploy/%s/%s/%s/%s?broken=false", environment, org, space, appName)
@@ -357,6 +634,42 @@ var _ = Describe("Controller", func() {
 				ret, _ := ioutil.ReadAll(response)
 				Eventually(string(ret)).Should(Equal("little-timmy-env.zip"))
 			})
+
+			It("does not run the silent deployer for a dry run", func() {
+				os.Setenv("SILENT_DEPLOY_ENVIRONMENT", environment)
+				deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+				deployment := &I.Deployment{
+					Body: &[]byte{},
+					Type: I.DeploymentType{JSON: true},
+					CFContext: I.CFContext{
+						Environment:  environment,
+						Organization: org,
+						Space:        space,
+						Application:  appName,
+					},
+					DryRun: true,
+				}
+				deployResponse := controller.RunDeployment(deployment, &bytes.Buffer{})
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+				Expect(deployer.DeployCall.Called).To(Equal(1))
+				Expect(silentDeployer.DeployCall.Called).To(Equal(0))
+				Expect(deployer.DeployCall.Received.Request.Header.Get(C.DryRunHeader)).To(Equal("true"))
+			})
 		})
 	})
 })
+
+// flushesRecorder is a response recorder that keeps what was written before every flush.
+type flushesRecorder struct {
+	*httptest.ResponseRecorder
+	flushed int
+	flushes []string
+}
+
+func (f *flushesRecorder) Flush() {
+	f.flushes = append(f.flushes, f.Body.String()[f.flushed:])
+	f.flushed = f.Body.Len()
+	f.ResponseRecorder.Flush()
+}
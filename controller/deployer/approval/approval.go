@@ -0,0 +1,213 @@
+// Package approval holds deployments to protected environments until an approver approves
+// or rejects them.
+package approval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/compozed/deployadactyl/config"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// PendingState is the state of a deployment that is waiting for approval.
+const PendingState = "pending_approval"
+
+// Gate has the deployments that are waiting for approval. Approvers are verified by
+// logging into a foundation of the environment with a courier from the CourierCreator.
+type Gate struct {
+	courierCreator I.CourierCreator
+	log            I.Logger
+
+	mutex   sync.Mutex
+	pending map[string]*pending
+}
+
+type pending struct {
+	deployment S.PendingDeployment
+	foundation S.Foundation
+	decisions  chan decision
+	decided    bool
+}
+
+type decision struct {
+	approved bool
+	approver string
+	reason   string
+}
+
+// NewGate returns a Gate without pending deployments.
+func NewGate(courierCreator I.CourierCreator, log I.Logger) *Gate {
+	return &Gate{
+		courierCreator: courierCreator,
+		log:            log,
+		pending:        map[string]*pending{},
+	}
+}
+
+// Wait returns immediately when the environment has no approvers. Otherwise the deployment
+// is pending until an approver approves it, rejects it or the approval timeout passes.
+// Returns a RejectedError or an ExpiredError when the deployment must not go ahead.
+func (g *Gate) Wait(environment S.Environment, deploymentInfo S.DeploymentInfo, response io.Writer) error {
+	if len(environment.Approvers) == 0 {
+		return nil
+	}
+
+	timeout, err := config.ApprovalTimeout(environment)
+	if err != nil {
+		return err
+	}
+
+	p := &pending{
+		deployment: S.PendingDeployment{
+			UUID:        deploymentInfo.UUID,
+			State:       PendingState,
+			Environment: deploymentInfo.Environment,
+			Org:         deploymentInfo.Org,
+			Space:       deploymentInfo.Space,
+			AppName:     deploymentInfo.AppName,
+			ArtifactURL: deploymentInfo.ArtifactURL,
+			Requester:   deploymentInfo.Username,
+			Approvers:   environment.Approvers,
+			Expires:     time.Now().Add(timeout).UTC(),
+		},
+		foundation: environment.GetFoundation(environment.Foundations[0]),
+		decisions:  make(chan decision, 1),
+	}
+
+	g.mutex.Lock()
+	g.pending[p.deployment.UUID] = p
+	g.mutex.Unlock()
+
+	defer func() {
+		g.mutex.Lock()
+		delete(g.pending, p.deployment.UUID)
+		g.mutex.Unlock()
+	}()
+
+	g.log.Infof("deployment %s of %s is pending approval by %v", p.deployment.UUID, p.deployment.AppName, p.deployment.Approvers)
+	fmt.Fprintf(response, "deployment %s is pending approval until %s\n", p.deployment.UUID, p.deployment.Expires.Format(time.RFC3339))
+
+	select {
+	case d := <-p.decisions:
+		if !d.approved {
+			return RejectedError{p.deployment.UUID, d.approver, d.reason}
+		}
+
+		g.log.Infof("deployment %s was approved by %s", p.deployment.UUID, d.approver)
+		fmt.Fprintf(response, "deployment %s was approved by %s\n", p.deployment.UUID, d.approver)
+		return nil
+	case <-time.After(timeout):
+		g.mutex.Lock()
+		p.decided = true
+		g.mutex.Unlock()
+
+		return ExpiredError{p.deployment.UUID, timeout}
+	}
+}
+
+// Approve lets a pending deployment go ahead.
+func (g *Gate) Approve(uuid, username, password string) error {
+	return g.decide(uuid, username, password, decision{approved: true, approver: username})
+}
+
+// Reject stops a pending deployment.
+func (g *Gate) Reject(uuid, username, password, reason string) error {
+	return g.decide(uuid, username, password, decision{approver: username, reason: reason})
+}
+
+// Pending returns the deployments that are waiting for approval, oldest first.
+func (g *Gate) Pending() []S.PendingDeployment {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	deployments := make([]S.PendingDeployment, 0, len(g.pending))
+	for _, p := range g.pending {
+		if !p.decided {
+			deployments = append(deployments, p.deployment)
+		}
+	}
+	sort.Sort(byExpires(deployments))
+
+	return deployments
+}
+
+// decide checks that the user is an approver of the deployment, is not the requester and
+// can log into the environment before deciding.
+func (g *Gate) decide(uuid, username, password string, d decision) error {
+	p, err := g.get(uuid)
+	if err != nil {
+		return err
+	}
+
+	if !contains(p.deployment.Approvers, username) {
+		return NotApproverError{username, p.deployment.Environment}
+	}
+
+	if username == p.deployment.Requester {
+		return SelfApprovalError{username}
+	}
+
+	err = g.login(p, username, password)
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if p.decided {
+		return NotPendingError{uuid}
+	}
+	p.decided = true
+	p.decisions <- d
+
+	return nil
+}
+
+func (g *Gate) get(uuid string) (*pending, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	p, ok := g.pending[uuid]
+	if !ok || p.decided {
+		return nil, NotPendingError{uuid}
+	}
+
+	return p, nil
+}
+
+func (g *Gate) login(p *pending, username, password string) error {
+	courier, err := g.courierCreator.CreateCourier()
+	if err != nil {
+		return err
+	}
+	defer courier.CleanUp()
+
+	out, err := courier.Login(p.foundation.URL, username, password, p.deployment.Org, p.deployment.Space, p.foundation.SkipSSL)
+	if err != nil {
+		g.log.Errorf("%s could not log into %s to decide on deployment %s: %s", username, p.foundation.URL, p.deployment.UUID, err)
+		return LoginError{username, out}
+	}
+
+	return nil
+}
+
+type byExpires []S.PendingDeployment
+
+func (b byExpires) Len() int           { return len(b) }
+func (b byExpires) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byExpires) Less(i, j int) bool { return b[i].Expires.Before(b[j].Expires) }
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
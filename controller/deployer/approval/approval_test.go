@@ -0,0 +1,153 @@
+package approval_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/approval"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Approval", func() {
+	var (
+		courier        *mocks.Courier
+		courierCreator *mocks.CourierCreator
+		gate           *Gate
+		environment    S.Environment
+		deploymentInfo S.DeploymentInfo
+		response       *bytes.Buffer
+		waitErr        chan error
+	)
+
+	BeforeEach(func() {
+		courier = &mocks.Courier{}
+		courierCreator = &mocks.CourierCreator{}
+		courierCreator.CreateCourierCall.Returns.Couriers = []I.Courier{courier, courier, courier}
+
+		gate = NewGate(courierCreator, logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "approval_test"))
+
+		environment = S.Environment{
+			Name:            "production",
+			Foundations:     []string{"https://api1.example.com"},
+			SkipSSL:         true,
+			Approvers:       []string{"approver", "requester"},
+			ApprovalTimeout: "1m",
+		}
+
+		deploymentInfo = S.DeploymentInfo{
+			UUID:        "uuid",
+			Environment: "production",
+			Org:         "org",
+			Space:       "space",
+			AppName:     "appName",
+			ArtifactURL: "https://example.com/artifact.jar",
+			Username:    "requester",
+		}
+
+		response = &bytes.Buffer{}
+		waitErr = make(chan error, 1)
+	})
+
+	wait := func() {
+		go func() { waitErr <- gate.Wait(environment, deploymentInfo, response) }()
+		Eventually(gate.Pending).Should(HaveLen(1))
+	}
+
+	It("does not wait when the environment has no approvers", func() {
+		environment.Approvers = nil
+
+		Expect(gate.Wait(environment, deploymentInfo, response)).To(Succeed())
+		Expect(response.String()).To(BeEmpty())
+	})
+
+	It("lists the deployment as pending approval", func() {
+		wait()
+
+		pending := gate.Pending()[0]
+		Expect(pending.UUID).To(Equal("uuid"))
+		Expect(pending.State).To(Equal(PendingState))
+		Expect(pending.Requester).To(Equal("requester"))
+		Expect(pending.Approvers).To(Equal([]string{"approver", "requester"}))
+		Expect(pending.Expires).To(BeTemporally("~", time.Now().Add(time.Minute), 5*time.Second))
+
+		Expect(gate.Reject("uuid", "approver", "password", "")).To(Succeed())
+		Eventually(waitErr).Should(Receive())
+	})
+
+	It("lets the deployment go ahead when an approver approves it", func() {
+		wait()
+
+		Expect(gate.Approve("uuid", "approver", "password")).To(Succeed())
+
+		Eventually(waitErr).Should(Receive(BeNil()))
+		Expect(gate.Pending()).To(BeEmpty())
+		Expect(response.String()).To(ContainSubstring("deployment uuid was approved by approver"))
+
+		Expect(courier.LoginCall.Received.FoundationURL).To(Equal("https://api1.example.com"))
+		Expect(courier.LoginCall.Received.Username).To(Equal("approver"))
+		Expect(courier.LoginCall.Received.Password).To(Equal("password"))
+		Expect(courier.LoginCall.Received.Org).To(Equal("org"))
+		Expect(courier.LoginCall.Received.Space).To(Equal("space"))
+		Expect(courier.LoginCall.Received.SkipSSL).To(BeTrue())
+	})
+
+	It("stops the deployment when an approver rejects it", func() {
+		wait()
+
+		Expect(gate.Reject("uuid", "approver", "password", "not today")).To(Succeed())
+
+		Eventually(waitErr).Should(Receive(MatchError(RejectedError{"uuid", "approver", "not today"})))
+	})
+
+	It("abandons the deployment when the approval timeout passes", func() {
+		environment.ApprovalTimeout = "10ms"
+
+		err := gate.Wait(environment, deploymentInfo, response)
+
+		Expect(err).To(MatchError(ExpiredError{"uuid", 10 * time.Millisecond}))
+		Expect(gate.Pending()).To(BeEmpty())
+		Expect(gate.Approve("uuid", "approver", "password")).To(MatchError(NotPendingError{"uuid"}))
+	})
+
+	Describe("deciding", func() {
+		BeforeEach(func() {
+			wait()
+		})
+
+		AfterEach(func() {
+			gate.Reject("uuid", "approver", "password", "")
+			Eventually(waitErr).Should(Receive())
+		})
+
+		It("returns an error when the deployment is not pending", func() {
+			Expect(gate.Approve("other-uuid", "approver", "password")).To(MatchError(NotPendingError{"other-uuid"}))
+		})
+
+		It("returns an error when the user is not an approver", func() {
+			Expect(gate.Approve("uuid", "someone", "password")).To(MatchError(NotApproverError{"someone", "production"}))
+		})
+
+		It("returns an error when the approver requested the deployment", func() {
+			Expect(gate.Approve("uuid", "requester", "password")).To(MatchError(SelfApprovalError{"requester"}))
+			Expect(gate.Reject("uuid", "requester", "password", "")).To(MatchError(SelfApprovalError{"requester"}))
+		})
+
+		It("returns an error when the approver cannot log in", func() {
+			courier.LoginCall.Returns.Output = []byte("bad credentials")
+			courier.LoginCall.Returns.Error = errors.New("login error")
+
+			Expect(gate.Approve("uuid", "approver", "password")).To(MatchError(LoginError{"approver", []byte("bad credentials")}))
+			Expect(gate.Pending()).To(HaveLen(1))
+
+			courier.LoginCall.Returns.Error = nil
+		})
+	})
+})
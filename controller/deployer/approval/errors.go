@@ -0,0 +1,62 @@
+package approval
+
+import (
+	"fmt"
+	"time"
+)
+
+type NotPendingError struct {
+	UUID string
+}
+
+func (e NotPendingError) Error() string {
+	return fmt.Sprintf("deployment %s is not pending approval", e.UUID)
+}
+
+type NotApproverError struct {
+	Username    string
+	Environment string
+}
+
+func (e NotApproverError) Error() string {
+	return fmt.Sprintf("%s is not an approver of %s", e.Username, e.Environment)
+}
+
+type SelfApprovalError struct {
+	Username string
+}
+
+func (e SelfApprovalError) Error() string {
+	return fmt.Sprintf("%s requested the deployment and cannot approve or reject it", e.Username)
+}
+
+type LoginError struct {
+	Username string
+	Out      []byte
+}
+
+func (e LoginError) Error() string {
+	return fmt.Sprintf("cannot verify the credentials of %s: %s", e.Username, e.Out)
+}
+
+type RejectedError struct {
+	UUID     string
+	Approver string
+	Reason   string
+}
+
+func (e RejectedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("deployment %s was rejected by %s", e.UUID, e.Approver)
+	}
+	return fmt.Sprintf("deployment %s was rejected by %s: %s", e.UUID, e.Approver, e.Reason)
+}
+
+type ExpiredError struct {
+	UUID    string
+	Timeout time.Duration
+}
+
+func (e ExpiredError) Error() string {
+	return fmt.Sprintf("deployment %s was abandoned because it was not approved within %s", e.UUID, e.Timeout)
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/logger"
@@ -13,11 +15,17 @@ import (
 )
 
 // BlueGreen has a PusherCreator to creater pushers for blue green deployments.
+// Pushes are registered with the Drain, when there is one, so a shutdown can wait for them.
+// A push that is cancelled by the Drain is undone before the Drain is told it has stopped,
+// unless it was already finishing, in which case it finishes first.
 type BlueGreen struct {
 	PusherCreator I.PusherCreator
 	Log           I.Logger
+	Drain         I.Drain
 	actors        []actor
 	buffers       []*bytes.Buffer
+	errors        []error
+	undoErrors    []error
 }
 
 // Push will login to all the Cloud Foundry instances provided in the Config and then push the application to all the instances concurrently.
@@ -25,23 +33,80 @@ type BlueGreen struct {
 func (bg BlueGreen) Push(environment S.Environment, appPath string, deploymentInfo S.DeploymentInfo, response io.ReadWriter) I.DeploymentError {
 	bg.actors = make([]actor, len(environment.Foundations))
 	bg.buffers = make([]*bytes.Buffer, len(environment.Foundations))
+	bg.errors = make([]error, len(environment.Foundations))
+	bg.undoErrors = make([]error, len(environment.Foundations))
+	pushers := make([]I.Pusher, len(environment.Foundations))
+
+	// stopped is closed once nothing is running on the foundations anymore.
+	stopped := make(chan struct{})
+	defer close(stopped)
 
 	deploymentLogger := logger.DeploymentLogger{Log: bg.Log, UUID: deploymentInfo.UUID}
 
 	for i, foundationURL := range environment.Foundations {
 		bg.buffers[i] = &bytes.Buffer{}
 
-		pusher, err := bg.PusherCreator.CreatePusher(deploymentInfo, bg.buffers[i])
+		pusher, err := bg.PusherCreator.CreatePusher(deploymentInfo, environment.GetFoundation(foundationURL), bg.buffers[i])
 		if err != nil {
 			return InitializationError{err}
 		}
 		defer pusher.CleanUp()
 
+		pushers[i] = pusher
 		bg.actors[i] = newActor(pusher, foundationURL)
 		defer close(bg.actors[i].commands)
 	}
 
+	var pushed, rolledBack int32
+
+	// phase is either cancelled or finishing. Once the push is finishing the old application
+	// is being replaced and cannot be undone, so it finishes even when it is cancelled.
+	var phase struct {
+		sync.Mutex
+		cancelled bool
+		finishing bool
+	}
+	isCancelled := func() bool {
+		phase.Lock()
+		defer phase.Unlock()
+		return phase.cancelled
+	}
+	startFinishing := func() bool {
+		phase.Lock()
+		defer phase.Unlock()
+		phase.finishing = !phase.cancelled
+		return phase.finishing
+	}
+
+	if bg.Drain != nil {
+		finish, err := bg.Drain.Start(deploymentInfo.UUID, func() {
+			phase.Lock()
+			if !phase.finishing {
+				phase.cancelled = true
+				for _, pusher := range pushers {
+					pusher.Cancel()
+				}
+			}
+			phase.Unlock()
+			<-stopped
+		})
+		if err != nil {
+			return ShutdownError{err}
+		}
+		defer finish()
+	}
+
 	defer func() {
+		if recorder, ok := response.(I.FoundationRecorder); ok {
+			status := "success"
+			if atomic.LoadInt32(&pushed) == 0 {
+				status = "skipped"
+			} else if isCancelled() || atomic.LoadInt32(&rolledBack) == 1 {
+				status = "rolled_back"
+			}
+			bg.recordFoundations(recorder, environment.Foundations, status)
+		}
+
 		for _, buffer := range bg.buffers {
 			fmt.Fprintf(response, "\n%s Cloud Foundry Output %s\n", strings.Repeat("-", 19), strings.Repeat("-", 19))
 
@@ -52,15 +117,27 @@ func (bg BlueGreen) Push(environment S.Environment, appPath string, deploymentIn
 	}()
 
 	loginErrors := bg.loginAll()
+	if isCancelled() {
+		return CancelledError{}
+	}
+
 	if len(loginErrors) != 0 {
 		return LoginError{loginErrors}
 	}
 
+	atomic.StoreInt32(&pushed, 1)
 	pushErrors := bg.pushAll(appPath)
+	if isCancelled() {
+		return bg.undoCancelled(environment, deploymentInfo, deploymentLogger)
+	}
+
 	if len(pushErrors) != 0 {
 		if !environment.EnableRollback {
 			deploymentLogger.Errorf("Failed to deploy, deployment not rolled back due to EnableRollback=false")
 
+			if !startFinishing() {
+				return bg.undoCancelled(environment, deploymentInfo, deploymentLogger)
+			}
 			finishPushErrors := bg.finishPushAll()
 			if len(finishPushErrors) != 0 {
 				return FinishPushError{finishPushErrors}
@@ -68,7 +145,11 @@ func (bg BlueGreen) Push(environment S.Environment, appPath string, deploymentIn
 
 			return PushError{pushErrors}
 		} else {
+			atomic.StoreInt32(&rolledBack, 1)
 			rollbackErrors := bg.undoPushAll(deploymentLogger)
+			if isCancelled() {
+				return bg.undoCancelled(environment, deploymentInfo, deploymentLogger)
+			}
 			if len(rollbackErrors) != 0 {
 				return RollbackError{pushErrors, rollbackErrors}
 			}
@@ -77,6 +158,9 @@ func (bg BlueGreen) Push(environment S.Environment, appPath string, deploymentIn
 		}
 	}
 
+	if !startFinishing() {
+		return bg.undoCancelled(environment, deploymentInfo, deploymentLogger)
+	}
 	finishPushErrors := bg.finishPushAll()
 	if len(finishPushErrors) != 0 {
 		return FinishPushError{finishPushErrors}
@@ -91,8 +175,9 @@ func (bg BlueGreen) loginAll() (manyErrors []error) {
 			return pusher.Login(foundationURL)
 		}
 	}
-	for _, a := range bg.actors {
+	for i, a := range bg.actors {
 		if err := <-a.errs; err != nil {
+			bg.recordError(i, err)
 			manyErrors = append(manyErrors, err)
 		}
 	}
@@ -106,8 +191,9 @@ func (bg BlueGreen) pushAll(appPath string) (manyErrors []error) {
 			return pusher.Push(appPath, foundationURL)
 		}
 	}
-	for _, a := range bg.actors {
+	for i, a := range bg.actors {
 		if err := <-a.errs; err != nil {
+			bg.recordError(i, err)
 			manyErrors = append(manyErrors, err)
 		}
 	}
@@ -122,8 +208,9 @@ func (bg BlueGreen) finishPushAll() (manyErrors []error) {
 		}
 	}
 
-	for _, a := range bg.actors {
+	for i, a := range bg.actors {
 		if err := <-a.errs; err != nil {
+			bg.recordError(i, err)
 			manyErrors = append(manyErrors, err)
 		}
 	}
@@ -142,11 +229,95 @@ func (bg BlueGreen) undoPushAll(log I.Logger) (manyErrors []error) {
 		}
 	}
 
-	for _, a := range bg.actors {
+	for i, a := range bg.actors {
 		if err := <-a.errs; err != nil {
+			bg.recordError(i, err)
+			bg.undoErrors[i] = err
 			manyErrors = append(manyErrors, err)
 		}
 	}
 
 	return
 }
+
+// recordError keeps the first error of the foundation at index i.
+func (bg BlueGreen) recordError(i int, err error) {
+	if bg.errors[i] == nil {
+		bg.errors[i] = err
+	}
+}
+
+// recordFoundations records the result and output of every foundation. Foundations have the
+// status of the deployment: success, rolled_back when the push was undone or skipped when
+// nothing was pushed. Foundations with an error have failed unless their push was undone,
+// including the foundations that failed the deployment. Foundations that could not be undone
+// have failed.
+func (bg BlueGreen) recordFoundations(recorder I.FoundationRecorder, foundationURLs []string, status string) {
+	for i, foundationURL := range foundationURLs {
+		result := S.FoundationResult{
+			FoundationURL: foundationURL,
+			Status:        status,
+			Logs:          bg.buffers[i].String(),
+		}
+
+		if bg.errors[i] != nil {
+			result.Error = bg.errors[i].Error()
+			if status != "rolled_back" {
+				result.Status = "failure"
+			}
+		}
+
+		if bg.undoErrors[i] != nil {
+			result.Status = "failure"
+			if result.Error == "" {
+				result.Error = bg.undoErrors[i].Error()
+			}
+		}
+
+		recorder.RecordFoundation(result)
+	}
+}
+
+// undoCancelled undoes the push to every foundation after the deployment was cancelled and
+// every actor has returned. The pushers of the deployment were cancelled and cannot run
+// commands anymore, so new pushers log in again to undo the push.
+//
+// Returns a CancelledError once the push is undone.
+func (bg BlueGreen) undoCancelled(environment S.Environment, deploymentInfo S.DeploymentInfo, log I.Logger) I.DeploymentError {
+	pushers := make([]I.Pusher, len(environment.Foundations))
+	for i, foundationURL := range environment.Foundations {
+		pusher, err := bg.PusherCreator.CreatePusher(deploymentInfo, environment.GetFoundation(foundationURL), bg.buffers[i])
+		if err != nil {
+			log.Errorf("Could not rollback cancelled deployment on foundation %s with error: %s", foundationURL, err.Error())
+			bg.undoErrors[i] = err
+			continue
+		}
+		defer pusher.CleanUp()
+
+		pushers[i] = pusher
+	}
+
+	wg := sync.WaitGroup{}
+	for i, pusher := range pushers {
+		if pusher == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, pusher I.Pusher, foundationURL string) {
+			defer wg.Done()
+
+			err := pusher.Login(foundationURL)
+			if err == nil {
+				err = pusher.UndoPush()
+			}
+			if err != nil {
+				log.Errorf("Could not rollback cancelled deployment on foundation %s with error: %s", foundationURL, err.Error())
+				bg.undoErrors[i] = err
+			}
+		}(i, pusher, environment.Foundations[i])
+	}
+	wg.Wait()
+
+	return CancelledError{}
+}
@@ -159,6 +159,25 @@ var _ = Describe("Bluegreen", func() {
 			Eventually(response).Should(Say(pushOutput))
 		})
 
+		It("creates each pusher with the configuration of its foundation", func() {
+			environment.SkipSSL = true
+			environment.FoundationConfigs = map[string]S.Foundation{
+				environment.Foundations[1]: {
+					URL:        environment.Foundations[1],
+					Name:       "foundation-" + randomizer.StringRunes(10),
+					AppsDomain: "apps-" + randomizer.StringRunes(10),
+					Timeout:    30,
+				},
+			}
+
+			Expect(blueGreen.Push(environment, appPath, deploymentInfo, response)).To(Succeed())
+
+			Expect(pusherFactory.CreatePusherCall.Received.Foundations).To(Equal([]S.Foundation{
+				{URL: environment.Foundations[0], SkipSSL: true},
+				environment.FoundationConfigs[environment.Foundations[1]],
+			}))
+		})
+
 		Context("when enable_rollback is false", func() {
 			It("can push an app that does not rollback on fail", func() {
 				By("setting a single foundation")
@@ -291,4 +310,248 @@ var _ = Describe("Bluegreen", func() {
 			Expect(pushers[0].UndoPushCall.Received.UndoPushWasCalled).To(Equal(false))
 		})
 	})
+
+	Context("when the response records the result of every foundation", func() {
+		var recorder *recordingResponse
+
+		BeforeEach(func() {
+			recorder = &recordingResponse{Buffer: response}
+		})
+
+		It("records every foundation as successful", func() {
+			Expect(blueGreen.Push(environment, appPath, deploymentInfo, recorder)).To(Succeed())
+
+			Expect(recorder.results).To(HaveLen(2))
+			for i, result := range recorder.results {
+				Expect(result.FoundationURL).To(Equal(environment.Foundations[i]))
+				Expect(result.Status).To(Equal("success"))
+				Expect(result.Error).To(BeEmpty())
+			}
+		})
+
+		It("records every foundation as rolled back with the error of the foundation that failed", func() {
+			pushers[1].PushCall.Returns.Error = pushError
+
+			blueGreen.Push(environment, appPath, deploymentInfo, recorder)
+
+			Expect(recorder.results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: "rolled_back"},
+				{FoundationURL: environment.Foundations[1], Status: "rolled_back", Error: pushError.Error()},
+			}))
+		})
+
+		It("records the foundations that could not be rolled back as failed", func() {
+			pushers[1].PushCall.Returns.Error = pushError
+			pushers[0].UndoPushCall.Returns.Error = rollbackError
+
+			blueGreen.Push(environment, appPath, deploymentInfo, recorder)
+
+			Expect(recorder.results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: "failure", Error: rollbackError.Error()},
+				{FoundationURL: environment.Foundations[1], Status: "rolled_back", Error: pushError.Error()},
+			}))
+		})
+
+		It("does not record foundations as rolled back when rollback is disabled", func() {
+			environment.EnableRollback = false
+			pushers[1].PushCall.Returns.Error = pushError
+
+			blueGreen.Push(environment, appPath, deploymentInfo, recorder)
+
+			Expect(recorder.results[0].Status).To(Equal("success"))
+			Expect(recorder.results[1].Status).To(Equal("failure"))
+		})
+
+		It("records a failed login and skips the other foundations", func() {
+			pushers[0].LoginCall.Returns.Error = errors.New("login failed")
+
+			blueGreen.Push(environment, appPath, deploymentInfo, recorder)
+
+			Expect(recorder.results[0].Status).To(Equal("failure"))
+			Expect(recorder.results[0].Error).To(Equal("login failed"))
+			Expect(recorder.results[1].Status).To(Equal("skipped"))
+		})
+	})
+
+	Context("when there is a drain", func() {
+		var drain *mocks.Drain
+
+		BeforeEach(func() {
+			drain = &mocks.Drain{}
+			blueGreen.Drain = drain
+			deploymentInfo.UUID = "uuid-" + randomizer.StringRunes(10)
+		})
+
+		It("registers the push until it is finished", func() {
+			Expect(blueGreen.Push(environment, appPath, deploymentInfo, response)).To(Succeed())
+
+			Expect(drain.StartCall.Received.UUID).To(Equal(deploymentInfo.UUID))
+			Expect(drain.FinishCall.TimesCalled).To(Equal(1))
+		})
+
+		It("does not push when the server is shutting down", func() {
+			drainingError := errors.New("shutting down")
+			drain.StartCall.Returns.Error = drainingError
+
+			err := blueGreen.Push(environment, appPath, deploymentInfo, response)
+
+			Expect(err).To(MatchError(ShutdownError{drainingError}))
+			for _, pusher := range pushers {
+				Expect(pusher.LoginCall.Received.FoundationURL).To(BeEmpty())
+			}
+		})
+
+		It("stops the pushes, then undoes them with new pushers when it is cancelled", func() {
+			pushing := make(chan struct{})
+			release := make(chan struct{})
+			pusherFactory.CreatePusherCall.Returns.Pushers[0] = blockingPusher{pushers[0], pushing, release}
+
+			var undoPushers []*mocks.Pusher
+			for range environment.Foundations {
+				pusher := &mocks.Pusher{Response: response}
+				undoPushers = append(undoPushers, pusher)
+				pusherFactory.CreatePusherCall.Returns.Pushers = append(pusherFactory.CreatePusherCall.Returns.Pushers, pusher)
+				pusherFactory.CreatePusherCall.Returns.Error = append(pusherFactory.CreatePusherCall.Returns.Error, nil)
+			}
+
+			result := make(chan I.DeploymentError)
+			go func() { result <- blueGreen.Push(environment, appPath, deploymentInfo, response) }()
+
+			Eventually(pushing).Should(BeClosed())
+
+			cancelled := make(chan struct{})
+			go func() {
+				drain.StartCall.Received.Cancel()
+				close(cancelled)
+			}()
+
+			Eventually(pushers[1].CancelTimesCalled).Should(Equal(1))
+			Consistently(cancelled).ShouldNot(BeClosed())
+			close(release)
+
+			Eventually(result).Should(Receive(Equal(CancelledError{})))
+			Eventually(cancelled).Should(BeClosed())
+
+			for i, pusher := range pushers {
+				Expect(pusher.CancelTimesCalled()).To(Equal(1))
+				Expect(pusher.UndoPushCall.Received.UndoPushWasCalled).To(BeFalse())
+				Expect(undoPushers[i].LoginCall.Received.FoundationURL).To(Equal(environment.Foundations[i]))
+				Expect(undoPushers[i].UndoPushCall.Received.UndoPushWasCalled).To(BeTrue())
+			}
+			Expect(drain.FinishCall.TimesCalled).To(Equal(1))
+		})
+
+		It("records the foundations that could not be undone after it is cancelled as failed", func() {
+			pushing := make(chan struct{})
+			release := make(chan struct{})
+			pusherFactory.CreatePusherCall.Returns.Pushers[0] = blockingPusher{pushers[0], pushing, release}
+
+			for i := range environment.Foundations {
+				pusher := &mocks.Pusher{Response: response}
+				if i == 1 {
+					pusher.UndoPushCall.Returns.Error = rollbackError
+				}
+				pusherFactory.CreatePusherCall.Returns.Pushers = append(pusherFactory.CreatePusherCall.Returns.Pushers, pusher)
+				pusherFactory.CreatePusherCall.Returns.Error = append(pusherFactory.CreatePusherCall.Returns.Error, nil)
+			}
+
+			recorder := &recordingResponse{Buffer: response}
+			result := make(chan I.DeploymentError)
+			go func() { result <- blueGreen.Push(environment, appPath, deploymentInfo, recorder) }()
+
+			Eventually(pushing).Should(BeClosed())
+			go drain.StartCall.Received.Cancel()
+			Eventually(pushers[1].CancelTimesCalled).Should(Equal(1))
+			close(release)
+
+			Eventually(result).Should(Receive(Equal(CancelledError{})))
+			Expect(recorder.results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: "rolled_back"},
+				{FoundationURL: environment.Foundations[1], Status: "failure", Error: rollbackError.Error()},
+			}))
+		})
+
+		It("finishes the push when it is cancelled while finishing", func() {
+			finishing := make(chan struct{})
+			release := make(chan struct{})
+			pusherFactory.CreatePusherCall.Returns.Pushers[0] = blockingFinishPusher{pushers[0], finishing, release}
+			pushers[1].FinishPushCall.Returns.Error = pushError
+
+			recorder := &recordingResponse{Buffer: response}
+			result := make(chan I.DeploymentError)
+			go func() { result <- blueGreen.Push(environment, appPath, deploymentInfo, recorder) }()
+
+			Eventually(finishing).Should(BeClosed())
+
+			cancelled := make(chan struct{})
+			go func() {
+				drain.StartCall.Received.Cancel()
+				close(cancelled)
+			}()
+
+			Consistently(cancelled).ShouldNot(BeClosed())
+			close(release)
+
+			Eventually(result).Should(Receive(Equal(FinishPushError{[]error{pushError}})))
+			Eventually(cancelled).Should(BeClosed())
+
+			for _, pusher := range pushers {
+				Expect(pusher.CancelTimesCalled()).To(Equal(0))
+				Expect(pusher.UndoPushCall.Received.UndoPushWasCalled).To(BeFalse())
+			}
+			Expect(pusherFactory.CreatePusherCall.TimesCalled).To(Equal(len(environment.Foundations)))
+			Expect(recorder.results).To(Equal([]S.FoundationResult{
+				{FoundationURL: environment.Foundations[0], Status: "success"},
+				{FoundationURL: environment.Foundations[1], Status: "failure", Error: pushError.Error()},
+			}))
+		})
+
+		It("stops waiting when the deployment is cancelled after it finished", func() {
+			Expect(blueGreen.Push(environment, appPath, deploymentInfo, response)).To(Succeed())
+
+			drain.StartCall.Received.Cancel()
+
+			for _, pusher := range pushers {
+				Expect(pusher.UndoPushCall.Received.UndoPushWasCalled).To(BeFalse())
+			}
+		})
+	})
 })
+
+// blockingPusher pushes once release is closed, so a deployment can be cancelled while it
+// is pushing.
+type blockingPusher struct {
+	*mocks.Pusher
+	pushing chan struct{}
+	release chan struct{}
+}
+
+func (p blockingPusher) Push(appPath, foundationURL string) error {
+	close(p.pushing)
+	<-p.release
+	return p.Pusher.Push(appPath, foundationURL)
+}
+
+// blockingFinishPusher finishes its push once release is closed, so a deployment can be
+// cancelled while it is finishing.
+type blockingFinishPusher struct {
+	*mocks.Pusher
+	finishing chan struct{}
+	release   chan struct{}
+}
+
+func (p blockingFinishPusher) FinishPush() error {
+	close(p.finishing)
+	<-p.release
+	return p.Pusher.FinishPush()
+}
+
+// recordingResponse is a response that keeps the result of every foundation.
+type recordingResponse struct {
+	*Buffer
+	results []S.FoundationResult
+}
+
+func (r *recordingResponse) RecordFoundation(result S.FoundationResult) {
+	r.results = append(r.results, result)
+}
@@ -89,6 +89,28 @@ func (e FinishDeployError) Code() string {
 	return "FinishDeployError"
 }
 
+type ShutdownError struct {
+	Err error
+}
+
+func (e ShutdownError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ShutdownError) Code() string {
+	return "ShutdownError"
+}
+
+type CancelledError struct{}
+
+func (e CancelledError) Error() string {
+	return "deployment cancelled because deployadactyl shut down: the push was rolled back"
+}
+
+func (e CancelledError) Code() string {
+	return "CancelledError"
+}
+
 func makeErrorString(manyErrors []error) error {
 	var result string
 	for i, e := range manyErrors {
@@ -73,6 +73,13 @@ func (c Courier) MapRouteWithPath(appName, domain, hostname, path string) ([]byt
 	return c.Executor.Execute("map-route", appName, domain, "-n", hostname, "--path", path)
 }
 
+// MapRouteWithPort runs the Cloud Foundry map-route command for a TCP route.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) MapRouteWithPort(appName, domain string, port int) ([]byte, error) {
+	return c.Executor.Execute("map-route", appName, domain, "--port", fmt.Sprint(port))
+}
+
 // MapRoute runs the Cloud Foundry map-route command.
 //
 // Returns the combined standard output and standard error.
@@ -98,6 +105,20 @@ func (c Courier) DeleteRoute(domain, hostname string) ([]byte, error) {
 	return c.Executor.Execute("delete-route", domain, "-n", hostname, "-f")
 }
 
+// DeleteRouteWithPath runs the Cloud Foundry delete-route command with a path.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) DeleteRouteWithPath(domain, hostname, path string) ([]byte, error) {
+	return c.Executor.Execute("delete-route", domain, "-n", hostname, "--path", path, "-f")
+}
+
+// DeleteRouteWithPort runs the Cloud Foundry delete-route command for a TCP route.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) DeleteRouteWithPort(domain string, port int) ([]byte, error) {
+	return c.Executor.Execute("delete-route", domain, "--port", fmt.Sprint(port), "-f")
+}
+
 // Logs runs the Cloud Foundry logs command.
 //
 // Returns the combined standard output and standard error.
@@ -127,6 +148,33 @@ func (c Courier) Exists(appName string) bool {
 	return err == nil
 }
 
+// Routes returns the routes that are mapped to an application.
+//
+// Returns an error if the application does not exist.
+func (c Courier) Routes(appName string) ([]string, error) {
+	output, err := c.Executor.Execute("app", appName)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "routes:") && !strings.HasPrefix(line, "urls:") {
+			continue
+		}
+
+		line = strings.TrimSpace(line[strings.Index(line, ":")+1:])
+		for _, route := range strings.Split(line, ",") {
+			if route = strings.TrimSpace(route); route != "" {
+				routes = append(routes, route)
+			}
+		}
+	}
+
+	return routes, nil
+}
+
 // Domains returns a list of domain in a foundation.
 //
 // Returns the combined standard output and standard error.
@@ -141,6 +189,45 @@ func (c Courier) Domains() ([]string, error) {
 	return domains, err
 }
 
+// SpaceGUID returns the guid of a space in the targeted org.
+func (c Courier) SpaceGUID(space string) (string, error) {
+	output, err := c.Executor.Execute("space", space, "--guid")
+	return strings.TrimSpace(string(output)), err
+}
+
+// Curl runs the Cloud Foundry curl command against the Cloud Controller API.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) Curl(path string) ([]byte, error) {
+	return c.Executor.Execute("curl", path)
+}
+
+// CurlWithBody runs the Cloud Foundry curl command against the Cloud Controller API with
+// an HTTP method and a request body.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) CurlWithBody(method, path, body string) ([]byte, error) {
+	return c.Executor.Execute("curl", path, "-X", method, "-d", body)
+}
+
+// AppGUID returns the guid of an application in the targeted space.
+func (c Courier) AppGUID(appName string) (string, error) {
+	output, err := c.Executor.Execute("app", appName, "--guid")
+	return strings.TrimSpace(string(output)), err
+}
+
+// SetEnv runs the Cloud Foundry set-env command.
+//
+// Returns the combined standard output and standard error.
+func (c Courier) SetEnv(appName, name, value string) ([]byte, error) {
+	return c.Executor.Execute("set-env", appName, name, value)
+}
+
+// Cancel kills the commands of the Executor that are running.
+func (c Courier) Cancel() {
+	c.Executor.Cancel()
+}
+
 // CleanUp removes the temporary directory created by the Executor.
 func (c Courier) CleanUp() error {
 	return c.Executor.CleanUp()
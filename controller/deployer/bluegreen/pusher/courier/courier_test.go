@@ -1,6 +1,7 @@
 package courier_test
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 
@@ -213,6 +214,92 @@ var _ = Describe("Courier", func() {
 		})
 	})
 
+	Describe("mapping a TCP route", func() {
+		It("should map the route with the port", func() {
+			var (
+				domain       = "domain-" + randomizer.StringRunes(10)
+				expectedArgs = []string{"map-route", appName, domain, "--port", "61000"}
+			)
+
+			executor.ExecuteCall.Returns.Output = []byte(output)
+			executor.ExecuteCall.Returns.Error = nil
+
+			out, err := courier.MapRouteWithPort(appName, domain, 61000)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
+	Describe("deleting a TCP route", func() {
+		It("should delete the route with the port", func() {
+			var (
+				domain       = "domain-" + randomizer.StringRunes(10)
+				expectedArgs = []string{"delete-route", domain, "--port", "61000", "-f"}
+			)
+
+			executor.ExecuteCall.Returns.Output = []byte(output)
+			executor.ExecuteCall.Returns.Error = nil
+
+			out, err := courier.DeleteRouteWithPort(domain, 61000)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
+	Describe("deleting a route with a path", func() {
+		It("should delete route with hostname, domain and path", func() {
+			var (
+				domain       = "domain-" + randomizer.StringRunes(10)
+				path         = "path-" + randomizer.StringRunes(10)
+				expectedArgs = []string{"delete-route", domain, "-n", hostname, "--path", path, "-f"}
+			)
+
+			executor.ExecuteCall.Returns.Output = []byte(output)
+			executor.ExecuteCall.Returns.Error = nil
+
+			out, err := courier.DeleteRouteWithPath(domain, hostname, path)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
+	Describe("getting the routes of an application", func() {
+		It("returns the routes from the app command", func() {
+			expectedArgs := []string{"app", appName}
+
+			executor.ExecuteCall.Returns.Output = []byte("Showing health and status for app\n\nname:              example\nroutes:            example.example0.com, other.example1.com/path\nlast uploaded:     Mon 01 Jan\n")
+			executor.ExecuteCall.Returns.Error = nil
+
+			routes, err := courier.Routes(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(routes).To(Equal([]string{"example.example0.com", "other.example1.com/path"}))
+		})
+
+		It("returns the routes from the urls of older Cloud Foundry CLIs", func() {
+			executor.ExecuteCall.Returns.Output = []byte("requested state: started\ninstances: 1/1\nurls: example.example0.com\n")
+
+			routes, err := courier.Routes(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(routes).To(Equal([]string{"example.example0.com"}))
+		})
+
+		It("returns an error when the app does not exist", func() {
+			executor.ExecuteCall.Returns.Error = errors.New("app not found")
+
+			_, err := courier.Routes(appName)
+			Expect(err).To(MatchError("app not found"))
+		})
+	})
+
 	Describe("getting the logs for an application", func() {
 		It("should get the recent Cloud Foundry logs", func() {
 			expectedArgs := []string{"logs", appName, "--recent"}
@@ -390,6 +477,51 @@ var _ = Describe("Courier", func() {
 		})
 	})
 
+	Describe("curling with a request body", func() {
+		It("should get a valid Cloud Foundry curl command", func() {
+			expectedArgs := []string{"curl", "/v3/apps/guid", "-X", "PATCH", "-d", `{"metadata": {}}`}
+
+			executor.ExecuteCall.Returns.Output = []byte(output)
+			executor.ExecuteCall.Returns.Error = nil
+
+			out, err := courier.CurlWithBody("PATCH", "/v3/apps/guid", `{"metadata": {}}`)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
+	Describe("getting the guid of an application", func() {
+		It("returns the guid without whitespace", func() {
+			expectedArgs := []string{"app", appName, "--guid"}
+
+			executor.ExecuteCall.Returns.Output = []byte("app-guid\n")
+			executor.ExecuteCall.Returns.Error = nil
+
+			guid, err := courier.AppGUID(appName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(guid).To(Equal("app-guid"))
+		})
+	})
+
+	Describe("setting an environment variable", func() {
+		It("should get a valid Cloud Foundry set-env command", func() {
+			expectedArgs := []string{"set-env", appName, "NAME", "value"}
+
+			executor.ExecuteCall.Returns.Output = []byte(output)
+			executor.ExecuteCall.Returns.Error = nil
+
+			out, err := courier.SetEnv(appName, "NAME", "value")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(executor.ExecuteCall.Received.Args).To(Equal(expectedArgs))
+			Expect(string(out)).To(Equal(output))
+		})
+	})
+
 	Describe("cleaning up executor directories", func() {
 		It("should be successful", func() {
 			executor.CleanUpCall.Returns.Error = nil
@@ -0,0 +1,166 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	C "github.com/compozed/deployadactyl/constants"
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// DryRunGUID is the GUID of an application that a dry run would have pushed.
+const DryRunGUID = "dry-run-guid"
+
+// readOnlyCommands do not change a foundation, so a dry run runs them.
+var readOnlyCommands = map[string]bool{
+	"login":   true,
+	"app":     true,
+	"apps":    true,
+	"domains": true,
+	"space":   true,
+	"routes":  true,
+	"logs":    true,
+}
+
+// DryRun runs the commands that only read from Cloud Foundry with the Executor and writes the
+// commands that would change it to Out instead of running them. Passwords are not written.
+//
+// Applications that the dry run would push, rename or delete are tracked, so later commands
+// about them behave as if the commands had run.
+type DryRun struct {
+	Executor I.Executor
+	Out      io.Writer
+
+	mutex sync.Mutex
+	apps  map[string]bool
+}
+
+// NewDryRun returns a DryRun that writes to out.
+func NewDryRun(executor I.Executor, out io.Writer) *DryRun {
+	return &DryRun{
+		Executor: executor,
+		Out:      out,
+		apps:     map[string]bool{},
+	}
+}
+
+// Execute runs a read only command, or writes the command to Out.
+func (d *DryRun) Execute(args ...string) ([]byte, error) {
+	if output, err, handled := d.simulate(args); handled {
+		return output, err
+	}
+
+	return d.Executor.Execute(args...)
+}
+
+// ExecuteInDirectory runs a read only command in the directory, or writes the command and
+// the directory to Out.
+func (d *DryRun) ExecuteInDirectory(directory string, args ...string) ([]byte, error) {
+	if len(args) > 0 && !readOnly(args) {
+		fmt.Fprintf(d.Out, "%s cf %s (in %s)\n", C.DryRunPrefix, redact(args), directory)
+		d.track(args)
+		return []byte{}, nil
+	}
+
+	return d.Executor.ExecuteInDirectory(directory, args...)
+}
+
+// Cancel kills the commands of the Executor that are running.
+func (d *DryRun) Cancel() {
+	d.Executor.Cancel()
+}
+
+// CleanUp removes the temporary directory of the Executor.
+func (d *DryRun) CleanUp() error {
+	return d.Executor.CleanUp()
+}
+
+// simulate returns the result of a command that must not run, or of a command about an
+// application that only exists in the dry run. Handled is false when the command must run.
+func (d *DryRun) simulate(args []string) (output []byte, err error, handled bool) {
+	if len(args) == 0 {
+		return nil, nil, false
+	}
+
+	if !readOnly(args) {
+		fmt.Fprintf(d.Out, "%s cf %s\n", C.DryRunPrefix, redact(args))
+		d.track(args)
+		return []byte{}, nil, true
+	}
+
+	if args[0] == "login" {
+		fmt.Fprintf(d.Out, "%s running cf %s\n", C.DryRunPrefix, redact(args))
+		return nil, nil, false
+	}
+
+	if args[0] == "app" && len(args) > 1 {
+		d.mutex.Lock()
+		exists, tracked := d.apps[args[1]]
+		d.mutex.Unlock()
+
+		switch {
+		case tracked && exists && len(args) > 2 && args[2] == "--guid":
+			return []byte(DryRunGUID), nil, true
+		case tracked && exists:
+			return []byte{}, nil, true
+		case tracked:
+			return []byte(fmt.Sprintf("App %s not found", args[1])), AppNotFoundError{args[1]}, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// track records the applications a command would push, rename or delete.
+func (d *DryRun) track(args []string) {
+	if len(args) < 2 {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	switch args[0] {
+	case "push":
+		d.apps[args[1]] = true
+	case "delete":
+		d.apps[args[1]] = false
+	case "rename":
+		if len(args) > 2 {
+			d.apps[args[1]] = false
+			d.apps[args[2]] = true
+		}
+	}
+}
+
+// readOnly returns true for commands that do not change a foundation. Curl is read only
+// unless it has a method other than GET.
+func readOnly(args []string) bool {
+	if args[0] == "curl" {
+		for i, arg := range args {
+			if arg == "-X" && i+1 < len(args) && !strings.EqualFold(args[i+1], "GET") {
+				return false
+			}
+		}
+		return true
+	}
+
+	return readOnlyCommands[args[0]]
+}
+
+// redact returns the arguments of a command with the value of every -p flag, which is a
+// password or service credentials, replaced.
+func redact(args []string) string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+
+	for i := range redacted {
+		if redacted[i] == "-p" && i+1 < len(redacted) {
+			redacted[i+1] = "********"
+		}
+	}
+
+	return strings.Join(redacted, " ")
+}
@@ -0,0 +1,126 @@
+package executor_test
+
+import (
+	"bytes"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher/courier/executor"
+	"github.com/compozed/deployadactyl/mocks"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DryRun", func() {
+	var (
+		ex       *mocks.Executor
+		response *bytes.Buffer
+		dryRun   *DryRun
+	)
+
+	BeforeEach(func() {
+		ex = &mocks.Executor{}
+		response = &bytes.Buffer{}
+		dryRun = NewDryRun(ex, response)
+	})
+
+	It("runs commands that only read from the foundation", func() {
+		ex.ExecuteCall.Returns.Output = []byte("domains output")
+
+		output, err := dryRun.Execute("domains")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(Equal("domains output"))
+		Expect(ex.ExecuteCall.Received.Args).To(Equal([]string{"domains"}))
+		Expect(response.String()).To(BeEmpty())
+	})
+
+	It("logs in and reports the login without the password", func() {
+		_, err := dryRun.Execute("login", "-a", "https://api.example.com", "-u", "user", "-p", "secret", "-o", "org", "-s", "space", "")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ex.ExecuteCall.Received.Args[0]).To(Equal("login"))
+		Expect(response.String()).To(ContainSubstring("dry run: running cf login -a https://api.example.com -u user -p ********"))
+		Expect(response.String()).ToNot(ContainSubstring("secret"))
+	})
+
+	It("reports commands that change the foundation instead of running them", func() {
+		output, err := dryRun.Execute("map-route", "app", "example.com", "-n", "host")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(BeEmpty())
+		Expect(ex.ExecuteCall.Received.Args).To(BeNil())
+		Expect(response.String()).To(Equal("dry run: cf map-route app example.com -n host\n"))
+	})
+
+	It("does not report service credentials", func() {
+		dryRun.Execute("cups", "app", "-p", `{"password":"secret"}`)
+
+		Expect(response.String()).To(Equal("dry run: cf cups app -p ********\n"))
+	})
+
+	It("reports pushes with the directory they would run in", func() {
+		_, err := dryRun.ExecuteInDirectory("/tmp/app", "push", "app-new-build", "-i", "2", "-n", "app")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ex.ExecuteInDirectoryCall.Received.Args).To(BeNil())
+		Expect(response.String()).To(Equal("dry run: cf push app-new-build -i 2 -n app (in /tmp/app)\n"))
+	})
+
+	Describe("curl", func() {
+		It("runs GET requests", func() {
+			dryRun.Execute("curl", "/v2/apps")
+			Expect(ex.ExecuteCall.Received.Args).To(Equal([]string{"curl", "/v2/apps"}))
+
+			dryRun.Execute("curl", "/v2/routes", "-X", "get")
+			Expect(ex.ExecuteCall.Received.Args).To(Equal([]string{"curl", "/v2/routes", "-X", "get"}))
+
+			Expect(response.String()).To(BeEmpty())
+		})
+
+		It("reports requests with other methods", func() {
+			dryRun.Execute("curl", "/v2/apps/guid", "-X", "PUT", "-d", "{}")
+
+			Expect(ex.ExecuteCall.Received.Args).To(BeNil())
+			Expect(response.String()).To(Equal("dry run: cf curl /v2/apps/guid -X PUT -d {}\n"))
+		})
+	})
+
+	Describe("applications changed by the dry run", func() {
+		It("finds applications it would have pushed", func() {
+			dryRun.ExecuteInDirectory("/tmp/app", "push", "app-new-build", "-i", "1", "-n", "app")
+
+			_, err := dryRun.Execute("app", "app-new-build")
+			Expect(err).ToNot(HaveOccurred())
+
+			guid, err := dryRun.Execute("app", "app-new-build", "--guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(guid)).To(Equal(DryRunGUID))
+
+			Expect(ex.ExecuteCall.Received.Args).To(BeNil())
+		})
+
+		It("follows renames and deletes", func() {
+			dryRun.Execute("rename", "app", "app-venerable")
+			dryRun.Execute("rename", "app-new-build", "app")
+			dryRun.Execute("delete", "app-venerable", "-f")
+
+			_, err := dryRun.Execute("app", "app")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = dryRun.Execute("app", "app-venerable")
+			Expect(err).To(MatchError(AppNotFoundError{"app-venerable"}))
+
+			Expect(ex.ExecuteCall.Received.Args).To(BeNil())
+		})
+
+		It("runs commands for other applications", func() {
+			dryRun.Execute("app", "other")
+
+			Expect(ex.ExecuteCall.Received.Args).To(Equal([]string{"app", "other"}))
+		})
+	})
+
+	It("cleans up the executor", func() {
+		Expect(dryRun.CleanUp()).To(Succeed())
+	})
+})
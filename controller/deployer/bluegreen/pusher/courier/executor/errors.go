@@ -0,0 +1,19 @@
+package executor
+
+import "fmt"
+
+type AppNotFoundError struct {
+	AppName string
+}
+
+func (e AppNotFoundError) Error() string {
+	return fmt.Sprintf("application %s does not exist in the dry run", e.AppName)
+}
+
+type CancelledError struct {
+	Args []string
+}
+
+func (e CancelledError) Error() string {
+	return fmt.Sprintf("cf %s was cancelled", redact(e.Args))
+}
@@ -2,23 +2,43 @@
 package executor
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/spf13/afero"
 )
 
+// TempDirPrefix is the prefix of the temporary CF_HOME directory of every Executor.
+const TempDirPrefix = "deployadactyl-executor-"
+
+// tempDirs are the temporary directories of the Executors of this process that have not
+// been cleaned up. Other processes on the same host have their own.
+var (
+	tempDirsMutex sync.Mutex
+	tempDirs      = map[string]bool{}
+)
+
 // New returns a new Executor struct.
 func New(fileSystem *afero.Afero) (Executor, error) {
-	tempDir, err := fileSystem.TempDir("", "deployadactyl-executor-")
+	tempDir, err := fileSystem.TempDir("", TempDirPrefix)
 	if err != nil {
 		return Executor{}, err
 	}
 
+	tempDirsMutex.Lock()
+	tempDirs[tempDir] = true
+	tempDirsMutex.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return Executor{
 		fileSystem: fileSystem,
 		tempDir:    tempDir,
+		ctx:        ctx,
+		cancel:     cancel,
 	}, nil
 }
 
@@ -26,32 +46,69 @@ func New(fileSystem *afero.Afero) (Executor, error) {
 type Executor struct {
 	tempDir    string
 	fileSystem *afero.Afero
+	ctx        context.Context
+	cancel     context.CancelFunc
 }
 
 // Execute takes a slice of string args and runs them together against the cf command on the Cloud Foundry binary.
 //
 // Returns the combined standard output and standard error.
 func (e Executor) Execute(args ...string) ([]byte, error) {
-	command := exec.Command("cf", args...)
+	command := exec.CommandContext(e.ctx, "cf", args...)
 	command.Env = setEnv(os.Environ(), "CF_HOME", e.tempDir)
-	return command.CombinedOutput()
+	return e.run(command)
 }
 
 // ExecuteInDirectory does the same thing as Execute does, but does it in a specific directory.
 //
 // Returns the combined standard output and standard error.
 func (e Executor) ExecuteInDirectory(directory string, args ...string) ([]byte, error) {
-	command := exec.Command("cf", args...)
+	command := exec.CommandContext(e.ctx, "cf", args...)
 	command.Env = setEnv(os.Environ(), "CF_HOME", e.tempDir)
 	command.Dir = directory
-	return command.CombinedOutput()
+	return e.run(command)
+}
+
+// Cancel kills the commands that are running. Commands that are run afterwards fail
+// without running.
+func (e Executor) Cancel() {
+	e.cancel()
 }
 
 // CleanUp removes the temporary directory of the Executor.
 func (e Executor) CleanUp() error {
+	tempDirsMutex.Lock()
+	delete(tempDirs, e.tempDir)
+	tempDirsMutex.Unlock()
+
 	return e.fileSystem.RemoveAll(e.tempDir)
 }
 
+// CleanUpAll removes the temporary directories of the Executors of this process that were
+// not cleaned up, such as those of deployments that were cancelled while they were running.
+func CleanUpAll(fileSystem *afero.Afero) error {
+	tempDirsMutex.Lock()
+	defer tempDirsMutex.Unlock()
+
+	for tempDir := range tempDirs {
+		err := fileSystem.RemoveAll(tempDir)
+		if err != nil {
+			return err
+		}
+		delete(tempDirs, tempDir)
+	}
+
+	return nil
+}
+
+func (e Executor) run(command *exec.Cmd) ([]byte, error) {
+	output, err := command.CombinedOutput()
+	if e.ctx.Err() != nil {
+		return output, CancelledError{command.Args[1:]}
+	}
+	return output, err
+}
+
 func setEnv(env []string, key, value string) []string {
 	keyValuePair := key + "=" + value
 
@@ -0,0 +1,109 @@
+package executor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher/courier/executor"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Executor", func() {
+	var (
+		fileSystem *afero.Afero
+		binDir     string
+		path       string
+	)
+
+	BeforeEach(func() {
+		fileSystem = &afero.Afero{Fs: afero.NewOsFs()}
+
+		var err error
+		binDir, err = ioutil.TempDir("", "executor-bin")
+		Expect(err).ToNot(HaveOccurred())
+
+		// cf prints its arguments, or sleeps when it is asked to
+		script := "#!/bin/sh\nif [ \"$1\" = sleep ]; then exec sleep 10; fi\necho \"$@\"\n"
+		Expect(ioutil.WriteFile(filepath.Join(binDir, "cf"), []byte(script), 0755)).To(Succeed())
+
+		path = os.Getenv("PATH")
+		os.Setenv("PATH", binDir+string(os.PathListSeparator)+path)
+	})
+
+	AfterEach(func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(binDir)
+	})
+
+	It("runs cf", func() {
+		ex, err := New(fileSystem)
+		Expect(err).ToNot(HaveOccurred())
+		defer ex.CleanUp()
+
+		output, err := ex.Execute("apps")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(Equal("apps\n"))
+	})
+
+	Describe("Cancel", func() {
+		It("kills the commands that are running", func() {
+			ex, err := New(fileSystem)
+			Expect(err).ToNot(HaveOccurred())
+			defer ex.CleanUp()
+
+			result := make(chan error)
+			go func() {
+				_, err := ex.Execute("sleep")
+				result <- err
+			}()
+
+			Consistently(result, 200*time.Millisecond).ShouldNot(Receive())
+			ex.Cancel()
+
+			Eventually(result).Should(Receive(Equal(CancelledError{Args: []string{"sleep"}})))
+		})
+
+		It("fails the commands that are run afterwards", func() {
+			ex, err := New(fileSystem)
+			Expect(err).ToNot(HaveOccurred())
+			defer ex.CleanUp()
+
+			ex.Cancel()
+
+			output, err := ex.ExecuteInDirectory(binDir, "login", "-p", "secret")
+
+			Expect(err).To(MatchError("cf login -p ******** was cancelled"))
+			Expect(output).To(BeEmpty())
+		})
+	})
+
+	Describe("CleanUpAll", func() {
+		It("removes only the temporary directories of this process", func() {
+			other, err := ioutil.TempDir("", TempDirPrefix)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(other)
+
+			before, err := filepath.Glob(filepath.Join(os.TempDir(), TempDirPrefix+"*"))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = New(fileSystem)
+			Expect(err).ToNot(HaveOccurred())
+			second, err := New(fileSystem)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second.CleanUp()).To(Succeed())
+
+			Expect(CleanUpAll(fileSystem)).To(Succeed())
+
+			after, err := filepath.Glob(filepath.Join(os.TempDir(), TempDirPrefix+"*"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(after).To(Equal(before))
+			Expect(after).To(ContainElement(other))
+		})
+	})
+})
@@ -60,3 +60,12 @@ type UnmapRouteError struct {
 func (e UnmapRouteError) Error() string {
 	return fmt.Sprintf("failed to unmap route for %s: %s", e.ApplicationName, string(e.Out))
 }
+
+type ProvenanceError struct {
+	ApplicationName string
+	Out             []byte
+}
+
+func (e ProvenanceError) Error() string {
+	return fmt.Sprintf("cannot record the deployment on %s: %s", e.ApplicationName, string(e.Out))
+}
@@ -2,8 +2,10 @@
 package pusher
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
 	C "github.com/compozed/deployadactyl/constants"
 	I "github.com/compozed/deployadactyl/interfaces"
@@ -19,29 +21,36 @@ const TemporaryNameSuffix = "-new-build-"
 type Pusher struct {
 	Courier        I.Courier
 	DeploymentInfo S.DeploymentInfo
+	Foundation     S.Foundation
 	EventManager   I.EventManager
 	Response       io.ReadWriter
 	Log            I.Logger
 }
 
 // Login will login to a Cloud Foundry instance.
+// It uses the service account of the foundation if it has one.
 func (p Pusher) Login(foundationURL string) error {
+	username, password := p.DeploymentInfo.Username, p.DeploymentInfo.Password
+	if p.Foundation.ServiceAccount.Username != "" {
+		username, password = p.Foundation.ServiceAccount.Username, p.Foundation.ServiceAccount.Password
+	}
+
 	p.Log.Debugf(
 		`logging into cloud foundry with parameters:
 		foundation URL: %+v
 		username: %+v
 		org: %+v
 		space: %+v`,
-		foundationURL, p.DeploymentInfo.Username, p.DeploymentInfo.Org, p.DeploymentInfo.Space,
+		foundationURL, username, p.DeploymentInfo.Org, p.DeploymentInfo.Space,
 	)
 
 	output, err := p.Courier.Login(
 		foundationURL,
-		p.DeploymentInfo.Username,
-		p.DeploymentInfo.Password,
+		username,
+		password,
 		p.DeploymentInfo.Org,
 		p.DeploymentInfo.Space,
-		p.DeploymentInfo.SkipSSL,
+		p.Foundation.SkipSSL,
 	)
 	p.Response.Write(output)
 	if err != nil {
@@ -79,10 +88,13 @@ func (p Pusher) Push(appPath, foundationURL string) error {
 		}
 	}
 
+	p.recordProvenance(tempAppWithUUID)
+
 	p.Log.Debugf("emitting a %s event", C.PushFinishedEvent)
 	pushData := S.PushEventData{
 		AppPath:         appPath,
 		FoundationURL:   foundationURL,
+		Foundation:      p.Foundation,
 		TempAppWithUUID: tempAppWithUUID,
 		DeploymentInfo:  &p.DeploymentInfo,
 		Courier:         p.Courier,
@@ -100,9 +112,26 @@ func (p Pusher) Push(appPath, foundationURL string) error {
 
 // FinishPush will delete the original application if it existed. It will always
 // rename the the newly pushed application to the appName.
+// A FinishPushEvent is emitted before the original application is deleted.
 func (p Pusher) FinishPush() error {
 	if p.Courier.Exists(p.DeploymentInfo.AppName) {
-		err := p.unMapLoadBalancedRoute()
+		p.Log.Debugf("emitting a %s event", C.FinishPushEvent)
+		pushData := S.PushEventData{
+			FoundationURL:   p.Foundation.URL,
+			Foundation:      p.Foundation,
+			TempAppWithUUID: p.DeploymentInfo.AppName + TemporaryNameSuffix + p.DeploymentInfo.UUID,
+			DeploymentInfo:  &p.DeploymentInfo,
+			Courier:         p.Courier,
+			Response:        p.Response,
+		}
+
+		err := p.EventManager.Emit(I.Event{Type: C.FinishPushEvent, Data: pushData})
+		if err != nil {
+			return err
+		}
+		p.Log.Infof("emitted a %s event", C.FinishPushEvent)
+
+		err = p.unMapLoadBalancedRoute()
 		if err != nil {
 			return err
 		}
@@ -148,6 +177,12 @@ func (p Pusher) UndoPush() error {
 	return nil
 }
 
+// Cancel kills the Cloud Foundry commands that are running. Commands that are run
+// afterwards fail, so a cancelled Pusher cannot be used again.
+func (p Pusher) Cancel() {
+	p.Courier.Cancel()
+}
+
 // CleanUp removes the temporary directory created by the Executor.
 func (p Pusher) CleanUp() error {
 	return p.Courier.CleanUp()
@@ -246,3 +281,84 @@ func (p Pusher) renameNewBuildToOriginalAppName() error {
 
 	return nil
 }
+
+// recordProvenance records which deployment produced an application as metadata
+// annotations on the application. Foundations that do not support metadata get
+// environment variables instead.
+//
+// Provenance is informational, so a failure is only logged and written to the response.
+func (p Pusher) recordProvenance(appName string) {
+	provenance := []struct{ annotation, envVar, value string }{
+		{C.DeploymentUUIDAnnotation, C.DeploymentUUIDEnvVar, p.DeploymentInfo.UUID},
+		{C.ArtifactURLAnnotation, C.ArtifactURLEnvVar, p.DeploymentInfo.ArtifactURL},
+		{C.ArtifactChecksumAnnotation, C.ArtifactChecksumEnvVar, p.DeploymentInfo.ArtifactChecksum},
+		{C.DeployedByAnnotation, C.DeployedByEnvVar, p.DeploymentInfo.Username},
+		{C.DeployedAtAnnotation, C.DeployedAtEnvVar, time.Now().UTC().Format(time.RFC3339)},
+	}
+
+	p.Log.Debugf("recording provenance on %s", appName)
+
+	annotations := map[string]string{}
+	for _, item := range provenance {
+		annotations[item.annotation] = item.value
+	}
+
+	err := p.setMetadata(appName, annotations)
+	if err == nil {
+		p.Log.Infof("recorded provenance on %s as metadata", appName)
+		return
+	}
+	p.Log.Infof("could not record provenance on %s as metadata, setting environment variables: %s", appName, err)
+
+	for _, item := range provenance {
+		out, err := p.Courier.SetEnv(appName, item.envVar, item.value)
+		if err != nil {
+			err = ProvenanceError{appName, out}
+			p.Log.Error(err)
+			fmt.Fprintln(p.Response, err)
+			return
+		}
+	}
+
+	p.Log.Infof("recorded provenance on %s as environment variables", appName)
+}
+
+// setMetadata adds annotations, and the deployment UUID as a label, to an application
+// with the v3 Cloud Controller API.
+func (p Pusher) setMetadata(appName string, annotations map[string]string) error {
+	guid, err := p.Courier.AppGUID(appName)
+	if err != nil {
+		return err
+	}
+
+	var metadata struct {
+		Metadata struct {
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	metadata.Metadata.Labels = map[string]string{C.DeploymentUUIDAnnotation: p.DeploymentInfo.UUID}
+	metadata.Metadata.Annotations = annotations
+
+	body, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	out, err := p.Courier.CurlWithBody("PATCH", "/v3/apps/"+guid, string(body))
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, out)
+	}
+
+	// cf curl succeeds when the Cloud Controller returns an error, so the response is checked for errors
+	var response struct {
+		Errors []struct {
+			Detail string
+		}
+	}
+	if json.Unmarshal(out, &response) != nil || len(response.Errors) > 0 {
+		return fmt.Errorf("%s", out)
+	}
+
+	return nil
+}
@@ -1,9 +1,11 @@
 package pusher_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
+	"time"
 
 	C "github.com/compozed/deployadactyl/constants"
 	. "github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher"
@@ -38,6 +40,7 @@ var _ = Describe("Pusher", func() {
 		tempAppWithUUID     string
 		skipSSL             bool
 		deploymentInfo      S.DeploymentInfo
+		foundation          S.Foundation
 		response            *Buffer
 		logBuffer           *Buffer
 	)
@@ -78,9 +81,15 @@ var _ = Describe("Pusher", func() {
 			HealthCheckEndpoint: randomEndpoint,
 		}
 
+		foundation = S.Foundation{
+			URL:     randomFoundationURL,
+			SkipSSL: skipSSL,
+		}
+
 		pusher = Pusher{
 			Courier:        courier,
 			DeploymentInfo: deploymentInfo,
+			Foundation:     foundation,
 			EventManager:   eventManager,
 			Response:       response,
 			Log:            logger.DefaultLogger(logBuffer, logging.DEBUG, "pusher_test"),
@@ -101,6 +110,24 @@ var _ = Describe("Pusher", func() {
 				Expect(courier.LoginCall.Received.SkipSSL).To(Equal(skipSSL))
 			})
 
+			It("uses the service account of the foundation when it has one", func() {
+				pusher.Foundation.ServiceAccount = S.Credentials{Username: "service-username", Password: "service-password"}
+
+				Expect(pusher.Login(randomFoundationURL)).To(Succeed())
+
+				Expect(courier.LoginCall.Received.Username).To(Equal("service-username"))
+				Expect(courier.LoginCall.Received.Password).To(Equal("service-password"))
+			})
+
+			It("uses the skip ssl setting of the foundation", func() {
+				pusher.DeploymentInfo.SkipSSL = false
+				pusher.Foundation.SkipSSL = true
+
+				Expect(pusher.Login(randomFoundationURL)).To(Succeed())
+
+				Expect(courier.LoginCall.Received.SkipSSL).To(BeTrue())
+			})
+
 			It("writes the output of the courier to the response", func() {
 				courier.LoginCall.Returns.Output = []byte("login succeeded")
 
@@ -253,6 +280,76 @@ var _ = Describe("Pusher", func() {
 		})
 	})
 
+	Describe("recording provenance on the temporary application", func() {
+		BeforeEach(func() {
+			pusher.DeploymentInfo.ArtifactURL = "https://example.com/artifact.jar"
+			pusher.DeploymentInfo.ArtifactChecksum = "checksum"
+
+			courier.AppGUIDCall.Returns.GUID = "app-guid"
+			courier.CurlWithBodyCall.Returns.Output = []byte(`{"guid": "app-guid"}`)
+		})
+
+		It("adds metadata to the application", func() {
+			Expect(pusher.Push(randomAppPath, randomFoundationURL)).To(Succeed())
+
+			Expect(courier.AppGUIDCall.Received.AppName).To(Equal(tempAppWithUUID))
+			Expect(courier.CurlWithBodyCall.Received.Method).To(Equal([]string{"PATCH"}))
+			Expect(courier.CurlWithBodyCall.Received.Path).To(Equal([]string{"/v3/apps/app-guid"}))
+
+			var body struct {
+				Metadata struct {
+					Labels      map[string]string
+					Annotations map[string]string
+				}
+			}
+			Expect(json.Unmarshal([]byte(courier.CurlWithBodyCall.Received.Body[0]), &body)).To(Succeed())
+
+			Expect(body.Metadata.Labels).To(Equal(map[string]string{C.DeploymentUUIDAnnotation: randomUUID}))
+			Expect(body.Metadata.Annotations).To(HaveKeyWithValue(C.DeploymentUUIDAnnotation, randomUUID))
+			Expect(body.Metadata.Annotations).To(HaveKeyWithValue(C.ArtifactURLAnnotation, "https://example.com/artifact.jar"))
+			Expect(body.Metadata.Annotations).To(HaveKeyWithValue(C.ArtifactChecksumAnnotation, "checksum"))
+			Expect(body.Metadata.Annotations).To(HaveKeyWithValue(C.DeployedByAnnotation, randomUsername))
+
+			deployedAt, err := time.Parse(time.RFC3339, body.Metadata.Annotations[C.DeployedAtAnnotation])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployedAt).To(BeTemporally("~", time.Now(), time.Minute))
+
+			Expect(courier.SetEnvCall.Received.Name).To(BeEmpty())
+		})
+
+		Context("when the foundation does not support metadata", func() {
+			It("sets environment variables on the application", func() {
+				courier.CurlWithBodyCall.Returns.Output = []byte(`{"errors": [{"detail": "Unknown request"}]}`)
+
+				Expect(pusher.Push(randomAppPath, randomFoundationURL)).To(Succeed())
+
+				Expect(courier.SetEnvCall.Received.AppName).To(ConsistOf(tempAppWithUUID, tempAppWithUUID, tempAppWithUUID, tempAppWithUUID, tempAppWithUUID))
+				Expect(courier.SetEnvCall.Received.Name).To(Equal([]string{
+					C.DeploymentUUIDEnvVar,
+					C.ArtifactURLEnvVar,
+					C.ArtifactChecksumEnvVar,
+					C.DeployedByEnvVar,
+					C.DeployedAtEnvVar,
+				}))
+				Expect(courier.SetEnvCall.Received.Value[:4]).To(Equal([]string{randomUUID, "https://example.com/artifact.jar", "checksum", randomUsername}))
+			})
+		})
+
+		Context("when the provenance cannot be recorded", func() {
+			It("writes the error to the response and does not fail the push", func() {
+				courier.AppGUIDCall.Returns.Error = errors.New("app guid error")
+				courier.SetEnvCall.Returns.Output = []byte("set-env failed")
+				courier.SetEnvCall.Returns.Error = errors.New("set-env error")
+
+				Expect(pusher.Push(randomAppPath, randomFoundationURL)).To(Succeed())
+
+				Expect(courier.SetEnvCall.Received.Name).To(HaveLen(1))
+				Eventually(response).Should(Say(ProvenanceError{tempAppWithUUID, []byte("set-env failed")}.Error()))
+				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.PushFinishedEvent))
+			})
+		})
+	})
+
 	Describe("finishing a push", func() {
 		It("renames the newly pushed app to the original name", func() {
 			Expect(pusher.FinishPush()).To(Succeed())
@@ -289,6 +386,25 @@ var _ = Describe("Pusher", func() {
 				Expect(courier.ExistsCall.Received.AppName).To(Equal(randomAppName))
 			})
 
+			It("emits a finish push event", func() {
+				Expect(pusher.FinishPush()).To(Succeed())
+
+				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.FinishPushEvent))
+				Expect(eventManager.EmitCall.Received.Events[0].Data.(S.PushEventData).TempAppWithUUID).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
+				Expect(eventManager.EmitCall.Received.Events[0].Data.(S.PushEventData).DeploymentInfo.AppName).To(Equal(randomAppName))
+			})
+
+			Context("when the finish push event fails", func() {
+				It("returns an error and does not delete the original application", func() {
+					eventManager.EmitCall.Returns.Error[0] = errors.New("event manager error")
+
+					err := pusher.FinishPush()
+					Expect(err).To(MatchError("event manager error"))
+
+					Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+				})
+			})
+
 			It("unmaps the load balanced route", func() {
 				Expect(pusher.FinishPush()).To(Succeed())
 
@@ -361,6 +477,7 @@ var _ = Describe("Pusher", func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+				Expect(eventManager.EmitCall.Received.Events).To(BeEmpty())
 
 				Eventually(logBuffer).ShouldNot(Say("delete"))
 			})
@@ -445,6 +562,12 @@ var _ = Describe("Pusher", func() {
 
 				Expect(eventManager.EmitCall.Received.Events[0].Data.(S.PushEventData).TempAppWithUUID).To(Equal(randomAppName + TemporaryNameSuffix + randomUUID))
 			})
+
+			It("has the foundation configuration on the event", func() {
+				Expect(pusher.Push(randomAppPath, randomFoundationURL)).To(Succeed())
+
+				Expect(eventManager.EmitCall.Received.Events[0].Data.(S.PushEventData).Foundation).To(Equal(foundation))
+			})
 		})
 
 		Context("when an event fails", func() {
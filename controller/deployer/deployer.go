@@ -6,19 +6,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"regexp"
 
 	"bytes"
 
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"log"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/compozed/deployadactyl/config"
 	C "github.com/compozed/deployadactyl/constants"
+	"github.com/compozed/deployadactyl/controller/deployer/approval"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/controller/deployer/drain"
+	"github.com/compozed/deployadactyl/controller/deployer/freeze"
 	"github.com/compozed/deployadactyl/controller/deployer/manifestro"
+	"github.com/compozed/deployadactyl/controller/openapi"
 	"github.com/compozed/deployadactyl/geterrors"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/logger"
@@ -32,6 +41,11 @@ If you experience any problems after this point, check that you can manually pus
 It is likely that it is an error with your application and not with Deployadactyl.
 Thanks for using Deployadactyl! Please push down pull up on your lap bar and exit to your left.
 
+`
+
+	successfulDryRun = `Your dry run was successful! (^_^)b
+No changes were made. The commands above are the ones a deploy would run on each foundation.
+
 `
 
 	deploymentOutput = `Deployment Parameters:
@@ -80,20 +94,47 @@ func (d SilentDeployer) Deploy(req *http.Request, environment, org, space, appNa
 	reqChannel <- deployResponse
 }
 
+// deployReport is the response of a deployment. It keeps the result of every foundation and
+// the errors found in the output, so they can be returned to clients that accept JSON. The
+// output is kept as it is written, so the errors can be found in the output of a response
+// that is streamed.
+type deployReport struct {
+	io.ReadWriter
+	output        bytes.Buffer
+	foundations   []S.FoundationResult
+	matchedErrors []I.LogMatchedError
+}
+
+// Write writes the output to the response and keeps it.
+func (r *deployReport) Write(p []byte) (int, error) {
+	r.output.Write(p)
+	return r.ReadWriter.Write(p)
+}
+
+// RecordFoundation keeps the result of the deployment on a foundation.
+func (r *deployReport) RecordFoundation(result S.FoundationResult) {
+	r.foundations = append(r.foundations, result)
+}
+
 type Deployer struct {
-	Config       config.Config
-	BlueGreener  I.BlueGreener
-	Fetcher      I.Fetcher
-	Prechecker   I.Prechecker
-	EventManager I.EventManager
-	Randomizer   I.Randomizer
-	ErrorFinder  I.ErrorFinder
-	Log          I.Logger
-	FileSystem   *afero.Afero
+	Config         config.Config
+	BlueGreener    I.BlueGreener
+	Fetcher        I.Fetcher
+	Prechecker     I.Prechecker
+	RouteValidator I.RouteValidator
+	EventManager   I.EventManager
+	Randomizer     I.Randomizer
+	ErrorFinder    I.ErrorFinder
+	Log            I.Logger
+	FileSystem     *afero.Afero
+	ConfigWatcher  *config.Watcher
+	ApprovalGate   I.ApprovalGate
+	Drain          I.Drain
 }
 
 func (d Deployer) Deploy(req *http.Request, environment, org, space, appName, uuid string, contentType I.DeploymentType, response io.ReadWriter, reqChannel chan I.DeployResponse) {
 	deployResponse := I.DeployResponse{}
+	report := &deployReport{ReadWriter: response}
 	statusCode, deploymentInfo, err := d.deployInternal(
 		req,
 		environment,
@@ -102,21 +143,25 @@ func (d Deployer) Deploy(req *http.Request, environment, org, space, appName, uu
 		appName,
 		uuid,
 		contentType,
-		response,
+		report,
 	)
 	deployResponse.StatusCode = statusCode
 	deployResponse.DeploymentInfo = deploymentInfo
 	deployResponse.Error = err
+	deployResponse.Foundations = report.foundations
+	deployResponse.MatchedErrors = report.matchedErrors
 	reqChannel <- deployResponse
 }
 
-func (d Deployer) deployInternal(req *http.Request, environment, org, space, appName, uuid string, contentType I.DeploymentType, response io.ReadWriter) (statusCode int, deploymentInfo *S.DeploymentInfo, err error) {
+func (d Deployer) deployInternal(req *http.Request, environment, org, space, appName, uuid string, contentType I.DeploymentType, response *deployReport) (statusCode int, deploymentInfo *S.DeploymentInfo, err error) {
 	var (
-		environments           = d.Config.Environments
+		cfg                    = d.getConfig()
+		environments           = cfg.Environments
 		authenticationRequired = environments[environment].Authenticate
 		deployEventData        = S.DeployEventData{}
 		manifest               []byte
 		appPath                string
+		useServiceAccount      bool
 	)
 	deploymentInfo = &S.DeploymentInfo{}
 
@@ -128,6 +173,11 @@ func (d Deployer) deployInternal(req *http.Request, environment, org, space, app
 	d.Log.Debugf("Starting deploy of %s with UUID %s", appName, uuid)
 	deploymentLogger := logger.DeploymentLogger{d.Log, uuid}
 
+	if d.Drain != nil && d.Drain.Draining() {
+		fmt.Fprintln(response, drain.DrainingError{}.Error())
+		return http.StatusServiceUnavailable, deploymentInfo, drain.DrainingError{}
+	}
+
 	e, ok := environments[environment]
 	if !ok {
 		fmt.Fprintln(response, EnvironmentNotFoundError{environment}.Error())
@@ -143,18 +193,56 @@ func (d Deployer) deployInternal(req *http.Request, environment, org, space, app
 
 	deploymentLogger.Debug("checking for basic auth")
 	username, password, ok := req.BasicAuth()
+	subject := req.Header.Get(C.SubjectHeader)
 	if !ok {
-		if authenticationRequired {
+		apiToken := req.Header.Get(C.APITokenHeader)
+		if authenticationRequired && apiToken == "" && subject == "" {
 			return http.StatusUnauthorized, deploymentInfo, BasicAuthError{}
 		}
-		username = d.Config.Username
-		password = d.Config.Password
+		if apiToken != "" {
+			deploymentLogger.Debugf("authenticated with API token %s, using the service account", apiToken)
+		}
+		if subject != "" {
+			deploymentLogger.Debugf("authenticated as %s, using the service account", subject)
+		}
+		credentials := cfg.GetCredentials(e, "")
+		username = credentials.Username
+		password = credentials.Password
+		useServiceAccount = true
+	}
+
+	deploymentLogger.Debug("checking for freeze windows")
+	err = d.checkFreeze(e, S.FreezeEventData{
+		Response:    response,
+		Environment: environment,
+		Org:         org,
+		Space:       space,
+		AppName:     appName,
+		UUID:        uuid,
+		Username:    requester(username, subject, useServiceAccount),
+		Reason:      req.Header.Get(C.EmergencyReasonHeader),
+	}, deploymentLogger)
+	if err != nil {
+		deploymentLogger.Error(err)
+		if _, ok := err.(FreezeError); ok {
+			return http.StatusConflict, deploymentInfo, err
+		}
+		return http.StatusInternalServerError, deploymentInfo, err
 	}
 
 	if contentType.JSON {
 		deploymentLogger.Debug("deploying from json request")
+		deploymentLogger.Debug("validating the request body")
+		body, _ := ioutil.ReadAll(req.Body)
+		err = openapi.Validate(openapi.DeploymentSchema(), body)
+		if err != nil {
+			deploymentLogger.Error(err)
+			fmt.Fprintln(response, err)
+			return http.StatusBadRequest, deploymentInfo, err
+		}
+
 		deploymentLogger.Debug("building deploymentInfo")
-		deploymentInfo, err = getDeploymentInfo(req.Body)
+		deploymentInfo, err = getDeploymentInfo(bytes.NewReader(body))
 		if err != nil {
 			deploymentLogger.Error(err)
 			return http.StatusInternalServerError, deploymentInfo, err
@@ -199,6 +287,16 @@ func (d Deployer) deployInternal(req *http.Request, environment, org, space, app
 	deploymentInfo.Manifest = string(manifest)
 	deploymentInfo.Domain = environments[environment].Domain
 	deploymentInfo.AppPath = appPath
+	deploymentInfo.DryRun = req.Header.Get(C.DryRunHeader) == "true"
+
+	checksum := d.getChecksum(appPath, deploymentLogger)
+	if deploymentInfo.ArtifactChecksum != "" && deploymentInfo.ArtifactChecksum != checksum {
+		err = ChecksumError{Expected: deploymentInfo.ArtifactChecksum, Actual: checksum}
+		deploymentLogger.Error(err)
+		return http.StatusBadRequest, deploymentInfo, err
+	}
+	deploymentInfo.ArtifactChecksum = checksum
+
 	deploymentInfo.CustomParams = make(map[string]interface{})
 	deploymentInfo.CustomParams = environments[environment].CustomParams
 
@@ -221,6 +319,39 @@ func (d Deployer) deployInternal(req *http.Request, environment, org, space, app
 		return http.StatusInternalServerError, deploymentInfo, err
 	}
 
+	if useServiceAccount {
+		e = cfg.WithServiceAccounts(e)
+	}
+
+	// A subject has no password, so it is recorded as the user the deployment is by while
+	// every foundation is logged into with its service account.
+	if useServiceAccount && subject != "" {
+		deploymentInfo.Username = requester(username, subject, useServiceAccount)
+		deploymentInfo.Password = ""
+	}
+
+	// Routes are validated before waiting for approval so approvers are not asked to
+	// approve a deployment that cannot go ahead.
+	deploymentLogger.Debug("validating routes")
+	err = d.RouteValidator.Validate(e, *deploymentInfo)
+	if err != nil {
+		deploymentLogger.Error(err)
+		fmt.Fprintln(response, err)
+		return http.StatusBadRequest, deploymentInfo, err
+	}
+
+	if d.ApprovalGate != nil && deploymentInfo.DryRun {
+		fmt.Fprintf(response, "%s not waiting for approval\n", C.DryRunPrefix)
+	} else if d.ApprovalGate != nil {
+		deploymentLogger.Debug("waiting for approval")
+		err = d.ApprovalGate.Wait(e, *deploymentInfo, response)
+		if err != nil {
+			deploymentLogger.Error(err)
+			fmt.Fprintln(response, err)
+			return approvalStatusCode(err), deploymentInfo, err
+		}
+	}
+
 	deploymentMessage := fmt.Sprintf(deploymentOutput, deploymentInfo.ArtifactURL, deploymentInfo.Username, deploymentInfo.Environment, deploymentInfo.Org, deploymentInfo.Space, deploymentInfo.AppName)
 	deploymentLogger.Info(deploymentMessage)
 	fmt.Fprintln(response, deploymentMessage)
@@ -243,6 +374,11 @@ func (d Deployer) deployInternal(req *http.Request, environment, org, space, app
 	err = d.BlueGreener.Push(e, appPath, *deploymentInfo, response)
 
 	if err != nil {
+		if _, ok := err.(bluegreen.ShutdownError); ok {
+			fmt.Fprintln(response, err)
+			return http.StatusServiceUnavailable, deploymentInfo, err
+		}
+
 		if !enableRollback {
 			deploymentLogger.Errorf("EnableRollback %t, returning status %d and err %s", enableRollback, http.StatusOK, err)
 			return http.StatusOK, deploymentInfo, err
@@ -255,12 +391,121 @@ func (d Deployer) deployInternal(req *http.Request, environment, org, space, app
 		return http.StatusInternalServerError, deploymentInfo, err
 	}
 
+	if deploymentInfo.DryRun {
+		deploymentLogger.Infof("successfully dry ran the deployment of application %s", deploymentInfo.AppName)
+		fmt.Fprintf(response, "\n%s", successfulDryRun)
+		return http.StatusOK, deploymentInfo, err
+	}
+
 	deploymentLogger.Infof("successfully deployed application %s", deploymentInfo.AppName)
 	fmt.Fprintf(response, "\n%s", successfulDeploy)
 
 	return http.StatusOK, deploymentInfo, err
 }
 
+// checkFreeze returns a FreezeError when the environment is in a freeze window, unless the
+// environment allows emergency overrides and the request gives an emergency reason.
+// Rejected and overridden deployments emit an event so they can be audited.
+func (d Deployer) checkFreeze(environment S.Environment, freezeEventData S.FreezeEventData, deploymentLogger logger.DeploymentLogger) error {
+	window, end, frozen, err := freeze.Active(environment.FreezeWindows, time.Now())
+	if err != nil || !frozen {
+		return err
+	}
+
+	freezeEventData.Window = window.Name
+	freezeEventData.End = end
+
+	if freezeEventData.Reason != "" && environment.AllowEmergencyOverride {
+		deploymentLogger.Infof("deploying during freeze window %s with an emergency override: %s", window.Name, freezeEventData.Reason)
+		fmt.Fprintf(freezeEventData.Response, "deploying during freeze window %s with an emergency override: %s\n", window.Name, freezeEventData.Reason)
+
+		err = d.EventManager.Emit(I.Event{Type: C.DeployFreezeOverrideEvent, Data: freezeEventData})
+		if err != nil {
+			return EventError{Type: C.DeployFreezeOverrideEvent, Err: err}
+		}
+		return nil
+	}
+
+	err = d.EventManager.Emit(I.Event{Type: C.DeployFrozenEvent, Data: freezeEventData})
+	if err != nil {
+		deploymentLogger.Errorf("an error occurred when emitting a %s event: %s", C.DeployFrozenEvent, err)
+	}
+
+	return FreezeError{
+		Environment:       environment.Name,
+		Window:            window.Name,
+		End:               end,
+		OverrideRequested: freezeEventData.Reason != "",
+	}
+}
+
+// approvalStatusCode returns the status code of a deployment that did not get approval.
+func approvalStatusCode(err error) int {
+	switch err.(type) {
+	case approval.RejectedError:
+		return http.StatusForbidden
+	case approval.ExpiredError:
+		return http.StatusRequestTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// getConfig returns the config for a deployment. When there is a ConfigWatcher it returns the
+// most recently loaded config, so a reloaded config is used by subsequent deployments.
+func (d Deployer) getConfig() config.Config {
+	if d.ConfigWatcher != nil {
+		return d.ConfigWatcher.Config()
+	}
+
+	return d.Config
+}
+
+// getChecksum returns the sha256 checksum of the files of an application. An application
+// has the same checksum whether it was fetched from an artifact url or uploaded as a zip.
+//
+// Returns an empty checksum if the files cannot be read, because the checksum is only
+// recorded on the application and is not needed to deploy it.
+func (d Deployer) getChecksum(appPath string, deploymentLogger logger.DeploymentLogger) string {
+	hash := sha256.New()
+
+	err := d.FileSystem.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := d.FileSystem.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(appPath, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(relativePath), len(data))
+		hash.Write(data)
+
+		return nil
+	})
+	if err != nil {
+		deploymentLogger.Errorf("could not get the checksum of %s: %s", appPath, err)
+		return ""
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// requester returns who a deployment is by: the subject of a JWT when the deployment uses
+// the service account for it, or the username otherwise.
+func requester(username, subject string, useServiceAccount bool) string {
+	if useServiceAccount && subject != "" {
+		return subject
+	}
+	return username
+}
+
 func getDeploymentInfo(reader io.Reader) (*S.DeploymentInfo, error) {
 	deploymentInfo := S.DeploymentInfo{}
 	err := json.NewDecoder(reader).Decode(&deploymentInfo)
@@ -295,7 +540,7 @@ func emitDeployFinish(d Deployer, deployEventData S.DeployEventData, response io
 	}
 }
 
-func emitDeploySuccess(d Deployer, deployEventData S.DeployEventData, response io.ReadWriter, err *error, statusCode *int, deploymentLogger logger.DeploymentLogger) {
+func emitDeploySuccess(d Deployer, deployEventData S.DeployEventData, response *deployReport, err *error, statusCode *int, deploymentLogger logger.DeploymentLogger) {
 	deployEvent := I.Event{Type: C.DeploySuccessEvent, Data: deployEventData}
 	if *err != nil {
 		printErrors(d, response, err)
@@ -312,12 +557,10 @@ func emitDeploySuccess(d Deployer, deployEventData S.DeployEventData, response i
 	}
 }
 
-func printErrors(d Deployer, response io.ReadWriter, err *error) {
-	tempBuffer := bytes.Buffer{}
-	tempBuffer.ReadFrom(response)
-	fmt.Fprint(response, tempBuffer.String())
-
-	errors := d.ErrorFinder.FindErrors(tempBuffer.String())
+// printErrors finds the errors in the output of a deployment and writes them to the output.
+func printErrors(d Deployer, response *deployReport, err *error) {
+	errors := d.ErrorFinder.FindErrors(response.output.String())
+	response.matchedErrors = errors
 	if len(errors) > 0 {
 		*err = errors[0]
 		for _, error := range errors {
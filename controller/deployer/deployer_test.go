@@ -2,11 +2,17 @@ package deployer_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -17,8 +23,11 @@ import (
 	"github.com/compozed/deployadactyl/config"
 	C "github.com/compozed/deployadactyl/constants"
 	. "github.com/compozed/deployadactyl/controller/deployer"
+	"github.com/compozed/deployadactyl/controller/deployer/approval"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	"github.com/compozed/deployadactyl/controller/deployer/drain"
 	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
+	"github.com/compozed/deployadactyl/controller/openapi"
 	"github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/logger"
 	"github.com/compozed/deployadactyl/mocks"
@@ -44,9 +53,12 @@ var _ = Describe("Deployer", func() {
 		blueGreener    *mocks.BlueGreener
 		fetcher        *mocks.Fetcher
 		prechecker     *mocks.Prechecker
+		routeValidator *mocks.RouteValidator
 		eventManager   *mocks.EventManager
 		randomizerMock *mocks.Randomizer
 		errorFinder    *mocks.ErrorFinder
+		approvalGate   *mocks.ApprovalGate
+		drainMock      *mocks.Drain
 
 		req                          *http.Request
 		requestBody                  *bytes.Buffer
@@ -79,9 +91,12 @@ var _ = Describe("Deployer", func() {
 		blueGreener = &mocks.BlueGreener{}
 		fetcher = &mocks.Fetcher{}
 		prechecker = &mocks.Prechecker{}
+		routeValidator = &mocks.RouteValidator{}
 		eventManager = &mocks.EventManager{}
 		randomizerMock = &mocks.Randomizer{}
 		errorFinder = &mocks.ErrorFinder{}
+		approvalGate = &mocks.ApprovalGate{}
+		drainMock = &mocks.Drain{}
 
 		appName = "appName-" + randomizer.StringRunes(10)
 		appPath = "appPath-" + randomizer.StringRunes(10)
@@ -180,14 +195,202 @@ var _ = Describe("Deployer", func() {
 			blueGreener,
 			fetcher,
 			prechecker,
+			routeValidator,
 			eventManager,
 			randomizerMock,
 			errorFinder,
 			log,
 			af,
+			nil,
+			approvalGate,
+			drainMock,
 		}
 	})
 
+	Describe("freeze windows", func() {
+		var frozen S.Environment
+
+		BeforeEach(func() {
+			frozen = environments[environment]
+			frozen.FreezeWindows = []S.FreezeWindow{{
+				Name:  "year-end",
+				Start: time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02"),
+				End:   time.Now().UTC().AddDate(0, 0, 2).Format("2006-01-02"),
+			}}
+			environments[environment] = frozen
+		})
+
+		Context("when the environment is in a freeze window", func() {
+			It("rejects the request with a http.StatusConflict and emits a frozen event", func() {
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusConflict))
+				Expect(deployResponse.Error).To(BeAssignableToTypeOf(FreezeError{}))
+				Expect(deployResponse.Error.(FreezeError).Window).To(Equal("year-end"))
+				Expect(deployResponse.Error.(FreezeError).OverrideRequested).To(BeFalse())
+
+				Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+				Expect(eventManager.EmitCall.Received.Events).To(HaveLen(1))
+				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.DeployFrozenEvent))
+
+				eventData := eventManager.EmitCall.Received.Events[0].Data.(S.FreezeEventData)
+				Expect(eventData.Environment).To(Equal(environment))
+				Expect(eventData.AppName).To(Equal(appName))
+				Expect(eventData.Username).To(Equal(username))
+				Expect(eventData.Window).To(Equal("year-end"))
+			})
+		})
+
+		Context("when an emergency reason is given", func() {
+			BeforeEach(func() {
+				req.Header.Set(C.EmergencyReasonHeader, "outage")
+			})
+
+			It("deploys and emits a freeze override event when the environment allows emergency overrides", func() {
+				frozen.AllowEmergencyOverride = true
+				environments[environment] = frozen
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.Error).ToNot(HaveOccurred())
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+				Expect(response.String()).To(ContainSubstring("deploying during freeze window year-end with an emergency override: outage"))
+
+				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.DeployFreezeOverrideEvent))
+				Expect(eventManager.EmitCall.Received.Events[0].Data.(S.FreezeEventData).Reason).To(Equal("outage"))
+				Expect(eventManager.EmitCall.Received.Events[1].Type).To(Equal(C.DeployStartEvent))
+			})
+
+			It("rejects the request when the environment does not allow emergency overrides", func() {
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusConflict))
+				Expect(deployResponse.Error.(FreezeError).OverrideRequested).To(BeTrue())
+				Expect(deployResponse.Error.Error()).To(ContainSubstring("emergency overrides are not allowed"))
+				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.DeployFrozenEvent))
+			})
+		})
+	})
+
+	Describe("waiting for approval", func() {
+		It("waits for approval after fetching the artifact and before pushing", func() {
+			fetcher.FetchCall.Returns.AppPath = appPath
+
+			reqChannel1 := make(chan interfaces.DeployResponse)
+			go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+			deployResponse := <-reqChannel1
+
+			Expect(deployResponse.Error).ToNot(HaveOccurred())
+			Expect(approvalGate.WaitCall.TimesCalled).To(Equal(1))
+			Expect(approvalGate.WaitCall.Received.Environment).To(Equal(c.WithServiceAccounts(environments[environment])))
+			Expect(approvalGate.WaitCall.Received.DeploymentInfo.UUID).To(Equal(uuid))
+			Expect(approvalGate.WaitCall.Received.DeploymentInfo.AppPath).To(Equal(appPath))
+			Expect(approvalGate.WaitCall.Received.DeploymentInfo.Username).To(Equal(username))
+		})
+
+		Context("when the deployment is rejected", func() {
+			It("does not push and returns http.StatusForbidden", func() {
+				approvalGate.WaitCall.Returns.Error = approval.RejectedError{UUID: uuid, Approver: "approver"}
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusForbidden))
+				Expect(deployResponse.Error).To(MatchError(approval.RejectedError{UUID: uuid, Approver: "approver"}))
+				Expect(blueGreener.PushCall.Received.AppPath).To(BeEmpty())
+				Expect(eventManager.EmitCall.Received.Events).To(BeEmpty())
+			})
+		})
+
+		Context("when the approval timeout passes", func() {
+			It("removes the fetched artifact and returns http.StatusRequestTimeout", func() {
+				directoryName, err := af.TempDir("", "deployadactyl-")
+				Expect(err).ToNot(HaveOccurred())
+				fetcher.FetchCall.Returns.AppPath = directoryName
+
+				approvalGate.WaitCall.Returns.Error = approval.ExpiredError{UUID: uuid, Timeout: time.Hour}
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusRequestTimeout))
+				Expect(blueGreener.PushCall.Received.AppPath).To(BeEmpty())
+
+				exists, err := af.DirExists(directoryName)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(exists).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("dry runs", func() {
+		It("tells the blue greener that the deployment is a dry run", func() {
+			req.Header.Set(C.DryRunHeader, "true")
+
+			reqChannel1 := make(chan interfaces.DeployResponse)
+			go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+			deployResponse := <-reqChannel1
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+			Expect(blueGreener.PushCall.Received.DeploymentInfo.DryRun).To(BeTrue())
+			Expect(response.String()).To(ContainSubstring("Your dry run was successful!"))
+			Expect(response.String()).ToNot(ContainSubstring("Your deploy was successful!"))
+		})
+
+		It("does not wait for approval", func() {
+			req.Header.Set(C.DryRunHeader, "true")
+
+			reqChannel1 := make(chan interfaces.DeployResponse)
+			go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+			<-reqChannel1
+
+			Expect(approvalGate.WaitCall.TimesCalled).To(Equal(0))
+			Expect(response.String()).To(ContainSubstring("dry run: not waiting for approval"))
+		})
+
+		It("is not a dry run without the header", func() {
+			reqChannel1 := make(chan interfaces.DeployResponse)
+			go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+			<-reqChannel1
+
+			Expect(blueGreener.PushCall.Received.DeploymentInfo.DryRun).To(BeFalse())
+		})
+	})
+
+	Describe("shutting down", func() {
+		It("rejects the request with a http.StatusServiceUnavailable when the server is draining", func() {
+			drainMock.DrainingCall.Returns.Draining = true
+
+			reqChannel1 := make(chan interfaces.DeployResponse)
+			go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+			deployResponse := <-reqChannel1
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(deployResponse.Error).To(MatchError(drain.DrainingError{}))
+			Expect(prechecker.AssertAllFoundationsUpCall.Received.Environment).To(BeZero())
+			Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+		})
+
+		It("returns http.StatusServiceUnavailable when the push is refused", func() {
+			blueGreener.PushCall.Returns.Error = bluegreen.ShutdownError{Err: drain.DrainingError{}}
+
+			reqChannel1 := make(chan interfaces.DeployResponse)
+			go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+			deployResponse := <-reqChannel1
+
+			Expect(deployResponse.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(response.String()).To(ContainSubstring("shutting down"))
+		})
+	})
+
 	Describe("prechecking the environments", func() {
 		Context("when Prechecker fails", func() {
 			It("rejects the request with a http.StatusInternalServerError", func() {
@@ -227,6 +430,59 @@ var _ = Describe("Deployer", func() {
 				})
 			})
 
+			Context("when the environment has its own credentials", func() {
+				It("uses the environment credentials instead of the config username and password", func() {
+					deployer.Config.Credentials = map[string]S.Credentials{
+						"env-credentials": {Username: "env-username", Password: "env-password"},
+					}
+					deployer.Config.Environments[environment] = S.Environment{Credentials: "env-credentials"}
+
+					reqChannel1 := make(chan interfaces.DeployResponse)
+					go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+					deployResponse := <-reqChannel1
+
+					Expect(deployResponse.Error).ToNot(HaveOccurred())
+					Expect(blueGreener.PushCall.Received.DeploymentInfo.Username).To(Equal("env-username"))
+					Expect(blueGreener.PushCall.Received.DeploymentInfo.Password).To(Equal("env-password"))
+				})
+
+				It("resolves the service accounts of foundations with their own credentials", func() {
+					deployer.Config.Credentials = map[string]S.Credentials{
+						"foundation-credentials": {Username: "foundation-username", Password: "foundation-password"},
+					}
+					deployer.Config.Environments[environment] = S.Environment{
+						Foundations:       foundations,
+						FoundationConfigs: map[string]S.Foundation{foundations[0]: {URL: foundations[0], Credentials: "foundation-credentials"}},
+					}
+
+					reqChannel1 := make(chan interfaces.DeployResponse)
+					go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+					<-reqChannel1
+
+					foundation := blueGreener.PushCall.Received.Environment.GetFoundation(foundations[0])
+					Expect(foundation.ServiceAccount).To(Equal(S.Credentials{Username: "foundation-username", Password: "foundation-password"}))
+					Expect(deployer.Config.Environments[environment].GetFoundation(foundations[0]).ServiceAccount).To(Equal(S.Credentials{}))
+				})
+
+				It("does not use the service accounts when basic auth is provided", func() {
+					deployer.Config.Credentials = map[string]S.Credentials{
+						"foundation-credentials": {Username: "foundation-username", Password: "foundation-password"},
+					}
+					deployer.Config.Environments[environment] = S.Environment{
+						Foundations:       foundations,
+						FoundationConfigs: map[string]S.Foundation{foundations[0]: {URL: foundations[0], Credentials: "foundation-credentials"}},
+					}
+					req.SetBasicAuth("request-username", "request-password")
+
+					reqChannel1 := make(chan interfaces.DeployResponse)
+					go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+					<-reqChannel1
+
+					Expect(blueGreener.PushCall.Received.DeploymentInfo.Username).To(Equal("request-username"))
+					Expect(blueGreener.PushCall.Received.Environment.GetFoundation(foundations[0]).ServiceAccount).To(Equal(S.Credentials{}))
+				})
+			})
+
 			Context("when authenticate in the config is true", func() {
 				It("rejects the request with a http.StatusUnauthorized", func() {
 					deployer.Config.Environments[environment] = S.Environment{Authenticate: true}
@@ -242,13 +498,53 @@ var _ = Describe("Deployer", func() {
 					Expect(deployResponse.StatusCode).To(Equal(http.StatusUnauthorized))
 					Expect(eventManager.EmitCall.TimesCalled).To(Equal(0), eventManagerNotEnoughCalls)
 				})
+
+				It("uses the service account when the request was authenticated with an API token", func() {
+					deployer.Config.Credentials = map[string]S.Credentials{
+						"env-credentials": {Username: "env-username", Password: "env-password"},
+					}
+					deployer.Config.Environments[environment] = S.Environment{Authenticate: true, Credentials: "env-credentials"}
+					req.Header.Set(C.APITokenHeader, "ci")
+
+					reqChannel1 := make(chan interfaces.DeployResponse)
+					go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+					deployResponse := <-reqChannel1
+
+					Expect(deployResponse.Error).ToNot(HaveOccurred())
+					Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+					Expect(blueGreener.PushCall.Received.DeploymentInfo.Username).To(Equal("env-username"))
+					Expect(blueGreener.PushCall.Received.DeploymentInfo.Password).To(Equal("env-password"))
+				})
+
+				It("records the subject and uses the service accounts when the request was authenticated with a JWT", func() {
+					deployer.Config.Credentials = map[string]S.Credentials{
+						"env-credentials": {Username: "env-username", Password: "env-password"},
+					}
+					deployer.Config.Environments[environment] = S.Environment{Authenticate: true, Credentials: "env-credentials", Foundations: foundations}
+					req.Header.Set(C.SubjectHeader, "carol@example.com")
+
+					reqChannel1 := make(chan interfaces.DeployResponse)
+					go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+					deployResponse := <-reqChannel1
+
+					Expect(deployResponse.Error).ToNot(HaveOccurred())
+					Expect(deployResponse.StatusCode).To(Equal(http.StatusOK))
+					Expect(blueGreener.PushCall.Received.DeploymentInfo.Username).To(Equal("carol@example.com"))
+					Expect(blueGreener.PushCall.Received.DeploymentInfo.Password).To(BeEmpty())
+
+					for _, foundationURL := range foundations {
+						foundation := blueGreener.PushCall.Received.Environment.GetFoundation(foundationURL)
+						Expect(foundation.ServiceAccount).To(Equal(S.Credentials{Username: "env-username", Password: "env-password"}))
+					}
+					Expect(response.String()).To(ContainSubstring("carol@example.com"))
+				})
 			})
 		})
 	})
 
 	Describe("deploying with JSON in the request body", func() {
 		Context("with missing properties in the JSON", func() {
-			It("returns an error and http.StatusInternalServerError", func() {
+			It("returns an error and http.StatusBadRequest", func() {
 				By("sending empty JSON")
 				requestBody = bytes.NewBufferString("{}")
 
@@ -258,9 +554,30 @@ var _ = Describe("Deployer", func() {
 				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
 				deployResponse := <-reqChannel1
 
-				Expect(deployResponse.Error).To(MatchError("The following properties are missing: artifact_url"))
+				Expect(deployResponse.Error).To(MatchError(openapi.ValidationError{FieldErrors: []S.FieldError{{Field: "artifact_url", Message: "is required"}}}))
 
-				Expect(deployResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+			})
+		})
+
+		Context("with properties of the wrong type in the JSON", func() {
+			It("returns every problem and http.StatusBadRequest", func() {
+				requestBody = bytes.NewBufferString(`{"artifact_url": "", "environment_variables": {"KEY": 1}, "health_check_endpoint": true}`)
+
+				req, _ = http.NewRequest("POST", "", requestBody)
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(deployResponse.Error).To(MatchError(openapi.ValidationError{FieldErrors: []S.FieldError{
+					{Field: "artifact_url", Message: "must not be empty"},
+					{Field: "environment_variables.KEY", Message: "must be a string"},
+					{Field: "health_check_endpoint", Message: "must be a string"},
+				}}))
+				Expect(response.String()).To(ContainSubstring("environment_variables.KEY must be a string"))
 			})
 		})
 
@@ -487,6 +804,29 @@ applications:
 		})
 	})
 
+	Describe("reloading the config", func() {
+		Context("when there is a config watcher", func() {
+			It("uses the environments of the watched config", func() {
+				watchedEnvironment := environments[environment]
+				watchedEnvironment.Domain = "reloaded-" + domain
+
+				deployer.Config = config.Config{}
+				deployer.ConfigWatcher = config.NewWatcher(os.Getenv, "", config.Config{
+					Username:     username,
+					Password:     password,
+					Environments: map[string]S.Environment{environment: watchedEnvironment},
+				}, log)
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.Error).ToNot(HaveOccurred())
+				Expect(blueGreener.PushCall.Received.Environment.Domain).To(Equal("reloaded-" + domain))
+			})
+		})
+	})
+
 	Describe("deployment output", func() {
 		It("shows the user deployment info properties", func() {
 
@@ -616,6 +956,40 @@ applications:
 				Expect(deployResponse.Error.(interfaces.DeploymentError).Code()).To(Equal("TestCode"))
 
 			})
+
+			It("finds the errors in the output of a response that cannot be read back", func() {
+				blueGreener.PushCall.Returns.Error = bluegreen.PushError{[]error{errors.New("push failed")}}
+				errorFinder.FindErrorsCall.Returns.Errors = []interfaces.LogMatchedError{error_finder.CreateLogMatchedError("an error description", []string{"error 1"}, "error solution", "TestCode")}
+
+				writes := &writesResponse{}
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, writes, reqChannel1)
+				<-reqChannel1
+
+				output := strings.Join(writes.writes, "")
+				Expect(errorFinder.FindErrorsCall.Received.Response).To(ContainSubstring("Deployment Parameters"))
+				Expect(strings.Count(output, "Deployment Parameters")).To(Equal(1))
+				Expect(output).To(ContainSubstring("an error description"))
+			})
+
+			It("returns the matched errors and the result of every foundation", func() {
+				blueGreener.PushCall.Returns.Error = bluegreen.PushError{[]error{errors.New("push failed")}}
+				blueGreener.PushCall.Record = []S.FoundationResult{
+					{FoundationURL: "foundation-1", Status: "rolled_back"},
+					{FoundationURL: "foundation-2", Status: "failure", Error: "push failed"},
+				}
+
+				matchedErrors := []interfaces.LogMatchedError{error_finder.CreateLogMatchedError("an error description", []string{"error 1"}, "error solution", "TestCode")}
+				errorFinder.FindErrorsCall.Returns.Errors = matchedErrors
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.MatchedErrors).To(Equal(matchedErrors))
+				Expect(deployResponse.Foundations).To(Equal(blueGreener.PushCall.Record))
+			})
 		})
 
 		Context("when blue greener succeeds", func() {
@@ -667,6 +1041,48 @@ applications:
 		})
 	})
 
+	Describe("validating routes", func() {
+		It("validates the routes of the deployment before pushing", func() {
+			reqChannel1 := make(chan interfaces.DeployResponse)
+			go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+			<-reqChannel1
+
+			Expect(routeValidator.ValidateCall.TimesCalled).To(Equal(1))
+			Expect(routeValidator.ValidateCall.Received.Environment.Name).To(Equal(environments[environment].Name))
+			Expect(routeValidator.ValidateCall.Received.DeploymentInfo.AppName).To(Equal(appName))
+			Expect(routeValidator.ValidateCall.Received.DeploymentInfo.Manifest).To(Equal(manifest))
+		})
+
+		Context("when RouteValidator fails", func() {
+			It("does not push and returns a http.StatusBadRequest", func() {
+				routeValidator.ValidateCall.Returns.Error = errors.New("route validation failed")
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				Expect(deployResponse.Error).To(MatchError("route validation failed"))
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+
+				Expect(blueGreener.PushCall.Received.AppPath).To(BeEmpty())
+				Expect(response.String()).To(ContainSubstring("route validation failed"))
+			})
+
+			It("does not wait for approval or emit a start event", func() {
+				routeValidator.ValidateCall.Returns.Error = errors.New("route validation failed")
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				<-reqChannel1
+
+				Expect(approvalGate.WaitCall.TimesCalled).To(Equal(0))
+				for _, event := range eventManager.EmitCall.Received.Events {
+					Expect(event.Type).ToNot(Equal(C.DeployStartEvent))
+				}
+			})
+		})
+	})
+
 	Describe("BlueGreener.Push", func() {
 		Context("when BlueGreener fails with a login failed error", func() {
 			It("returns an error and a http.StatusUnauthorized", func() {
@@ -751,11 +1167,15 @@ applications:
 				blueGreener,
 				fetcher,
 				prechecker,
+				routeValidator,
 				eventManager,
 				randomizerMock,
 				errorFinder,
 				log,
 				af,
+				nil,
+				approvalGate,
+				drainMock,
 			}
 
 			directoryName, err := af.TempDir("", "deployadactyl-")
@@ -805,10 +1225,49 @@ applications:
 				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.DeployStartEvent))
 				Expect(eventManager.EmitCall.Received.Events[1].Type).To(Equal(C.DeploySuccessEvent))
 				Expect(eventManager.EmitCall.Received.Events[2].Type).To(Equal(C.DeployFinishEvent))
-				Expect(blueGreener.PushCall.Received.Environment).To(Equal(environments[environment]))
+				Expect(blueGreener.PushCall.Received.Environment).To(Equal(c.WithServiceAccounts(environments[environment])))
 				Expect(blueGreener.PushCall.Received.AppPath).To(Equal(appPath))
 				Expect(blueGreener.PushCall.Received.DeploymentInfo).To(Equal(deploymentInfo))
 			})
+
+			It("gives the checksum of the application files to the blue greener", func() {
+				Expect(af.WriteFile(appPath+"/manifest.yml", []byte("manifest"), 0644)).To(Succeed())
+				Expect(af.WriteFile(appPath+"/lib/app.jar", []byte("jar"), 0644)).To(Succeed())
+				defer af.RemoveAll(appPath)
+
+				fetcher.FetchCall.Returns.AppPath = appPath
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				<-reqChannel1
+
+				hash := sha256.New()
+				fmt.Fprintf(hash, "lib/app.jar\x003\x00jar")
+				fmt.Fprintf(hash, "manifest.yml\x008\x00manifest")
+
+				Expect(blueGreener.PushCall.Received.DeploymentInfo.ArtifactChecksum).To(Equal(hex.EncodeToString(hash.Sum(nil))))
+			})
+
+			It("does not deploy when the checksum of the application files is not the expected checksum", func() {
+				Expect(af.WriteFile(appPath+"/manifest.yml", []byte("manifest"), 0644)).To(Succeed())
+				defer af.RemoveAll(appPath)
+
+				fetcher.FetchCall.Returns.AppPath = appPath
+
+				requestBody = bytes.NewBufferString(fmt.Sprintf(`{"artifact_url": "%s", "artifact_checksum": "expected"}`, artifactURL))
+				req, _ = http.NewRequest("POST", "", requestBody)
+
+				reqChannel1 := make(chan interfaces.DeployResponse)
+				go deployer.Deploy(req, environment, org, space, appName, uuid, interfaces.DeploymentType{JSON: true}, response, reqChannel1)
+				deployResponse := <-reqChannel1
+
+				hash := sha256.New()
+				fmt.Fprintf(hash, "manifest.yml\x008\x00manifest")
+
+				Expect(deployResponse.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(deployResponse.Error).To(MatchError(ChecksumError{Expected: "expected", Actual: hex.EncodeToString(hash.Sum(nil))}))
+				Expect(blueGreener.PushCall.Received.AppPath).To(BeEmpty())
+			})
 		})
 	})
 
@@ -843,7 +1302,7 @@ applications:
 				Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.DeployStartEvent))
 				Expect(eventManager.EmitCall.Received.Events[1].Type).To(Equal(C.DeploySuccessEvent))
 				Expect(eventManager.EmitCall.Received.Events[2].Type).To(Equal(C.DeployFinishEvent))
-				Expect(blueGreener.PushCall.Received.Environment).To(Equal(environments[environment]))
+				Expect(blueGreener.PushCall.Received.Environment).To(Equal(c.WithServiceAccounts(environments[environment])))
 				Expect(blueGreener.PushCall.Received.AppPath).To(Equal(testManifestLocation))
 				Expect(blueGreener.PushCall.Received.DeploymentInfo.Manifest).To(Equal(fmt.Sprintf("---\napplications:\n- name: deployadactyl\n  memory: 256M\n  disk_quota: 256M\n")))
 				Expect(blueGreener.PushCall.Received.DeploymentInfo.ArtifactURL).To(ContainSubstring(testManifestLocation))
@@ -884,11 +1343,15 @@ applications:
 					blueGreener,
 					fetcher,
 					prechecker,
+					routeValidator,
 					eventManager,
 					randomizerMock,
 					errorFinder,
 					log,
 					af,
+					nil,
+					approvalGate,
+					drainMock,
 				}
 			})
 
@@ -904,3 +1367,18 @@ applications:
 		})
 	})
 })
+
+// writesResponse is a response that keeps everything that is written to it and cannot be
+// read back, like a response that is streamed.
+type writesResponse struct {
+	writes []string
+}
+
+func (w *writesResponse) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *writesResponse) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
@@ -0,0 +1,139 @@
+// Package drain tracks the pushes in progress so the server can shut down without leaving
+// new builds on the foundations.
+package drain
+
+import (
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+)
+
+// DefaultTimeout is how long a shutdown waits for pushes to finish when no timeout is given.
+const DefaultTimeout = 5 * time.Minute
+
+// Drain has the pushes in progress. Once it is draining, new pushes are refused.
+type Drain struct {
+	log I.Logger
+
+	mutex    sync.Mutex
+	draining bool
+	next     int
+	running  map[int]*push
+	idle     chan struct{}
+}
+
+type push struct {
+	uuid   string
+	cancel func()
+}
+
+// NewDrain returns a Drain without pushes in progress.
+func NewDrain(log I.Logger) *Drain {
+	return &Drain{
+		log:     log,
+		running: map[int]*push{},
+	}
+}
+
+// Start registers a push. Cancel is called if the push is still running when a shutdown
+// times out. The returned function must be called when the push is finished.
+//
+// Returns a DrainingError when the server is shutting down.
+func (d *Drain) Start(uuid string, cancel func()) (func(), error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.draining {
+		return nil, DrainingError{}
+	}
+
+	id := d.next
+	d.next++
+	d.running[id] = &push{uuid: uuid, cancel: cancel}
+
+	var once sync.Once
+	return func() { once.Do(func() { d.finish(id) }) }, nil
+}
+
+// Draining returns true once the server is shutting down.
+func (d *Drain) Draining() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.draining
+}
+
+// Running returns true while a push of the deployment is in progress.
+func (d *Drain) Running(uuid string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, p := range d.running {
+		if p.uuid == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// Shutdown refuses new pushes and waits up to the timeout for the pushes in progress to
+// finish. The pushes that are still running are then cancelled.
+//
+// Returns the UUIDs of the cancelled deployments.
+func (d *Drain) Shutdown(timeout time.Duration) []string {
+	d.mutex.Lock()
+	d.draining = true
+	idle := make(chan struct{})
+	d.idle = idle
+	if len(d.running) == 0 {
+		close(idle)
+	}
+	d.log.Infof("draining %d deployments", len(d.running))
+	d.mutex.Unlock()
+
+	select {
+	case <-idle:
+		d.log.Info("all deployments finished")
+		return nil
+	case <-time.After(timeout):
+	}
+
+	d.mutex.Lock()
+	remaining := make([]*push, 0, len(d.running))
+	for _, p := range d.running {
+		remaining = append(remaining, p)
+	}
+	d.mutex.Unlock()
+
+	uuids := make([]string, len(remaining))
+	wg := sync.WaitGroup{}
+	for i, p := range remaining {
+		uuids[i] = p.uuid
+		d.log.Errorf("deployment %s did not finish within %s: cancelling it", p.uuid, timeout)
+
+		if p.cancel == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(cancel func()) {
+			defer wg.Done()
+			cancel()
+		}(p.cancel)
+	}
+	wg.Wait()
+
+	return uuids
+}
+
+func (d *Drain) finish(id int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.running, id)
+	if d.idle != nil && len(d.running) == 0 {
+		close(d.idle)
+		d.idle = nil
+	}
+}
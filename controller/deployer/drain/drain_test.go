@@ -0,0 +1,76 @@
+package drain_test
+
+import (
+	"sync/atomic"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/drain"
+	"github.com/compozed/deployadactyl/logger"
+	logging "github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drain", func() {
+	var drain *Drain
+
+	BeforeEach(func() {
+		drain = NewDrain(logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "drain_test"))
+	})
+
+	It("starts pushes until it is draining", func() {
+		finish, err := drain.Start("uuid-1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drain.Draining()).To(BeFalse())
+		finish()
+
+		Expect(drain.Shutdown(time.Second)).To(BeEmpty())
+
+		Expect(drain.Draining()).To(BeTrue())
+		_, err = drain.Start("uuid-2", nil)
+		Expect(err).To(MatchError(DrainingError{}))
+	})
+
+	It("knows which deployments are pushing", func() {
+		finish, err := drain.Start("uuid-1", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(drain.Running("uuid-1")).To(BeTrue())
+		Expect(drain.Running("uuid-2")).To(BeFalse())
+
+		finish()
+
+		Expect(drain.Running("uuid-1")).To(BeFalse())
+	})
+
+	It("waits for the pushes in progress to finish", func() {
+		finish, err := drain.Start("uuid-1", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		done := make(chan []string)
+		go func() { done <- drain.Shutdown(time.Minute) }()
+
+		Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+		Eventually(drain.Draining).Should(BeTrue())
+
+		finish()
+		finish()
+
+		Eventually(done).Should(Receive(BeEmpty()))
+	})
+
+	It("cancels the pushes that are still running after the timeout", func() {
+		var cancelled int32
+
+		_, err := drain.Start("uuid-1", func() { atomic.AddInt32(&cancelled, 1) })
+		Expect(err).ToNot(HaveOccurred())
+
+		finish, err := drain.Start("uuid-2", func() { atomic.AddInt32(&cancelled, 10) })
+		Expect(err).ToNot(HaveOccurred())
+		finish()
+
+		Expect(drain.Shutdown(10 * time.Millisecond)).To(ConsistOf("uuid-1"))
+		Expect(atomic.LoadInt32(&cancelled)).To(Equal(int32(1)))
+	})
+})
@@ -0,0 +1,7 @@
+package drain
+
+type DrainingError struct{}
+
+func (e DrainingError) Error() string {
+	return "deployadactyl is shutting down: try the deployment again later"
+}
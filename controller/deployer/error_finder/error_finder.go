@@ -1,14 +1,20 @@
 package error_finder
 
 import (
+	"sync"
+
 	"github.com/compozed/deployadactyl/interfaces"
 )
 
 type ErrorFinder struct {
 	Matchers []interfaces.ErrorMatcher
+	mutex    sync.RWMutex
 }
 
 func (e *ErrorFinder) FindErrors(responseString string) []interfaces.LogMatchedError {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
 	errors := make([]interfaces.LogMatchedError, 0, 0)
 
 	if len(e.Matchers) > 0 {
@@ -21,3 +27,11 @@ func (e *ErrorFinder) FindErrors(responseString string) []interfaces.LogMatchedE
 	}
 	return errors
 }
+
+// SetMatchers replaces the matchers used to find errors, such as when the config is reloaded.
+func (e *ErrorFinder) SetMatchers(matchers []interfaces.ErrorMatcher) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.Matchers = matchers
+}
@@ -1,6 +1,9 @@
 package deployer
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type BasicAuthError struct{}
 
@@ -38,3 +41,27 @@ type EnvironmentNotFoundError struct {
 func (e EnvironmentNotFoundError) Error() string {
 	return fmt.Sprintf("environment not found: %s", e.Environment)
 }
+
+type ChecksumError struct {
+	Expected string
+	Actual   string
+}
+
+func (e ChecksumError) Error() string {
+	return fmt.Sprintf("artifact checksum %s does not match the expected checksum %s", e.Actual, e.Expected)
+}
+
+type FreezeError struct {
+	Environment       string
+	Window            string
+	End               time.Time
+	OverrideRequested bool
+}
+
+func (e FreezeError) Error() string {
+	message := fmt.Sprintf("deployments to %s are frozen by freeze window %s until %s", e.Environment, e.Window, e.End.Format(time.RFC3339))
+	if e.OverrideRequested {
+		message += fmt.Sprintf(": emergency overrides are not allowed in %s", e.Environment)
+	}
+	return message
+}
@@ -0,0 +1,26 @@
+// Package freeze decides whether a deployment falls in a freeze window of an environment.
+package freeze
+
+import (
+	"time"
+
+	"github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Active returns the first freeze window that now falls in and the time that window ends.
+// The windows are checked when the config is loaded, see config.ParseFreezeWindow.
+func Active(windows []S.FreezeWindow, now time.Time) (S.FreezeWindow, time.Time, bool, error) {
+	for _, window := range windows {
+		schedule, err := config.ParseFreezeWindow(window)
+		if err != nil {
+			return S.FreezeWindow{}, time.Time{}, false, err
+		}
+
+		if end, ok := schedule.Active(now); ok {
+			return window, end, true, nil
+		}
+	}
+
+	return S.FreezeWindow{}, time.Time{}, false, nil
+}
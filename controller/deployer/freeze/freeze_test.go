@@ -0,0 +1,153 @@
+package freeze_test
+
+import (
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/freeze"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Freeze", func() {
+	var chicago *time.Location
+
+	BeforeEach(func() {
+		var err error
+		chicago, err = time.LoadLocation("America/Chicago")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("date range windows", func() {
+		var window S.FreezeWindow
+
+		BeforeEach(func() {
+			window = S.FreezeWindow{
+				Name:     "year-end",
+				Start:    "2026-12-24",
+				End:      "2027-01-02 08:00",
+				TimeZone: "America/Chicago",
+			}
+		})
+
+		It("is active between the start and the end in the time zone", func() {
+			now := time.Date(2026, 12, 24, 6, 0, 0, 0, time.UTC)
+
+			active, end, ok, err := Active([]S.FreezeWindow{window}, now)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(active).To(Equal(window))
+			Expect(end.Equal(time.Date(2027, 1, 2, 8, 0, 0, 0, chicago))).To(BeTrue())
+		})
+
+		It("is not active before the start in the time zone", func() {
+			now := time.Date(2026, 12, 24, 5, 59, 0, 0, time.UTC)
+
+			_, _, ok, err := Active([]S.FreezeWindow{window}, now)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("is not active at the end", func() {
+			now := time.Date(2027, 1, 2, 8, 0, 0, 0, chicago)
+
+			_, _, ok, err := Active([]S.FreezeWindow{window}, now)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("uses UTC when there is no time zone", func() {
+			window.TimeZone = ""
+			now := time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC)
+
+			_, _, ok, err := Active([]S.FreezeWindow{window}, now)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Describe("cron windows", func() {
+		var window S.FreezeWindow
+
+		BeforeEach(func() {
+			window = S.FreezeWindow{
+				Name:     "business-hours",
+				Cron:     "0 9 * * 1-5",
+				Duration: "8h",
+				TimeZone: "America/Chicago",
+			}
+		})
+
+		It("is active for the duration after the cron matches", func() {
+			now := time.Date(2026, 10, 19, 16, 59, 0, 0, chicago)
+
+			_, end, ok, err := Active([]S.FreezeWindow{window}, now)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(end.Equal(time.Date(2026, 10, 19, 17, 0, 0, 0, chicago))).To(BeTrue())
+		})
+
+		It("is not active after the duration", func() {
+			now := time.Date(2026, 10, 19, 17, 0, 0, 0, chicago)
+
+			_, _, ok, err := Active([]S.FreezeWindow{window}, now)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("is not active on days the cron does not match", func() {
+			now := time.Date(2026, 10, 18, 12, 0, 0, 0, chicago)
+
+			_, _, ok, err := Active([]S.FreezeWindow{window}, now)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("supports lists and steps", func() {
+			window.Cron = "*/30 1,22 * * *"
+			window.Duration = "10m"
+
+			_, _, ok, _ := Active([]S.FreezeWindow{window}, time.Date(2026, 10, 18, 22, 35, 0, 0, chicago))
+			Expect(ok).To(BeTrue())
+
+			_, _, ok, _ = Active([]S.FreezeWindow{window}, time.Date(2026, 10, 18, 22, 45, 0, 0, chicago))
+			Expect(ok).To(BeFalse())
+		})
+
+		It("matches either the day of month or the day of week when both are given", func() {
+			window.Cron = "0 0 1 * 0"
+			window.Duration = "24h"
+
+			_, _, ok, _ := Active([]S.FreezeWindow{window}, time.Date(2026, 10, 1, 12, 0, 0, 0, chicago))
+			Expect(ok).To(BeTrue())
+
+			_, _, ok, _ = Active([]S.FreezeWindow{window}, time.Date(2026, 10, 18, 12, 0, 0, 0, chicago))
+			Expect(ok).To(BeTrue())
+
+			_, _, ok, _ = Active([]S.FreezeWindow{window}, time.Date(2026, 10, 19, 12, 0, 0, 0, chicago))
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	It("returns the first active window", func() {
+		windows := []S.FreezeWindow{
+			{Name: "first", Start: "2026-01-01", End: "2026-01-02"},
+			{Name: "second", Start: "2026-10-01", End: "2026-11-01"},
+			{Name: "third", Start: "2026-10-15", End: "2026-11-01"},
+		}
+
+		active, _, ok, err := Active(windows, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(active.Name).To(Equal("second"))
+	})
+})
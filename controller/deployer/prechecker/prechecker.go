@@ -11,6 +11,9 @@ import (
 	S "github.com/compozed/deployadactyl/structs"
 )
 
+// DefaultTimeout is the time to wait for a foundation to respond when it does not have its own timeout.
+const DefaultTimeout = 15 * time.Second
+
 // Prechecker has an eventmanager used to manage event if prechecks fail.
 type Prechecker struct {
 	EventManager I.EventManager
@@ -28,14 +31,19 @@ func (p Prechecker) AssertAllFoundationsUp(environment S.Environment) error {
 		return NoFoundationsConfiguredError{}
 	}
 
-	insecureClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
-			ResponseHeaderTimeout: 15 * time.Second,
-		},
-	}
-
 	for _, foundationURL := range environment.Foundations {
+		timeout := DefaultTimeout
+		if foundation := environment.GetFoundation(foundationURL); foundation.Timeout > 0 {
+			timeout = time.Duration(foundation.Timeout) * time.Second
+		}
+
+		insecureClient := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+				ResponseHeaderTimeout: timeout,
+			},
+		}
+
 		resp, err := insecureClient.Get(fmt.Sprintf("%s/v2/info", foundationURL))
 		if err != nil {
 			return InvalidGetRequestError{foundationURL, err}
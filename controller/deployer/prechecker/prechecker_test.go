@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	. "github.com/compozed/deployadactyl/controller/deployer/prechecker"
 	"github.com/compozed/deployadactyl/mocks"
@@ -18,6 +19,7 @@ var _ = Describe("Prechecker", func() {
 	Describe("AssertAllFoundationsUp", func() {
 		var (
 			httpStatus     int
+			delay          time.Duration
 			foundationURls []string
 			prechecker     Prechecker
 			eventManager   *mocks.EventManager
@@ -28,12 +30,14 @@ var _ = Describe("Prechecker", func() {
 
 		BeforeEach(func() {
 			foundationURls = []string{}
+			delay = 0
 
 			eventManager = &mocks.EventManager{}
 			prechecker = Prechecker{EventManager: eventManager}
 
 			testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				foundationURls = append(foundationURls, r.URL.Path)
+				time.Sleep(delay)
 				w.WriteHeader(httpStatus)
 			}))
 
@@ -95,6 +99,21 @@ var _ = Describe("Prechecker", func() {
 			})
 		})
 
+		Context("when a foundation does not respond within its configured timeout", func() {
+			It("returns an error", func() {
+				httpStatus = http.StatusOK
+				delay = 2 * time.Second
+
+				environment.FoundationConfigs = map[string]S.Foundation{
+					testServer.URL: {URL: testServer.URL, Timeout: 1},
+				}
+
+				err := prechecker.AssertAllFoundationsUp(environment)
+
+				Expect(err).To(BeAssignableToTypeOf(InvalidGetRequestError{}))
+			})
+		})
+
 		Context("when a foundation returns a 500 internal server error", func() {
 			It("returns an error and emits an event", func() {
 				event = I.Event{
@@ -0,0 +1,49 @@
+package routevalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("route validation failed:\n%s", strings.Join(e.Problems, "\n"))
+}
+
+type InvalidRouteError struct {
+	Route         string
+	FoundationURL string
+}
+
+func (e InvalidRouteError) Error() string {
+	return fmt.Sprintf("invalid route %s: the domain does not exist in the foundation: %s", e.Route, e.FoundationURL)
+}
+
+type RouteOwnershipError struct {
+	Route         string
+	FoundationURL string
+}
+
+func (e RouteOwnershipError) Error() string {
+	return fmt.Sprintf("route %s is owned by another space in the foundation: %s", e.Route, e.FoundationURL)
+}
+
+type FoundationError struct {
+	FoundationURL string
+	Err           error
+}
+
+func (e FoundationError) Error() string {
+	return fmt.Sprintf("could not validate routes in the foundation: %s: %s", e.FoundationURL, e.Err)
+}
+
+type ManifestError struct {
+	Err error
+}
+
+func (e ManifestError) Error() string {
+	return fmt.Sprintf("cannot validate routes: the manifest cannot be parsed: %s", e.Err)
+}
@@ -0,0 +1,156 @@
+// Package routevalidator checks the routes in a manifest against every foundation before a deploy.
+package routevalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/compozed/deployadactyl/eventmanager/handlers/routemapper"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// RouteValidator has a CourierCreator used to log into each foundation of an environment.
+type RouteValidator struct {
+	CourierCreator I.CourierCreator
+	Log            I.Logger
+}
+
+type routesResponse struct {
+	Resources []struct {
+		Entity struct {
+			Host      string
+			Path      string
+			Port      int
+			SpaceGUID string `json:"space_guid"`
+			Domain    struct {
+				Entity struct {
+					Name string
+				}
+			}
+		}
+	}
+}
+
+// Validate checks that every route in the manifest has a domain on each foundation of
+// the environment and that no route is owned by another space.
+//
+// Returns a ManifestError when the manifest cannot be parsed, or a ValidationError listing
+// every problem that was found.
+func (v RouteValidator) Validate(environment S.Environment, deploymentInfo S.DeploymentInfo) error {
+	routes, err := routemapper.ManifestRoutes([]byte(deploymentInfo.Manifest))
+	if err != nil {
+		v.Log.Errorf("failed to parse manifest: %s", err.Error())
+		return ManifestError{err}
+	}
+
+	if len(routes) == 0 {
+		v.Log.Debug("no routes to validate")
+		return nil
+	}
+
+	problems := []string{}
+	for _, foundationURL := range environment.Foundations {
+		problems = append(problems, v.validateFoundation(environment.GetFoundation(foundationURL), deploymentInfo, routes)...)
+	}
+
+	if len(problems) > 0 {
+		return ValidationError{problems}
+	}
+
+	v.Log.Infof("validated %d routes", len(routes))
+	return nil
+}
+
+func (v RouteValidator) validateFoundation(foundation S.Foundation, deploymentInfo S.DeploymentInfo, routes []string) []string {
+	courier, err := v.CourierCreator.CreateCourier()
+	if err != nil {
+		return []string{FoundationError{foundation.URL, err}.Error()}
+	}
+	defer courier.CleanUp()
+
+	username, password := deploymentInfo.Username, deploymentInfo.Password
+	if foundation.ServiceAccount.Username != "" {
+		username, password = foundation.ServiceAccount.Username, foundation.ServiceAccount.Password
+	}
+
+	output, err := courier.Login(foundation.URL, username, password, deploymentInfo.Org, deploymentInfo.Space, foundation.SkipSSL)
+	if err != nil {
+		return []string{FoundationError{foundation.URL, fmt.Errorf("login failed: %s", string(output))}.Error()}
+	}
+
+	domains, err := courier.Domains()
+	if err != nil {
+		return []string{FoundationError{foundation.URL, fmt.Errorf("could not get domains: %s", err)}.Error()}
+	}
+
+	spaceGUID, err := courier.SpaceGUID(deploymentInfo.Space)
+	if err != nil {
+		return []string{FoundationError{foundation.URL, fmt.Errorf("could not get space: %s", err)}.Error()}
+	}
+
+	problems := []string{}
+	for _, route := range routes {
+		p, ok := routemapper.ParseRoute(route, domains)
+		if !ok {
+			problems = append(problems, InvalidRouteError{route, foundation.URL}.Error())
+			continue
+		}
+
+		// routes that are only a domain are mapped with the application name as the hostname
+		if p.Hostname == "" && p.Port == 0 {
+			p.Hostname = deploymentInfo.AppName
+		}
+
+		owned, err := v.isOwnedByAnotherSpace(courier, p, spaceGUID)
+		if err != nil {
+			problems = append(problems, FoundationError{foundation.URL, fmt.Errorf("could not check route %s: %s", route, err)}.Error())
+		} else if owned {
+			problems = append(problems, RouteOwnershipError{route, foundation.URL}.Error())
+		}
+	}
+
+	return problems
+}
+
+func (v RouteValidator) isOwnedByAnotherSpace(courier I.Courier, route routemapper.ParsedRoute, spaceGUID string) (bool, error) {
+	query := fmt.Sprintf("host:%s", route.Hostname)
+	if route.Port != 0 {
+		query = fmt.Sprintf("port:%d", route.Port)
+	}
+
+	output, err := courier.Curl("/v2/routes?inline-relations-depth=1&q=" + url.QueryEscape(query))
+	if err != nil {
+		return false, err
+	}
+
+	response := routesResponse{}
+	err = json.Unmarshal(output, &response)
+	if err != nil {
+		return false, err
+	}
+
+	for _, resource := range response.Resources {
+		entity := resource.Entity
+
+		if entity.Host != route.Hostname || entity.Domain.Entity.Name != route.Domain || entity.Port != route.Port {
+			continue
+		}
+
+		path := ""
+		if route.Path != "" {
+			path = "/" + route.Path
+		}
+
+		if entity.Path != path {
+			continue
+		}
+
+		if entity.SpaceGUID != spaceGUID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,13 @@
+package routevalidator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRoutevalidator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Routevalidator Suite")
+}
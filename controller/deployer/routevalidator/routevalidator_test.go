@@ -0,0 +1,244 @@
+package routevalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	. "github.com/compozed/deployadactyl/controller/deployer/routevalidator"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("RouteValidator", func() {
+	var (
+		randomAppName   string
+		randomDomain    string
+		randomHostName  string
+		randomSpace     string
+		randomSpaceGUID string
+		foundationURLs  []string
+
+		courierCreator *mocks.CourierCreator
+		couriers       []*mocks.Courier
+		environment    S.Environment
+		deploymentInfo S.DeploymentInfo
+		logBuffer      *Buffer
+
+		routeValidator RouteValidator
+	)
+
+	routesPath := func(query string) string {
+		return "/v2/routes?inline-relations-depth=1&q=" + url.QueryEscape(query)
+	}
+
+	routesResponse := func(host, domain, spaceGUID string) []byte {
+		return []byte(fmt.Sprintf(`{"resources": [{"entity": {"host": "%s", "path": "", "port": null, "space_guid": "%s", "domain": {"entity": {"name": "%s"}}}}]}`, host, spaceGUID, domain))
+	}
+
+	BeforeEach(func() {
+		randomAppName = "randomAppName-" + randomizer.StringRunes(10)
+		randomDomain = "apps.random-" + randomizer.StringRunes(10) + ".com"
+		randomHostName = "randomHostName-" + randomizer.StringRunes(10)
+		randomSpace = "randomSpace-" + randomizer.StringRunes(10)
+		randomSpaceGUID = "randomSpaceGUID-" + randomizer.StringRunes(10)
+		foundationURLs = []string{
+			"https://api.cf.foundation0-" + randomizer.StringRunes(10) + ".com",
+			"https://api.cf.foundation1-" + randomizer.StringRunes(10) + ".com",
+		}
+
+		courierCreator = &mocks.CourierCreator{}
+		couriers = []*mocks.Courier{}
+
+		for range foundationURLs {
+			courier := &mocks.Courier{}
+			courier.DomainsCall.Returns.Domains = []string{randomDomain}
+			courier.SpaceGUIDCall.Returns.GUID = randomSpaceGUID
+			courier.CurlCall.Returns.Output = map[string][]byte{
+				routesPath("host:" + randomHostName): []byte(`{"resources": []}`),
+				routesPath("port:61000"):             []byte(`{"resources": []}`),
+			}
+
+			couriers = append(couriers, courier)
+			courierCreator.CreateCourierCall.Returns.Couriers = append(courierCreator.CreateCourierCall.Returns.Couriers, I.Courier(courier))
+		}
+
+		environment = S.Environment{Name: "environment", Foundations: foundationURLs}
+
+		deploymentInfo = S.DeploymentInfo{
+			Username: "username",
+			Password: "password",
+			Org:      "org",
+			Space:    randomSpace,
+			AppName:  randomAppName,
+			Manifest: fmt.Sprintf(`---
+applications:
+- name: example
+  routes:
+  - route: %s.%s`, randomHostName, randomDomain),
+		}
+
+		logBuffer = NewBuffer()
+
+		routeValidator = RouteValidator{
+			CourierCreator: courierCreator,
+			Log:            logger.DefaultLogger(logBuffer, logging.DEBUG, "routevalidator_test"),
+		}
+	})
+
+	Context("when the manifest has no routes", func() {
+		It("does not log into any foundation", func() {
+			deploymentInfo.Manifest = ""
+
+			Expect(routeValidator.Validate(environment, deploymentInfo)).To(Succeed())
+
+			Expect(courierCreator.CreateCourierCall.TimesCalled).To(Equal(0))
+		})
+	})
+
+	Context("when the manifest cannot be parsed", func() {
+		It("returns an error without logging into any foundation", func() {
+			deploymentInfo.Manifest = "applications:\n- name: app\n  routes: [\n"
+
+			err := routeValidator.Validate(environment, deploymentInfo)
+
+			Expect(err).To(BeAssignableToTypeOf(ManifestError{}))
+			Expect(err.Error()).To(ContainSubstring("the manifest cannot be parsed"))
+			Expect(courierCreator.CreateCourierCall.TimesCalled).To(Equal(0))
+		})
+	})
+
+	Context("when the routes are valid on every foundation", func() {
+		It("returns nil", func() {
+			Expect(routeValidator.Validate(environment, deploymentInfo)).To(Succeed())
+
+			Eventually(logBuffer).Should(Say("validated 1 routes"))
+		})
+
+		It("logs into every foundation in the space of the deployment", func() {
+			routeValidator.Validate(environment, deploymentInfo)
+
+			for i, courier := range couriers {
+				Expect(courier.LoginCall.Received.FoundationURL).To(Equal(foundationURLs[i]))
+				Expect(courier.LoginCall.Received.Username).To(Equal("username"))
+				Expect(courier.LoginCall.Received.Space).To(Equal(randomSpace))
+				Expect(courier.SpaceGUIDCall.Received.Space).To(Equal(randomSpace))
+			}
+		})
+
+		It("checks the ownership of the route", func() {
+			routeValidator.Validate(environment, deploymentInfo)
+
+			Expect(couriers[0].CurlCall.Received.Path).To(Equal([]string{routesPath("host:" + randomHostName)}))
+		})
+
+		It("does not fail when the route is owned by the space of the deployment", func() {
+			couriers[0].CurlCall.Returns.Output[routesPath("host:"+randomHostName)] = routesResponse(randomHostName, randomDomain, randomSpaceGUID)
+
+			Expect(routeValidator.Validate(environment, deploymentInfo)).To(Succeed())
+		})
+	})
+
+	Context("when a foundation has a service account", func() {
+		It("logs in with the service account", func() {
+			environment.FoundationConfigs = map[string]S.Foundation{
+				foundationURLs[1]: {URL: foundationURLs[1], ServiceAccount: S.Credentials{Username: "service", Password: "account"}},
+			}
+
+			routeValidator.Validate(environment, deploymentInfo)
+
+			Expect(couriers[0].LoginCall.Received.Username).To(Equal("username"))
+			Expect(couriers[1].LoginCall.Received.Username).To(Equal("service"))
+			Expect(couriers[1].LoginCall.Received.Password).To(Equal("account"))
+		})
+	})
+
+	Context("when a domain does not exist in a foundation", func() {
+		It("returns an error listing the foundation", func() {
+			couriers[1].DomainsCall.Returns.Domains = []string{"other-" + randomDomain}
+
+			err := routeValidator.Validate(environment, deploymentInfo)
+
+			route := fmt.Sprintf("%s.%s", randomHostName, randomDomain)
+			Expect(err).To(MatchError(ValidationError{[]string{InvalidRouteError{route, foundationURLs[1]}.Error()}}))
+		})
+	})
+
+	Context("when a route is owned by another space", func() {
+		It("returns an error", func() {
+			couriers[0].CurlCall.Returns.Output[routesPath("host:"+randomHostName)] = routesResponse(randomHostName, randomDomain, "other-space-guid")
+
+			err := routeValidator.Validate(environment, deploymentInfo)
+
+			route := fmt.Sprintf("%s.%s", randomHostName, randomDomain)
+			Expect(err).To(MatchError(ValidationError{[]string{RouteOwnershipError{route, foundationURLs[0]}.Error()}}))
+		})
+	})
+
+	Context("when a route is a TCP route", func() {
+		It("checks the ownership of the port", func() {
+			tcpDomain := "tcp." + randomDomain
+			couriers[0].DomainsCall.Returns.Domains = []string{tcpDomain}
+			couriers[1].DomainsCall.Returns.Domains = []string{tcpDomain}
+			deploymentInfo.Manifest = fmt.Sprintf(`---
+applications:
+- name: example
+  routes:
+  - route: %s:61000`, tcpDomain)
+
+			Expect(routeValidator.Validate(environment, deploymentInfo)).To(Succeed())
+
+			Expect(couriers[0].CurlCall.Received.Path).To(Equal([]string{routesPath("port:61000")}))
+		})
+	})
+
+	Context("when there are problems with several routes", func() {
+		It("returns all of the problems", func() {
+			deploymentInfo.Manifest = fmt.Sprintf(`---
+applications:
+- name: example
+  routes:
+  - route: %s.%s
+  - route: %s.unknown.com`, randomHostName, randomDomain, randomHostName)
+
+			couriers[0].CurlCall.Returns.Output[routesPath("host:"+randomHostName)] = routesResponse(randomHostName, randomDomain, "other-space-guid")
+
+			err := routeValidator.Validate(environment, deploymentInfo)
+
+			Expect(err).To(MatchError(ValidationError{[]string{
+				RouteOwnershipError{fmt.Sprintf("%s.%s", randomHostName, randomDomain), foundationURLs[0]}.Error(),
+				InvalidRouteError{randomHostName + ".unknown.com", foundationURLs[0]}.Error(),
+				InvalidRouteError{randomHostName + ".unknown.com", foundationURLs[1]}.Error(),
+			}}))
+		})
+	})
+
+	Context("when logging into a foundation fails", func() {
+		It("returns an error", func() {
+			couriers[0].LoginCall.Returns.Output = []byte("login output")
+			couriers[0].LoginCall.Returns.Error = errors.New("login error")
+
+			err := routeValidator.Validate(environment, deploymentInfo)
+
+			Expect(err).To(MatchError(ValidationError{[]string{FoundationError{foundationURLs[0], errors.New("login failed: login output")}.Error()}}))
+		})
+	})
+
+	Context("when a courier cannot be created", func() {
+		It("returns an error", func() {
+			courierCreator.CreateCourierCall.Returns.Error = errors.New("courier error")
+
+			err := routeValidator.Validate(environment, deploymentInfo)
+
+			Expect(err.Error()).To(ContainSubstring("courier error"))
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/compozed/deployadactyl/controller/deployer/approval"
+	"github.com/gin-gonic/gin"
+)
+
+type rejection struct {
+	Reason string `json:"reason"`
+}
+
+// ListPendingDeployments writes the deployments that are pending approval as JSON.
+func (c *Controller) ListPendingDeployments(g *gin.Context) {
+	g.JSON(http.StatusOK, gin.H{"deployments": c.ApprovalGate.Pending()})
+}
+
+// ApproveDeployment lets a deployment that is pending approval go ahead. The approver is
+// given with basic auth.
+func (c *Controller) ApproveDeployment(g *gin.Context) {
+	uuid := g.Param("uuid")
+
+	username, password, ok := g.Request.BasicAuth()
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": BasicAuthError{}.Error()})
+		return
+	}
+
+	err := c.ApprovalGate.Approve(uuid, username, password)
+	if err != nil {
+		c.Log.Errorf("%s cannot approve deployment %s: %s", username, uuid, err)
+		g.JSON(decisionStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Log.Infof("%s approved deployment %s", username, uuid)
+	g.JSON(http.StatusOK, gin.H{"uuid": uuid, "approved_by": username})
+}
+
+// RejectDeployment stops a deployment that is pending approval. The approver is given
+// with basic auth and a reason can be given in a JSON body.
+func (c *Controller) RejectDeployment(g *gin.Context) {
+	uuid := g.Param("uuid")
+
+	username, password, ok := g.Request.BasicAuth()
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": BasicAuthError{}.Error()})
+		return
+	}
+
+	var body rejection
+	if g.Request.Body != nil {
+		err := json.NewDecoder(g.Request.Body).Decode(&body)
+		if err != nil && err != io.EOF {
+			g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := c.ApprovalGate.Reject(uuid, username, password, body.Reason)
+	if err != nil {
+		c.Log.Errorf("%s cannot reject deployment %s: %s", username, uuid, err)
+		g.JSON(decisionStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Log.Infof("%s rejected deployment %s", username, uuid)
+	g.JSON(http.StatusOK, gin.H{"uuid": uuid, "rejected_by": username})
+}
+
+func decisionStatusCode(err error) int {
+	switch err.(type) {
+	case approval.NotPendingError:
+		return http.StatusNotFound
+	case approval.NotApproverError, approval.SelfApprovalError:
+		return http.StatusForbidden
+	case approval.LoginError:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
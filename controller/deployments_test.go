@@ -0,0 +1,150 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/controller/deployer/approval"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Deployments", func() {
+	var (
+		controller   *Controller
+		approvalGate *mocks.ApprovalGate
+		router       *gin.Engine
+		resp         *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		approvalGate = &mocks.ApprovalGate{}
+
+		controller = &Controller{
+			ApprovalGate: approvalGate,
+			Log:          logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "deployments_test"),
+		}
+
+		router = gin.New()
+		router.GET("/v2/deployments", controller.ListPendingDeployments)
+		router.POST("/v2/deployments/:uuid/approve", controller.ApproveDeployment)
+		router.POST("/v2/deployments/:uuid/reject", controller.RejectDeployment)
+
+		resp = httptest.NewRecorder()
+	})
+
+	Describe("ListPendingDeployments handler", func() {
+		It("returns the deployments that are pending approval", func() {
+			approvalGate.PendingCall.Returns.Deployments = []S.PendingDeployment{{UUID: "uuid", State: approval.PendingState, AppName: "appName"}}
+
+			req, err := http.NewRequest("GET", "/v2/deployments", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+
+			var body struct {
+				Deployments []S.PendingDeployment
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &body)).To(Succeed())
+			Expect(body.Deployments).To(Equal(approvalGate.PendingCall.Returns.Deployments))
+		})
+	})
+
+	Describe("ApproveDeployment handler", func() {
+		It("approves the deployment as the basic auth user", func() {
+			req, err := http.NewRequest("POST", "/v2/deployments/uuid/approve", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("approver", "password")
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(approvalGate.ApproveCall.Received.UUID).To(Equal("uuid"))
+			Expect(approvalGate.ApproveCall.Received.Username).To(Equal("approver"))
+			Expect(approvalGate.ApproveCall.Received.Password).To(Equal("password"))
+		})
+
+		It("returns http.StatusUnauthorized without basic auth", func() {
+			req, err := http.NewRequest("POST", "/v2/deployments/uuid/approve", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(approvalGate.ApproveCall.Received.UUID).To(BeEmpty())
+		})
+
+		It("returns a status code for each approval error", func() {
+			statusCodes := []struct {
+				err        error
+				statusCode int
+			}{
+				{approval.NotPendingError{UUID: "uuid"}, http.StatusNotFound},
+				{approval.NotApproverError{Username: "approver", Environment: "production"}, http.StatusForbidden},
+				{approval.SelfApprovalError{Username: "approver"}, http.StatusForbidden},
+				{approval.LoginError{Username: "approver"}, http.StatusUnauthorized},
+			}
+
+			for _, s := range statusCodes {
+				approvalGate.ApproveCall.Returns.Error = s.err
+
+				req, err := http.NewRequest("POST", "/v2/deployments/uuid/approve", nil)
+				Expect(err).ToNot(HaveOccurred())
+				req.SetBasicAuth("approver", "password")
+
+				resp = httptest.NewRecorder()
+				router.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(s.statusCode))
+				Expect(resp.Body.String()).To(ContainSubstring(s.err.Error()))
+			}
+		})
+	})
+
+	Describe("RejectDeployment handler", func() {
+		It("rejects the deployment with the reason", func() {
+			req, err := http.NewRequest("POST", "/v2/deployments/uuid/reject", bytes.NewBufferString(`{"reason": "not today"}`))
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("approver", "password")
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(approvalGate.RejectCall.Received.UUID).To(Equal("uuid"))
+			Expect(approvalGate.RejectCall.Received.Username).To(Equal("approver"))
+			Expect(approvalGate.RejectCall.Received.Reason).To(Equal("not today"))
+		})
+
+		It("rejects the deployment without a reason", func() {
+			req, err := http.NewRequest("POST", "/v2/deployments/uuid/reject", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("approver", "password")
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(approvalGate.RejectCall.Received.Reason).To(BeEmpty())
+		})
+
+		It("returns http.StatusBadRequest when the body is malformed", func() {
+			req, err := http.NewRequest("POST", "/v2/deployments/uuid/reject", bytes.NewBufferString(`{"reason"`))
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("approver", "password")
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(approvalGate.RejectCall.Received.UUID).To(BeEmpty())
+		})
+	})
+})
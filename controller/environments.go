@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// redacted replaces the value of custom params that look like secrets.
+const redacted = "REDACTED"
+
+// secretKeys are the parts of custom param names that mark a value as a secret.
+var secretKeys = []string{"password", "secret", "token", "key", "credential"}
+
+type environmentsResponse struct {
+	Environments  []environmentResponse `json:"environments"`
+	ErrorMatchers []string              `json:"error_matchers"`
+}
+
+type environmentResponse struct {
+	Name         string                 `json:"name"`
+	Domain       string                 `json:"domain"`
+	Foundations  []string               `json:"foundations"`
+	Instances    uint16                 `json:"instances"`
+	Rollback     bool                   `json:"rollback_enabled"`
+	Authenticate bool                   `json:"authenticate"`
+	SkipSSL      bool                   `json:"skip_ssl"`
+	CustomParams map[string]interface{} `json:"custom_params"`
+}
+
+// ListEnvironments writes every configured environment and the configured error matchers as JSON.
+func (c *Controller) ListEnvironments(g *gin.Context) {
+	cfg := c.getConfig()
+
+	names := make([]string, 0, len(cfg.Environments))
+	for name := range cfg.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	response := environmentsResponse{
+		Environments:  make([]environmentResponse, 0, len(names)),
+		ErrorMatchers: make([]string, 0, len(cfg.ErrorMatchers)),
+	}
+
+	for _, name := range names {
+		response.Environments = append(response.Environments, newEnvironmentResponse(cfg.Environments[name]))
+	}
+
+	for _, matcher := range cfg.ErrorMatchers {
+		response.ErrorMatchers = append(response.ErrorMatchers, matcher.Descriptor())
+	}
+
+	g.JSON(http.StatusOK, response)
+}
+
+// GetEnvironment writes a single configured environment as JSON.
+func (c *Controller) GetEnvironment(g *gin.Context) {
+	name := g.Param("environment")
+
+	environment, ok := c.getConfig().Environments[strings.ToLower(name)]
+	if !ok {
+		g.JSON(http.StatusNotFound, gin.H{"error": EnvironmentNotFoundError{name}.Error()})
+		return
+	}
+
+	g.JSON(http.StatusOK, newEnvironmentResponse(environment))
+}
+
+// getConfig returns the current config from the ConfigWatcher, or Config when there is no watcher.
+func (c *Controller) getConfig() config.Config {
+	if c.ConfigWatcher != nil {
+		return c.ConfigWatcher.Config()
+	}
+	return c.Config
+}
+
+func newEnvironmentResponse(environment S.Environment) environmentResponse {
+	foundations := environment.Foundations
+	if foundations == nil {
+		foundations = []string{}
+	}
+
+	return environmentResponse{
+		Name:         environment.Name,
+		Domain:       environment.Domain,
+		Foundations:  foundations,
+		Instances:    environment.Instances,
+		Rollback:     environment.EnableRollback,
+		Authenticate: environment.Authenticate,
+		SkipSSL:      environment.SkipSSL,
+		CustomParams: redactParams(environment.CustomParams),
+	}
+}
+
+// redactParams returns a copy of custom params with the values of secret looking keys
+// replaced, including keys of nested maps.
+func redactParams(params map[string]interface{}) map[string]interface{} {
+	redactedParams := map[string]interface{}{}
+
+	for key, value := range params {
+		redactedParams[key] = redactValue(key, value)
+	}
+
+	return redactedParams
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	if isSecret(key) {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactParams(v)
+	case map[interface{}]interface{}:
+		params := map[string]interface{}{}
+		for k, nested := range v {
+			params[toString(k)] = nested
+		}
+		return redactParams(params)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, nested := range v {
+			values[i] = redactValue("", nested)
+		}
+		return values
+	default:
+		return value
+	}
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, secretKey := range secretKeys {
+		if strings.Contains(key, secretKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func toString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
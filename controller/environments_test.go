@@ -0,0 +1,154 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/compozed/deployadactyl/config"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Environments", func() {
+	var (
+		controller *Controller
+		router     *gin.Engine
+		resp       *httptest.ResponseRecorder
+		matcher    *mocks.ErrorMatcherMock
+	)
+
+	BeforeEach(func() {
+		matcher = &mocks.ErrorMatcherMock{}
+		matcher.DescriptorCall.Returns = "a matcher: ab: 12: an error code"
+
+		controller = &Controller{
+			Log: logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "environments_test"),
+			Config: config.Config{
+				Environments: map[string]S.Environment{
+					"test": {
+						Name:           "Test",
+						Domain:         "test.example.com",
+						Foundations:    []string{"https://api1.example.com", "https://api2.example.com"},
+						Instances:      2,
+						EnableRollback: true,
+						CustomParams: map[string]interface{}{
+							"table_name": "u_change",
+							"api_token":  "shh",
+							"service_now": map[interface{}]interface{}{
+								"Password": "shh",
+								"user":     "someone",
+							},
+						},
+					},
+					"prod": {
+						Name:         "Prod",
+						Domain:       "example.com",
+						Foundations:  []string{"https://api3.example.com"},
+						Instances:    4,
+						Authenticate: true,
+					},
+				},
+				ErrorMatchers: []I.ErrorMatcher{matcher},
+			},
+		}
+
+		router = gin.New()
+		router.GET("/v2/environments", controller.ListEnvironments)
+		router.GET("/v2/environments/:environment", controller.GetEnvironment)
+
+		resp = httptest.NewRecorder()
+	})
+
+	Describe("ListEnvironments handler", func() {
+		It("returns every environment ordered by name and the error matchers", func() {
+			req, err := http.NewRequest("GET", "/v2/environments", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+
+			var body struct {
+				Environments []struct {
+					Name         string
+					Domain       string
+					Foundations  []string
+					Instances    int
+					Rollback     bool `json:"rollback_enabled"`
+					Authenticate bool
+				}
+				ErrorMatchers []string `json:"error_matchers"`
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &body)).To(Succeed())
+
+			Expect(body.Environments).To(HaveLen(2))
+			Expect(body.Environments[0].Name).To(Equal("Prod"))
+			Expect(body.Environments[0].Authenticate).To(BeTrue())
+			Expect(body.Environments[1].Name).To(Equal("Test"))
+			Expect(body.Environments[1].Domain).To(Equal("test.example.com"))
+			Expect(body.Environments[1].Foundations).To(Equal([]string{"https://api1.example.com", "https://api2.example.com"}))
+			Expect(body.Environments[1].Instances).To(Equal(2))
+			Expect(body.Environments[1].Rollback).To(BeTrue())
+			Expect(body.ErrorMatchers).To(Equal([]string{"a matcher: ab: 12: an error code"}))
+		})
+
+		It("uses the current config of the config watcher", func() {
+			controller.ConfigWatcher = config.NewWatcher(nil, "./does_not_exist.yml", config.Config{
+				Environments: map[string]S.Environment{"reloaded": {Name: "Reloaded"}},
+			}, logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "environments_test"))
+
+			req, err := http.NewRequest("GET", "/v2/environments", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Body.String()).To(ContainSubstring(`"name":"Reloaded"`))
+			Expect(resp.Body.String()).ToNot(ContainSubstring(`"name":"Test"`))
+		})
+	})
+
+	Describe("GetEnvironment handler", func() {
+		It("returns the environment with secret custom params redacted", func() {
+			req, err := http.NewRequest("GET", "/v2/environments/TEST", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+
+			var body struct {
+				Name         string
+				CustomParams map[string]interface{} `json:"custom_params"`
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &body)).To(Succeed())
+
+			Expect(body.Name).To(Equal("Test"))
+			Expect(body.CustomParams).To(Equal(map[string]interface{}{
+				"table_name": "u_change",
+				"api_token":  "REDACTED",
+				"service_now": map[string]interface{}{
+					"Password": "REDACTED",
+					"user":     "someone",
+				},
+			}))
+		})
+
+		It("returns http.StatusNotFound when the environment does not exist", func() {
+			req, err := http.NewRequest("GET", "/v2/environments/doesnt_exist", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			router.ServeHTTP(resp, req)
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+			Expect(resp.Body.String()).To(ContainSubstring(EnvironmentNotFoundError{"doesnt_exist"}.Error()))
+		})
+	})
+})
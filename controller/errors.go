@@ -0,0 +1,74 @@
+package controller
+
+import "fmt"
+
+type EnvironmentNotFoundError struct {
+	Environment string
+}
+
+func (e EnvironmentNotFoundError) Error() string {
+	return fmt.Sprintf("environment not found: %s", e.Environment)
+}
+
+type BasicAuthError struct{}
+
+func (e BasicAuthError) Error() string {
+	return "basic auth header not found"
+}
+
+type DeploymentNotFoundError struct {
+	AppName     string
+	Environment string
+}
+
+func (e DeploymentNotFoundError) Error() string {
+	return fmt.Sprintf("%s has no successful deployment in %s", e.AppName, e.Environment)
+}
+
+type ArtifactURLError struct {
+	UUID        string
+	ArtifactURL string
+}
+
+func (e ArtifactURLError) Error() string {
+	return fmt.Sprintf("deployment %s was uploaded as a zip and has no artifact url: %s", e.UUID, e.ArtifactURL)
+}
+
+type ForbiddenError struct {
+	Identity string
+	Action   string
+	Resource string
+}
+
+func (e ForbiddenError) Error() string {
+	return fmt.Sprintf("%s is not allowed to %s %s", e.Identity, e.Action, e.Resource)
+}
+
+type TokenScopeError struct {
+	Name        string
+	Environment string
+}
+
+func (e TokenScopeError) Error() string {
+	return fmt.Sprintf("API token %s is not scoped to %s", e.Name, e.Environment)
+}
+
+type AdminDisabledError struct{}
+
+func (e AdminDisabledError) Error() string {
+	return "API token administration is disabled because no admin token is configured"
+}
+
+type AdminTokenError struct{}
+
+func (e AdminTokenError) Error() string {
+	return "the admin token is not valid"
+}
+
+type DryRunError struct {
+	Value string
+}
+
+func (e DryRunError) Error() string {
+	return fmt.Sprintf("dry_run must be true or false: %s", e.Value)
+}
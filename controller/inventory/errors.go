@@ -0,0 +1,12 @@
+package inventory
+
+import "fmt"
+
+type FoundationError struct {
+	FoundationURL string
+	Err           error
+}
+
+func (e FoundationError) Error() string {
+	return fmt.Sprintf("could not get the application from the foundation: %s: %s", e.FoundationURL, e.Err)
+}
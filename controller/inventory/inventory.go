@@ -0,0 +1,235 @@
+// Package inventory reports the state of an application on every foundation of an environment.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	C "github.com/compozed/deployadactyl/constants"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Inventory has a CourierCreator used to log into each foundation of an environment.
+type Inventory struct {
+	CourierCreator I.CourierCreator
+	Log            I.Logger
+}
+
+type appsResponse struct {
+	Resources []struct {
+		Metadata struct {
+			GUID string
+		}
+		Entity struct {
+			State       string
+			Instances   int
+			Environment map[string]interface{} `json:"environment_json"`
+		}
+	}
+}
+
+type appResponse struct {
+	Metadata struct {
+		Annotations map[string]string
+	}
+}
+
+// Get queries every foundation of the environment concurrently for the application.
+// Foundations that cannot be queried have their Error set and count as drift.
+func (i Inventory) Get(environment S.Environment, deploymentInfo S.DeploymentInfo) S.AppInventory {
+	apps := make([]S.FoundationApp, len(environment.Foundations))
+
+	var wg sync.WaitGroup
+	for index, foundationURL := range environment.Foundations {
+		foundation := environment.GetFoundation(foundationURL)
+
+		courier, err := i.CourierCreator.CreateCourier()
+		if err != nil {
+			apps[index] = newFoundationApp(foundation)
+			apps[index].Error = FoundationError{foundation.URL, err}.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(index int, foundation S.Foundation, courier I.Courier) {
+			defer wg.Done()
+			defer courier.CleanUp()
+
+			apps[index] = i.getApp(courier, foundation, deploymentInfo)
+		}(index, foundation, courier)
+	}
+	wg.Wait()
+
+	inventory := S.AppInventory{
+		Environment:  deploymentInfo.Environment,
+		Org:          deploymentInfo.Org,
+		Space:        deploymentInfo.Space,
+		AppName:      deploymentInfo.AppName,
+		Foundations:  apps,
+		DriftReasons: findDrift(apps),
+	}
+	inventory.Drift = len(inventory.DriftReasons) > 0
+
+	return inventory
+}
+
+func (i Inventory) getApp(courier I.Courier, foundation S.Foundation, deploymentInfo S.DeploymentInfo) S.FoundationApp {
+	app := newFoundationApp(foundation)
+
+	username, password := deploymentInfo.Username, deploymentInfo.Password
+	if foundation.ServiceAccount.Username != "" {
+		username, password = foundation.ServiceAccount.Username, foundation.ServiceAccount.Password
+	}
+
+	output, err := courier.Login(foundation.URL, username, password, deploymentInfo.Org, deploymentInfo.Space, foundation.SkipSSL)
+	if err != nil {
+		i.Log.Errorf("could not login to %s", foundation.URL)
+		app.Error = FoundationError{foundation.URL, fmt.Errorf("login failed: %s", string(output))}.Error()
+		return app
+	}
+
+	spaceGUID, err := courier.SpaceGUID(deploymentInfo.Space)
+	if err != nil {
+		app.Error = FoundationError{foundation.URL, fmt.Errorf("could not get space: %s", err)}.Error()
+		return app
+	}
+
+	output, err = courier.Curl(fmt.Sprintf("/v2/apps?q=%s&q=%s", url.QueryEscape("name:"+deploymentInfo.AppName), url.QueryEscape("space_guid:"+spaceGUID)))
+	if err != nil {
+		app.Error = FoundationError{foundation.URL, fmt.Errorf("could not get application: %s", err)}.Error()
+		return app
+	}
+
+	var apps appsResponse
+	err = json.Unmarshal(output, &apps)
+	if err != nil {
+		app.Error = FoundationError{foundation.URL, fmt.Errorf("could not get application: %s", err)}.Error()
+		return app
+	}
+
+	if len(apps.Resources) == 0 {
+		i.Log.Debugf("%s does not exist in %s", deploymentInfo.AppName, foundation.URL)
+		return app
+	}
+
+	entity := apps.Resources[0].Entity
+	app.Exists = true
+	app.State = entity.State
+	app.Instances = entity.Instances
+	app.DeploymentUUID = envString(entity.Environment, C.DeploymentUUIDEnvVar)
+	app.ArtifactURL = envString(entity.Environment, C.ArtifactURLEnvVar)
+
+	// the deployment is recorded as metadata on foundations that support it
+	annotations := getAnnotations(courier, apps.Resources[0].Metadata.GUID)
+	if uuid, ok := annotations[C.DeploymentUUIDAnnotation]; ok {
+		app.DeploymentUUID = uuid
+		app.ArtifactURL = annotations[C.ArtifactURLAnnotation]
+	}
+
+	routes, err := courier.Routes(deploymentInfo.AppName)
+	if err != nil {
+		app.Error = FoundationError{foundation.URL, fmt.Errorf("could not get routes: %s", err)}.Error()
+		return app
+	}
+	if routes != nil {
+		app.Routes = routes
+	}
+
+	return app
+}
+
+// getAnnotations returns the metadata annotations of an application, or nil when the
+// foundation does not support metadata.
+func getAnnotations(courier I.Courier, appGUID string) map[string]string {
+	output, err := courier.Curl("/v3/apps/" + appGUID)
+	if err != nil {
+		return nil
+	}
+
+	var app appResponse
+	if json.Unmarshal(output, &app) != nil {
+		return nil
+	}
+
+	return app.Metadata.Annotations
+}
+
+func newFoundationApp(foundation S.Foundation) S.FoundationApp {
+	return S.FoundationApp{
+		FoundationURL: foundation.URL,
+		Name:          foundation.Label(),
+		Routes:        []string{},
+	}
+}
+
+func envString(environment map[string]interface{}, key string) string {
+	if value, ok := environment[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// findDrift compares the application on every foundation. Routes are not compared
+// because foundations usually have their own apps domains.
+//
+// Returns a reason for every property that is not the same on all foundations.
+func findDrift(apps []S.FoundationApp) []string {
+	reasons := []string{}
+
+	for _, app := range apps {
+		if app.Error != "" {
+			reasons = append(reasons, app.Error)
+		}
+	}
+
+	properties := []struct {
+		name  string
+		value func(S.FoundationApp) string
+	}{
+		{"exists", func(a S.FoundationApp) string { return fmt.Sprint(a.Exists) }},
+		{"state", func(a S.FoundationApp) string { return a.State }},
+		{"instances", func(a S.FoundationApp) string { return fmt.Sprint(a.Instances) }},
+		{"deployment uuid", func(a S.FoundationApp) string { return a.DeploymentUUID }},
+		{"artifact url", func(a S.FoundationApp) string { return a.ArtifactURL }},
+	}
+
+	for _, property := range properties {
+		values := map[string][]string{}
+		for _, app := range apps {
+			if app.Error == "" {
+				value := property.value(app)
+				values[value] = append(values[value], app.Name)
+			}
+		}
+
+		if len(values) > 1 {
+			reasons = append(reasons, fmt.Sprintf("%s differs between foundations: %s", property.name, describe(values)))
+		}
+	}
+
+	return reasons
+}
+
+func describe(values map[string][]string) string {
+	keys := make([]string, 0, len(values))
+	for value := range values {
+		keys = append(keys, value)
+	}
+	sort.Strings(keys)
+
+	descriptions := make([]string, 0, len(keys))
+	for _, value := range keys {
+		label := value
+		if label == "" {
+			label = "none"
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s on %s", label, strings.Join(values[value], ", ")))
+	}
+
+	return strings.Join(descriptions, "; ")
+}
@@ -0,0 +1,195 @@
+package inventory_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	C "github.com/compozed/deployadactyl/constants"
+	. "github.com/compozed/deployadactyl/controller/inventory"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inventory", func() {
+	var (
+		randomAppName   string
+		randomSpace     string
+		randomSpaceGUID string
+		randomUUID      string
+		foundationURLs  []string
+
+		courierCreator *mocks.CourierCreator
+		couriers       []*mocks.Courier
+		environment    S.Environment
+		deploymentInfo S.DeploymentInfo
+
+		inventory Inventory
+	)
+
+	appsPath := func(appName, spaceGUID string) string {
+		return fmt.Sprintf("/v2/apps?q=%s&q=%s", url.QueryEscape("name:"+appName), url.QueryEscape("space_guid:"+spaceGUID))
+	}
+
+	appsResponse := func(state string, instances int, uuid, artifactURL string) []byte {
+		return []byte(fmt.Sprintf(`{"resources": [{"entity": {"state": "%s", "instances": %d, "environment_json": {"%s": "%s", "%s": "%s"}}}]}`,
+			state, instances, C.DeploymentUUIDEnvVar, uuid, C.ArtifactURLEnvVar, artifactURL))
+	}
+
+	BeforeEach(func() {
+		randomAppName = "randomAppName-" + randomizer.StringRunes(10)
+		randomSpace = "randomSpace-" + randomizer.StringRunes(10)
+		randomSpaceGUID = "randomSpaceGUID-" + randomizer.StringRunes(10)
+		randomUUID = "randomUUID-" + randomizer.StringRunes(10)
+		foundationURLs = []string{
+			"https://api.cf.foundation0-" + randomizer.StringRunes(10) + ".com",
+			"https://api.cf.foundation1-" + randomizer.StringRunes(10) + ".com",
+		}
+
+		courierCreator = &mocks.CourierCreator{}
+		couriers = []*mocks.Courier{}
+
+		for i := range foundationURLs {
+			courier := &mocks.Courier{}
+			courier.SpaceGUIDCall.Returns.GUID = randomSpaceGUID
+			courier.CurlCall.Returns.Output = map[string][]byte{
+				appsPath(randomAppName, randomSpaceGUID): appsResponse("STARTED", 2, randomUUID, "https://example.com/artifact.jar"),
+			}
+			courier.RoutesCall.Returns.Routes = map[string][]string{
+				randomAppName: {fmt.Sprintf("%s.apps%d.example.com", randomAppName, i)},
+			}
+
+			couriers = append(couriers, courier)
+			courierCreator.CreateCourierCall.Returns.Couriers = append(courierCreator.CreateCourierCall.Returns.Couriers, I.Courier(courier))
+		}
+
+		environment = S.Environment{Name: "environment", Foundations: foundationURLs}
+
+		deploymentInfo = S.DeploymentInfo{
+			Username:    "username",
+			Password:    "password",
+			Environment: "environment",
+			Org:         "org",
+			Space:       randomSpace,
+			AppName:     randomAppName,
+		}
+
+		inventory = Inventory{
+			CourierCreator: courierCreator,
+			Log:            logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "inventory_test"),
+		}
+	})
+
+	It("returns the application on every foundation", func() {
+		appInventory := inventory.Get(environment, deploymentInfo)
+
+		Expect(appInventory.AppName).To(Equal(randomAppName))
+		Expect(appInventory.Space).To(Equal(randomSpace))
+		Expect(appInventory.Foundations).To(HaveLen(2))
+
+		for i, app := range appInventory.Foundations {
+			Expect(app.FoundationURL).To(Equal(foundationURLs[i]))
+			Expect(app.Exists).To(BeTrue())
+			Expect(app.State).To(Equal("STARTED"))
+			Expect(app.Instances).To(Equal(2))
+			Expect(app.Routes).To(Equal([]string{fmt.Sprintf("%s.apps%d.example.com", randomAppName, i)}))
+			Expect(app.DeploymentUUID).To(Equal(randomUUID))
+			Expect(app.ArtifactURL).To(Equal("https://example.com/artifact.jar"))
+			Expect(app.Error).To(BeEmpty())
+		}
+
+		Expect(appInventory.Drift).To(BeFalse())
+		Expect(appInventory.DriftReasons).To(BeEmpty())
+	})
+
+	It("logs into each foundation with the service account when it has one", func() {
+		environment.FoundationConfigs = map[string]S.Foundation{
+			foundationURLs[1]: {URL: foundationURLs[1], ServiceAccount: S.Credentials{Username: "service-username", Password: "service-password"}},
+		}
+
+		inventory.Get(environment, deploymentInfo)
+
+		Expect(couriers[0].LoginCall.Received.Username).To(Equal("username"))
+		Expect(couriers[1].LoginCall.Received.Username).To(Equal("service-username"))
+		Expect(couriers[1].LoginCall.Received.Password).To(Equal("service-password"))
+		Expect(couriers[1].LoginCall.Received.Space).To(Equal(randomSpace))
+	})
+
+	It("creates a courier for every foundation", func() {
+		inventory.Get(environment, deploymentInfo)
+
+		Expect(courierCreator.CreateCourierCall.TimesCalled).To(Equal(2))
+	})
+
+	Context("when the deployment is recorded as metadata", func() {
+		It("returns the deployment from the annotations", func() {
+			couriers[0].CurlCall.Returns.Output[appsPath(randomAppName, randomSpaceGUID)] = []byte(`{"resources": [{"metadata": {"guid": "app-guid"}, "entity": {"state": "STARTED", "instances": 2}}]}`)
+			couriers[0].CurlCall.Returns.Output["/v3/apps/app-guid"] = []byte(fmt.Sprintf(`{"metadata": {"annotations": {"%s": "%s", "%s": "%s"}}}`,
+				C.DeploymentUUIDAnnotation, randomUUID, C.ArtifactURLAnnotation, "https://example.com/artifact.jar"))
+
+			appInventory := inventory.Get(environment, deploymentInfo)
+
+			Expect(appInventory.Foundations[0].DeploymentUUID).To(Equal(randomUUID))
+			Expect(appInventory.Foundations[0].ArtifactURL).To(Equal("https://example.com/artifact.jar"))
+			Expect(appInventory.Drift).To(BeFalse())
+		})
+	})
+
+	Context("when the application does not exist on a foundation", func() {
+		It("reports drift", func() {
+			couriers[1].CurlCall.Returns.Output[appsPath(randomAppName, randomSpaceGUID)] = []byte(`{"resources": []}`)
+
+			appInventory := inventory.Get(environment, deploymentInfo)
+
+			Expect(appInventory.Foundations[1].Exists).To(BeFalse())
+			Expect(couriers[1].RoutesCall.TimesCalled).To(Equal(0))
+			Expect(appInventory.Drift).To(BeTrue())
+			Expect(appInventory.DriftReasons).To(ContainElement(fmt.Sprintf("exists differs between foundations: false on %s; true on %s", foundationURLs[1], foundationURLs[0])))
+		})
+	})
+
+	Context("when the foundations run different deployments", func() {
+		It("reports drift", func() {
+			couriers[1].CurlCall.Returns.Output[appsPath(randomAppName, randomSpaceGUID)] = appsResponse("STOPPED", 2, "other-uuid", "https://example.com/artifact.jar")
+
+			appInventory := inventory.Get(environment, deploymentInfo)
+
+			Expect(appInventory.Drift).To(BeTrue())
+			Expect(appInventory.DriftReasons).To(ConsistOf(
+				fmt.Sprintf("state differs between foundations: STARTED on %s; STOPPED on %s", foundationURLs[0], foundationURLs[1]),
+				fmt.Sprintf("deployment uuid differs between foundations: other-uuid on %s; %s on %s", foundationURLs[1], randomUUID, foundationURLs[0]),
+			))
+		})
+	})
+
+	Context("when a foundation cannot be queried", func() {
+		It("returns the error for the foundation and reports drift", func() {
+			couriers[0].LoginCall.Returns.Error = errors.New("login error")
+			couriers[0].LoginCall.Returns.Output = []byte("bad credentials")
+
+			appInventory := inventory.Get(environment, deploymentInfo)
+
+			expectedError := FoundationError{foundationURLs[0], errors.New("login failed: bad credentials")}.Error()
+
+			Expect(appInventory.Foundations[0].Error).To(Equal(expectedError))
+			Expect(appInventory.Foundations[1].Exists).To(BeTrue())
+			Expect(appInventory.DriftReasons).To(Equal([]string{expectedError}))
+		})
+
+		It("returns an error when a courier cannot be created", func() {
+			courierCreator.CreateCourierCall.Returns.Error = errors.New("courier error")
+
+			appInventory := inventory.Get(environment, deploymentInfo)
+
+			Expect(appInventory.Foundations[0].Error).To(Equal(FoundationError{foundationURLs[0], errors.New("courier error")}.Error()))
+			Expect(appInventory.Drift).To(BeTrue())
+		})
+	})
+})
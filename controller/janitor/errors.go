@@ -0,0 +1,33 @@
+package janitor
+
+import "fmt"
+
+type EnvironmentNotFoundError struct {
+	Environment string
+}
+
+func (e EnvironmentNotFoundError) Error() string {
+	return fmt.Sprintf("janitor space refers to unknown environment: %s", e.Environment)
+}
+
+type FoundationError struct {
+	FoundationURL string
+	Org           string
+	Space         string
+	Err           error
+}
+
+func (e FoundationError) Error() string {
+	return fmt.Sprintf("cannot sweep %s/%s on %s: %s", e.Org, e.Space, e.FoundationURL, e.Err)
+}
+
+type DeleteError struct {
+	Kind          string
+	Name          string
+	FoundationURL string
+	Out           string
+}
+
+func (e DeleteError) Error() string {
+	return fmt.Sprintf("cannot delete %s %s from %s: %s", e.Kind, e.Name, e.FoundationURL, e.Out)
+}
@@ -0,0 +1,283 @@
+// Package janitor removes the temporary applications and routes that deployments which did
+// not finish left behind on the foundations.
+package janitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+const (
+	// DefaultInterval is how often the spaces are swept when no interval is configured.
+	DefaultInterval = time.Hour
+
+	// DefaultOlderThan is how old a temporary application or route must be before it is
+	// removed when no age is configured.
+	DefaultOlderThan = 24 * time.Hour
+
+	// AppKind is the kind of a temporary application in a report.
+	AppKind = "app"
+
+	// RouteKind is the kind of a temporary route in a report.
+	RouteKind = "route"
+)
+
+// Enabled returns true when spaces are configured to be swept.
+func Enabled(config S.Janitor) bool {
+	return len(config.Spaces) > 0
+}
+
+// Interval returns how often the spaces are swept.
+func Interval(config S.Janitor) time.Duration {
+	return parseDuration(config.Interval, DefaultInterval)
+}
+
+// OlderThan returns how old a temporary application or route must be before it is removed.
+func OlderThan(config S.Janitor) time.Duration {
+	return parseDuration(config.OlderThan, DefaultOlderThan)
+}
+
+// parseDuration returns a duration of the config, which was checked when the config was
+// loaded, or the default when it is not set.
+func parseDuration(value string, defaultDuration time.Duration) time.Duration {
+	if value == "" {
+		return defaultDuration
+	}
+
+	duration, _ := time.ParseDuration(value)
+	return duration
+}
+
+// Janitor has a CourierCreator used to log into every foundation of the swept spaces.
+// Temporary applications and routes of deployments that the Drain has in progress are kept.
+type Janitor struct {
+	CourierCreator I.CourierCreator
+	Drain          I.Drain
+	Log            I.Logger
+}
+
+type resources struct {
+	NextURL   string `json:"next_url"`
+	Resources []resource
+}
+
+// resource is an application or a route of a Cloud Controller list.
+type resource struct {
+	Metadata struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+	Entity struct {
+		Name       string
+		Host       string
+		DomainGUID string `json:"domain_guid"`
+	}
+}
+
+type domainResponse struct {
+	Entity struct {
+		Name string
+	}
+}
+
+// Run sweeps the spaces immediately and then every interval until stop is closed. The janitor
+// configuration and environments are read for every sweep so reloaded configs are used, and
+// nothing is swept while no spaces are configured.
+// Every foundation of the environments must have its service account resolved.
+func (j Janitor) Run(getConfig func() (S.Janitor, map[string]S.Environment), stop <-chan struct{}) {
+	for {
+		config, environments := getConfig()
+		if Enabled(config) {
+			j.Sweep(config, environments, time.Now())
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(Interval(config)):
+		}
+	}
+}
+
+// Sweep finds the temporary applications and routes in the spaces on every foundation that
+// are older than the configured age and do not belong to a deployment in progress. They are
+// removed unless the janitor is in dry-run mode.
+//
+// Returns a report of what was removed. Foundations that cannot be swept are reported as errors.
+func (j Janitor) Sweep(config S.Janitor, environments map[string]S.Environment, now time.Time) S.JanitorReport {
+	report := S.JanitorReport{
+		DryRun:  config.DryRun,
+		Removed: []S.JanitorItem{},
+		Errors:  []string{},
+	}
+	cutoff := now.Add(-OlderThan(config))
+
+	for _, space := range config.Spaces {
+		environment, ok := environments[strings.ToLower(space.Environment)]
+		if !ok {
+			report.Errors = append(report.Errors, EnvironmentNotFoundError{space.Environment}.Error())
+			continue
+		}
+
+		for _, foundationURL := range environment.Foundations {
+			err := j.sweepFoundation(environment.GetFoundation(foundationURL), environment, space, cutoff, &report)
+			if err != nil {
+				err = FoundationError{foundationURL, space.Org, space.Space, err}
+				j.Log.Error(err)
+				report.Errors = append(report.Errors, err.Error())
+			}
+		}
+	}
+
+	verb := "removed"
+	if report.DryRun {
+		verb = "would remove"
+	}
+	for _, item := range report.Removed {
+		j.Log.Infof("janitor %s %s %s from %s %s/%s", verb, item.Kind, item.Name, item.Foundation, item.Org, item.Space)
+	}
+	j.Log.Infof("janitor %s %d temporary applications and routes with %d errors", verb, len(report.Removed), len(report.Errors))
+
+	return report
+}
+
+func (j Janitor) sweepFoundation(foundation S.Foundation, environment S.Environment, space S.JanitorSpace, cutoff time.Time, report *S.JanitorReport) error {
+	courier, err := j.CourierCreator.CreateCourier()
+	if err != nil {
+		return err
+	}
+	defer courier.CleanUp()
+
+	credentials := foundation.ServiceAccount
+	output, err := courier.Login(foundation.URL, credentials.Username, credentials.Password, space.Org, space.Space, foundation.SkipSSL)
+	if err != nil {
+		return fmt.Errorf("login failed: %s", string(output))
+	}
+
+	spaceGUID, err := courier.SpaceGUID(space.Space)
+	if err != nil {
+		return fmt.Errorf("could not get space: %s", err)
+	}
+
+	newItem := func(kind, name string, createdAt time.Time) S.JanitorItem {
+		return S.JanitorItem{
+			Kind:        kind,
+			Name:        name,
+			Environment: environment.Name,
+			Foundation:  foundation.URL,
+			Org:         space.Org,
+			Space:       space.Space,
+			CreatedAt:   createdAt,
+		}
+	}
+
+	apps, err := curlAll(courier, "/v2/apps?q="+url.QueryEscape("space_guid:"+spaceGUID))
+	if err != nil {
+		return fmt.Errorf("could not get applications: %s", err)
+	}
+
+	for _, app := range apps {
+		if !j.orphaned(app.Entity.Name, app.Metadata.CreatedAt, cutoff) {
+			continue
+		}
+
+		if !report.DryRun {
+			output, err := courier.Delete(app.Entity.Name)
+			if err != nil {
+				report.Errors = append(report.Errors, DeleteError{AppKind, app.Entity.Name, foundation.URL, string(output)}.Error())
+				continue
+			}
+		}
+		report.Removed = append(report.Removed, newItem(AppKind, app.Entity.Name, app.Metadata.CreatedAt))
+	}
+
+	routes, err := curlAll(courier, fmt.Sprintf("/v2/spaces/%s/routes", spaceGUID))
+	if err != nil {
+		return fmt.Errorf("could not get routes: %s", err)
+	}
+
+	domains := map[string]string{}
+	for _, route := range routes {
+		if !j.orphaned(route.Entity.Host, route.Metadata.CreatedAt, cutoff) {
+			continue
+		}
+
+		domain, ok := domains[route.Entity.DomainGUID]
+		if !ok {
+			domain, err = getDomain(courier, route.Entity.DomainGUID)
+			if err != nil {
+				report.Errors = append(report.Errors, FoundationError{foundation.URL, space.Org, space.Space, fmt.Errorf("could not get domain: %s", err)}.Error())
+				continue
+			}
+			domains[route.Entity.DomainGUID] = domain
+		}
+
+		name := route.Entity.Host + "." + domain
+		if !report.DryRun {
+			output, err := courier.DeleteRoute(domain, route.Entity.Host)
+			if err != nil {
+				report.Errors = append(report.Errors, DeleteError{RouteKind, name, foundation.URL, string(output)}.Error())
+				continue
+			}
+		}
+		report.Removed = append(report.Removed, newItem(RouteKind, name, route.Metadata.CreatedAt))
+	}
+
+	return nil
+}
+
+// orphaned returns true for the name of a temporary application or route that was created
+// before the cutoff by a deployment that is not in progress.
+func (j Janitor) orphaned(name string, createdAt, cutoff time.Time) bool {
+	index := strings.LastIndex(name, pusher.TemporaryNameSuffix)
+	if index == -1 || !createdAt.Before(cutoff) {
+		return false
+	}
+
+	uuid := name[index+len(pusher.TemporaryNameSuffix):]
+	return j.Drain == nil || !j.Drain.Running(uuid)
+}
+
+// curlAll returns the resources of every page of a Cloud Controller list.
+func curlAll(courier I.Courier, path string) ([]resource, error) {
+	var all []resource
+
+	for path != "" {
+		output, err := courier.Curl(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var page resources
+		err = json.Unmarshal(output, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, page.Resources...)
+		path = page.NextURL
+	}
+
+	return all, nil
+}
+
+func getDomain(courier I.Courier, domainGUID string) (string, error) {
+	output, err := courier.Curl("/v2/domains/" + domainGUID)
+	if err != nil {
+		return "", err
+	}
+
+	var domain domainResponse
+	err = json.Unmarshal(output, &domain)
+	if err != nil {
+		return "", err
+	}
+
+	return domain.Entity.Name, nil
+}
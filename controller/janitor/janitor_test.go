@@ -0,0 +1,233 @@
+package janitor_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/janitor"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Janitor", func() {
+	var (
+		now           time.Time
+		old           string
+		recent        string
+		foundationURL string
+		spaceGUID     string
+		orphanUUID    string
+		activeUUID    string
+
+		courier        *mocks.Courier
+		courierCreator *mocks.CourierCreator
+		drain          *mocks.Drain
+		logBuffer      *Buffer
+		config         S.Janitor
+		environments   map[string]S.Environment
+		janitor        Janitor
+	)
+
+	appsPath := func() string {
+		return "/v2/apps?q=" + url.QueryEscape("space_guid:"+spaceGUID)
+	}
+
+	routesPath := func() string {
+		return fmt.Sprintf("/v2/spaces/%s/routes", spaceGUID)
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+		old = now.Add(-48 * time.Hour).Format(time.RFC3339)
+		recent = now.Add(-time.Hour).Format(time.RFC3339)
+		foundationURL = "https://api.cf.foundation-" + randomizer.StringRunes(10) + ".com"
+		spaceGUID = "spaceGUID-" + randomizer.StringRunes(10)
+		orphanUUID = "orphan-" + randomizer.StringRunes(10)
+		activeUUID = "active-" + randomizer.StringRunes(10)
+
+		courier = &mocks.Courier{}
+		courier.SpaceGUIDCall.Returns.GUID = spaceGUID
+		courier.CurlCall.Returns.Output = map[string][]byte{
+			appsPath(): []byte(fmt.Sprintf(`{"resources": [
+				{"metadata": {"created_at": "%s"}, "entity": {"name": "app"}},
+				{"metadata": {"created_at": "%s"}, "entity": {"name": "app-new-build-%s"}},
+				{"metadata": {"created_at": "%s"}, "entity": {"name": "app-new-build-young"}},
+				{"metadata": {"created_at": "%s"}, "entity": {"name": "app-new-build-%s"}}
+			]}`, old, old, orphanUUID, recent, old, activeUUID)),
+			routesPath(): []byte(fmt.Sprintf(`{"resources": [
+				{"metadata": {"created_at": "%s"}, "entity": {"host": "app", "domain_guid": "domain-guid"}},
+				{"metadata": {"created_at": "%s"}, "entity": {"host": "app-new-build-%s", "domain_guid": "domain-guid"}}
+			]}`, old, old, orphanUUID)),
+			"/v2/domains/domain-guid": []byte(`{"entity": {"name": "apps.example.com"}}`),
+		}
+
+		courierCreator = &mocks.CourierCreator{}
+		courierCreator.CreateCourierCall.Returns.Couriers = append(courierCreator.CreateCourierCall.Returns.Couriers, courier)
+
+		drain = &mocks.Drain{}
+		drain.RunningCall.Returns.Running = map[string]bool{activeUUID: true}
+
+		logBuffer = NewBuffer()
+
+		config = S.Janitor{Spaces: []S.JanitorSpace{{Environment: "Production", Org: "org", Space: "space"}}}
+
+		environments = map[string]S.Environment{
+			"production": {
+				Name:        "production",
+				Foundations: []string{foundationURL},
+				FoundationConfigs: map[string]S.Foundation{
+					foundationURL: {URL: foundationURL, ServiceAccount: S.Credentials{Username: "service", Password: "secret"}},
+				},
+			},
+		}
+
+		janitor = Janitor{
+			CourierCreator: courierCreator,
+			Drain:          drain,
+			Log:            logger.DefaultLogger(logBuffer, logging.DEBUG, "janitor_test"),
+		}
+	})
+
+	Describe("reading the configuration", func() {
+		It("uses the defaults", func() {
+			Expect(Interval(config)).To(Equal(DefaultInterval))
+			Expect(OlderThan(config)).To(Equal(DefaultOlderThan))
+		})
+
+		It("uses the configured durations", func() {
+			config.Interval = "30m"
+			config.OlderThan = "2h"
+
+			Expect(Interval(config)).To(Equal(30 * time.Minute))
+			Expect(OlderThan(config)).To(Equal(2 * time.Hour))
+		})
+	})
+
+	Describe("running", func() {
+		It("sweeps immediately and stops when told to", func() {
+			stop := make(chan struct{})
+			close(stop)
+
+			janitor.Run(func() (S.Janitor, map[string]S.Environment) { return config, environments }, stop)
+
+			Expect(courierCreator.CreateCourierCall.TimesCalled).To(Equal(1))
+		})
+
+		It("does not sweep when no spaces are configured", func() {
+			stop := make(chan struct{})
+			close(stop)
+
+			janitor.Run(func() (S.Janitor, map[string]S.Environment) { return S.Janitor{}, environments }, stop)
+
+			Expect(courierCreator.CreateCourierCall.TimesCalled).To(Equal(0))
+		})
+	})
+
+	Describe("sweeping", func() {
+		It("logs into the space with the service account of the foundation", func() {
+			janitor.Sweep(config, environments, now)
+
+			Expect(courier.LoginCall.Received.FoundationURL).To(Equal(foundationURL))
+			Expect(courier.LoginCall.Received.Username).To(Equal("service"))
+			Expect(courier.LoginCall.Received.Password).To(Equal("secret"))
+			Expect(courier.LoginCall.Received.Org).To(Equal("org"))
+			Expect(courier.LoginCall.Received.Space).To(Equal("space"))
+		})
+
+		It("removes the temporary applications and routes of deployments that did not finish", func() {
+			report := janitor.Sweep(config, environments, now)
+
+			Expect(report.DryRun).To(BeFalse())
+			Expect(report.Errors).To(BeEmpty())
+			Expect(report.Removed).To(HaveLen(2))
+			Expect(report.Removed[0].Kind).To(Equal(AppKind))
+			Expect(report.Removed[0].Name).To(Equal("app-new-build-" + orphanUUID))
+			Expect(report.Removed[0].Foundation).To(Equal(foundationURL))
+			Expect(report.Removed[0].CreatedAt).To(Equal(now.Add(-48 * time.Hour)))
+			Expect(report.Removed[1].Kind).To(Equal(RouteKind))
+			Expect(report.Removed[1].Name).To(Equal("app-new-build-" + orphanUUID + ".apps.example.com"))
+
+			Expect(courier.DeleteCall.Received.AppName).To(Equal("app-new-build-" + orphanUUID))
+			Expect(courier.DeleteRouteCall.Received.Domain).To(Equal("apps.example.com"))
+			Expect(courier.DeleteRouteCall.Received.Hostname).To(Equal("app-new-build-" + orphanUUID))
+
+			Eventually(logBuffer).Should(Say("janitor removed app app-new-build-%s", orphanUUID))
+		})
+
+		It("keeps the temporary applications of deployments in progress", func() {
+			janitor.Sweep(config, environments, now)
+
+			Expect(drain.RunningCall.Received.UUIDs).To(ContainElement(activeUUID))
+			Expect(drain.RunningCall.Received.UUIDs).ToNot(ContainElement("young"))
+		})
+
+		It("follows every page of applications", func() {
+			courier.CurlCall.Returns.Output[appsPath()] = []byte(`{"next_url": "/v2/apps?page=2", "resources": []}`)
+			courier.CurlCall.Returns.Output["/v2/apps?page=2"] = []byte(fmt.Sprintf(
+				`{"resources": [{"metadata": {"created_at": "%s"}, "entity": {"name": "other-new-build-abc"}}]}`, old))
+
+			report := janitor.Sweep(config, environments, now)
+
+			Expect(report.Removed[0].Name).To(Equal("other-new-build-abc"))
+		})
+
+		Context("in dry-run mode", func() {
+			It("reports what it would remove without deleting anything", func() {
+				config.DryRun = true
+
+				report := janitor.Sweep(config, environments, now)
+
+				Expect(report.DryRun).To(BeTrue())
+				Expect(report.Removed).To(HaveLen(2))
+				Expect(courier.DeleteCall.Received.AppName).To(BeEmpty())
+				Expect(courier.DeleteRouteCall.Received.Hostname).To(BeEmpty())
+				Eventually(logBuffer).Should(Say("janitor would remove app"))
+			})
+		})
+
+		Context("when an application cannot be deleted", func() {
+			It("reports the error and continues", func() {
+				courier.DeleteCall.Returns.Output = []byte("delete failed")
+				courier.DeleteCall.Returns.Error = errors.New("exit status 1")
+
+				report := janitor.Sweep(config, environments, now)
+
+				Expect(report.Removed).To(HaveLen(1))
+				Expect(report.Removed[0].Kind).To(Equal(RouteKind))
+				Expect(report.Errors).To(ConsistOf(ContainSubstring("cannot delete app app-new-build-%s from %s: delete failed", orphanUUID, foundationURL)))
+			})
+		})
+
+		Context("when the foundation cannot be logged into", func() {
+			It("reports the foundation as an error", func() {
+				courier.LoginCall.Returns.Output = []byte("bad credentials")
+				courier.LoginCall.Returns.Error = errors.New("exit status 1")
+
+				report := janitor.Sweep(config, environments, now)
+
+				Expect(report.Removed).To(BeEmpty())
+				Expect(report.Errors).To(ConsistOf(fmt.Sprintf("cannot sweep org/space on %s: login failed: bad credentials", foundationURL)))
+			})
+		})
+
+		Context("when the environment does not exist", func() {
+			It("reports the space as an error", func() {
+				config.Spaces[0].Environment = "staging"
+
+				report := janitor.Sweep(config, environments, now)
+
+				Expect(report.Errors).To(ConsistOf(EnvironmentNotFoundError{"staging"}.Error()))
+				Expect(courierCreator.CreateCourierCall.TimesCalled).To(Equal(0))
+			})
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package oidc
+
+import (
+	"fmt"
+	"time"
+)
+
+type JWKSError struct {
+	Source string
+	Err    error
+}
+
+func (e JWKSError) Error() string {
+	return fmt.Sprintf("cannot load the JSON web key set from %s: %s", e.Source, e.Err)
+}
+
+type MalformedError struct {
+	Reason string
+}
+
+func (e MalformedError) Error() string {
+	return fmt.Sprintf("the JWT is malformed: %s", e.Reason)
+}
+
+type AlgorithmError struct {
+	Algorithm string
+}
+
+func (e AlgorithmError) Error() string {
+	return fmt.Sprintf("the JWT is signed with an unsupported algorithm: %s", e.Algorithm)
+}
+
+type KeyNotFoundError struct {
+	KeyID string
+}
+
+func (e KeyNotFoundError) Error() string {
+	return fmt.Sprintf("the JWT is signed with an unknown key: %s", e.KeyID)
+}
+
+type SignatureError struct{}
+
+func (e SignatureError) Error() string {
+	return "the JWT signature is not valid"
+}
+
+type IssuerError struct {
+	Issuer string
+}
+
+func (e IssuerError) Error() string {
+	return fmt.Sprintf("the JWT is issued by an unknown issuer: %s", e.Issuer)
+}
+
+type AudienceError struct {
+	Audience []string
+}
+
+func (e AudienceError) Error() string {
+	return fmt.Sprintf("the JWT is not for this audience: %v", e.Audience)
+}
+
+type ExpiredError struct {
+	ExpiresAt time.Time
+}
+
+func (e ExpiredError) Error() string {
+	return fmt.Sprintf("the JWT expired at %s", e.ExpiresAt.Format(time.RFC3339))
+}
+
+type NotYetValidError struct {
+	NotBefore time.Time
+}
+
+func (e NotYetValidError) Error() string {
+	return fmt.Sprintf("the JWT is not valid before %s", e.NotBefore.Format(time.RFC3339))
+}
+
+type SubjectError struct {
+	Claim string
+}
+
+func (e SubjectError) Error() string {
+	return fmt.Sprintf("the JWT has no %s claim", e.Claim)
+}
@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// key is a public key of a JSON web key set.
+type key struct {
+	id     string
+	public crypto.PublicKey
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	} `json:"keys"`
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// parseJWKS returns the RSA and EC signing keys of a JSON web key set. Keys of other types
+// and keys that are not for signatures are skipped.
+func parseJWKS(data []byte) ([]key, error) {
+	var set jwks
+	err := json.Unmarshal(data, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []key{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %s has a malformed n: %s", k.Kid, err)
+			}
+			e, err := decodeInt(k.E)
+			if err != nil || e.BitLen() > 31 || e.Int64() < 3 {
+				return nil, fmt.Errorf("key %s has a malformed e", k.Kid)
+			}
+			keys = append(keys, key{k.Kid, &rsa.PublicKey{N: n, E: int(e.Int64())}})
+		case "EC":
+			curve, ok := curves[k.Crv]
+			if !ok {
+				return nil, fmt.Errorf("key %s has an unsupported curve: %s", k.Kid, k.Crv)
+			}
+			x, err := decodeInt(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("key %s has a malformed x: %s", k.Kid, err)
+			}
+			y, err := decodeInt(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("key %s has a malformed y: %s", k.Kid, err)
+			}
+			if !curve.IsOnCurve(x, y) {
+				return nil, fmt.Errorf("key %s is not on curve %s", k.Kid, k.Crv)
+			}
+			keys = append(keys, key{k.Kid, &ecdsa.PublicKey{Curve: curve, X: x, Y: y}})
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("there are no RSA or EC signing keys")
+	}
+
+	return keys, nil
+}
+
+// findKey returns the key with the id. A JWT without a key id can only be verified when
+// there is a single key.
+func findKey(keys []key, id string) (crypto.PublicKey, bool) {
+	if id == "" {
+		if len(keys) == 1 {
+			return keys[0].public, true
+		}
+		return nil, false
+	}
+
+	for _, k := range keys {
+		if k.id == id {
+			return k.public, true
+		}
+	}
+	return nil, false
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("it is empty")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
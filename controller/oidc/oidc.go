@@ -0,0 +1,338 @@
+// Package oidc verifies the JWTs of an OpenID Connect identity provider.
+package oidc
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+const (
+	// DefaultSubjectClaim is the claim with the subject when none is configured.
+	DefaultSubjectClaim = "sub"
+
+	// DefaultGroupsClaim is the claim with the groups when none is configured.
+	DefaultGroupsClaim = "groups"
+
+	// DefaultClockSkew is how far the clocks of Deployadactyl and the identity provider can
+	// differ when none is configured.
+	DefaultClockSkew = time.Minute
+
+	// refreshInterval is how often a JSON web key set URL is fetched again for an unknown key.
+	refreshInterval = time.Minute
+)
+
+// algorithm is a JWT signature algorithm. Curve is the bit size of the curve of EC algorithms.
+type algorithm struct {
+	hash  crypto.Hash
+	curve int
+}
+
+var algorithms = map[string]algorithm{
+	"RS256": {crypto.SHA256, 0},
+	"RS384": {crypto.SHA384, 0},
+	"RS512": {crypto.SHA512, 0},
+	"ES256": {crypto.SHA256, 256},
+	"ES384": {crypto.SHA384, 384},
+	"ES512": {crypto.SHA512, 521},
+}
+
+// Enabled returns true when an identity provider is configured.
+func Enabled(config S.OIDC) bool {
+	return config != S.OIDC{}
+}
+
+// Verifier verifies the signature, issuer, audience and expiry of JWTs. The keys of a
+// JSON web key set URL are fetched again when a JWT is signed with a key that is not known,
+// so keys can be rotated without restarting.
+type Verifier struct {
+	config       S.OIDC
+	subjectClaim string
+	groupsClaim  string
+	clockSkew    time.Duration
+	client       *http.Client
+	fileSystem   *afero.Afero
+	log          I.Logger
+	mutex        sync.Mutex
+	keys         []key
+	fetchedAt    time.Time
+}
+
+// NewVerifier returns a Verifier for an identity provider. A JSON web key set file is read
+// immediately, while a URL that cannot be fetched is fetched again when a JWT is verified.
+// The configuration is checked when the config is loaded, see config.CheckOIDC.
+//
+// Returns an error when the file cannot be read.
+func NewVerifier(config S.OIDC, client *http.Client, fileSystem *afero.Afero, log I.Logger) (*Verifier, error) {
+	v := &Verifier{
+		config:       config,
+		subjectClaim: config.SubjectClaim,
+		groupsClaim:  config.GroupsClaim,
+		clockSkew:    DefaultClockSkew,
+		client:       client,
+		fileSystem:   fileSystem,
+		log:          log,
+	}
+
+	if v.subjectClaim == "" {
+		v.subjectClaim = DefaultSubjectClaim
+	}
+	if v.groupsClaim == "" {
+		v.groupsClaim = DefaultGroupsClaim
+	}
+	if config.ClockSkew != "" {
+		v.clockSkew, _ = time.ParseDuration(config.ClockSkew)
+	}
+
+	err := v.load()
+	if err != nil {
+		if !isURL(config.JWKS) {
+			return nil, err
+		}
+		log.Errorf("%s", err)
+		v.fetchedAt = time.Time{}
+	}
+
+	return v, nil
+}
+
+// Verify returns the claims of a JWT when it is signed by a key of the identity provider,
+// issued by the issuer, for the audience and has not expired.
+func (v *Verifier) Verify(raw string) (S.Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return S.Claims{}, MalformedError{"it does not have three parts"}
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodePart(parts[0], &header)
+	if err != nil {
+		return S.Claims{}, MalformedError{fmt.Sprintf("the header cannot be decoded: %s", err)}
+	}
+
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return S.Claims{}, AlgorithmError{header.Alg}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return S.Claims{}, MalformedError{fmt.Sprintf("the signature cannot be decoded: %s", err)}
+	}
+
+	public, err := v.key(header.Kid)
+	if err != nil {
+		return S.Claims{}, err
+	}
+
+	if !verifySignature(alg, public, parts[0]+"."+parts[1], signature) {
+		return S.Claims{}, SignatureError{}
+	}
+
+	var claims map[string]interface{}
+	err = decodePart(parts[1], &claims)
+	if err != nil {
+		return S.Claims{}, MalformedError{fmt.Sprintf("the claims cannot be decoded: %s", err)}
+	}
+
+	return v.checkClaims(claims)
+}
+
+func (v *Verifier) checkClaims(claims map[string]interface{}) (S.Claims, error) {
+	issuer, _ := claims["iss"].(string)
+	if issuer != v.config.Issuer {
+		return S.Claims{}, IssuerError{issuer}
+	}
+
+	audience := toStrings(claims["aud"])
+	if !contains(audience, v.config.Audience) {
+		return S.Claims{}, AudienceError{audience}
+	}
+
+	now := time.Now()
+
+	expiresAt, ok := toTime(claims["exp"])
+	if !ok {
+		return S.Claims{}, MalformedError{"it has no exp claim"}
+	}
+	if !now.Before(expiresAt.Add(v.clockSkew)) {
+		return S.Claims{}, ExpiredError{expiresAt}
+	}
+
+	if notBefore, ok := toTime(claims["nbf"]); ok && now.Add(v.clockSkew).Before(notBefore) {
+		return S.Claims{}, NotYetValidError{notBefore}
+	}
+
+	subject, _ := claims[v.subjectClaim].(string)
+	if subject == "" {
+		return S.Claims{}, SubjectError{v.subjectClaim}
+	}
+
+	return S.Claims{
+		Subject:   subject,
+		Groups:    toStrings(claims[v.groupsClaim]),
+		Issuer:    issuer,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// key returns the public key with the id, fetching the JSON web key set URL again when
+// the key is not known and it was not fetched recently.
+func (v *Verifier) key(id string) (crypto.PublicKey, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if public, ok := findKey(v.keys, id); ok {
+		return public, nil
+	}
+
+	if isURL(v.config.JWKS) && time.Since(v.fetchedAt) >= refreshInterval {
+		err := v.loadLocked()
+		if err != nil {
+			v.log.Errorf("%s", err)
+		}
+
+		if public, ok := findKey(v.keys, id); ok {
+			return public, nil
+		}
+	}
+
+	return nil, KeyNotFoundError{id}
+}
+
+func (v *Verifier) load() error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	return v.loadLocked()
+}
+
+func (v *Verifier) loadLocked() error {
+	v.fetchedAt = time.Now()
+
+	data, err := v.read()
+	if err != nil {
+		return JWKSError{v.config.JWKS, err}
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return JWKSError{v.config.JWKS, err}
+	}
+
+	v.keys = keys
+	v.log.Debugf("loaded %d keys from %s", len(keys), v.config.JWKS)
+
+	return nil
+}
+
+func (v *Verifier) read() ([]byte, error) {
+	if !isURL(v.config.JWKS) {
+		return v.fileSystem.ReadFile(v.config.JWKS)
+	}
+
+	resp, err := v.client.Get(v.config.JWKS)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func verifySignature(alg algorithm, public crypto.PublicKey, signed string, signature []byte) bool {
+	h := alg.hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		return alg.curve == 0 && rsa.VerifyPKCS1v15(k, alg.hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if k.Curve.Params().BitSize != alg.curve || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	}
+	return false
+}
+
+func decodePart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// toStrings returns a claim that is a string or a list of strings as a list.
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
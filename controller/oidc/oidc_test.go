@@ -0,0 +1,269 @@
+package oidc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/oidc"
+	"github.com/compozed/deployadactyl/controller/oidc/oidctest"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var provider *oidctest.Provider
+
+var _ = BeforeSuite(func() {
+	var err error
+	provider, err = oidctest.NewProvider("https://idp.example.com", "deployadactyl")
+	Expect(err).ToNot(HaveOccurred())
+})
+
+var _ = Describe("OIDC", func() {
+	var (
+		fileSystem *afero.Afero
+		log        I.Logger
+		config     S.OIDC
+		verifier   *Verifier
+	)
+
+	BeforeEach(func() {
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		log = logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "oidc_test")
+
+		Expect(fileSystem.WriteFile("/jwks.json", provider.JWKS(), 0644)).To(Succeed())
+
+		config = S.OIDC{
+			Issuer:   provider.Issuer,
+			Audience: provider.Audience,
+			JWKS:     "/jwks.json",
+		}
+
+		var err error
+		verifier, err = NewVerifier(config, http.DefaultClient, fileSystem, log)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("Verify", func() {
+		It("returns the subject and groups of a JWT signed with RS256", func() {
+			claims, err := verifier.Verify(provider.Sign(provider.Claims("alice", "developers", "release-managers")))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claims.Subject).To(Equal("alice"))
+			Expect(claims.Groups).To(Equal([]string{"developers", "release-managers"}))
+			Expect(claims.Issuer).To(Equal(provider.Issuer))
+		})
+
+		It("verifies JWTs signed with ES256", func() {
+			claims, err := verifier.Verify(provider.SignES256(provider.Claims("alice")))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claims.Subject).To(Equal("alice"))
+		})
+
+		It("accepts an audience list", func() {
+			c := provider.Claims("alice")
+			c["aud"] = []string{"other", provider.Audience}
+
+			_, err := verifier.Verify(provider.Sign(c))
+
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("uses the configured subject and groups claims", func() {
+			config.SubjectClaim = "email"
+			config.GroupsClaim = "roles"
+			verifier, err := NewVerifier(config, http.DefaultClient, fileSystem, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			c := provider.Claims("1234")
+			c["email"] = "alice@example.com"
+			c["roles"] = "deployer"
+
+			claims, err := verifier.Verify(provider.Sign(c))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claims.Subject).To(Equal("alice@example.com"))
+			Expect(claims.Groups).To(Equal([]string{"deployer"}))
+		})
+
+		It("returns a SignatureError when the JWT has been tampered with", func() {
+			parts := strings.Split(provider.Sign(provider.Claims("alice")), ".")
+			forged := strings.Split(provider.Sign(provider.Claims("mallory")), ".")
+
+			_, err := verifier.Verify(parts[0] + "." + forged[1] + "." + parts[2])
+
+			Expect(err).To(MatchError(SignatureError{}))
+		})
+
+		It("returns an AlgorithmError for unsigned and HMAC JWTs", func() {
+			parts := strings.Split(provider.Sign(provider.Claims("alice")), ".")
+
+			_, err := verifier.Verify("eyJhbGciOiJub25lIn0." + parts[1] + ".")
+			Expect(err).To(MatchError(AlgorithmError{"none"}))
+
+			_, err = verifier.Verify("eyJhbGciOiJIUzI1NiJ9." + parts[1] + "." + parts[2])
+			Expect(err).To(MatchError(AlgorithmError{"HS256"}))
+		})
+
+		It("returns a MalformedError when the JWT does not have three parts", func() {
+			_, err := verifier.Verify("not-a-jwt")
+
+			Expect(err).To(BeAssignableToTypeOf(MalformedError{}))
+		})
+
+		It("returns an IssuerError for another issuer", func() {
+			c := provider.Claims("alice")
+			c["iss"] = "https://other.example.com"
+
+			_, err := verifier.Verify(provider.Sign(c))
+
+			Expect(err).To(MatchError(IssuerError{"https://other.example.com"}))
+		})
+
+		It("returns an AudienceError for another audience", func() {
+			c := provider.Claims("alice")
+			c["aud"] = "other"
+
+			_, err := verifier.Verify(provider.Sign(c))
+
+			Expect(err).To(MatchError(AudienceError{[]string{"other"}}))
+		})
+
+		It("returns an ExpiredError for an expired JWT beyond the clock skew", func() {
+			expiresAt := time.Now().Add(-2 * DefaultClockSkew)
+			c := provider.Claims("alice")
+			c["exp"] = expiresAt.Unix()
+
+			_, err := verifier.Verify(provider.Sign(c))
+
+			Expect(err).To(MatchError(ExpiredError{time.Unix(expiresAt.Unix(), 0)}))
+
+			c["exp"] = time.Now().Add(-DefaultClockSkew / 2).Unix()
+			_, err = verifier.Verify(provider.Sign(c))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns a NotYetValidError for a JWT that is not valid yet", func() {
+			notBefore := time.Now().Add(time.Hour)
+			c := provider.Claims("alice")
+			c["nbf"] = notBefore.Unix()
+
+			_, err := verifier.Verify(provider.Sign(c))
+
+			Expect(err).To(MatchError(NotYetValidError{time.Unix(notBefore.Unix(), 0)}))
+		})
+
+		It("returns a MalformedError when the JWT does not expire", func() {
+			c := provider.Claims("alice")
+			delete(c, "exp")
+
+			_, err := verifier.Verify(provider.Sign(c))
+
+			Expect(err).To(MatchError(MalformedError{"it has no exp claim"}))
+		})
+
+		It("returns a SubjectError when the JWT has no subject", func() {
+			_, err := verifier.Verify(provider.Sign(provider.Claims("")))
+
+			Expect(err).To(MatchError(SubjectError{"sub"}))
+		})
+	})
+
+	Describe("a JSON web key set URL", func() {
+		var (
+			server   *httptest.Server
+			requests int32
+		)
+
+		BeforeEach(func() {
+			requests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				provider.ServeHTTP(w, r)
+			}))
+			config.JWKS = server.URL
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("verifies JWTs with the keys from the URL", func() {
+			verifier, err := NewVerifier(config, http.DefaultClient, fileSystem, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = verifier.Verify(provider.Sign(provider.Claims("alice")))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		})
+
+		It("fetches the keys when a JWT is verified if they could not be fetched at first", func() {
+			unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				provider.ServeHTTP(w, r)
+			}))
+			defer unavailable.Close()
+			config.JWKS = unavailable.URL
+
+			verifier, err := NewVerifier(config, http.DefaultClient, fileSystem, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = verifier.Verify(provider.Sign(provider.Claims("alice")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+		})
+
+		It("returns a KeyNotFoundError when the keys cannot be fetched", func() {
+			server.Close()
+
+			verifier, err := NewVerifier(config, http.DefaultClient, fileSystem, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = verifier.Verify(provider.Sign(provider.Claims("alice")))
+			Expect(err).To(BeAssignableToTypeOf(KeyNotFoundError{}))
+		})
+
+		It("does not fetch the keys again for an unknown key before the refresh interval", func() {
+			verifier, err := NewVerifier(config, http.DefaultClient, fileSystem, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(provider.Rotate()).To(Succeed())
+
+			_, err = verifier.Verify(provider.Sign(provider.Claims("alice")))
+			Expect(err).To(BeAssignableToTypeOf(KeyNotFoundError{}))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		})
+	})
+
+	Describe("NewVerifier", func() {
+		It("returns an error when the JSON web key set file cannot be read", func() {
+			config.JWKS = "/missing.json"
+
+			_, err := NewVerifier(config, http.DefaultClient, fileSystem, log)
+
+			Expect(err).To(BeAssignableToTypeOf(JWKSError{}))
+		})
+
+		It("returns an error when the JSON web key set has no signing keys", func() {
+			Expect(fileSystem.WriteFile("/empty.json", []byte(`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`), 0644)).To(Succeed())
+			config.JWKS = "/empty.json"
+
+			_, err := NewVerifier(config, http.DefaultClient, fileSystem, log)
+
+			Expect(err).To(BeAssignableToTypeOf(JWKSError{}))
+		})
+	})
+})
@@ -0,0 +1,156 @@
+// Package oidctest is a local stand-in for an OpenID Connect identity provider, so JWTs
+// can be verified in tests without a real identity provider.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Provider signs JWTs with an RSA key and an EC key and serves them as a JSON web key set.
+type Provider struct {
+	Issuer   string
+	Audience string
+
+	mutex    sync.RWMutex
+	rsaKey   *rsa.PrivateKey
+	rsaKeyID string
+	ecKey    *ecdsa.PrivateKey
+	rotation int
+}
+
+// NewProvider returns a Provider with new keys.
+func NewProvider(issuer, audience string) (*Provider, error) {
+	p := &Provider{Issuer: issuer, Audience: audience}
+
+	err := p.Rotate()
+	if err != nil {
+		return nil, err
+	}
+
+	p.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Rotate replaces the RSA key with a new key with a new key id.
+func (p *Provider) Rotate() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.rotation++
+	p.rsaKey = key
+	p.rsaKeyID = fmt.Sprintf("rsa-%d", p.rotation)
+
+	return nil
+}
+
+// Claims returns valid claims for the subject and groups that expire in an hour.
+func (p *Provider) Claims(subject string, groups ...string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":    p.Issuer,
+		"aud":    p.Audience,
+		"sub":    subject,
+		"groups": groups,
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Hour).Unix(),
+	}
+}
+
+// Sign returns a JWT with the claims signed with the RSA key using RS256.
+func (p *Provider) Sign(claims map[string]interface{}) string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	signed := encode(map[string]string{"alg": "RS256", "typ": "JWT", "kid": p.rsaKeyID}, claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// SignES256 returns a JWT with the claims signed with the EC key using ES256.
+func (p *Provider) SignES256(claims map[string]interface{}) string {
+	signed := encode(map[string]string{"alg": "ES256", "typ": "JWT", "kid": "ec-1"}, claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	r, s, err := ecdsa.Sign(rand.Reader, p.ecKey, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	signature := append(pad(r, 32), pad(s, 32)...)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// JWKS returns the public keys as a JSON web key set.
+func (p *Provider) JWKS() []byte {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": p.rsaKeyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(p.rsaKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec-1",
+				"use": "sig",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(pad(p.ecKey.X, 32)),
+				"y":   base64.RawURLEncoding.EncodeToString(pad(p.ecKey.Y, 32)),
+			},
+		},
+	})
+	return data
+}
+
+// ServeHTTP serves the JSON web key set.
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(p.JWKS())
+}
+
+func encode(header map[string]string, claims map[string]interface{}) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+}
+
+func pad(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"net/http"
+
+	C "github.com/compozed/deployadactyl/constants"
+	"github.com/compozed/deployadactyl/controller/openapi"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion is the version of the API described by the OpenAPI document.
+const APIVersion = "2.0.0"
+
+// Routes are the paths the handlers are registered on, in the syntax of the router.
+type Routes struct {
+	Deploy       string
+	Environments string
+	Environment  string
+	App          string
+	Reconcile    string
+	Promote      string
+	Deployments  string
+	Approve      string
+	Reject       string
+	Tokens       string
+	Token        string
+	OpenAPI      string
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// text is the content of the responses that are the output of a deployment.
+var text = map[string]openapi.MediaType{"text/plain": {Schema: &openapi.Schema{Type: "string"}}}
+
+// OpenAPI writes the OpenAPI document of the API as JSON.
+func (c *Controller) OpenAPI(g *gin.Context) {
+	g.JSON(http.StatusOK, APIDocument(c.Routes))
+}
+
+// APIDocument returns the OpenAPI document of the API served on the routes.
+func APIDocument(routes Routes) openapi.Document {
+	deployParameters := []openapi.Parameter{
+		{Name: "dry_run", In: "query", Description: "Runs the deployment without changing the foundations.", Schema: &openapi.Schema{Type: "boolean"}},
+		{Name: C.EmergencyReasonHeader, In: "header", Description: "Reason for deploying during a freeze window.", Schema: &openapi.Schema{Type: "string"}},
+	}
+
+	document := openapi.New("Deployadactyl", "Deploys applications to every Cloud Foundry foundation of an environment.", APIVersion)
+
+	errorContent := openapi.JSON(openapi.SchemaOf(errorResponse{}))
+
+	deployResult := openapi.SchemaOf(S.DeployResult{})
+	deployResult.Properties["status"].Enum = []string{"success", "dry_run", "failure"}
+
+	document.Add("POST", routes.Deploy, openapi.Operation{
+		OperationID: "deploy",
+		Summary:     "Deploys an application to every foundation of an environment.",
+		Parameters:  deployParameters,
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				"application/json": {Schema: openapi.DeploymentSchema()},
+				"application/zip":  {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			},
+		},
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The deployment output, or its result when JSON is accepted. A stream is always 200 and has the output while the deployment runs followed by its result.", Content: deployStreamContent(deployResult)},
+			"400": {Description: "The request is not valid or a route cannot be used.", Content: deployContent(deployResult)},
+			"401": {Description: "The environment requires authentication.", Content: deployContent(deployResult)},
+			"403": {Description: "The deployment is not allowed or was rejected.", Content: deployContent(deployResult)},
+			"409": {Description: "The environment is in a freeze window.", Content: deployContent(deployResult)},
+			"500": {Description: "The deployment failed.", Content: deployContent(deployResult)},
+			"503": {Description: "The server is shutting down.", Content: deployContent(deployResult)},
+		},
+	})
+
+	document.Add("GET", routes.Environments, openapi.Operation{
+		OperationID: "listEnvironments",
+		Summary:     "Lists the configured environments and error matchers.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The environments.", Content: openapi.JSON(openapi.SchemaOf(environmentsResponse{}))},
+		},
+	})
+
+	document.Add("GET", routes.Environment, openapi.Operation{
+		OperationID: "getEnvironment",
+		Summary:     "Shows a configured environment.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The environment.", Content: openapi.JSON(openapi.SchemaOf(environmentResponse{}))},
+			"404": {Description: "The environment is not configured.", Content: errorContent},
+		},
+	})
+
+	document.Add("GET", routes.App, openapi.Operation{
+		OperationID: "getApp",
+		Summary:     "Shows an application on every foundation of an environment.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The application on every foundation.", Content: openapi.JSON(openapi.SchemaOf(S.AppInventory{}))},
+			"401": {Description: "The environment requires authentication.", Content: errorContent},
+			"403": {Description: "Reading the application is not allowed.", Content: text},
+			"404": {Description: "The environment is not configured.", Content: errorContent},
+		},
+	})
+
+	document.Add("POST", routes.Reconcile, openapi.Operation{
+		OperationID: "reconcileApp",
+		Summary:     "Deploys the latest successful deployment of an application again to the foundations that drifted from it.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The deployment output.", Content: text},
+			"403": {Description: "The reconcile was rejected by an approver.", Content: text},
+			"404": {Description: "The environment is not configured or the application was never deployed.", Content: text},
+			"408": {Description: "The reconcile was not approved in time.", Content: text},
+			"409": {Description: "The environment is in a freeze window.", Content: text},
+			"500": {Description: "The deployment failed.", Content: text},
+		},
+	})
+
+	document.Add("POST", routes.Promote, openapi.Operation{
+		OperationID: "promoteApp",
+		Summary:     "Deploys the latest successful deployment of an application in one environment to another.",
+		Parameters:  deployParameters,
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The deployment output.", Content: text},
+			"400": {Description: "The deployment was uploaded as a zip or dry_run is not a boolean.", Content: text},
+			"404": {Description: "The application was never deployed to the environment.", Content: text},
+			"500": {Description: "The deployment failed.", Content: text},
+		},
+	})
+
+	document.Add("GET", routes.Deployments, openapi.Operation{
+		OperationID: "listPendingDeployments",
+		Summary:     "Lists the deployments that are waiting for approval.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The pending deployments.", Content: openapi.JSON(openapi.SchemaOf(struct {
+				Deployments []S.PendingDeployment `json:"deployments"`
+			}{}))},
+		},
+	})
+
+	document.Add("POST", routes.Approve, openapi.Operation{
+		OperationID: "approveDeployment",
+		Summary:     "Approves a deployment that is waiting for approval.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The deployment was approved.", Content: openapi.JSON(openapi.SchemaOf(struct {
+				UUID       string `json:"uuid"`
+				ApprovedBy string `json:"approved_by"`
+			}{}))},
+			"401": {Description: "Basic auth was not given.", Content: errorContent},
+			"403": {Description: "The user cannot approve the deployment.", Content: errorContent},
+			"404": {Description: "The deployment is not waiting for approval.", Content: errorContent},
+		},
+	})
+
+	document.Add("POST", routes.Reject, openapi.Operation{
+		OperationID: "rejectDeployment",
+		Summary:     "Rejects a deployment that is waiting for approval.",
+		RequestBody: &openapi.RequestBody{Content: openapi.JSON(openapi.SchemaOf(rejection{}))},
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The deployment was rejected.", Content: openapi.JSON(openapi.SchemaOf(struct {
+				UUID       string `json:"uuid"`
+				RejectedBy string `json:"rejected_by"`
+			}{}))},
+			"400": {Description: "The body is not valid.", Content: errorContent},
+			"401": {Description: "Basic auth was not given.", Content: errorContent},
+			"403": {Description: "The user cannot reject the deployment.", Content: errorContent},
+			"404": {Description: "The deployment is not waiting for approval.", Content: errorContent},
+		},
+	})
+
+	document.Add("POST", routes.Tokens, openapi.Operation{
+		OperationID: "issueToken",
+		Summary:     "Issues an API token. Requires the admin token.",
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(openapi.SchemaOf(tokenRequest{}))},
+		Responses: map[string]openapi.Response{
+			"201": {Description: "The API token with its secret, which is only shown once.", Content: openapi.JSON(openapi.SchemaOf(issuedToken{}))},
+			"400": {Description: "The body is not valid.", Content: errorContent},
+			"401": {Description: "The admin token is not valid.", Content: errorContent},
+			"403": {Description: "API token administration is disabled.", Content: errorContent},
+			"409": {Description: "An API token with the name exists.", Content: errorContent},
+		},
+	})
+
+	document.Add("GET", routes.Tokens, openapi.Operation{
+		OperationID: "listTokens",
+		Summary:     "Lists the API tokens without their secrets. Requires the admin token.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The API tokens.", Content: openapi.JSON(openapi.SchemaOf(struct {
+				Tokens []S.APIToken `json:"tokens"`
+			}{}))},
+			"401": {Description: "The admin token is not valid.", Content: errorContent},
+			"403": {Description: "API token administration is disabled.", Content: errorContent},
+		},
+	})
+
+	document.Add("DELETE", routes.Token, openapi.Operation{
+		OperationID: "revokeToken",
+		Summary:     "Revokes an API token. Requires the admin token.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The API token was revoked.", Content: openapi.JSON(openapi.SchemaOf(struct {
+				ID      string `json:"id"`
+				Revoked bool   `json:"revoked"`
+			}{}))},
+			"401": {Description: "The admin token is not valid.", Content: errorContent},
+			"403": {Description: "API token administration is disabled.", Content: errorContent},
+			"404": {Description: "The API token does not exist.", Content: errorContent},
+		},
+	})
+
+	document.Add("GET", routes.OpenAPI, openapi.Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "Shows this document.",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The OpenAPI document of the API.", Content: openapi.JSON(&openapi.Schema{Type: "object"})},
+		},
+	})
+
+	return document
+}
+
+// deployStreamContent is the content of a successful deploy response, which can also be a stream.
+func deployStreamContent(deployResult *openapi.Schema) map[string]openapi.MediaType {
+	content := deployContent(deployResult)
+
+	streamLine := openapi.SchemaOf(S.DeployStreamLine{})
+	streamLine.Properties["result"] = deployResult
+	content[C.StreamContentType] = openapi.MediaType{Schema: streamLine}
+
+	return content
+}
+
+// deployContent is the content of a deploy response, which is text unless JSON is accepted.
+func deployContent(deployResult *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{
+		"text/plain":       text["text/plain"],
+		"application/json": {Schema: deployResult},
+	}
+}
@@ -0,0 +1,26 @@
+package openapi
+
+import S "github.com/compozed/deployadactyl/structs"
+
+// DeploymentSchema returns the schema of the JSON body of a deploy request.
+func DeploymentSchema() *Schema {
+	schema := SchemaOf(S.DeploymentInfo{})
+	schema.Required = []string{"artifact_url"}
+
+	describe(schema, "artifact_url", "URL the artifact of the application is downloaded from.")
+	schema.Properties["artifact_url"].MinLength = 1
+
+	describe(schema, "manifest", "Base64 encoded manifest of the application. It replaces the manifest in the artifact.")
+	schema.Properties["manifest"].Format = "byte"
+
+	describe(schema, "artifact_checksum", "Expected sha256 checksum of the application files.")
+	describe(schema, "environment_variables", "Environment variables that are added to the manifest.")
+	describe(schema, "health_check_endpoint", "Endpoint of the new application that must return 200 OK before the deployment is finished.")
+	describe(schema, "data", "Properties of the deployment for event handlers.")
+
+	return schema
+}
+
+func describe(schema *Schema, property, description string) {
+	schema.Properties[property].Description = description
+}
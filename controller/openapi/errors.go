@@ -0,0 +1,29 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+type ValidationError struct {
+	FieldErrors []S.FieldError
+}
+
+func (e ValidationError) Error() string {
+	problems := make([]string, 0, len(e.FieldErrors))
+	for _, fieldError := range e.FieldErrors {
+		if fieldError.Field == "" {
+			problems = append(problems, fmt.Sprintf("body %s", fieldError.Message))
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s %s", fieldError.Field, fieldError.Message))
+	}
+
+	return fmt.Sprintf("invalid request body: %s", strings.Join(problems, "; "))
+}
+
+func (e ValidationError) Code() string {
+	return "ValidationError"
+}
@@ -0,0 +1,109 @@
+// Package openapi describes an HTTP API with an OpenAPI 3 document and validates request
+// bodies against the schemas in it.
+package openapi
+
+import "strings"
+
+// Version is the version of the OpenAPI specification the documents follow.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document. Only the parts of the specification that are used to
+// describe the API are supported.
+type Document struct {
+	OpenAPI string              `json:"openapi"`
+	Info    Info                `json:"info"`
+	Paths   map[string]PathItem `json:"paths"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem has the operation of every method of a path, by lowercase method.
+type PathItem map[string]Operation
+
+// Operation is a single method of a path.
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path, query or header parameter of an operation.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request, by media type.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation, by media type.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType has the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// New returns a Document without paths.
+func New(title, description, version string) Document {
+	return Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Description: description, Version: version},
+		Paths:   map[string]PathItem{},
+	}
+}
+
+// Add adds the operation of a method to a path. The path is in the syntax of the router,
+// so its :parameters are added as required path parameters.
+func (d Document) Add(method, routerPath string, operation Operation) {
+	path, names := Path(routerPath)
+
+	parameters := make([]Parameter, 0, len(names)+len(operation.Parameters))
+	for _, name := range names {
+		parameters = append(parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	operation.Parameters = append(parameters, operation.Parameters...)
+
+	if d.Paths[path] == nil {
+		d.Paths[path] = PathItem{}
+	}
+	d.Paths[path][strings.ToLower(method)] = operation
+}
+
+// Path returns an OpenAPI path for a path in the syntax of the router, with :name replaced
+// by {name}, and the names of its parameters.
+func Path(routerPath string) (string, []string) {
+	segments := strings.Split(routerPath, "/")
+	names := []string{}
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			names = append(names, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), names
+}
+
+// JSON returns a body or response content of application/json with the schema.
+func JSON(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
@@ -0,0 +1,152 @@
+package openapi_test
+
+import (
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/openapi"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenAPI", func() {
+	Describe("Path", func() {
+		It("replaces the parameters of a router path", func() {
+			path, names := Path("/v2/deploy/:environment/:org/:space/:appName")
+
+			Expect(path).To(Equal("/v2/deploy/{environment}/{org}/{space}/{appName}"))
+			Expect(names).To(Equal([]string{"environment", "org", "space", "appName"}))
+		})
+
+		It("does not change paths without parameters", func() {
+			path, names := Path("/v2/environments")
+
+			Expect(path).To(Equal("/v2/environments"))
+			Expect(names).To(BeEmpty())
+		})
+	})
+
+	Describe("Add", func() {
+		It("adds the operation with the path parameters", func() {
+			document := New("title", "description", "1.0.0")
+
+			document.Add("POST", "/v2/tokens/:id", Operation{
+				OperationID: "operation",
+				Parameters:  []Parameter{{Name: "q", In: "query", Schema: &Schema{Type: "string"}}},
+			})
+
+			operation := document.Paths["/v2/tokens/{id}"]["post"]
+			Expect(operation.OperationID).To(Equal("operation"))
+			Expect(operation.Parameters).To(Equal([]Parameter{
+				{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+				{Name: "q", In: "query", Schema: &Schema{Type: "string"}},
+			}))
+			Expect(document.OpenAPI).To(Equal(Version))
+			Expect(document.Info.Version).To(Equal("1.0.0"))
+		})
+	})
+
+	Describe("SchemaOf", func() {
+		type embedded struct {
+			Embedded string `json:"embedded"`
+		}
+
+		type value struct {
+			embedded
+			Name      string            `json:"name"`
+			Count     uint16            `json:"count,omitempty"`
+			Ratio     float64           `json:"ratio"`
+			Enabled   bool              `json:"enabled"`
+			Tags      []string          `json:"tags"`
+			Labels    map[string]string `json:"labels"`
+			Data      interface{}       `json:"data"`
+			CreatedAt time.Time         `json:"created_at"`
+			Untagged  string
+			Ignored   string `json:"-"`
+		}
+
+		It("describes the tagged fields", func() {
+			schema := SchemaOf(&value{})
+
+			Expect(schema.Type).To(Equal("object"))
+			Expect(schema.Properties).To(Equal(map[string]*Schema{
+				"embedded":   {Type: "string"},
+				"name":       {Type: "string"},
+				"count":      {Type: "integer"},
+				"ratio":      {Type: "number"},
+				"enabled":    {Type: "boolean"},
+				"tags":       {Type: "array", Items: &Schema{Type: "string"}},
+				"labels":     {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+				"data":       {},
+				"created_at": {Type: "string", Format: "date-time"},
+			}))
+		})
+	})
+
+	Describe("DeploymentSchema", func() {
+		It("describes the fields of the deploy request", func() {
+			schema := DeploymentSchema()
+
+			Expect(schema.Properties).To(HaveLen(6))
+			Expect(schema.Properties).To(HaveKey("artifact_url"))
+			Expect(schema.Properties).To(HaveKey("manifest"))
+			Expect(schema.Properties).To(HaveKey("artifact_checksum"))
+			Expect(schema.Properties).To(HaveKey("environment_variables"))
+			Expect(schema.Properties).To(HaveKey("health_check_endpoint"))
+			Expect(schema.Properties).To(HaveKey("data"))
+			Expect(schema.Required).To(Equal([]string{"artifact_url"}))
+		})
+	})
+
+	Describe("Validate", func() {
+		var schema *Schema
+
+		BeforeEach(func() {
+			schema = DeploymentSchema()
+		})
+
+		It("accepts a valid body", func() {
+			body := `{"artifact_url": "https://example.com/artifact.jar", "manifest": null, "environment_variables": {"KEY": "value"}, "data": {"any": [1, "two"]}, "unknown": 1}`
+
+			Expect(Validate(schema, []byte(body))).To(Succeed())
+		})
+
+		It("returns every problem with the fields", func() {
+			body := `{"artifact_url": "", "manifest": 1, "environment_variables": {"A": "a", "B": false}, "health_check_endpoint": ["/health"], "data": "data"}`
+
+			Expect(Validate(schema, []byte(body))).To(MatchError(ValidationError{FieldErrors: []S.FieldError{
+				{Field: "artifact_url", Message: "must not be empty"},
+				{Field: "data", Message: "must be an object"},
+				{Field: "environment_variables.B", Message: "must be a string"},
+				{Field: "health_check_endpoint", Message: "must be a string"},
+				{Field: "manifest", Message: "must be a string"},
+			}}))
+		})
+
+		It("returns missing required fields", func() {
+			err := Validate(schema, []byte(`{"artifact_url": null}`))
+
+			Expect(err).To(MatchError(ValidationError{FieldErrors: []S.FieldError{{Field: "artifact_url", Message: "is required"}}}))
+			Expect(err).To(MatchError("invalid request body: artifact_url is required"))
+		})
+
+		It("checks integers and array items", func() {
+			schema = SchemaOf(struct {
+				Instances []int `json:"instances"`
+			}{})
+
+			err := Validate(schema, []byte(`{"instances": [1, 1.5, "2"]}`))
+
+			Expect(err).To(MatchError(ValidationError{FieldErrors: []S.FieldError{
+				{Field: "instances[1]", Message: "must be an integer"},
+				{Field: "instances[2]", Message: "must be an integer"},
+			}}))
+		})
+
+		It("returns an error when the body is not a JSON object", func() {
+			Expect(Validate(schema, []byte(`[]`))).To(MatchError("invalid request body: body must be an object"))
+			Expect(Validate(schema, []byte(`{`))).To(MatchError(ContainSubstring("invalid request body: body is not valid JSON")))
+		})
+	})
+})
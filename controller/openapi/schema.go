@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON schema of a value.
+type Schema struct {
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	MinLength   int                `json:"minLength,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+
+	// AdditionalProperties is the schema of the values of an object that is a map.
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns the schema of the JSON encoding of a value. Only the struct fields with
+// a json tag are part of the schema, because the other fields are not part of the API.
+func SchemaOf(value interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(value))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addProperties(schema, t)
+		return schema
+	}
+
+	// Interfaces can hold any value.
+	return &Schema{}
+}
+
+// addProperties adds the tagged fields of a struct to the schema. The fields of embedded
+// structs without a tag are added as if they were fields of the struct.
+func addProperties(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		if field.Anonymous && tag == "" {
+			addProperties(schema, field.Type)
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		schema.Properties[name] = schemaOf(field.Type)
+	}
+}
@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Validate checks a JSON body against the schema of an object. Properties that are not in
+// the schema are allowed.
+//
+// Returns a ValidationError with a FieldError for every problem that was found.
+func Validate(schema *Schema, body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return ValidationError{[]S.FieldError{{Field: "", Message: fmt.Sprintf("is not valid JSON: %s", err)}}}
+	}
+
+	fieldErrors := validate(schema, "", value)
+	if len(fieldErrors) > 0 {
+		return ValidationError{fieldErrors}
+	}
+
+	return nil
+}
+
+func validate(schema *Schema, field string, value interface{}) []S.FieldError {
+	if schema.Type == "" {
+		return nil
+	}
+
+	if value == nil {
+		return typeError(field, schema.Type)
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return typeError(field, schema.Type)
+		}
+		if len(s) < schema.MinLength {
+			return []S.FieldError{{Field: field, Message: "must not be empty"}}
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			return []S.FieldError{{Field: field, Message: fmt.Sprintf("must be one of %s", strings.Join(schema.Enum, ", "))}}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(field, schema.Type)
+		}
+	case "integer":
+		if n, ok := value.(json.Number); !ok || strings.ContainsAny(n.String(), ".eE") {
+			return typeError(field, schema.Type)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return typeError(field, schema.Type)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeError(field, schema.Type)
+		}
+		var fieldErrors []S.FieldError
+		for i, item := range items {
+			fieldErrors = append(fieldErrors, validate(schema.Items, fmt.Sprintf("%s[%d]", field, i), item)...)
+		}
+		return fieldErrors
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(field, schema.Type)
+		}
+		return validateObject(schema, field, object)
+	}
+
+	return nil
+}
+
+func validateObject(schema *Schema, field string, object map[string]interface{}) []S.FieldError {
+	var fieldErrors []S.FieldError
+
+	for _, name := range schema.Required {
+		if object[name] == nil {
+			fieldErrors = append(fieldErrors, S.FieldError{Field: join(field, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		if !ok {
+			property = schema.AdditionalProperties
+		}
+
+		// null is the same as a missing property, which is checked above when it is required.
+		if property != nil && object[name] != nil {
+			fieldErrors = append(fieldErrors, validate(property, join(field, name), object[name])...)
+		}
+	}
+
+	return fieldErrors
+}
+
+func typeError(field, schemaType string) []S.FieldError {
+	return []S.FieldError{{Field: field, Message: fmt.Sprintf("must be %s %s", article(schemaType), schemaType)}}
+}
+
+func article(schemaType string) string {
+	if strings.IndexAny(schemaType[:1], "aeiou") == 0 {
+		return "an"
+	}
+	return "a"
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/controller/openapi"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenAPI", func() {
+	var routes Routes
+
+	BeforeEach(func() {
+		routes = Routes{
+			Deploy:       "/v2/deploy/:environment/:org/:space/:appName",
+			Environments: "/v2/environments",
+			Environment:  "/v2/environments/:environment",
+			App:          "/v2/apps/:environment/:org/:space/:appName",
+			Reconcile:    "/v2/reconcile/:environment/:org/:space/:appName",
+			Promote:      "/v2/promote/:fromEnvironment/:toEnvironment/:org/:space/:appName",
+			Deployments:  "/v2/deployments",
+			Approve:      "/v2/deployments/:uuid/approve",
+			Reject:       "/v2/deployments/:uuid/reject",
+			Tokens:       "/v2/tokens",
+			Token:        "/v2/tokens/:id",
+			OpenAPI:      "/v2/openapi.json",
+		}
+	})
+
+	It("serves the document as JSON", func() {
+		controller := &Controller{Routes: routes}
+
+		router := gin.New()
+		router.GET(routes.OpenAPI, controller.OpenAPI)
+
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/v2/openapi.json", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		var document map[string]interface{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &document)).To(Succeed())
+		Expect(document["openapi"]).To(Equal(openapi.Version))
+		Expect(document["info"]).To(HaveKeyWithValue("version", APIVersion))
+		Expect(document["paths"]).To(HaveKey("/v2/deploy/{environment}/{org}/{space}/{appName}"))
+	})
+
+	It("describes every route", func() {
+		document := APIDocument(routes)
+
+		Expect(document.Paths).To(HaveLen(12))
+		Expect(document.Paths["/v2/tokens"]).To(HaveKey("post"))
+		Expect(document.Paths["/v2/tokens"]).To(HaveKey("get"))
+		Expect(document.Paths["/v2/tokens/{id}"]).To(HaveKey("delete"))
+
+		for path, item := range document.Paths {
+			for method, operation := range item {
+				Expect(operation.OperationID).ToNot(BeEmpty(), method+" "+path)
+				Expect(operation.Responses).ToNot(BeEmpty(), method+" "+path)
+			}
+		}
+	})
+
+	It("describes the deploy request with the schema it is validated against", func() {
+		deploy := APIDocument(routes).Paths["/v2/deploy/{environment}/{org}/{space}/{appName}"]["post"]
+
+		Expect(deploy.RequestBody.Content["application/json"].Schema).To(Equal(openapi.DeploymentSchema()))
+		Expect(deploy.RequestBody.Content).To(HaveKey("application/zip"))
+		Expect(deploy.Parameters[0].Name).To(Equal("environment"))
+		Expect(deploy.Parameters).To(ContainElement(openapi.Parameter{
+			Name:        "dry_run",
+			In:          "query",
+			Description: "Runs the deployment without changing the foundations.",
+			Schema:      &openapi.Schema{Type: "boolean"},
+		}))
+	})
+})
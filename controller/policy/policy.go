@@ -0,0 +1,122 @@
+// Package policy decides whether an identity may perform an action on an application.
+package policy
+
+import (
+	"path"
+	"strings"
+
+	"github.com/compozed/deployadactyl/config"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Actions that policies allow. Policies are checked when the config is loaded, so the
+// actions and the kinds of identities are the ones the config knows.
+const (
+	DeployAction   = config.DeployAction
+	RollbackAction = config.RollbackAction
+	PromoteAction  = config.PromoteAction
+	ApproveAction  = config.ApproveAction
+	ReadAction     = config.ReadAction
+)
+
+// Actions are every action that policies allow.
+var Actions = config.PolicyActions
+
+// Kinds of identities.
+const (
+	AnonymousIdentity = config.AnonymousIdentity
+	UserIdentity      = config.UserIdentity
+	TokenIdentity     = config.TokenIdentity
+	SubjectIdentity   = config.SubjectIdentity
+	GroupIdentity     = config.GroupIdentity
+)
+
+// Resource is the application an action is performed on.
+type Resource struct {
+	Environment string
+	Org         string
+	Space       string
+	AppName     string
+}
+
+func (r Resource) String() string {
+	return strings.Join([]string{r.Environment, r.Org, r.Space, r.AppName}, "/")
+}
+
+// Allowed returns true when a policy allows the identity to perform the action on the
+// resource. Every action is allowed when there are no policies.
+func Allowed(policies []S.Policy, identity S.Identity, action string, resource Resource) bool {
+	if len(policies) == 0 {
+		return true
+	}
+
+	for _, p := range policies {
+		if matchesIdentity(p.Identities, identity) &&
+			matchesAction(p.Actions, action) &&
+			matches(p.Environments, strings.ToLower(resource.Environment), true) &&
+			matches(p.Orgs, resource.Org, false) &&
+			matches(p.Spaces, resource.Space, false) &&
+			matches(p.Apps, resource.AppName, false) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesIdentity(identities []string, identity S.Identity) bool {
+	for _, i := range identities {
+		kind, name := config.ParsePolicyIdentity(i)
+		if kind == GroupIdentity && identity.Kind == SubjectIdentity {
+			for _, group := range identity.Groups {
+				if matched, _ := path.Match(name, group); matched {
+					return true
+				}
+			}
+			continue
+		}
+
+		if kind != identity.Kind {
+			continue
+		}
+
+		if kind == AnonymousIdentity {
+			return true
+		}
+
+		if matched, _ := path.Match(name, identity.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAction(actions []string, action string) bool {
+	return contains(actions, "*") || contains(actions, action)
+}
+
+func matches(patterns []string, name string, ignoreCase bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if ignoreCase {
+			pattern = strings.ToLower(pattern)
+		}
+
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
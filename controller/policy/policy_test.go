@@ -0,0 +1,110 @@
+package policy_test
+
+import (
+	. "github.com/compozed/deployadactyl/controller/policy"
+	S "github.com/compozed/deployadactyl/structs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policy", func() {
+	var (
+		policies []S.Policy
+		alice    S.Identity
+		resource Resource
+	)
+
+	BeforeEach(func() {
+		policies = []S.Policy{
+			{
+				Name:         "developers",
+				Identities:   []string{"user:alice", "user:dev-*"},
+				Environments: []string{"Preproduction"},
+				Spaces:       []string{"dev-*"},
+				Actions:      []string{DeployAction},
+			},
+			{
+				Name:         "release-managers",
+				Identities:   []string{"user:bob"},
+				Environments: []string{"production"},
+				Apps:         []string{"payments", "ledger"},
+				Actions:      []string{"*"},
+			},
+		}
+
+		alice = S.Identity{Kind: UserIdentity, Name: "alice"}
+		resource = Resource{Environment: "preproduction", Org: "org", Space: "dev-team", AppName: "payments"}
+	})
+
+	It("allows every action when there are no policies", func() {
+		Expect(Allowed(nil, S.Identity{Kind: AnonymousIdentity}, DeployAction, resource)).To(BeTrue())
+	})
+
+	It("allows an action when a policy matches the identity, action and resource", func() {
+		Expect(Allowed(policies, alice, DeployAction, resource)).To(BeTrue())
+		Expect(Allowed(policies, S.Identity{Kind: UserIdentity, Name: "dev-carol"}, DeployAction, resource)).To(BeTrue())
+	})
+
+	It("does not allow other actions", func() {
+		Expect(Allowed(policies, alice, PromoteAction, resource)).To(BeFalse())
+	})
+
+	It("does not allow other identities", func() {
+		Expect(Allowed(policies, S.Identity{Kind: UserIdentity, Name: "mallory"}, DeployAction, resource)).To(BeFalse())
+		Expect(Allowed(policies, S.Identity{Kind: AnonymousIdentity}, DeployAction, resource)).To(BeFalse())
+	})
+
+	It("does not allow other resources", func() {
+		resource.Space = "prod-team"
+		Expect(Allowed(policies, alice, DeployAction, resource)).To(BeFalse())
+
+		resource.Space = "dev-team"
+		resource.Environment = "production"
+		Expect(Allowed(policies, alice, DeployAction, resource)).To(BeFalse())
+	})
+
+	It("allows every action with *", func() {
+		bob := S.Identity{Kind: UserIdentity, Name: "bob"}
+		resource.Environment = "Production"
+
+		for _, action := range Actions {
+			Expect(Allowed(policies, bob, action, resource)).To(BeTrue())
+		}
+
+		resource.AppName = "website"
+		Expect(Allowed(policies, bob, DeployAction, resource)).To(BeFalse())
+	})
+
+	It("allows anonymous requests when a policy has the anonymous identity", func() {
+		policies = append(policies, S.Policy{Name: "sandbox", Identities: []string{"anonymous"}, Environments: []string{"sandbox"}, Actions: []string{DeployAction}})
+		resource.Environment = "sandbox"
+
+		Expect(Allowed(policies, S.Identity{Kind: AnonymousIdentity}, DeployAction, resource)).To(BeTrue())
+	})
+
+	It("allows API tokens when a policy has their token identity", func() {
+		policies = append(policies, S.Policy{Name: "ci", Identities: []string{"token:ci-*"}, Actions: []string{DeployAction}})
+
+		Expect(Allowed(policies, S.Identity{Kind: TokenIdentity, Name: "ci-payments"}, DeployAction, resource)).To(BeTrue())
+		Expect(Allowed(policies, S.Identity{Kind: UserIdentity, Name: "ci-payments"}, DeployAction, resource)).To(BeFalse())
+	})
+
+	It("allows subjects when a policy has their subject or one of their groups", func() {
+		policies = append(policies,
+			S.Policy{Name: "sso", Identities: []string{"subject:carol@example.com"}, Actions: []string{DeployAction}},
+			S.Policy{Name: "sso-groups", Identities: []string{"group:release-*"}, Actions: []string{PromoteAction}},
+		)
+
+		carol := S.Identity{Kind: SubjectIdentity, Name: "carol@example.com"}
+		Expect(Allowed(policies, carol, DeployAction, resource)).To(BeTrue())
+		Expect(Allowed(policies, carol, PromoteAction, resource)).To(BeFalse())
+
+		dave := S.Identity{Kind: SubjectIdentity, Name: "dave@example.com", Groups: []string{"developers", "release-managers"}}
+		Expect(Allowed(policies, dave, PromoteAction, resource)).To(BeTrue())
+		Expect(Allowed(policies, dave, DeployAction, resource)).To(BeFalse())
+
+		Expect(Allowed(policies, S.Identity{Kind: UserIdentity, Name: "carol@example.com"}, DeployAction, resource)).To(BeFalse())
+	})
+
+})
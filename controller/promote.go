@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	C "github.com/compozed/deployadactyl/constants"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// promotion is the deployment request sent to the Deployer to promote a deployment.
+type promotion struct {
+	ArtifactURL          string            `json:"artifact_url"`
+	ArtifactChecksum     string            `json:"artifact_checksum,omitempty"`
+	Manifest             string            `json:"manifest,omitempty"`
+	EnvironmentVariables map[string]string `json:"environment_variables,omitempty"`
+	HealthCheckEndpoint  string            `json:"health_check_endpoint,omitempty"`
+}
+
+// PromoteApp deploys the latest successful deployment of an application in one environment
+// to another environment with the same artifact, manifest and environment variables. The
+// deployment fails when the checksum of the artifact is not the recorded checksum.
+func (c *Controller) PromoteApp(g *gin.Context) {
+	from := g.Param("fromEnvironment")
+	cfContext := I.CFContext{
+		Environment:  g.Param("toEnvironment"),
+		Organization: g.Param("org"),
+		Space:        g.Param("space"),
+		Application:  g.Param("appName"),
+	}
+
+	response := &bytes.Buffer{}
+	defer io.Copy(g.Writer, response)
+
+	dryRun, err := getDryRun(g)
+	if err != nil {
+		g.Writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(response, "cannot promote application: %s\n", err)
+		return
+	}
+
+	record, ok := c.History.Latest(from, cfContext.Organization, cfContext.Space, cfContext.Application)
+	if !ok {
+		g.Writer.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(response, "cannot promote application: %s\n", DeploymentNotFoundError{cfContext.Application, from})
+		return
+	}
+
+	if !strings.HasPrefix(record.ArtifactURL, "http://") && !strings.HasPrefix(record.ArtifactURL, "https://") {
+		g.Writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(response, "cannot promote application: %s\n", ArtifactURLError{record.UUID, record.ArtifactURL})
+		return
+	}
+
+	c.Log.Debugf("promoting deployment %s of %s from %s to %s", record.UUID, cfContext.Application, from, cfContext.Environment)
+	fmt.Fprintf(response, "promoting deployment %s of %s from %s to %s\n", record.UUID, cfContext.Application, from, cfContext.Environment)
+
+	body, _ := json.Marshal(promotion{
+		ArtifactURL:          record.ArtifactURL,
+		ArtifactChecksum:     record.ArtifactChecksum,
+		Manifest:             base64.StdEncoding.EncodeToString([]byte(record.Manifest)),
+		EnvironmentVariables: record.EnvironmentVariables,
+		HealthCheckEndpoint:  record.HealthCheckEndpoint,
+	})
+
+	deployment := I.Deployment{
+		Body:            &body,
+		Type:            I.DeploymentType{JSON: true},
+		Authorization:   getAuthorization(g),
+		CFContext:       cfContext,
+		EmergencyReason: g.Request.Header.Get(C.EmergencyReasonHeader),
+		DryRun:          dryRun,
+	}
+
+	deployResponse := c.RunDeployment(&deployment, response)
+
+	g.Writer.WriteHeader(deployResponse.StatusCode)
+	if deployResponse.Error != nil {
+		fmt.Fprintf(response, "cannot promote application: %s\n", deployResponse.Error)
+	}
+}
@@ -0,0 +1,158 @@
+package controller_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	C "github.com/compozed/deployadactyl/constants"
+	. "github.com/compozed/deployadactyl/controller"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Promote", func() {
+	var (
+		controller *Controller
+		deployer   *mocks.Deployer
+		history    *mocks.History
+		router     *gin.Engine
+		resp       *httptest.ResponseRecorder
+		record     S.DeploymentRecord
+	)
+
+	BeforeEach(func() {
+		deployer = &mocks.Deployer{}
+		deployer.DeployCall.Returns.StatusCode = http.StatusOK
+
+		record = S.DeploymentRecord{
+			UUID:                 "uuid",
+			Environment:          "preproduction",
+			ArtifactURL:          "https://example.com/artifact.jar",
+			ArtifactChecksum:     "checksum",
+			Manifest:             "---\napplications:\n- name: appName\n",
+			HealthCheckEndpoint:  "/health",
+			EnvironmentVariables: map[string]string{"KEY": "value"},
+		}
+
+		history = &mocks.History{}
+		history.LatestCall.Returns.Record = record
+		history.LatestCall.Returns.Found = true
+
+		controller = &Controller{
+			Deployer: deployer,
+			History:  history,
+			Log:      logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "promote_test"),
+		}
+
+		router = gin.New()
+		router.POST("/v2/promote/:fromEnvironment/:toEnvironment/:org/:space/:appName", controller.PromoteApp)
+
+		resp = httptest.NewRecorder()
+	})
+
+	It("deploys the latest deployment of the source environment to the target environment", func() {
+		req, err := http.NewRequest("POST", "/v2/promote/preproduction/production/org/space/appName", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(ContainSubstring("promoting deployment uuid of appName from preproduction to production"))
+
+		Expect(history.LatestCall.Received.Environment).To(Equal("preproduction"))
+		Expect(history.LatestCall.Received.Org).To(Equal("org"))
+		Expect(history.LatestCall.Received.Space).To(Equal("space"))
+		Expect(history.LatestCall.Received.AppName).To(Equal("appName"))
+
+		Expect(deployer.DeployCall.Received.Environment).To(Equal("production"))
+		Expect(deployer.DeployCall.Received.Org).To(Equal("org"))
+		Expect(deployer.DeployCall.Received.Space).To(Equal("space"))
+		Expect(deployer.DeployCall.Received.AppName).To(Equal("appName"))
+		Expect(deployer.DeployCall.Received.ContentType).To(Equal(I.DeploymentType{JSON: true}))
+
+		username, password, ok := deployer.DeployCall.Received.Request.BasicAuth()
+		Expect(ok).To(BeTrue())
+		Expect(username).To(Equal("username"))
+		Expect(password).To(Equal("password"))
+
+		var deploymentInfo S.DeploymentInfo
+		Expect(json.NewDecoder(deployer.DeployCall.Received.Request.Body).Decode(&deploymentInfo)).To(Succeed())
+		Expect(deploymentInfo.ArtifactURL).To(Equal(record.ArtifactURL))
+		Expect(deploymentInfo.ArtifactChecksum).To(Equal(record.ArtifactChecksum))
+		Expect(deploymentInfo.Manifest).To(Equal(base64.StdEncoding.EncodeToString([]byte(record.Manifest))))
+		Expect(deploymentInfo.HealthCheckEndpoint).To(Equal(record.HealthCheckEndpoint))
+		Expect(deploymentInfo.EnvironmentVariables).To(Equal(record.EnvironmentVariables))
+	})
+
+	It("forwards the emergency reason and the dry run to the deployment", func() {
+		req, err := http.NewRequest("POST", "/v2/promote/preproduction/production/org/space/appName?dry_run=true", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(C.EmergencyReasonHeader, "outage")
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(deployer.DeployCall.Received.Request.Header.Get(C.EmergencyReasonHeader)).To(Equal("outage"))
+		Expect(deployer.DeployCall.Received.Request.Header.Get(C.DryRunHeader)).To(Equal("true"))
+	})
+
+	It("returns http.StatusBadRequest when dry_run is not a boolean", func() {
+		req, err := http.NewRequest("POST", "/v2/promote/preproduction/production/org/space/appName?dry_run=maybe", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(ContainSubstring("cannot promote application: " + DryRunError{"maybe"}.Error()))
+		Expect(deployer.DeployCall.Called).To(Equal(0))
+	})
+
+	It("returns the status code and the error of the deployment", func() {
+		deployer.DeployCall.Returns.StatusCode = http.StatusBadRequest
+		deployer.DeployCall.Returns.Error = errors.New("checksum error")
+
+		req, err := http.NewRequest("POST", "/v2/promote/preproduction/production/org/space/appName", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(ContainSubstring("cannot promote application: checksum error"))
+	})
+
+	It("returns http.StatusNotFound when the application was never deployed to the source environment", func() {
+		history.LatestCall.Returns.Found = false
+
+		req, err := http.NewRequest("POST", "/v2/promote/preproduction/production/org/space/appName", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+		Expect(resp.Body.String()).To(ContainSubstring(DeploymentNotFoundError{"appName", "preproduction"}.Error()))
+		Expect(deployer.DeployCall.Called).To(Equal(0))
+	})
+
+	It("returns http.StatusBadRequest when the deployment was uploaded as a zip", func() {
+		history.LatestCall.Returns.Record.ArtifactURL = "/tmp/deployadactyl-123"
+
+		req, err := http.NewRequest("POST", "/v2/promote/preproduction/production/org/space/appName", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		router.ServeHTTP(resp, req)
+
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(ContainSubstring(ArtifactURLError{"uuid", "/tmp/deployadactyl-123"}.Error()))
+		Expect(deployer.DeployCall.Called).To(Equal(0))
+	})
+})
@@ -0,0 +1,43 @@
+package reconciler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type NoDeploymentError struct {
+	AppName     string
+	Environment string
+}
+
+func (e NoDeploymentError) Error() string {
+	return fmt.Sprintf("no successful deployment of %s to %s was found", e.AppName, e.Environment)
+}
+
+type ArtifactURLError struct {
+	UUID        string
+	ArtifactURL string
+}
+
+func (e ArtifactURLError) Error() string {
+	return fmt.Sprintf("deployment %s cannot be deployed again because its artifact was uploaded instead of fetched from a url: %s", e.UUID, e.ArtifactURL)
+}
+
+type UnknownFoundationsError struct {
+	Problems []string
+}
+
+func (e UnknownFoundationsError) Error() string {
+	return fmt.Sprintf("could not check every foundation:\n%s", strings.Join(e.Problems, "\n"))
+}
+
+type FrozenError struct {
+	Environment string
+	Window      string
+	End         time.Time
+}
+
+func (e FrozenError) Error() string {
+	return fmt.Sprintf("%s cannot be reconciled during freeze window %s until %s", e.Environment, e.Window, e.End.Format(time.RFC3339))
+}
@@ -0,0 +1,228 @@
+// Package reconciler deploys the latest successful deployment of an application again to
+// the foundations of an environment that have drifted from it.
+package reconciler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	C "github.com/compozed/deployadactyl/constants"
+	"github.com/compozed/deployadactyl/controller/deployer/approval"
+	"github.com/compozed/deployadactyl/controller/deployer/freeze"
+	"github.com/compozed/deployadactyl/controller/deployer/manifestro"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// Reconciler compares an application on every foundation with the History and uses the
+// BlueGreener to push the latest successful deployment to the foundations that differ.
+// Reconciliations wait for the ApprovalGate like any other deployment.
+type Reconciler struct {
+	History      I.History
+	Inventory    I.Inventory
+	Fetcher      I.Fetcher
+	BlueGreener  I.BlueGreener
+	EventManager I.EventManager
+	ApprovalGate I.ApprovalGate
+	FileSystem   *afero.Afero
+	Log          I.Logger
+}
+
+// Reconcile pushes the latest successful deployment of an application to every foundation
+// where the application does not exist or runs another deployment. Foundations that cannot
+// be checked are skipped. deploymentInfo has the credentials, environment, org, space and
+// application name.
+//
+// The deployment events are emitted for the reconciliation as they are for a deployment.
+// Environments in a freeze window are not reconciled, because a reconciliation has no
+// emergency reason to override the freeze with.
+func (r Reconciler) Reconcile(environment S.Environment, deploymentInfo S.DeploymentInfo, response io.ReadWriter) I.DeployResponse {
+	record, ok := r.History.Latest(deploymentInfo.Environment, deploymentInfo.Org, deploymentInfo.Space, deploymentInfo.AppName)
+	if !ok {
+		return I.DeployResponse{StatusCode: http.StatusNotFound, Error: NoDeploymentError{deploymentInfo.AppName, deploymentInfo.Environment}}
+	}
+
+	deploymentLogger := logger.DeploymentLogger{Log: r.Log, UUID: record.UUID}
+	deploymentLogger.Infof("reconciling %s with deployment %s", deploymentInfo.AppName, record.UUID)
+
+	if !strings.HasPrefix(record.ArtifactURL, "http://") && !strings.HasPrefix(record.ArtifactURL, "https://") {
+		return I.DeployResponse{StatusCode: http.StatusBadRequest, Error: ArtifactURLError{record.UUID, record.ArtifactURL}}
+	}
+
+	err := r.checkFreeze(environment, deploymentInfo, record, response)
+	if err != nil {
+		deploymentLogger.Error(err)
+		if _, ok := err.(FrozenError); ok {
+			return I.DeployResponse{StatusCode: http.StatusConflict, Error: err}
+		}
+		return I.DeployResponse{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+
+	inventory := r.Inventory.Get(environment, deploymentInfo)
+
+	var drifted, problems []string
+	for _, app := range inventory.Foundations {
+		switch {
+		case app.Error != "":
+			problems = append(problems, app.Error)
+		case !app.Exists:
+			fmt.Fprintf(response, "%s does not exist on %s\n", deploymentInfo.AppName, app.Name)
+			drifted = append(drifted, app.FoundationURL)
+		case app.DeploymentUUID != record.UUID:
+			fmt.Fprintf(response, "%s runs deployment %q instead of %s on %s\n", deploymentInfo.AppName, app.DeploymentUUID, record.UUID, app.Name)
+			drifted = append(drifted, app.FoundationURL)
+		}
+	}
+
+	if len(problems) > 0 {
+		err = UnknownFoundationsError{problems}
+		deploymentLogger.Error(err)
+		fmt.Fprintln(response, err)
+	}
+
+	if len(drifted) == 0 {
+		fmt.Fprintf(response, "no foundations have drifted from deployment %s\n", record.UUID)
+		return newDeployResponse(err)
+	}
+
+	deploymentLogger.Infof("reconciling %d foundations", len(drifted))
+
+	if r.ApprovalGate != nil {
+		deploymentLogger.Debug("waiting for approval")
+		approvalErr := r.ApprovalGate.Wait(environment, newDeploymentInfo(environment, deploymentInfo, record, ""), response)
+		if approvalErr != nil {
+			deploymentLogger.Error(approvalErr)
+			return I.DeployResponse{StatusCode: approvalStatusCode(approvalErr), Error: approvalErr}
+		}
+	}
+
+	appPath, fetchErr := r.Fetcher.Fetch(record.ArtifactURL, record.Manifest)
+	if fetchErr != nil {
+		deploymentLogger.Error(fetchErr)
+		return I.DeployResponse{StatusCode: http.StatusInternalServerError, Error: fetchErr}
+	}
+	defer r.FileSystem.RemoveAll(appPath)
+
+	info := newDeploymentInfo(environment, deploymentInfo, record, appPath)
+	deployEventData := S.DeployEventData{Response: response, DeploymentInfo: &info}
+
+	pushErr := r.push(environment, drifted, appPath, deployEventData, deploymentLogger)
+	if pushErr != nil {
+		return I.DeployResponse{StatusCode: http.StatusInternalServerError, Error: pushErr, DeploymentInfo: &info}
+	}
+
+	fmt.Fprintf(response, "\nreconciled %s on %s\n", deploymentInfo.AppName, strings.Join(drifted, ", "))
+
+	deployResponse := newDeployResponse(err)
+	deployResponse.DeploymentInfo = &info
+	return deployResponse
+}
+
+// push emits the deployment events around pushing to the drifted foundations.
+func (r Reconciler) push(environment S.Environment, drifted []string, appPath string, deployEventData S.DeployEventData, deploymentLogger logger.DeploymentLogger) (err error) {
+	defer func() {
+		event := I.Event{Type: C.DeploySuccessEvent, Data: deployEventData}
+		if err != nil {
+			event.Type = C.DeployFailureEvent
+			event.Error = err
+		}
+
+		for _, eventType := range []string{event.Type, C.DeployFinishEvent} {
+			event.Type = eventType
+			if eventErr := r.EventManager.Emit(event); eventErr != nil {
+				deploymentLogger.Errorf("an error occurred when emitting a %s event: %s", eventType, eventErr)
+				fmt.Fprintln(deployEventData.Response, eventErr)
+			}
+		}
+	}()
+
+	err = r.EventManager.Emit(I.Event{Type: C.DeployStartEvent, Data: deployEventData})
+	if err != nil {
+		deploymentLogger.Error(err)
+		return err
+	}
+
+	subset := environment
+	subset.Foundations = drifted
+
+	err = r.BlueGreener.Push(subset, appPath, *deployEventData.DeploymentInfo, deployEventData.Response)
+	if err != nil {
+		deploymentLogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// checkFreeze returns a FrozenError when the environment is in a freeze window and emits an
+// event so the rejected reconciliation can be audited.
+func (r Reconciler) checkFreeze(environment S.Environment, deploymentInfo S.DeploymentInfo, record S.DeploymentRecord, response io.ReadWriter) error {
+	window, end, frozen, err := freeze.Active(environment.FreezeWindows, time.Now())
+	if err != nil || !frozen {
+		return err
+	}
+
+	freezeEventData := S.FreezeEventData{
+		Response:    response,
+		Environment: deploymentInfo.Environment,
+		Org:         deploymentInfo.Org,
+		Space:       deploymentInfo.Space,
+		AppName:     deploymentInfo.AppName,
+		UUID:        record.UUID,
+		Username:    deploymentInfo.Username,
+		Window:      window.Name,
+		End:         end,
+	}
+
+	err = r.EventManager.Emit(I.Event{Type: C.DeployFrozenEvent, Data: freezeEventData})
+	if err != nil {
+		r.Log.Errorf("an error occurred when emitting a %s event: %s", C.DeployFrozenEvent, err)
+	}
+
+	return FrozenError{Environment: environment.Name, Window: window.Name, End: end}
+}
+
+// approvalStatusCode returns the status code of a reconciliation that did not get approval.
+func approvalStatusCode(err error) int {
+	switch err.(type) {
+	case approval.RejectedError:
+		return http.StatusForbidden
+	case approval.ExpiredError:
+		return http.StatusRequestTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func newDeploymentInfo(environment S.Environment, deploymentInfo S.DeploymentInfo, record S.DeploymentRecord, appPath string) S.DeploymentInfo {
+	deploymentInfo.UUID = record.UUID
+	deploymentInfo.ArtifactURL = record.ArtifactURL
+	deploymentInfo.ArtifactChecksum = record.ArtifactChecksum
+	deploymentInfo.Manifest = record.Manifest
+	deploymentInfo.HealthCheckEndpoint = record.HealthCheckEndpoint
+	deploymentInfo.EnvironmentVariables = record.EnvironmentVariables
+	deploymentInfo.AppPath = appPath
+	deploymentInfo.Domain = environment.Domain
+	deploymentInfo.SkipSSL = environment.SkipSSL
+	deploymentInfo.CustomParams = environment.CustomParams
+
+	deploymentInfo.Instances = environment.Instances
+	if instances := manifestro.GetInstances(record.Manifest); instances != nil {
+		deploymentInfo.Instances = *instances
+	}
+
+	return deploymentInfo
+}
+
+// newDeployResponse returns http.StatusOK, or http.StatusInternalServerError when there is an error.
+func newDeployResponse(err error) I.DeployResponse {
+	if err != nil {
+		return I.DeployResponse{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+	return I.DeployResponse{StatusCode: http.StatusOK}
+}
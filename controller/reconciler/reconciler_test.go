@@ -0,0 +1,269 @@
+package reconciler_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	C "github.com/compozed/deployadactyl/constants"
+	"github.com/compozed/deployadactyl/controller/deployer/approval"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
+	. "github.com/compozed/deployadactyl/controller/reconciler"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reconciler", func() {
+	var (
+		history      *mocks.History
+		inventory    *mocks.Inventory
+		fetcher      *mocks.Fetcher
+		blueGreener  *mocks.BlueGreener
+		eventManager *mocks.EventManager
+		approvalGate *mocks.ApprovalGate
+		fileSystem   *afero.Afero
+
+		environment    S.Environment
+		deploymentInfo S.DeploymentInfo
+		record         S.DeploymentRecord
+		response       *bytes.Buffer
+		appPath        string
+
+		reconciler Reconciler
+	)
+
+	BeforeEach(func() {
+		history = &mocks.History{}
+		inventory = &mocks.Inventory{}
+		fetcher = &mocks.Fetcher{}
+		blueGreener = &mocks.BlueGreener{}
+		eventManager = &mocks.EventManager{}
+		approvalGate = &mocks.ApprovalGate{}
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		response = &bytes.Buffer{}
+
+		appPath = "/appPath-" + randomizer.StringRunes(10)
+		Expect(fileSystem.MkdirAll(appPath, 0755)).To(Succeed())
+
+		environment = S.Environment{
+			Name:        "production",
+			Domain:      "example.com",
+			Foundations: []string{"https://api1.example.com", "https://api2.example.com", "https://api3.example.com"},
+			Instances:   2,
+		}
+
+		deploymentInfo = S.DeploymentInfo{
+			Username:    "username",
+			Password:    "password",
+			Environment: "production",
+			Org:         "org",
+			Space:       "space",
+			AppName:     "appName",
+		}
+
+		record = S.DeploymentRecord{
+			UUID:                "uuid-" + randomizer.StringRunes(10),
+			ArtifactURL:         "https://example.com/artifact.jar",
+			ArtifactChecksum:    "checksum",
+			Manifest:            "---\napplications:\n- instances: 4\n",
+			HealthCheckEndpoint: "/health",
+		}
+		history.LatestCall.Returns.Record = record
+		history.LatestCall.Returns.Found = true
+
+		inventory.GetCall.Returns.AppInventory = S.AppInventory{
+			Foundations: []S.FoundationApp{
+				{FoundationURL: "https://api1.example.com", Name: "https://api1.example.com", Exists: true, DeploymentUUID: record.UUID},
+				{FoundationURL: "https://api2.example.com", Name: "https://api2.example.com", Exists: true, DeploymentUUID: "old-uuid"},
+				{FoundationURL: "https://api3.example.com", Name: "https://api3.example.com"},
+			},
+		}
+
+		fetcher.FetchCall.Returns.AppPath = appPath
+
+		reconciler = Reconciler{
+			History:      history,
+			Inventory:    inventory,
+			Fetcher:      fetcher,
+			BlueGreener:  blueGreener,
+			EventManager: eventManager,
+			ApprovalGate: approvalGate,
+			FileSystem:   fileSystem,
+			Log:          logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "reconciler_test"),
+		}
+	})
+
+	It("pushes the latest deployment only to the foundations that have drifted", func() {
+		reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+		Expect(reconcileResponse.Error).ToNot(HaveOccurred())
+		Expect(reconcileResponse.StatusCode).To(Equal(http.StatusOK))
+
+		Expect(history.LatestCall.Received.Environment).To(Equal("production"))
+		Expect(history.LatestCall.Received.AppName).To(Equal("appName"))
+		Expect(inventory.GetCall.Received.DeploymentInfo).To(Equal(deploymentInfo))
+
+		Expect(fetcher.FetchCall.Received.ArtifactURL).To(Equal(record.ArtifactURL))
+		Expect(fetcher.FetchCall.Received.Manifest).To(Equal(record.Manifest))
+
+		Expect(blueGreener.PushCall.Received.Environment.Foundations).To(Equal([]string{"https://api2.example.com", "https://api3.example.com"}))
+		Expect(blueGreener.PushCall.Received.AppPath).To(Equal(appPath))
+
+		pushed := blueGreener.PushCall.Received.DeploymentInfo
+		Expect(pushed.UUID).To(Equal(record.UUID))
+		Expect(pushed.Username).To(Equal("username"))
+		Expect(pushed.ArtifactChecksum).To(Equal("checksum"))
+		Expect(pushed.HealthCheckEndpoint).To(Equal("/health"))
+		Expect(pushed.Domain).To(Equal("example.com"))
+		Expect(pushed.Instances).To(Equal(uint16(4)))
+
+		Expect(response.String()).To(ContainSubstring(`appName runs deployment "old-uuid" instead of ` + record.UUID + ` on https://api2.example.com`))
+		Expect(response.String()).To(ContainSubstring("appName does not exist on https://api3.example.com"))
+		Expect(response.String()).To(ContainSubstring("reconciled appName on https://api2.example.com, https://api3.example.com"))
+	})
+
+	It("emits the deployment events", func() {
+		reconciler.Reconcile(environment, deploymentInfo, response)
+
+		Expect(eventManager.EmitCall.Received.Events).To(HaveLen(3))
+		Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.DeployStartEvent))
+		Expect(eventManager.EmitCall.Received.Events[0].Data.(S.DeployEventData).DeploymentInfo.AppPath).To(Equal(appPath))
+		Expect(eventManager.EmitCall.Received.Events[1].Type).To(Equal(C.DeploySuccessEvent))
+		Expect(eventManager.EmitCall.Received.Events[2].Type).To(Equal(C.DeployFinishEvent))
+	})
+
+	It("removes the fetched artifact", func() {
+		reconciler.Reconcile(environment, deploymentInfo, response)
+
+		exists, err := fileSystem.DirExists(appPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+
+	It("waits for approval before fetching the artifact", func() {
+		reconciler.Reconcile(environment, deploymentInfo, response)
+
+		Expect(approvalGate.WaitCall.TimesCalled).To(Equal(1))
+		Expect(approvalGate.WaitCall.Received.Environment).To(Equal(environment))
+		Expect(approvalGate.WaitCall.Received.DeploymentInfo.UUID).To(Equal(record.UUID))
+		Expect(approvalGate.WaitCall.Received.DeploymentInfo.ArtifactURL).To(Equal(record.ArtifactURL))
+		Expect(approvalGate.WaitCall.Received.Response).To(Equal(response))
+	})
+
+	Context("when the reconciliation is rejected", func() {
+		It("returns http.StatusForbidden and does not push", func() {
+			approvalGate.WaitCall.Returns.Error = approval.RejectedError{UUID: record.UUID, Approver: "approver"}
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusForbidden))
+			Expect(reconcileResponse.Error).To(MatchError(approvalGate.WaitCall.Returns.Error))
+			Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+			Expect(blueGreener.PushCall.Received.Environment.Foundations).To(BeEmpty())
+			Expect(eventManager.EmitCall.Received.Events).To(BeEmpty())
+		})
+	})
+
+	Context("when the environment is in a freeze window", func() {
+		It("returns http.StatusConflict, emits a frozen event and does not push", func() {
+			environment.FreezeWindows = []S.FreezeWindow{{
+				Name:  "year-end",
+				Start: time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02"),
+				End:   time.Now().UTC().AddDate(0, 0, 2).Format("2006-01-02"),
+			}}
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusConflict))
+			Expect(reconcileResponse.Error).To(BeAssignableToTypeOf(FrozenError{}))
+			Expect(reconcileResponse.Error.(FrozenError).Window).To(Equal("year-end"))
+
+			Expect(eventManager.EmitCall.Received.Events).To(HaveLen(1))
+			Expect(eventManager.EmitCall.Received.Events[0].Type).To(Equal(C.DeployFrozenEvent))
+			Expect(eventManager.EmitCall.Received.Events[0].Data.(S.FreezeEventData).UUID).To(Equal(record.UUID))
+
+			Expect(inventory.GetCall.TimesCalled).To(Equal(0))
+			Expect(approvalGate.WaitCall.TimesCalled).To(Equal(0))
+			Expect(blueGreener.PushCall.Received.Environment.Foundations).To(BeEmpty())
+		})
+	})
+
+	Context("when no foundations have drifted", func() {
+		It("does not push", func() {
+			inventory.GetCall.Returns.AppInventory.Foundations = inventory.GetCall.Returns.AppInventory.Foundations[:1]
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.String()).To(ContainSubstring("no foundations have drifted from deployment " + record.UUID))
+			Expect(fetcher.FetchCall.Received.ArtifactURL).To(BeEmpty())
+			Expect(eventManager.EmitCall.Received.Events).To(BeEmpty())
+		})
+	})
+
+	Context("when a foundation cannot be checked", func() {
+		It("reconciles the other foundations and returns an error", func() {
+			inventory.GetCall.Returns.AppInventory.Foundations[0].Error = "foundation error"
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(reconcileResponse.Error).To(MatchError(UnknownFoundationsError{[]string{"foundation error"}}))
+			Expect(blueGreener.PushCall.Received.Environment.Foundations).To(Equal([]string{"https://api2.example.com", "https://api3.example.com"}))
+		})
+	})
+
+	Context("when the application was never deployed", func() {
+		It("returns http.StatusNotFound", func() {
+			history.LatestCall.Returns.Found = false
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(reconcileResponse.Error).To(MatchError(NoDeploymentError{"appName", "production"}))
+			Expect(inventory.GetCall.TimesCalled).To(Equal(0))
+		})
+	})
+
+	Context("when the artifact was uploaded", func() {
+		It("returns http.StatusBadRequest", func() {
+			history.LatestCall.Returns.Record.ArtifactURL = "/tmp/deployadactyl-123"
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(reconcileResponse.Error).To(MatchError(ArtifactURLError{record.UUID, "/tmp/deployadactyl-123"}))
+		})
+	})
+
+	Context("when the artifact cannot be fetched", func() {
+		It("returns an error", func() {
+			fetcher.FetchCall.Returns.Error = errors.New("fetch error")
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(reconcileResponse.Error).To(MatchError("fetch error"))
+		})
+	})
+
+	Context("when the push fails", func() {
+		It("returns an error and emits a failure event", func() {
+			blueGreener.PushCall.Returns.Error = bluegreen.PushError{}
+
+			reconcileResponse := reconciler.Reconcile(environment, deploymentInfo, response)
+
+			Expect(reconcileResponse.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(reconcileResponse.Error).To(MatchError(bluegreen.PushError{}))
+			Expect(eventManager.EmitCall.Received.Events[1].Type).To(Equal(C.DeployFailureEvent))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	C "github.com/compozed/deployadactyl/constants"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// streamDeployment runs a deployment and streams its output to the client while it runs. The
+// status code is sent before the deployment finishes, so it is always http.StatusOK and the
+// last line has the result of the deployment with its status code.
+func (c *Controller) streamDeployment(g *gin.Context, deployment *I.Deployment) {
+	g.Header("Content-Type", C.StreamContentType)
+	g.Writer.WriteHeader(http.StatusOK)
+	g.Writer.Flush()
+
+	response := &streamResponse{writer: g.Writer, encoder: json.NewEncoder(g.Writer)}
+	deployResponse := c.RunDeployment(deployment, response)
+
+	response.finish(newDeployResult(deployResponse, ""))
+}
+
+// streamResponse is the response of a deployment that is streamed. Every write is sent to
+// the client as a line and flushed as it happens. The output is not kept, the Deployer
+// keeps what it needs to find the errors in it.
+type streamResponse struct {
+	mutex   sync.Mutex
+	writer  gin.ResponseWriter
+	encoder *json.Encoder
+}
+
+// Write sends the output to the client. The deployment carries on when the client has gone
+// away, so errors sending the output are not returned.
+func (s *streamResponse) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.send(S.DeployStreamLine{Output: string(p)})
+
+	return len(p), nil
+}
+
+// Read returns io.EOF, the output was sent to the client as it was written.
+func (s *streamResponse) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+// finish sends the result of the deployment as the last line.
+func (s *streamResponse) finish(result S.DeployResult) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.send(S.DeployStreamLine{Result: &result})
+}
+
+func (s *streamResponse) send(line S.DeployStreamLine) {
+	s.encoder.Encode(line)
+	s.writer.Flush()
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+type LoadError struct {
+	Path string
+	Err  error
+}
+
+func (e LoadError) Error() string {
+	return fmt.Sprintf("cannot load the API tokens from %s: %s", e.Path, e.Err)
+}
+
+type SaveError struct {
+	Path string
+	Err  error
+}
+
+func (e SaveError) Error() string {
+	return fmt.Sprintf("cannot save the API tokens to %s: %s", e.Path, e.Err)
+}
+
+type NameError struct{}
+
+func (e NameError) Error() string {
+	return "an API token must have a name"
+}
+
+type DuplicateNameError struct {
+	Name string
+}
+
+func (e DuplicateNameError) Error() string {
+	return fmt.Sprintf("an API token named %s already exists", e.Name)
+}
+
+type ScopeError struct {
+	Name string
+}
+
+func (e ScopeError) Error() string {
+	return fmt.Sprintf("API token %s must be scoped to at least one environment", e.Name)
+}
+
+type PatternError struct {
+	Name    string
+	Pattern string
+}
+
+func (e PatternError) Error() string {
+	return fmt.Sprintf("API token %s has a malformed environment pattern: %s", e.Name, e.Pattern)
+}
+
+type TTLError struct {
+	TTL time.Duration
+}
+
+func (e TTLError) Error() string {
+	return fmt.Sprintf("an API token must expire in between 1s and %s: %s", MaxTTL, e.TTL)
+}
+
+type InvalidTokenError struct{}
+
+func (e InvalidTokenError) Error() string {
+	return "the API token is not valid"
+}
+
+type RevokedError struct {
+	Name string
+}
+
+func (e RevokedError) Error() string {
+	return fmt.Sprintf("API token %s has been revoked", e.Name)
+}
+
+type ExpiredError struct {
+	Name      string
+	ExpiresAt time.Time
+}
+
+func (e ExpiredError) Error() string {
+	return fmt.Sprintf("API token %s expired at %s", e.Name, e.ExpiresAt.Format(time.RFC3339))
+}
+
+type NotFoundError struct {
+	ID string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("API token %s does not exist", e.ID)
+}
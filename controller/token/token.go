@@ -0,0 +1,264 @@
+// Package token issues and verifies the API tokens that CI systems deploy with.
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// Prefix starts every API token so that leaked tokens are easy to recognise.
+const Prefix = "dpl_"
+
+const (
+	// DefaultTTL is how long an API token lasts when no time to live is given.
+	DefaultTTL = 30 * 24 * time.Hour
+
+	// MaxTTL is the longest an API token can last.
+	MaxTTL = 365 * 24 * time.Hour
+)
+
+// Store keeps the API tokens. When it has a path the tokens are saved to a file, so
+// they are kept across restarts.
+type Store struct {
+	fileSystem *afero.Afero
+	path       string
+	log        I.Logger
+	mutex      sync.RWMutex
+	tokens     map[string]S.APIToken
+}
+
+// NewStore returns a Store with the tokens saved at path. An empty path keeps the
+// tokens in memory only.
+//
+// Returns a LoadError if the file exists but cannot be read.
+func NewStore(fileSystem *afero.Afero, path string, log I.Logger) (*Store, error) {
+	s := &Store{
+		fileSystem: fileSystem,
+		path:       path,
+		log:        log,
+		tokens:     map[string]S.APIToken{},
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := fileSystem.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, LoadError{path, err}
+	}
+
+	var tokens []S.APIToken
+	err = json.Unmarshal(data, &tokens)
+	if err != nil {
+		return nil, LoadError{path, err}
+	}
+
+	for _, token := range tokens {
+		s.tokens[token.ID] = token
+	}
+
+	return s, nil
+}
+
+// Issue creates an API token that can deploy to the environments matching the patterns
+// and expires after the time to live. The token is returned once and only its hash is kept.
+func (s *Store) Issue(name string, environments []string, ttl time.Duration) (string, S.APIToken, error) {
+	if name == "" {
+		return "", S.APIToken{}, NameError{}
+	}
+
+	if len(environments) == 0 {
+		return "", S.APIToken{}, ScopeError{name}
+	}
+
+	for _, pattern := range environments {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return "", S.APIToken{}, PatternError{name, pattern}
+		}
+	}
+
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < time.Second || ttl > MaxTTL {
+		return "", S.APIToken{}, TTLError{ttl}
+	}
+
+	id, err := random(8)
+	if err != nil {
+		return "", S.APIToken{}, err
+	}
+
+	secret, err := random(32)
+	if err != nil {
+		return "", S.APIToken{}, err
+	}
+	secret = Prefix + secret
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, token := range s.tokens {
+		if token.Name == name && !token.Revoked {
+			return "", S.APIToken{}, DuplicateNameError{name}
+		}
+	}
+
+	now := time.Now().UTC()
+	token := S.APIToken{
+		ID:           id,
+		Name:         name,
+		Hash:         hash(secret),
+		Environments: environments,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+
+	s.tokens[id] = token
+	s.log.Infof("issued API token %s named %s for %s", id, name, strings.Join(environments, ", "))
+
+	err = s.save()
+	if err != nil {
+		delete(s.tokens, id)
+		return "", S.APIToken{}, err
+	}
+
+	token.Hash = ""
+	return secret, token, nil
+}
+
+// Verify returns the API token of a secret.
+//
+// Returns an InvalidTokenError when there is no such token, a RevokedError when it has
+// been revoked and an ExpiredError when it has expired.
+func (s *Store) Verify(secret string) (S.APIToken, error) {
+	h := []byte(hash(secret))
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare(h, []byte(token.Hash)) != 1 {
+			continue
+		}
+
+		if token.Revoked {
+			return S.APIToken{}, RevokedError{token.Name}
+		}
+
+		if !time.Now().Before(token.ExpiresAt) {
+			return S.APIToken{}, ExpiredError{token.Name, token.ExpiresAt}
+		}
+
+		token.Hash = ""
+		return token, nil
+	}
+
+	return S.APIToken{}, InvalidTokenError{}
+}
+
+// Revoke stops an API token from being used. Revoked tokens are kept so they can be audited.
+//
+// Returns a NotFoundError when there is no such token.
+func (s *Store) Revoke(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token, ok := s.tokens[id]
+	if !ok {
+		return NotFoundError{id}
+	}
+
+	token.Revoked = true
+	s.tokens[id] = token
+	s.log.Infof("revoked API token %s named %s", id, token.Name)
+
+	return s.save()
+}
+
+// List returns every API token, oldest first, without their hashes.
+func (s *Store) List() []S.APIToken {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tokens := make([]S.APIToken, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		token.Hash = ""
+		tokens = append(tokens, token)
+	}
+
+	sort.Sort(byCreatedAt(tokens))
+	return tokens
+}
+
+// InScope returns true when an API token can deploy to the environment. Environment
+// names are not case sensitive.
+func InScope(token S.APIToken, environment string) bool {
+	for _, pattern := range token.Environments {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(environment)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	tokens := make([]S.APIToken, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Sort(byCreatedAt(tokens))
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return SaveError{s.path, err}
+	}
+
+	err = s.fileSystem.WriteFile(s.path, data, 0600)
+	if err != nil {
+		return SaveError{s.path, err}
+	}
+
+	return nil
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type byCreatedAt []S.APIToken
+
+func (b byCreatedAt) Len() int           { return len(b) }
+func (b byCreatedAt) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byCreatedAt) Less(i, j int) bool { return b[i].CreatedAt.Before(b[j].CreatedAt) }
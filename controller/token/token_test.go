@@ -0,0 +1,229 @@
+package token_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller/token"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Store", func() {
+	var (
+		fileSystem *afero.Afero
+		log        I.Logger
+		tokenPath  string
+		store      *Store
+	)
+
+	BeforeEach(func() {
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		log = logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "token_test")
+		tokenPath = "/tokens-" + randomizer.StringRunes(10) + ".json"
+
+		var err error
+		store, err = NewStore(fileSystem, tokenPath, log)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("issuing tokens", func() {
+		It("returns a secret that verifies as the token", func() {
+			secret, token, err := store.Issue("ci", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(secret).To(HavePrefix(Prefix))
+			Expect(token.Name).To(Equal("ci"))
+			Expect(token.Environments).To(Equal([]string{"preproduction"}))
+			Expect(token.Hash).To(BeEmpty())
+			Expect(token.ExpiresAt.Sub(token.CreatedAt)).To(Equal(time.Hour))
+
+			verified, err := store.Verify(secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verified).To(Equal(token))
+		})
+
+		It("lasts for the default time to live when none is given", func() {
+			_, token, err := store.Issue("ci", []string{"preproduction"}, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(token.ExpiresAt.Sub(token.CreatedAt)).To(Equal(DefaultTTL))
+		})
+
+		It("saves only the hash of the secret", func() {
+			secret, token, err := store.Issue("ci", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := fileSystem.ReadFile(tokenPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).ToNot(ContainSubstring(secret))
+
+			var tokens []S.APIToken
+			Expect(json.Unmarshal(data, &tokens)).To(Succeed())
+			Expect(tokens).To(HaveLen(1))
+			Expect(tokens[0].ID).To(Equal(token.ID))
+			Expect(tokens[0].Hash).To(Equal(hash(secret)))
+		})
+
+		It("keeps the tokens across restarts", func() {
+			secret, token, err := store.Issue("ci", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			restarted, err := NewStore(fileSystem, tokenPath, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			verified, err := restarted.Verify(secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verified.ID).To(Equal(token.ID))
+		})
+
+		It("returns an error when the token has no name", func() {
+			_, _, err := store.Issue("", []string{"preproduction"}, time.Hour)
+
+			Expect(err).To(MatchError(NameError{}))
+		})
+
+		It("returns an error when the token has no environments", func() {
+			_, _, err := store.Issue("ci", nil, time.Hour)
+
+			Expect(err).To(MatchError(ScopeError{"ci"}))
+		})
+
+		It("returns an error when an environment pattern is malformed", func() {
+			_, _, err := store.Issue("ci", []string{"pre["}, time.Hour)
+
+			Expect(err).To(MatchError(PatternError{"ci", "pre["}))
+		})
+
+		It("returns an error when the time to live is too long", func() {
+			_, _, err := store.Issue("ci", []string{"preproduction"}, MaxTTL+time.Hour)
+
+			Expect(err).To(MatchError(TTLError{MaxTTL + time.Hour}))
+		})
+
+		It("returns an error when a token with the same name has not been revoked", func() {
+			_, token, err := store.Issue("ci", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = store.Issue("ci", []string{"production"}, time.Hour)
+			Expect(err).To(MatchError(DuplicateNameError{"ci"}))
+
+			Expect(store.Revoke(token.ID)).To(Succeed())
+
+			_, _, err = store.Issue("ci", []string{"production"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("verifying tokens", func() {
+		It("returns an error for an unknown secret", func() {
+			_, err := store.Verify(Prefix + "unknown")
+
+			Expect(err).To(MatchError(InvalidTokenError{}))
+		})
+
+		It("returns an error for a revoked token", func() {
+			secret, token, err := store.Issue("ci", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.Revoke(token.ID)).To(Succeed())
+
+			_, err = store.Verify(secret)
+			Expect(err).To(MatchError(RevokedError{"ci"}))
+		})
+
+		It("returns an error for an expired token", func() {
+			expiresAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+			data, err := json.Marshal([]S.APIToken{{
+				ID:           "expired-id",
+				Name:         "ci",
+				Hash:         hash(Prefix + "secret"),
+				Environments: []string{"preproduction"},
+				CreatedAt:    expiresAt.Add(-time.Hour),
+				ExpiresAt:    expiresAt,
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileSystem.WriteFile(tokenPath, data, 0600)).To(Succeed())
+
+			store, err = NewStore(fileSystem, tokenPath, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = store.Verify(Prefix + "secret")
+			Expect(err).To(MatchError(ExpiredError{"ci", expiresAt}))
+		})
+	})
+
+	Describe("revoking tokens", func() {
+		It("returns an error for an unknown token", func() {
+			Expect(store.Revoke("unknown")).To(MatchError(NotFoundError{"unknown"}))
+		})
+
+		It("keeps the revoked token in the list", func() {
+			_, token, err := store.Issue("ci", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.Revoke(token.ID)).To(Succeed())
+
+			tokens := store.List()
+			Expect(tokens).To(HaveLen(1))
+			Expect(tokens[0].Revoked).To(BeTrue())
+		})
+	})
+
+	Describe("listing tokens", func() {
+		It("returns the tokens oldest first without their hashes", func() {
+			_, first, err := store.Issue("first", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+			time.Sleep(time.Millisecond)
+			_, second, err := store.Issue("second", []string{"production"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.List()).To(Equal([]S.APIToken{first, second}))
+		})
+	})
+
+	Describe("loading tokens", func() {
+		It("returns a LoadError when the file is malformed", func() {
+			Expect(fileSystem.WriteFile(tokenPath, []byte("not json"), 0600)).To(Succeed())
+
+			_, err := NewStore(fileSystem, tokenPath, log)
+
+			Expect(err).To(BeAssignableToTypeOf(LoadError{}))
+		})
+
+		It("keeps the tokens in memory when there is no path", func() {
+			store, err := NewStore(fileSystem, "", log)
+			Expect(err).ToNot(HaveOccurred())
+
+			secret, _, err := store.Issue("ci", []string{"preproduction"}, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = store.Verify(secret)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("InScope", func() {
+		It("matches the environment patterns of the token without case", func() {
+			token := S.APIToken{Environments: []string{"pre*", "Staging"}}
+
+			Expect(InScope(token, "Preproduction")).To(BeTrue())
+			Expect(InScope(token, "staging")).To(BeTrue())
+			Expect(InScope(token, "production")).To(BeFalse())
+		})
+	})
+})
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
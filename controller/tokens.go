@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/compozed/deployadactyl/controller/token"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+)
+
+type tokenRequest struct {
+	Name         string   `json:"name"`
+	Environments []string `json:"environments"`
+	ExpiresIn    string   `json:"expires_in"`
+}
+
+// issuedToken is an API token with its secret, which is only shown when it is issued.
+type issuedToken struct {
+	S.APIToken
+	Token string `json:"token"`
+}
+
+// IssueToken creates an API token from a JSON body with the name, environments and
+// expires_in of the token. Requires the admin token.
+func (c *Controller) IssueToken(g *gin.Context) {
+	if !c.authenticateAdmin(g) {
+		return
+	}
+
+	var body tokenRequest
+	err := json.NewDecoder(g.Request.Body).Decode(&body)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ttl time.Duration
+	if body.ExpiresIn != "" {
+		ttl, err = time.ParseDuration(body.ExpiresIn)
+		if err != nil {
+			g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	secret, apiToken, err := c.Tokens.Issue(body.Name, body.Environments, ttl)
+	if err != nil {
+		c.Log.Errorf("cannot issue API token %s: %s", body.Name, err)
+		g.JSON(tokenStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	g.JSON(http.StatusCreated, issuedToken{apiToken, secret})
+}
+
+// ListTokens writes every API token as JSON, without their secrets. Requires the admin token.
+func (c *Controller) ListTokens(g *gin.Context) {
+	if !c.authenticateAdmin(g) {
+		return
+	}
+
+	g.JSON(http.StatusOK, gin.H{"tokens": c.Tokens.List()})
+}
+
+// RevokeToken stops an API token from being used. Requires the admin token.
+func (c *Controller) RevokeToken(g *gin.Context) {
+	if !c.authenticateAdmin(g) {
+		return
+	}
+
+	id := g.Param("id")
+
+	err := c.Tokens.Revoke(id)
+	if err != nil {
+		c.Log.Errorf("cannot revoke API token %s: %s", id, err)
+		g.JSON(tokenStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	g.JSON(http.StatusOK, gin.H{"id": id, "revoked": true})
+}
+
+// authenticateAdmin writes an error and returns false unless the request has the admin
+// token as a bearer token.
+func (c *Controller) authenticateAdmin(g *gin.Context) bool {
+	if c.AdminToken == "" {
+		g.JSON(http.StatusForbidden, gin.H{"error": AdminDisabledError{}.Error()})
+		return false
+	}
+
+	secret, ok := bearerToken(g.Request)
+	if !ok || subtle.ConstantTimeCompare([]byte(secret), []byte(c.AdminToken)) != 1 {
+		c.Log.Errorf("%s", AdminTokenError{})
+		g.JSON(http.StatusUnauthorized, gin.H{"error": AdminTokenError{}.Error()})
+		return false
+	}
+
+	return true
+}
+
+func tokenStatusCode(err error) int {
+	switch err.(type) {
+	case token.NameError, token.ScopeError, token.PatternError, token.TTLError:
+		return http.StatusBadRequest
+	case token.DuplicateNameError:
+		return http.StatusConflict
+	case token.NotFoundError:
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,172 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/controller/token"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/op/go-logging"
+)
+
+var _ = Describe("Tokens", func() {
+	var (
+		controller *Controller
+		tokens     *mocks.TokenStore
+		router     *gin.Engine
+		resp       *httptest.ResponseRecorder
+		adminToken string
+	)
+
+	BeforeEach(func() {
+		tokens = &mocks.TokenStore{}
+		adminToken = "admin-secret"
+
+		controller = &Controller{
+			Tokens:     tokens,
+			AdminToken: adminToken,
+			Log:        logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "tokens_test"),
+		}
+
+		router = gin.New()
+		router.POST("/v2/tokens", controller.IssueToken)
+		router.GET("/v2/tokens", controller.ListTokens)
+		router.DELETE("/v2/tokens/:id", controller.RevokeToken)
+
+		resp = httptest.NewRecorder()
+	})
+
+	serve := func(method, url, body, secret string) {
+		req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+		Expect(err).ToNot(HaveOccurred())
+
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+
+		router.ServeHTTP(resp, req)
+	}
+
+	Describe("IssueToken", func() {
+		It("returns the token with its secret and http.StatusCreated", func() {
+			tokens.IssueCall.Returns.Secret = "dpl_secret"
+			tokens.IssueCall.Returns.Token = S.APIToken{ID: "id", Name: "ci", Environments: []string{"preproduction"}}
+
+			serve("POST", "/v2/tokens", `{"name": "ci", "environments": ["preproduction"], "expires_in": "24h"}`, adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusCreated))
+			Expect(tokens.IssueCall.Received.Name).To(Equal("ci"))
+			Expect(tokens.IssueCall.Received.Environments).To(Equal([]string{"preproduction"}))
+			Expect(tokens.IssueCall.Received.TTL).To(Equal(24 * time.Hour))
+
+			var body map[string]interface{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &body)).To(Succeed())
+			Expect(body["token"]).To(Equal("dpl_secret"))
+			Expect(body["id"]).To(Equal("id"))
+			Expect(body["name"]).To(Equal("ci"))
+			Expect(body).ToNot(HaveKey("hash"))
+		})
+
+		It("uses the default time to live when expires_in is not given", func() {
+			serve("POST", "/v2/tokens", `{"name": "ci", "environments": ["preproduction"]}`, adminToken)
+
+			Expect(tokens.IssueCall.Received.TTL).To(BeZero())
+		})
+
+		It("returns http.StatusBadRequest when expires_in is not a duration", func() {
+			serve("POST", "/v2/tokens", `{"name": "ci", "environments": ["preproduction"], "expires_in": "a month"}`, adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns http.StatusBadRequest when the body is malformed", func() {
+			serve("POST", "/v2/tokens", `{"name":`, adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns http.StatusBadRequest when the token is not scoped", func() {
+			tokens.IssueCall.Returns.Error = token.ScopeError{Name: "ci"}
+
+			serve("POST", "/v2/tokens", `{"name": "ci"}`, adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(resp.Body.String()).To(ContainSubstring(token.ScopeError{Name: "ci"}.Error()))
+		})
+
+		It("returns http.StatusConflict when the name is taken", func() {
+			tokens.IssueCall.Returns.Error = token.DuplicateNameError{Name: "ci"}
+
+			serve("POST", "/v2/tokens", `{"name": "ci", "environments": ["preproduction"]}`, adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("ListTokens", func() {
+		It("returns the tokens", func() {
+			tokens.ListCall.Returns.Tokens = []S.APIToken{{ID: "id", Name: "ci", Environments: []string{"preproduction"}}}
+
+			serve("GET", "/v2/tokens", "", adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+
+			var body struct {
+				Tokens []S.APIToken `json:"tokens"`
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &body)).To(Succeed())
+			Expect(body.Tokens).To(Equal(tokens.ListCall.Returns.Tokens))
+		})
+	})
+
+	Describe("RevokeToken", func() {
+		It("revokes the token", func() {
+			serve("DELETE", "/v2/tokens/id", "", adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(tokens.RevokeCall.Received.ID).To(Equal("id"))
+		})
+
+		It("returns http.StatusNotFound when the token does not exist", func() {
+			tokens.RevokeCall.Returns.Error = token.NotFoundError{ID: "id"}
+
+			serve("DELETE", "/v2/tokens/id", "", adminToken)
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("the admin token", func() {
+		It("is required", func() {
+			serve("DELETE", "/v2/tokens/id", "", "")
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(tokens.RevokeCall.Received.ID).To(BeEmpty())
+		})
+
+		It("must match", func() {
+			serve("GET", "/v2/tokens", "", "dpl_secret")
+
+			Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			Expect(resp.Body.String()).To(ContainSubstring(AdminTokenError{}.Error()))
+		})
+
+		It("disables token administration when it is not configured", func() {
+			controller.AdminToken = ""
+
+			serve("POST", "/v2/tokens", `{"name": "ci", "environments": ["preproduction"]}`, "")
+
+			Expect(resp.Code).To(Equal(http.StatusForbidden))
+			Expect(tokens.IssueCall.Received.Name).To(BeEmpty())
+		})
+	})
+})
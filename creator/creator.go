@@ -10,38 +10,122 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
+	"time"
 
 	"github.com/compozed/deployadactyl/artifetcher"
 	"github.com/compozed/deployadactyl/artifetcher/extractor"
 	"github.com/compozed/deployadactyl/config"
+	C "github.com/compozed/deployadactyl/constants"
 	"github.com/compozed/deployadactyl/controller"
 	"github.com/compozed/deployadactyl/controller/deployer"
+	"github.com/compozed/deployadactyl/controller/deployer/approval"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher/courier"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher/courier/executor"
+	"github.com/compozed/deployadactyl/controller/deployer/drain"
 	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
 	"github.com/compozed/deployadactyl/controller/deployer/prechecker"
+	"github.com/compozed/deployadactyl/controller/deployer/routevalidator"
+	"github.com/compozed/deployadactyl/controller/inventory"
+	"github.com/compozed/deployadactyl/controller/janitor"
+	"github.com/compozed/deployadactyl/controller/oidc"
+	"github.com/compozed/deployadactyl/controller/policy"
+	"github.com/compozed/deployadactyl/controller/reconciler"
+	"github.com/compozed/deployadactyl/controller/token"
 	"github.com/compozed/deployadactyl/eventmanager"
+	"github.com/compozed/deployadactyl/eventmanager/handlers/history"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/logger"
 	"github.com/compozed/deployadactyl/randomizer"
 	S "github.com/compozed/deployadactyl/structs"
+	"github.com/compozed/deployadactyl/tlsconfig"
 	"github.com/gin-gonic/gin"
 	"github.com/op/go-logging"
 	"github.com/spf13/afero"
 )
 
+// historyFileEnvVarName is the environment variable with the file the deployment history is saved to.
+const historyFileEnvVarName = "DEPLOYADACTYL_HISTORY_FILE"
+
+// tokenFileEnvVarName is the environment variable with the file the API tokens are saved to.
+const tokenFileEnvVarName = "DEPLOYADACTYL_TOKEN_FILE"
+
+// adminTokenEnvVarName is the environment variable with the token that administers API tokens.
+const adminTokenEnvVarName = "DEPLOYADACTYL_ADMIN_TOKEN"
+
+// jwksTimeout is how long fetching the JSON web key set of the identity provider can take.
+const jwksTimeout = 10 * time.Second
+
 // ENDPOINT is used by the handler to define the deployment endpoint.
 const ENDPOINT = "/v2/deploy/:environment/:org/:space/:appName"
 
+// ENVIRONMENTS_ENDPOINT is used by the handler to list the configured environments.
+const ENVIRONMENTS_ENDPOINT = "/v2/environments"
+
+// ENVIRONMENT_ENDPOINT is used by the handler to show a single configured environment.
+const ENVIRONMENT_ENDPOINT = "/v2/environments/:environment"
+
+// APP_ENDPOINT is used by the handler to show an application on every foundation of an environment.
+const APP_ENDPOINT = "/v2/apps/:environment/:org/:space/:appName"
+
+// RECONCILE_ENDPOINT is used by the handler to reconcile an application on every foundation of an environment.
+const RECONCILE_ENDPOINT = "/v2/reconcile/:environment/:org/:space/:appName"
+
+// PROMOTE_ENDPOINT is used by the handler to promote the latest deployment of an application to another environment.
+const PROMOTE_ENDPOINT = "/v2/promote/:fromEnvironment/:toEnvironment/:org/:space/:appName"
+
+// DEPLOYMENTS_ENDPOINT is used by the handler to list the deployments that are pending approval.
+const DEPLOYMENTS_ENDPOINT = "/v2/deployments"
+
+// APPROVE_ENDPOINT is used by the handler to approve a deployment that is pending approval.
+const APPROVE_ENDPOINT = "/v2/deployments/:uuid/approve"
+
+// REJECT_ENDPOINT is used by the handler to reject a deployment that is pending approval.
+const REJECT_ENDPOINT = "/v2/deployments/:uuid/reject"
+
+// TOKENS_ENDPOINT is used by the handler to issue and list API tokens.
+const TOKENS_ENDPOINT = "/v2/tokens"
+
+// TOKEN_ENDPOINT is used by the handler to revoke an API token.
+const TOKEN_ENDPOINT = "/v2/tokens/:id"
+
+// OPENAPI_ENDPOINT is used by the handler to show the OpenAPI document of the API.
+const OPENAPI_ENDPOINT = "/v2/openapi.json"
+
+// routes are the paths of the handlers, which are described by the OpenAPI document.
+var routes = controller.Routes{
+	Deploy:       ENDPOINT,
+	Environments: ENVIRONMENTS_ENDPOINT,
+	Environment:  ENVIRONMENT_ENDPOINT,
+	App:          APP_ENDPOINT,
+	Reconcile:    RECONCILE_ENDPOINT,
+	Promote:      PROMOTE_ENDPOINT,
+	Deployments:  DEPLOYMENTS_ENDPOINT,
+	Approve:      APPROVE_ENDPOINT,
+	Reject:       REJECT_ENDPOINT,
+	Tokens:       TOKENS_ENDPOINT,
+	Token:        TOKEN_ENDPOINT,
+	OpenAPI:      OPENAPI_ENDPOINT,
+}
+
 // Creator has a config, eventManager, logger and writer for creating dependencies.
 type Creator struct {
-	config       config.Config
-	eventManager I.EventManager
-	logger       I.Logger
-	writer       io.Writer
-	fileSystem   *afero.Afero
+	config        config.Config
+	configWatcher *config.Watcher
+	errorFinder   *error_finder.ErrorFinder
+	history       *history.History
+	eventManager  I.EventManager
+	logger        I.Logger
+	writer        io.Writer
+	fileSystem    *afero.Afero
+	approvalGate  *approval.Gate
+	tokens        *token.Store
+	adminToken    string
+	jwtVerifier   I.JWTVerifier
+	tlsReloader   *tlsconfig.Reloader
+	drain         *drain.Drain
 }
 
 // Default returns a default Creator and an Error.
@@ -50,7 +134,7 @@ func Default() (Creator, error) {
 	if err != nil {
 		return Creator{}, err
 	}
-	return createCreator(logging.DEBUG, cfg)
+	return createCreator(logging.DEBUG, cfg, config.DefaultConfigPath)
 }
 
 // Custom returns a custom Creator with an Error.
@@ -64,7 +148,7 @@ func Custom(level string, configFilename string) (Creator, error) {
 	if err != nil {
 		return Creator{}, err
 	}
-	return createCreator(l, cfg)
+	return createCreator(l, cfg, configFilename)
 }
 
 // CreateControllerHandler returns a gin.Engine that implements http.Handler.
@@ -76,36 +160,94 @@ func (c Creator) CreateControllerHandler(controller I.Controller) *gin.Engine {
 	r.Use(gin.LoggerWithWriter(c.createWriter()))
 	r.Use(gin.ErrorLogger())
 
-	r.POST(ENDPOINT, controller.RunDeploymentViaHttp)
+	r.POST(ENDPOINT, controller.Authorize(policy.DeployAction), controller.RunDeploymentViaHttp)
+	r.GET(ENVIRONMENTS_ENDPOINT, controller.ListEnvironments)
+	r.GET(ENVIRONMENT_ENDPOINT, controller.GetEnvironment)
+	r.GET(APP_ENDPOINT, controller.Authorize(policy.ReadAction), controller.GetApp)
+	r.POST(RECONCILE_ENDPOINT, controller.Authorize(policy.RollbackAction), controller.ReconcileApp)
+	r.POST(PROMOTE_ENDPOINT, controller.Authorize(policy.PromoteAction), controller.PromoteApp)
+	r.GET(DEPLOYMENTS_ENDPOINT, controller.ListPendingDeployments)
+	r.POST(APPROVE_ENDPOINT, controller.Authorize(policy.ApproveAction), controller.ApproveDeployment)
+	r.POST(REJECT_ENDPOINT, controller.Authorize(policy.ApproveAction), controller.RejectDeployment)
+	r.POST(TOKENS_ENDPOINT, controller.IssueToken)
+	r.GET(TOKENS_ENDPOINT, controller.ListTokens)
+	r.DELETE(TOKEN_ENDPOINT, controller.RevokeToken)
+	r.GET(OPENAPI_ENDPOINT, controller.OpenAPI)
 
 	return r
 }
 
-// CreateListener creates a listener TCP and listens for all incoming requests.
+// CreateListener creates a listener TCP on the bind address and listens for all incoming requests.
+// The listener serves TLS when a certificate is configured.
 func (c Creator) CreateListener() net.Listener {
-	ls, err := net.ListenTCP("tcp", &net.TCPAddr{
-		IP:   net.IPv4(0, 0, 0, 0),
-		Port: c.config.Port,
-		Zone: "",
-	})
+	ls, err := net.Listen("tcp", net.JoinHostPort(c.config.BindAddress, strconv.Itoa(c.config.Port)))
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if c.tlsReloader != nil {
+		return tls.NewListener(ls, c.tlsReloader.Config())
+	}
 	return ls
 }
 
+// CreateJanitor returns a Janitor that keeps the temporary applications of the deployments
+// in progress.
+func (c Creator) CreateJanitor() janitor.Janitor {
+	return janitor.Janitor{
+		CourierCreator: c,
+		Drain:          c.drain,
+		Log:            c.CreateLogger(),
+	}
+}
+
+// JanitorConfig returns the current janitor configuration and the environments it sweeps
+// with the service account of every foundation resolved.
+func (c Creator) JanitorConfig() (S.Janitor, map[string]S.Environment) {
+	cfg := c.CreateConfig()
+
+	environments := make(map[string]S.Environment, len(cfg.Environments))
+	for key, environment := range cfg.Environments {
+		environments[key] = cfg.WithServiceAccounts(environment)
+	}
+
+	return cfg.Janitor, environments
+}
+
+// CreateDrain returns the Drain of the pushes in progress.
+func (c Creator) CreateDrain() *drain.Drain {
+	return c.drain
+}
+
+// CreateTLSReloader returns the TLS certificate reloader, or nil when TLS is not configured.
+func (c Creator) CreateTLSReloader() *tlsconfig.Reloader {
+	return c.tlsReloader
+}
+
 // CreatePusher is used by the BlueGreener.
 //
 // Returns a pusher and error.
-func (c Creator) CreatePusher(deploymentInfo S.DeploymentInfo, response io.ReadWriter) (I.Pusher, error) {
-	newCourier, err := c.CreateCourier()
+func (c Creator) CreatePusher(deploymentInfo S.DeploymentInfo, foundation S.Foundation, response io.ReadWriter) (I.Pusher, error) {
+	ex, err := executor.New(c.CreateFileSystem())
 	if err != nil {
 		return nil, err
 	}
 
+	// A dry run writes the commands that would change the foundation to the response
+	// instead of running them.
+	var cfExecutor I.Executor = ex
+	if deploymentInfo.DryRun {
+		cfExecutor = executor.NewDryRun(ex, response)
+	}
+
+	newCourier := courier.Courier{
+		Executor: cfExecutor,
+	}
+
 	p := &pusher.Pusher{
 		Courier:        newCourier,
 		DeploymentInfo: deploymentInfo,
+		Foundation:     foundation,
 		EventManager:   c.CreateEventManager(),
 		Response:       response,
 		Log:            logger.DeploymentLogger{c.CreateLogger(), deploymentInfo.UUID},
@@ -131,9 +273,14 @@ func (c Creator) CreateLogger() I.Logger {
 	return c.logger
 }
 
-// CreateConfig returns a Config.
+// CreateConfig returns the current Config.
 func (c Creator) CreateConfig() config.Config {
-	return c.config
+	return c.configWatcher.Config()
+}
+
+// CreateConfigWatcher returns the Watcher used to reload the Config.
+func (c Creator) CreateConfigWatcher() *config.Watcher {
+	return c.configWatcher
 }
 
 // CreateEventManager returns an EventManager.
@@ -162,21 +309,35 @@ func (c Creator) CreateController() I.Controller {
 		Deployer:       c.createDeployer(),
 		SilentDeployer: c.createSilentDeployer(),
 		Log:            c.CreateLogger(),
+		Inventory:      c.createInventory(),
+		Reconciler:     c.createReconciler(),
+		History:        c.history,
+		ApprovalGate:   c.approvalGate,
+		Tokens:         c.tokens,
+		AdminToken:     c.adminToken,
+		JWTVerifier:    c.jwtVerifier,
+		Config:         c.CreateConfig(),
+		ConfigWatcher:  c.CreateConfigWatcher(),
+		Routes:         routes,
 	}
 	return con
 }
 
 func (c Creator) createDeployer() I.Deployer {
 	return deployer.Deployer{
-		Config:       c.CreateConfig(),
-		BlueGreener:  c.createBlueGreener(),
-		Fetcher:      c.createFetcher(),
-		Prechecker:   c.createPrechecker(),
-		EventManager: c.CreateEventManager(),
-		Randomizer:   c.createRandomizer(),
-		ErrorFinder:  c.createErrorFinder(),
-		Log:          c.CreateLogger(),
-		FileSystem:   c.CreateFileSystem(),
+		Config:         c.CreateConfig(),
+		BlueGreener:    c.createBlueGreener(),
+		Fetcher:        c.createFetcher(),
+		Prechecker:     c.createPrechecker(),
+		RouteValidator: c.createRouteValidator(),
+		EventManager:   c.CreateEventManager(),
+		Randomizer:     c.createRandomizer(),
+		ErrorFinder:    c.createErrorFinder(),
+		Log:            c.CreateLogger(),
+		FileSystem:     c.CreateFileSystem(),
+		ConfigWatcher:  c.CreateConfigWatcher(),
+		ApprovalGate:   c.approvalGate,
+		Drain:          c.drain,
 	}
 }
 
@@ -205,6 +366,33 @@ func (c Creator) createPrechecker() I.Prechecker {
 	}
 }
 
+func (c Creator) createRouteValidator() I.RouteValidator {
+	return routevalidator.RouteValidator{
+		CourierCreator: c,
+		Log:            c.CreateLogger(),
+	}
+}
+
+func (c Creator) createInventory() I.Inventory {
+	return inventory.Inventory{
+		CourierCreator: c,
+		Log:            c.CreateLogger(),
+	}
+}
+
+func (c Creator) createReconciler() I.Reconciler {
+	return reconciler.Reconciler{
+		History:      c.history,
+		Inventory:    c.createInventory(),
+		Fetcher:      c.createFetcher(),
+		BlueGreener:  c.createBlueGreener(),
+		EventManager: c.CreateEventManager(),
+		ApprovalGate: c.approvalGate,
+		FileSystem:   c.CreateFileSystem(),
+		Log:          c.CreateLogger(),
+	}
+}
+
 func (c Creator) createWriter() io.Writer {
 	return c.writer
 }
@@ -213,16 +401,15 @@ func (c Creator) createBlueGreener() I.BlueGreener {
 	return bluegreen.BlueGreen{
 		PusherCreator: c,
 		Log:           c.CreateLogger(),
+		Drain:         c.drain,
 	}
 }
 
 func (c Creator) createErrorFinder() I.ErrorFinder {
-	return &error_finder.ErrorFinder{
-		Matchers: c.config.ErrorMatchers,
-	}
+	return c.errorFinder
 }
 
-func createCreator(l logging.Level, cfg config.Config) (Creator, error) {
+func createCreator(l logging.Level, cfg config.Config, configPath string) (Creator, error) {
 	err := ensureCLI()
 	if err != nil {
 		return Creator{}, err
@@ -231,13 +418,63 @@ func createCreator(l logging.Level, cfg config.Config) (Creator, error) {
 	logger := logger.DefaultLogger(os.Stdout, l, "controller")
 	eventManager := eventmanager.NewEventManager(logger)
 
-	return Creator{
+	errorFinder := &error_finder.ErrorFinder{
+		Matchers: cfg.ErrorMatchers,
+	}
+
+	configWatcher := config.NewWatcher(os.Getenv, configPath, cfg, logger)
+	configWatcher.OnReload(func(cfg config.Config) {
+		errorFinder.SetMatchers(cfg.ErrorMatchers)
+	})
+
+	fileSystem := &afero.Afero{Fs: afero.NewOsFs()}
+
+	deploymentHistory, err := history.NewHistory(fileSystem, os.Getenv(historyFileEnvVarName), logger)
+	if err != nil {
+		return Creator{}, err
+	}
+	eventManager.AddHandler(deploymentHistory, C.DeploySuccessEvent)
+
+	tokens, err := token.NewStore(fileSystem, os.Getenv(tokenFileEnvVarName), logger)
+	if err != nil {
+		return Creator{}, err
+	}
+
+	creator := Creator{
 		cfg,
+		configWatcher,
+		errorFinder,
+		deploymentHistory,
 		eventManager,
 		logger,
 		os.Stdout,
-		&afero.Afero{Fs: afero.NewOsFs()},
-	}, nil
+		fileSystem,
+		nil,
+		tokens,
+		os.Getenv(adminTokenEnvVarName),
+		nil,
+		nil,
+		drain.NewDrain(logger),
+	}
+	creator.approvalGate = approval.NewGate(creator, logger)
+
+	if oidc.Enabled(cfg.OIDC) {
+		verifier, err := oidc.NewVerifier(cfg.OIDC, &http.Client{Timeout: jwksTimeout}, fileSystem, logger)
+		if err != nil {
+			return Creator{}, err
+		}
+		creator.jwtVerifier = verifier
+	}
+
+	if tlsconfig.Enabled(cfg.TLS) {
+		reloader, err := tlsconfig.NewReloader(cfg.TLS, fileSystem, logger)
+		if err != nil {
+			return Creator{}, err
+		}
+		creator.tlsReloader = reloader
+	}
+
+	return creator, nil
 
 }
 
@@ -1,7 +1,17 @@
 package creator
 
 import (
+	"bytes"
 	"os"
+	"strings"
+
+	"github.com/compozed/deployadactyl/controller"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher/courier"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher/courier/executor"
+	"github.com/compozed/deployadactyl/controller/openapi"
+	"github.com/compozed/deployadactyl/mocks"
+	S "github.com/compozed/deployadactyl/structs"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,6 +38,93 @@ var _ = Describe("Custom creator", func() {
 		Expect(creator.fileSystem).ToNot(BeNil())
 		Expect(creator.logger).ToNot(BeNil())
 		Expect(creator.writer).ToNot(BeNil())
+		Expect(creator.configWatcher).ToNot(BeNil())
+		Expect(creator.CreateDrain()).ToNot(BeNil())
+		Expect(creator.CreateConfig().Environments).To(Equal(creator.config.Environments))
+	})
+
+	It("listens on the bind address", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+		os.Setenv("DEPLOYADACTYL_BIND_ADDRESS", "127.0.0.1")
+		os.Setenv("PORT", "0")
+		defer os.Unsetenv("DEPLOYADACTYL_BIND_ADDRESS")
+		defer os.Unsetenv("PORT")
+
+		creator, err := Custom("DEBUG", "./testconfig.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		listener := creator.CreateListener()
+		defer listener.Close()
+
+		Expect(listener.Addr().String()).To(HavePrefix("127.0.0.1:"))
+		Expect(creator.CreateTLSReloader()).To(BeNil())
+	})
+
+	It("fails when the TLS certificate cannot be loaded", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+		os.Setenv("DEPLOYADACTYL_TLS_CERT_FILE", "./missing-cert.pem")
+		os.Setenv("DEPLOYADACTYL_TLS_KEY_FILE", "./missing-key.pem")
+		defer os.Unsetenv("DEPLOYADACTYL_TLS_CERT_FILE")
+		defer os.Unsetenv("DEPLOYADACTYL_TLS_KEY_FILE")
+
+		_, err := Custom("DEBUG", "./testconfig.yml")
+
+		Expect(err).To(MatchError(ContainSubstring("cannot load TLS certificate ./missing-cert.pem")))
+	})
+
+	It("resolves the service accounts of the environments the janitor sweeps", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+
+		creator, err := Custom("DEBUG", "./testconfig.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, environments := creator.JanitorConfig()
+
+		foundation := environments["sandbox"].GetFoundation("https://api.cf.sandbox-mpn.ro98.allstate.com")
+		Expect(foundation.ServiceAccount.Username).To(Equal("test user"))
+		Expect(foundation.ServiceAccount.Password).To(Equal("test pwd"))
+		Expect(foundation.SkipSSL).To(BeTrue())
+	})
+
+	It("creates pushers that do not run commands that change the foundation in a dry run", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+
+		creator, err := Custom("DEBUG", "./testconfig.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		response := &bytes.Buffer{}
+		p, err := creator.CreatePusher(S.DeploymentInfo{DryRun: true}, S.Foundation{}, response)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(p.(*pusher.Pusher).Courier.(courier.Courier).Executor).To(BeAssignableToTypeOf(&executor.DryRun{}))
+	})
+
+	It("describes every route of the controller handler in the OpenAPI document", func() {
+		os.Setenv("CF_USERNAME", "test user")
+		os.Setenv("CF_PASSWORD", "test pwd")
+
+		creator, err := Custom("DEBUG", "./testconfig.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		document := controller.APIDocument(routes)
+
+		registered := 0
+		for _, route := range creator.CreateControllerHandler(&mocks.Controller{}).Routes() {
+			path, _ := openapi.Path(route.Path)
+			Expect(document.Paths).To(HaveKey(path))
+			Expect(document.Paths[path]).To(HaveKey(strings.ToLower(route.Method)), route.Method+" "+route.Path)
+			registered++
+		}
+
+		documented := 0
+		for _, item := range document.Paths {
+			documented += len(item)
+		}
+		Expect(documented).To(Equal(registered))
 	})
 
 	It("fails due to lack of required env variables", func() {
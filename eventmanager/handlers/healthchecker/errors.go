@@ -2,6 +2,7 @@ package healthchecker
 
 import (
 	"fmt"
+	"time"
 )
 
 type HealthCheckError struct {
@@ -64,3 +65,12 @@ type WrongEventTypeError struct {
 func (e WrongEventTypeError) Error() string {
 	return fmt.Sprintf("wrong event type for healthchecker: %s", e.Type)
 }
+
+type TimeoutError struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("no response from %s after %s", e.URL, e.Timeout)
+}
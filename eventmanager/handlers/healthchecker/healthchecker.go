@@ -1,11 +1,14 @@
 package healthchecker
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	C "github.com/compozed/deployadactyl/constants"
 	I "github.com/compozed/deployadactyl/interfaces"
@@ -27,6 +30,14 @@ type HealthChecker struct {
 	SilentDeployURL         string
 	SilentDeployEnvironment string
 
+	// Timeout limits how long Check waits for a response. It is set from the
+	// foundation configuration in OnEvent. Zero waits as long as the Client does.
+	Timeout time.Duration
+
+	// Foundation is the label of the foundation that is checked, used in the logs.
+	// It is set from the foundation configuration in OnEvent.
+	Foundation string
+
 	Client  I.Client
 	Courier I.Courier
 	Log     I.Logger
@@ -45,6 +56,7 @@ func (h HealthChecker) OnEvent(event I.Event) error {
 		tempAppWithUUID  = event.Data.(S.PushEventData).TempAppWithUUID
 		foundationURL    = event.Data.(S.PushEventData).FoundationURL
 		deploymentInfo   = event.Data.(S.PushEventData).DeploymentInfo
+		foundation       = event.Data.(S.PushEventData).Foundation
 		newFoundationURL string
 		domain           string
 	)
@@ -54,10 +66,14 @@ func (h HealthChecker) OnEvent(event I.Event) error {
 	}
 
 	h.Courier = event.Data.(S.PushEventData).Courier.(I.Courier)
+	h.Timeout = time.Duration(foundation.Timeout) * time.Second
+	h.Foundation = foundation.Label()
 
-	h.Log.Debugf("starting health check")
+	h.Log.Debugf("starting health check on foundation %s", h.Foundation)
 
-	if event.Data.(S.PushEventData).DeploymentInfo.Environment != h.SilentDeployEnvironment {
+	if foundation.AppsDomain != "" {
+		domain = foundation.AppsDomain
+	} else if event.Data.(S.PushEventData).DeploymentInfo.Environment != h.SilentDeployEnvironment {
 		newFoundationURL = strings.Replace(foundationURL, h.OldURL, h.NewURL, 1)
 		domain = regexp.MustCompile(fmt.Sprintf("%s.*", h.NewURL)).FindString(newFoundationURL)
 	} else {
@@ -74,7 +90,17 @@ func (h HealthChecker) OnEvent(event I.Event) error {
 	defer h.deleteTemporaryRoute(tempAppWithUUID, domain)
 	defer h.unmapTemporaryRoute(tempAppWithUUID, domain)
 
-	newFoundationURL = strings.Replace(newFoundationURL, h.NewURL, fmt.Sprintf("%s.%s", tempAppWithUUID, h.NewURL), 1)
+	if foundation.AppsDomain != "" {
+		newFoundationURL = fmt.Sprintf("%s://%s.%s", getScheme(foundationURL), tempAppWithUUID, domain)
+	} else {
+		newFoundationURL = strings.Replace(newFoundationURL, h.NewURL, fmt.Sprintf("%s.%s", tempAppWithUUID, h.NewURL), 1)
+	}
+
+	// A dry run did not push the application, so there is nothing to check.
+	if deploymentInfo.DryRun {
+		h.Log.Infof("not checking %s%s on foundation %s in a dry run", newFoundationURL, deploymentInfo.HealthCheckEndpoint, h.Foundation)
+		return nil
+	}
 
 	return h.Check(newFoundationURL, deploymentInfo.HealthCheckEndpoint)
 }
@@ -84,24 +110,57 @@ func (h HealthChecker) OnEvent(event I.Event) error {
 func (h HealthChecker) Check(url, endpoint string) error {
 	trimmedEndpoint := strings.TrimPrefix(endpoint, "/")
 
-	h.Log.Debugf("checking route %s%s", url, endpoint)
+	h.Log.Debugf("checking route %s%s on foundation %s", url, endpoint, h.Foundation)
 
-	resp, err := h.Client.Get(fmt.Sprintf("%s/%s", url, trimmedEndpoint))
+	ctx := context.Background()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	resp, err := h.get(ctx, fmt.Sprintf("%s/%s", url, trimmedEndpoint))
 	if err != nil {
-		h.Log.Error(ClientError{err})
+		h.Log.Errorf("health check failed on foundation %s: %s", h.Foundation, ClientError{err})
 		return ClientError{err}
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(resp.Body)
-		h.Log.Errorf("health check failed for %s/%s", url, trimmedEndpoint)
+		h.Log.Errorf("health check failed for %s/%s on foundation %s", url, trimmedEndpoint, h.Foundation)
 		return HealthCheckError{resp.StatusCode, endpoint, body}
 	}
 
-	h.Log.Infof("health check successful for %s%s", url, endpoint)
+	h.Log.Infof("health check successful for %s%s on foundation %s", url, endpoint, h.Foundation)
 	return nil
 }
 
+// get sends a GET request that is cancelled when the context is done.
+//
+// Returns a TimeoutError when the context times out before there is a response.
+func (h HealthChecker) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.Client.Do(req.WithContext(ctx))
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, TimeoutError{url, h.Timeout}
+	}
+
+	return resp, err
+}
+
+func getScheme(foundationURL string) string {
+	u, err := url.Parse(foundationURL)
+	if err != nil || u.Scheme == "" {
+		return "https"
+	}
+	return u.Scheme
+}
+
 func (h HealthChecker) mapTemporaryRoute(tempAppWithUUID, domain string) error {
 	h.Log.Debugf("mapping temporary route %s.%s", tempAppWithUUID, domain)
 
@@ -139,5 +198,5 @@ func (h HealthChecker) unmapTemporaryRoute(tempAppWithUUID, domain string) {
 		h.Log.Infof("unmapped temporary route %s.%s", tempAppWithUUID, domain)
 	}
 
-	h.Log.Infof("finished health check")
+	h.Log.Infof("finished health check on foundation %s", h.Foundation)
 }
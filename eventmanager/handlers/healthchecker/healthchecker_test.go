@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	C "github.com/compozed/deployadactyl/constants"
 	. "github.com/compozed/deployadactyl/eventmanager/handlers/healthchecker"
@@ -66,6 +67,7 @@ var _ = Describe("Healthchecker", func() {
 			Data: S.PushEventData{
 				TempAppWithUUID: randomAppName,
 				FoundationURL:   randomFoundationURL,
+				Foundation:      S.Foundation{URL: randomFoundationURL},
 				Courier:         courier,
 				DeploymentInfo: &S.DeploymentInfo{
 					HealthCheckEndpoint: randomEndpoint,
@@ -88,7 +90,7 @@ var _ = Describe("Healthchecker", func() {
 			Log:                     logger.DefaultLogger(logBuffer, logging.DEBUG, "healthchecker_test"),
 		}
 
-		client.GetCall.Returns.Response = http.Response{
+		client.DoCall.Returns.Response = http.Response{
 			StatusCode: http.StatusOK,
 			Body:       NewBuffer(),
 		}
@@ -98,7 +100,7 @@ var _ = Describe("Healthchecker", func() {
 		Context("the new build application is healthy", func() {
 			Context("the endpoint provided is valid", func() {
 				It("does not return an error", func() {
-					client.GetCall.Returns.Response = http.Response{StatusCode: http.StatusOK}
+					client.DoCall.Returns.Response = http.Response{StatusCode: http.StatusOK}
 
 					err := healthchecker.OnEvent(event)
 
@@ -116,7 +118,7 @@ var _ = Describe("Healthchecker", func() {
 				It("formats the foundation url", func() {
 					healthchecker.OnEvent(event)
 
-					Expect(client.GetCall.Received.URL).To(Equal(fmt.Sprintf("https://%s.%s%s", randomAppName, randomDomain, randomEndpoint)))
+					Expect(client.DoCall.Received.URL).To(Equal(fmt.Sprintf("https://%s.%s%s", randomAppName, randomDomain, randomEndpoint)))
 				})
 
 				It("unmaps the temporary route", func() {
@@ -141,7 +143,7 @@ var _ = Describe("Healthchecker", func() {
 				})
 
 				It("prints success logs to the console", func() {
-					client.GetCall.Returns.Response = http.Response{StatusCode: http.StatusOK}
+					client.DoCall.Returns.Response = http.Response{StatusCode: http.StatusOK}
 
 					healthchecker.OnEvent(event)
 
@@ -172,9 +174,73 @@ var _ = Describe("Healthchecker", func() {
 				})
 			})
 
+			Context("when the foundation has an apps domain configured", func() {
+				var appsDomain string
+
+				BeforeEach(func() {
+					appsDomain = "apps-" + randomizer.StringRunes(10) + ".example.com"
+
+					data := event.Data.(S.PushEventData)
+					data.Foundation = S.Foundation{URL: randomFoundationURL, AppsDomain: appsDomain}
+					event.Data = data
+				})
+
+				It("maps the temporary route on the apps domain", func() {
+					Expect(healthchecker.OnEvent(event)).To(Succeed())
+
+					Expect(courier.MapRouteCall.Received.Domain[0]).To(Equal(appsDomain))
+					Expect(courier.DeleteRouteCall.Received.Domain).To(Equal(appsDomain))
+				})
+
+				It("checks the application on the apps domain", func() {
+					Expect(healthchecker.OnEvent(event)).To(Succeed())
+
+					Expect(client.DoCall.Received.URL).To(Equal(fmt.Sprintf("https://%s.%s%s", randomAppName, appsDomain, randomEndpoint)))
+				})
+			})
+
+			Context("when the foundation has a timeout configured", func() {
+				It("returns an error if the application does not respond in time", func() {
+					data := event.Data.(S.PushEventData)
+					data.Foundation = S.Foundation{URL: randomFoundationURL, Timeout: 1}
+					event.Data = data
+
+					client.DoCall.Delay = 2 * time.Second
+
+					start := time.Now()
+					err := healthchecker.OnEvent(event)
+
+					Expect(err).To(BeAssignableToTypeOf(ClientError{}))
+					Expect(err.Error()).To(ContainSubstring("no response from"))
+					Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+				})
+			})
+
+			Context("when the foundation has a name", func() {
+				It("logs the name of the foundation", func() {
+					data := event.Data.(S.PushEventData)
+					data.Foundation = S.Foundation{URL: randomFoundationURL, Name: "east"}
+					event.Data = data
+
+					Expect(healthchecker.OnEvent(event)).To(Succeed())
+
+					Expect(logBuffer).To(Say("starting health check on foundation east"))
+					Expect(logBuffer).To(Say("health check successful for .* on foundation east"))
+				})
+			})
+
+			It("closes the response body", func() {
+				body := NewBuffer()
+				client.DoCall.Returns.Response = http.Response{StatusCode: http.StatusOK, Body: body}
+
+				Expect(healthchecker.OnEvent(event)).To(Succeed())
+
+				Expect(body.Closed()).To(BeTrue())
+			})
+
 			Context("the endpoint provided is not valid", func() {
 				BeforeEach(func() {
-					client.GetCall.Returns.Response = http.Response{
+					client.DoCall.Returns.Response = http.Response{
 						StatusCode: http.StatusNotFound,
 						Body:       NewBuffer(),
 					}
@@ -186,7 +252,7 @@ var _ = Describe("Healthchecker", func() {
 					buf := NewBuffer()
 					buf.Write(body)
 
-					client.GetCall.Returns.Response = http.Response{
+					client.DoCall.Returns.Response = http.Response{
 						StatusCode: http.StatusNotFound,
 						Body:       buf,
 					}
@@ -209,9 +275,33 @@ var _ = Describe("Healthchecker", func() {
 			})
 		})
 
+		Context("when the deployment is a dry run", func() {
+			BeforeEach(func() {
+				event.Data.(S.PushEventData).DeploymentInfo.DryRun = true
+			})
+
+			It("does not check the application", func() {
+				client.DoCall.Returns.Response = http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       NewBuffer(),
+				}
+
+				Expect(healthchecker.OnEvent(event)).To(Succeed())
+				Expect(client.DoCall.Received.URL).To(BeEmpty())
+			})
+
+			It("still maps, unmaps and deletes the temporary route", func() {
+				healthchecker.OnEvent(event)
+
+				Expect(courier.MapRouteCall.Received.AppName[0]).To(Equal(randomAppName))
+				Expect(courier.UnmapRouteCall.Received.AppName).To(Equal(randomAppName))
+				Expect(courier.DeleteRouteCall.Received.Hostname).To(Equal(randomAppName))
+			})
+		})
+
 		Context("the new build application is not healthy", func() {
 			It("returns an error", func() {
-				client.GetCall.Returns.Response = http.Response{
+				client.DoCall.Returns.Response = http.Response{
 					StatusCode: http.StatusNotFound,
 					Body:       NewBuffer(),
 				}
@@ -236,7 +326,7 @@ var _ = Describe("Healthchecker", func() {
 
 		Context("when the client fails to send the GET", func() {
 			It("returns an error", func() {
-				client.GetCall.Returns.Error = errors.New("client GET error")
+				client.DoCall.Returns.Error = errors.New("client GET error")
 
 				err := healthchecker.OnEvent(event)
 
@@ -244,7 +334,7 @@ var _ = Describe("Healthchecker", func() {
 			})
 
 			It("prints the error to the logs", func() {
-				client.GetCall.Returns.Error = errors.New("client GET error")
+				client.DoCall.Returns.Error = errors.New("client GET error")
 
 				healthchecker.OnEvent(event)
 
@@ -305,7 +395,7 @@ var _ = Describe("Healthchecker", func() {
 
 				healthchecker.Check(randomFoundationURL, endpoint)
 
-				Expect(client.GetCall.Received.URL).To(Equal(fmt.Sprintf("%s/%s", randomFoundationURL, endpoint)))
+				Expect(client.DoCall.Received.URL).To(Equal(fmt.Sprintf("%s/%s", randomFoundationURL, endpoint)))
 			})
 		})
 
@@ -315,7 +405,7 @@ var _ = Describe("Healthchecker", func() {
 
 				healthchecker.Check(randomFoundationURL, endpoint)
 
-				Expect(client.GetCall.Received.URL).To(Equal(fmt.Sprintf("%s%s", randomFoundationURL, endpoint)))
+				Expect(client.DoCall.Received.URL).To(Equal(fmt.Sprintf("%s%s", randomFoundationURL, endpoint)))
 			})
 		})
 	})
@@ -0,0 +1,29 @@
+package history
+
+import "fmt"
+
+type WrongEventTypeError struct {
+	Type string
+}
+
+func (e WrongEventTypeError) Error() string {
+	return fmt.Sprintf("wrong event type for history: %s", e.Type)
+}
+
+type LoadError struct {
+	Path string
+	Err  error
+}
+
+func (e LoadError) Error() string {
+	return fmt.Sprintf("cannot load the deployment history from %s: %s", e.Path, e.Err)
+}
+
+type SaveError struct {
+	Path string
+	Err  error
+}
+
+func (e SaveError) Error() string {
+	return fmt.Sprintf("cannot save the deployment history to %s: %s", e.Path, e.Err)
+}
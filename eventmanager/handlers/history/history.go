@@ -0,0 +1,133 @@
+// Package history records the successful deployments of every application.
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	C "github.com/compozed/deployadactyl/constants"
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// History keeps the latest successful deployment of every application in every environment.
+// When it has a path the deployments are saved to a file, so they are kept across restarts.
+type History struct {
+	fileSystem  *afero.Afero
+	path        string
+	log         I.Logger
+	mutex       sync.RWMutex
+	deployments map[string]S.DeploymentRecord
+}
+
+// NewHistory returns a History with the deployments saved at path. An empty path keeps
+// the deployments in memory only.
+//
+// Returns a LoadError if the file exists but cannot be read.
+func NewHistory(fileSystem *afero.Afero, path string, log I.Logger) (*History, error) {
+	h := &History{
+		fileSystem:  fileSystem,
+		path:        path,
+		log:         log,
+		deployments: map[string]S.DeploymentRecord{},
+	}
+
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := fileSystem.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, LoadError{path, err}
+	}
+
+	var records []S.DeploymentRecord
+	err = json.Unmarshal(data, &records)
+	if err != nil {
+		return nil, LoadError{path, err}
+	}
+
+	for _, record := range records {
+		h.deployments[key(record.Environment, record.Org, record.Space, record.AppName)] = record
+	}
+
+	return h, nil
+}
+
+// OnEvent records the deployment of a DeploySuccessEvent. Dry runs are not recorded.
+func (h *History) OnEvent(event I.Event) error {
+	if event.Type != C.DeploySuccessEvent {
+		return WrongEventTypeError{event.Type}
+	}
+
+	deploymentInfo := event.Data.(S.DeployEventData).DeploymentInfo
+	if deploymentInfo == nil || deploymentInfo.DryRun {
+		return nil
+	}
+
+	record := S.DeploymentRecord{
+		UUID:                 deploymentInfo.UUID,
+		Environment:          deploymentInfo.Environment,
+		Org:                  deploymentInfo.Org,
+		Space:                deploymentInfo.Space,
+		AppName:              deploymentInfo.AppName,
+		ArtifactURL:          deploymentInfo.ArtifactURL,
+		ArtifactChecksum:     deploymentInfo.ArtifactChecksum,
+		Manifest:             deploymentInfo.Manifest,
+		HealthCheckEndpoint:  deploymentInfo.HealthCheckEndpoint,
+		EnvironmentVariables: deploymentInfo.EnvironmentVariables,
+		Username:             deploymentInfo.Username,
+		DeployedAt:           time.Now().UTC(),
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.deployments[key(record.Environment, record.Org, record.Space, record.AppName)] = record
+	h.log.Infof("recorded deployment %s of %s", record.UUID, record.AppName)
+
+	return h.save()
+}
+
+// Latest returns the latest successful deployment of an application.
+func (h *History) Latest(environment, org, space, appName string) (S.DeploymentRecord, bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	record, ok := h.deployments[key(environment, org, space, appName)]
+	return record, ok
+}
+
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	records := make([]S.DeploymentRecord, 0, len(h.deployments))
+	for _, record := range h.deployments {
+		records = append(records, record)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return SaveError{h.path, err}
+	}
+
+	err = h.fileSystem.WriteFile(h.path, data, 0600)
+	if err != nil {
+		return SaveError{h.path, err}
+	}
+
+	return nil
+}
+
+func key(environment, org, space, appName string) string {
+	return strings.Join([]string{strings.ToLower(environment), org, space, appName}, "/")
+}
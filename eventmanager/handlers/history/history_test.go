@@ -0,0 +1,154 @@
+package history_test
+
+import (
+	"time"
+
+	C "github.com/compozed/deployadactyl/constants"
+	. "github.com/compozed/deployadactyl/eventmanager/handlers/history"
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/randomizer"
+	S "github.com/compozed/deployadactyl/structs"
+	logging "github.com/op/go-logging"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var (
+		fileSystem     *afero.Afero
+		log            I.Logger
+		deploymentInfo S.DeploymentInfo
+		event          I.Event
+		historyPath    string
+	)
+
+	BeforeEach(func() {
+		fileSystem = &afero.Afero{Fs: afero.NewMemMapFs()}
+		log = logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "history_test")
+		historyPath = "/history-" + randomizer.StringRunes(10) + ".json"
+
+		deploymentInfo = S.DeploymentInfo{
+			UUID:                 "uuid-" + randomizer.StringRunes(10),
+			Environment:          "Production",
+			Org:                  "org",
+			Space:                "space",
+			AppName:              "appName",
+			Username:             "username",
+			Password:             "password",
+			ArtifactURL:          "https://example.com/artifact.jar",
+			ArtifactChecksum:     "checksum",
+			Manifest:             "manifest",
+			HealthCheckEndpoint:  "/health",
+			EnvironmentVariables: map[string]string{"KEY": "value"},
+		}
+
+		event = I.Event{Type: C.DeploySuccessEvent, Data: S.DeployEventData{DeploymentInfo: &deploymentInfo}}
+	})
+
+	It("records the latest successful deployment of an application", func() {
+		history, err := NewHistory(fileSystem, "", log)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(history.OnEvent(event)).To(Succeed())
+
+		record, ok := history.Latest("production", "org", "space", "appName")
+		Expect(ok).To(BeTrue())
+
+		Expect(record.UUID).To(Equal(deploymentInfo.UUID))
+		Expect(record.ArtifactURL).To(Equal("https://example.com/artifact.jar"))
+		Expect(record.ArtifactChecksum).To(Equal("checksum"))
+		Expect(record.Manifest).To(Equal("manifest"))
+		Expect(record.HealthCheckEndpoint).To(Equal("/health"))
+		Expect(record.EnvironmentVariables).To(Equal(map[string]string{"KEY": "value"}))
+		Expect(record.Username).To(Equal("username"))
+		Expect(record.DeployedAt).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	It("replaces the previous deployment of an application", func() {
+		history, err := NewHistory(fileSystem, "", log)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(history.OnEvent(event)).To(Succeed())
+
+		deploymentInfo.UUID = "newer-uuid"
+		Expect(history.OnEvent(event)).To(Succeed())
+
+		record, _ := history.Latest("production", "org", "space", "appName")
+		Expect(record.UUID).To(Equal("newer-uuid"))
+	})
+
+	It("does not find applications that were never deployed", func() {
+		history, err := NewHistory(fileSystem, "", log)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(history.OnEvent(event)).To(Succeed())
+
+		_, ok := history.Latest("production", "org", "space", "anotherApp")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("does not record dry runs", func() {
+		history, err := NewHistory(fileSystem, "", log)
+		Expect(err).ToNot(HaveOccurred())
+
+		deploymentInfo.DryRun = true
+		Expect(history.OnEvent(event)).To(Succeed())
+
+		_, ok := history.Latest("production", "org", "space", "appName")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("returns an error for other events", func() {
+		history, err := NewHistory(fileSystem, "", log)
+		Expect(err).ToNot(HaveOccurred())
+
+		event.Type = C.DeployFailureEvent
+
+		Expect(history.OnEvent(event)).To(MatchError(WrongEventTypeError{C.DeployFailureEvent}))
+	})
+
+	Context("when it has a path", func() {
+		It("loads the deployments that were saved", func() {
+			history, err := NewHistory(fileSystem, historyPath, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(history.OnEvent(event)).To(Succeed())
+
+			loaded, err := NewHistory(fileSystem, historyPath, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			record, ok := loaded.Latest("production", "org", "space", "appName")
+			Expect(ok).To(BeTrue())
+			Expect(record.UUID).To(Equal(deploymentInfo.UUID))
+		})
+
+		It("does not save the password", func() {
+			history, err := NewHistory(fileSystem, historyPath, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(history.OnEvent(event)).To(Succeed())
+
+			data, err := fileSystem.ReadFile(historyPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).ToNot(ContainSubstring("password"))
+		})
+
+		It("returns an error when the file cannot be loaded", func() {
+			Expect(fileSystem.WriteFile(historyPath, []byte("not json"), 0600)).To(Succeed())
+
+			_, err := NewHistory(fileSystem, historyPath, log)
+
+			Expect(err).To(BeAssignableToTypeOf(LoadError{}))
+		})
+
+		It("returns an error when the file cannot be saved", func() {
+			history, err := NewHistory(&afero.Afero{Fs: afero.NewReadOnlyFs(afero.NewMemMapFs())}, historyPath, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(history.OnEvent(event)).To(BeAssignableToTypeOf(SaveError{}))
+		})
+	})
+})
@@ -26,3 +26,18 @@ type ReadFileError struct {
 func (e ReadFileError) Error() string {
 	return fmt.Sprintf("failed to read manifest file: %s", e.Err.Error())
 }
+
+type DeleteRouteError struct {
+	Route string
+	Out   []byte
+}
+
+func (e DeleteRouteError) Error() string {
+	return fmt.Sprintf("failed to delete route: %s: %s", e.Route, string(e.Out))
+}
+
+type RouteNotFoundError struct{}
+
+func (e RouteNotFoundError) Error() string {
+	return "the route was not found in the routes of the application"
+}
@@ -1,14 +1,22 @@
 package routemapper
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
+	C "github.com/compozed/deployadactyl/constants"
 	I "github.com/compozed/deployadactyl/interfaces"
 	S "github.com/compozed/deployadactyl/structs"
 	"github.com/spf13/afero"
 )
 
+// InternalDomain is the domain used for container to container networking.
+// It is always considered a domain of the foundation.
+const InternalDomain = "apps.internal"
+
 // RouteMapper will map additional routes to an application at
 // deploy time if they are specified in the manifest.
 type RouteMapper struct {
@@ -23,16 +31,91 @@ type manifest struct {
 
 type application struct {
 	CustomRoutes []route `yaml:"custom-routes"`
+	Routes       []route
 }
 
 type route struct {
 	Route string
 }
 
+// routes returns the custom-routes and the standard Cloud Foundry routes of an application.
+func (a application) routes() []route {
+	return append(append([]route{}, a.CustomRoutes...), a.Routes...)
+}
+
+// ParsedRoute is a route split into the arguments of the Cloud Foundry route commands.
+// TCP routes have a port and no hostname or path.
+type ParsedRoute struct {
+	Hostname string
+	Domain   string
+	Path     string
+	Port     int
+}
+
+// String returns the route the way Cloud Foundry shows it: host.domain/path or domain:port.
+func (p ParsedRoute) String() string {
+	route := p.Domain
+	if p.Hostname != "" {
+		route = p.Hostname + "." + route
+	}
+	if p.Port != 0 {
+		route = fmt.Sprintf("%s:%d", route, p.Port)
+	}
+	if p.Path != "" {
+		route = route + "/" + p.Path
+	}
+	return route
+}
+
+type routesResponse struct {
+	Resources []struct {
+		Metadata struct {
+			GUID string
+		}
+		Entity struct {
+			Host   string
+			Path   string
+			Port   int
+			Domain struct {
+				Entity struct {
+					Name string
+				}
+			}
+		}
+	}
+}
+
+type appsResponse struct {
+	Resources []struct {
+		Metadata struct {
+			GUID string
+		}
+	}
+}
+
 // OnEvent is triggered by the EventManager and maps additional
 // routes from the manifest. It will check if the route is a domain
 // in the foundation.
+//
+// On a PushFinishedEvent it maps the routes and reports the routes that will be
+// added to and removed from the application. On a FinishPushEvent it deletes the
+// routes of the original application that are no longer in the manifest.
 func (r RouteMapper) OnEvent(event I.Event) error {
+	r.Courier = event.Data.(S.PushEventData).Courier.(I.Courier)
+
+	if event.Type == C.FinishPushEvent {
+		return r.deleteOrphanedRoutes(event.Data.(S.PushEventData))
+	}
+
+	err := r.mapRoutes(event)
+	if err != nil {
+		return err
+	}
+
+	return r.reportRoutes(event.Data.(S.PushEventData))
+}
+
+func (r RouteMapper) mapRoutes(event I.Event) error {
 	r.Log.Debugf("starting route mapper")
 
 	var (
@@ -43,8 +126,6 @@ func (r RouteMapper) OnEvent(event I.Event) error {
 		err           error
 	)
 
-	r.Courier = event.Data.(S.PushEventData).Courier.(I.Courier)
-
 	manifestBytes, err = r.readManifest(deploymentInfo)
 	if err != nil || manifestBytes == nil {
 		return err
@@ -59,12 +140,12 @@ func (r RouteMapper) OnEvent(event I.Event) error {
 		return err
 	}
 
-	if m.Applications == nil || len(m.Applications[0].CustomRoutes) == 0 {
+	if m.Applications == nil || len(m.Applications[0].routes()) == 0 {
 		r.Log.Info("finished mapping routes: no routes to map")
 		return nil
 	}
 
-	r.Log.Infof("found %d routes in the manifest", len(m.Applications[0].CustomRoutes))
+	r.Log.Infof("found %d routes in the manifest", len(m.Applications[0].routes()))
 
 	domains, _ := r.Courier.Domains()
 
@@ -72,15 +153,6 @@ func (r RouteMapper) OnEvent(event I.Event) error {
 	return r.routeMapper(m, tempAppWithUUID, domains, deploymentInfo)
 }
 
-func isRouteADomainInTheFoundation(route string, domains []string) bool {
-	for _, domain := range domains {
-		if route == domain {
-			return true
-		}
-	}
-	return false
-}
-
 func (r RouteMapper) readManifest(deploymentInfo *S.DeploymentInfo) ([]byte, error) {
 	var (
 		manifestBytes []byte
@@ -103,40 +175,35 @@ func (r RouteMapper) readManifest(deploymentInfo *S.DeploymentInfo) ([]byte, err
 }
 
 // routeMapper is used to decide how to map an applications routes that are given to it from the manifest.
-// if the route does not include appname or path it will map the given domain to the given application by default
-// if the route has an app name it will remove the app name so it can map it with the given domain
-// if the route has an app name and a path it will remove the app name so it can map it with the given domain and the path as well
+// if the route is a domain it will map the domain with the app name as the hostname
+// if the route has a hostname it will map the hostname with the domain, including a wildcard hostname
+// if the route has a hostname and a path it will map the hostname with the domain and the path as well
+// if the route has a port it will map the domain as a TCP route with the port
 func (r RouteMapper) routeMapper(manifest *manifest, tempAppWithUUID string, domains []string, deploymentInfo *S.DeploymentInfo) error {
 
-	for _, route := range manifest.Applications[0].CustomRoutes {
-		var domainAndPath []string
-
-		appNameAndDomain := strings.SplitN(route.Route, ".", 2)
-
-		if len(appNameAndDomain) >= 2 {
-			domainAndPath = strings.SplitN(appNameAndDomain[1], "/", 2)
+	for _, route := range manifest.Applications[0].routes() {
+		p, ok := ParseRoute(route.Route, domains)
+		if !ok {
+			return InvalidRouteError{route.Route}
 		}
 
-		if isRouteADomainInTheFoundation(route.Route, domains) {
-			output, err := r.Courier.MapRoute(tempAppWithUUID, route.Route, deploymentInfo.AppName)
-			if err != nil {
-				r.Log.Errorf("failed to map route: %s: %s", route.Route, string(output))
-				return MapRouteError{route.Route, output}
-			}
-		} else if len(appNameAndDomain) >= 2 && isRouteADomainInTheFoundation(appNameAndDomain[1], domains) {
-			output, err := r.Courier.MapRoute(tempAppWithUUID, appNameAndDomain[1], appNameAndDomain[0])
-			if err != nil {
-				r.Log.Errorf("failed to map route: %s: %s", route.Route, string(output))
-				return MapRouteError{route.Route, output}
-			}
-		} else if domainAndPath != nil && isRouteADomainInTheFoundation(domainAndPath[0], domains) {
-			output, err := r.Courier.MapRouteWithPath(tempAppWithUUID, domainAndPath[0], appNameAndDomain[0], domainAndPath[1])
-			if err != nil {
-				r.Log.Error(MapRouteError{route.Route, output})
-				return MapRouteError{route.Route, output}
-			}
+		var (
+			output []byte
+			err    error
+		)
+
+		if p.Port != 0 {
+			output, err = r.Courier.MapRouteWithPort(tempAppWithUUID, p.Domain, p.Port)
+		} else if p.Hostname == "" {
+			output, err = r.Courier.MapRoute(tempAppWithUUID, p.Domain, deploymentInfo.AppName)
+		} else if p.Path != "" {
+			output, err = r.Courier.MapRouteWithPath(tempAppWithUUID, p.Domain, p.Hostname, p.Path)
 		} else {
-			return InvalidRouteError{route.Route}
+			output, err = r.Courier.MapRoute(tempAppWithUUID, p.Domain, p.Hostname)
+		}
+		if err != nil {
+			r.Log.Errorf("failed to map route: %s: %s", route.Route, string(output))
+			return MapRouteError{route.Route, output}
 		}
 
 		r.Log.Infof("mapped route %s to %s", route.Route, tempAppWithUUID)
@@ -145,3 +212,284 @@ func (r RouteMapper) routeMapper(manifest *manifest, tempAppWithUUID string, dom
 	r.Log.Info("route mapping successful: finished mapping routes")
 	return nil
 }
+
+// ManifestRoutes returns the routes of the first application in a manifest from both
+// the routes and custom-routes keys.
+func ManifestRoutes(manifestBytes []byte) ([]string, error) {
+	m := &manifest{}
+
+	err := candiedyaml.Unmarshal(manifestBytes, m)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []string{}
+	if len(m.Applications) > 0 {
+		for _, route := range m.Applications[0].routes() {
+			routes = append(routes, route.Route)
+		}
+	}
+
+	return routes, nil
+}
+
+// reportRoutes compares the routes of the original application with the routes of
+// the newly pushed application and writes the differences to the response.
+func (r RouteMapper) reportRoutes(data S.PushEventData) error {
+	appName := data.DeploymentInfo.AppName
+
+	if !r.Courier.Exists(appName) {
+		return nil
+	}
+
+	oldRoutes, newRoutes, err := r.getRoutes(appName, data.TempAppWithUUID)
+	if err != nil {
+		r.Log.Errorf("failed to compare routes: %s", err.Error())
+		return nil
+	}
+
+	added := difference(newRoutes, oldRoutes)
+	removed := difference(oldRoutes, newRoutes)
+
+	r.Log.Infof("routes added to %s: %s", appName, strings.Join(added, ", "))
+	r.Log.Infof("routes removed from %s: %s", appName, strings.Join(removed, ", "))
+
+	if data.Response != nil {
+		fmt.Fprintf(data.Response, "routes added to %s: %s\n", appName, strings.Join(added, ", "))
+		fmt.Fprintf(data.Response, "routes removed from %s: %s\n", appName, strings.Join(removed, ", "))
+	}
+
+	return nil
+}
+
+// deleteOrphanedRoutes deletes the routes of the original application that are no longer
+// in the manifest. Routes that are mapped to the newly pushed application or to any other
+// application are kept. Failing to delete a route does not fail the deployment.
+func (r RouteMapper) deleteOrphanedRoutes(data S.PushEventData) error {
+	r.Log.Debugf("starting orphaned route removal")
+
+	appName := data.DeploymentInfo.AppName
+
+	manifestBytes, err := r.readManifest(data.DeploymentInfo)
+	if err != nil || manifestBytes == nil {
+		r.Log.Info("finished removing routes: no manifest found")
+		return nil
+	}
+
+	manifestRoutes, err := ManifestRoutes(manifestBytes)
+	if err != nil {
+		r.Log.Errorf("failed to find orphaned routes: failed to parse manifest: %s", err.Error())
+		return nil
+	}
+
+	if len(manifestRoutes) == 0 {
+		r.Log.Info("finished removing routes: no routes in the manifest")
+		return nil
+	}
+
+	oldRoutes, newRoutes, err := r.getRoutes(appName, data.TempAppWithUUID)
+	if err != nil {
+		r.Log.Errorf("failed to find orphaned routes: %s", err.Error())
+		return nil
+	}
+
+	domains, _ := r.Courier.Domains()
+
+	desiredRoutes := append([]string{}, newRoutes...)
+	for _, route := range manifestRoutes {
+		p, ok := ParseRoute(route, domains)
+		if !ok {
+			continue
+		}
+
+		// routes that are only a domain are mapped with the application name as the hostname
+		if p.Hostname == "" && p.Port == 0 {
+			p.Hostname = appName
+		}
+
+		desiredRoutes = append(desiredRoutes, p.String())
+	}
+
+	orphanedRoutes := difference(oldRoutes, desiredRoutes)
+	if len(orphanedRoutes) == 0 {
+		r.Log.Info("finished removing routes: no orphaned routes")
+		return nil
+	}
+
+	appGUID, routeGUIDs, err := r.getRouteGUIDs(appName)
+	if err != nil {
+		r.Log.Errorf("failed to find orphaned routes: %s", err.Error())
+		return nil
+	}
+
+	for _, route := range orphanedRoutes {
+		p, ok := ParseRoute(route, domains)
+		if !ok {
+			r.Log.Errorf("failed to delete orphaned route: %s", InvalidRouteError{route})
+			continue
+		}
+
+		shared, err := r.isShared(routeGUIDs[p.String()], appGUID)
+		if err != nil {
+			r.Log.Errorf("failed to delete orphaned route: %s: %s", route, err.Error())
+			continue
+		}
+		if shared {
+			r.Log.Infof("not deleting orphaned route %s: it is mapped to other applications", route)
+			continue
+		}
+
+		var output []byte
+		if p.Port != 0 {
+			output, err = r.Courier.DeleteRouteWithPort(p.Domain, p.Port)
+		} else if p.Path != "" {
+			output, err = r.Courier.DeleteRouteWithPath(p.Domain, p.Hostname, p.Path)
+		} else {
+			output, err = r.Courier.DeleteRoute(p.Domain, p.Hostname)
+		}
+		if err != nil {
+			r.Log.Error(DeleteRouteError{route, output})
+			continue
+		}
+
+		r.Log.Infof("deleted orphaned route %s", route)
+		if data.Response != nil {
+			fmt.Fprintf(data.Response, "deleted orphaned route %s\n", route)
+		}
+	}
+
+	r.Log.Info("finished removing orphaned routes")
+	return nil
+}
+
+// getRouteGUIDs returns the GUID of an application and the GUIDs of its routes by route.
+func (r RouteMapper) getRouteGUIDs(appName string) (string, map[string]string, error) {
+	appGUID, err := r.Courier.AppGUID(appName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	output, err := r.Courier.Curl(fmt.Sprintf("/v2/apps/%s/routes?inline-relations-depth=1&results-per-page=100", appGUID))
+	if err != nil {
+		return "", nil, err
+	}
+
+	response := routesResponse{}
+	err = json.Unmarshal(output, &response)
+	if err != nil {
+		return "", nil, err
+	}
+
+	routeGUIDs := map[string]string{}
+	for _, resource := range response.Resources {
+		p := ParsedRoute{
+			Hostname: resource.Entity.Host,
+			Domain:   resource.Entity.Domain.Entity.Name,
+			Path:     strings.TrimPrefix(resource.Entity.Path, "/"),
+			Port:     resource.Entity.Port,
+		}
+		routeGUIDs[p.String()] = resource.Metadata.GUID
+	}
+
+	return appGUID, routeGUIDs, nil
+}
+
+// isShared returns true when a route is mapped to an application other than appGUID.
+func (r RouteMapper) isShared(routeGUID, appGUID string) (bool, error) {
+	if routeGUID == "" {
+		return false, RouteNotFoundError{}
+	}
+
+	output, err := r.Courier.Curl(fmt.Sprintf("/v2/routes/%s/apps", routeGUID))
+	if err != nil {
+		return false, err
+	}
+
+	response := appsResponse{}
+	err = json.Unmarshal(output, &response)
+	if err != nil {
+		return false, err
+	}
+
+	for _, resource := range response.Resources {
+		if resource.Metadata.GUID != appGUID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r RouteMapper) getRoutes(appName, tempAppWithUUID string) ([]string, []string, error) {
+	oldRoutes, err := r.Courier.Routes(appName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newRoutes, err := r.Courier.Routes(tempAppWithUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return oldRoutes, newRoutes, nil
+}
+
+// difference returns the routes in a that are not in b.
+func difference(a, b []string) []string {
+	routes := []string{}
+
+	for _, route := range a {
+		found := false
+		for _, r := range b {
+			if route == r {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			routes = append(routes, route)
+		}
+	}
+
+	return routes
+}
+
+// ParseRoute splits a route into its hostname, domain, path and port using the
+// longest domain of the foundation that matches the route. A route is one of
+// domain, host.domain, host.domain/path or domain:port.
+func ParseRoute(route string, domains []string) (ParsedRoute, bool) {
+	var p ParsedRoute
+
+	hostAndDomain := route
+	if i := strings.Index(route, "/"); i != -1 {
+		hostAndDomain, p.Path = route[:i], route[i+1:]
+	}
+
+	if i := strings.LastIndex(hostAndDomain, ":"); i != -1 {
+		port, err := strconv.Atoi(hostAndDomain[i+1:])
+		if err != nil || port <= 0 || p.Path != "" {
+			return ParsedRoute{}, false
+		}
+
+		hostAndDomain, p.Port = hostAndDomain[:i], port
+	}
+
+	for _, domain := range append(append([]string{}, domains...), InternalDomain) {
+		if domain == "" || len(domain) <= len(p.Domain) {
+			continue
+		}
+
+		if hostAndDomain == domain {
+			p.Hostname, p.Domain = "", domain
+		} else if strings.HasSuffix(hostAndDomain, "."+domain) {
+			p.Hostname, p.Domain = strings.TrimSuffix(hostAndDomain, "."+domain), domain
+		}
+	}
+
+	if p.Domain == "" || (p.Port != 0 && p.Hostname != "") {
+		return ParsedRoute{}, false
+	}
+
+	return p, true
+}
@@ -400,6 +400,166 @@ applications:
 		})
 	})
 
+	Context("when routes are in the standard routes key of the manifest", func() {
+		It("maps the routes", func() {
+			courier.DomainsCall.Returns.Domains = []string{randomDomain}
+
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s.%s
+  - route: %s.%s/%s`, randomHostName, randomDomain, randomHostName, randomDomain, randomPath)
+
+			Expect(routemapper.OnEvent(event)).To(Succeed())
+
+			Expect(courier.MapRouteCall.Received.Domain).To(Equal([]string{randomDomain}))
+			Expect(courier.MapRouteCall.Received.Hostname).To(Equal([]string{randomHostName}))
+			Expect(courier.MapRouteWithPathCall.Received.Domain).To(Equal([]string{randomDomain}))
+			Expect(courier.MapRouteWithPathCall.Received.Hostname).To(Equal([]string{randomHostName}))
+			Expect(courier.MapRouteWithPathCall.Received.Path).To(Equal([]string{randomPath}))
+		})
+
+		It("maps the routes of both the routes and custom-routes keys", func() {
+			courier.DomainsCall.Returns.Domains = []string{randomDomain}
+
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  custom-routes:
+  - route: %s0.%s
+  routes:
+  - route: %s1.%s`, randomHostName, randomDomain, randomHostName, randomDomain)
+
+			Expect(routemapper.OnEvent(event)).To(Succeed())
+
+			Expect(courier.MapRouteCall.Received.Hostname).To(Equal([]string{randomHostName + "0", randomHostName + "1"}))
+		})
+	})
+
+	Context("when a route in the manifest is a TCP route", func() {
+		var tcpDomain string
+
+		BeforeEach(func() {
+			tcpDomain = "tcp." + randomDomain
+			courier.DomainsCall.Returns.Domains = []string{randomDomain, tcpDomain}
+		})
+
+		It("maps the route with the port", func() {
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s:61000`, tcpDomain)
+
+			Expect(routemapper.OnEvent(event)).To(Succeed())
+
+			Expect(courier.MapRouteWithPortCall.Received.AppName).To(Equal([]string{randomTemporaryAppName}))
+			Expect(courier.MapRouteWithPortCall.Received.Domain).To(Equal([]string{tcpDomain}))
+			Expect(courier.MapRouteWithPortCall.Received.Port).To(Equal([]int{61000}))
+			Expect(courier.MapRouteCall.Received.Domain).To(BeEmpty())
+		})
+
+		It("returns an error when the port is not a number", func() {
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s:port`, tcpDomain)
+
+			err := routemapper.OnEvent(event)
+
+			Expect(err).To(MatchError(InvalidRouteError{tcpDomain + ":port"}))
+		})
+
+		It("returns an error when the route has a hostname", func() {
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s.%s:61000`, randomHostName, randomDomain)
+
+			err := routemapper.OnEvent(event)
+
+			Expect(err).To(MatchError(InvalidRouteError{fmt.Sprintf("%s.%s:61000", randomHostName, randomDomain)}))
+		})
+
+		It("returns an error when map route fails", func() {
+			courier.MapRouteWithPortCall.Returns.Output = []byte("map route output")
+			courier.MapRouteWithPortCall.Returns.Error = errors.New("map route error")
+
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s:61000`, tcpDomain)
+
+			err := routemapper.OnEvent(event)
+
+			Expect(err).To(MatchError(MapRouteError{tcpDomain + ":61000", []byte("map route output")}))
+		})
+	})
+
+	Context("when a route in the manifest has a wildcard hostname", func() {
+		It("maps the route with the wildcard hostname", func() {
+			courier.DomainsCall.Returns.Domains = []string{randomDomain}
+
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: "*.%s"`, randomDomain)
+
+			Expect(routemapper.OnEvent(event)).To(Succeed())
+
+			Expect(courier.MapRouteCall.Received.Domain).To(Equal([]string{randomDomain}))
+			Expect(courier.MapRouteCall.Received.Hostname).To(Equal([]string{"*"}))
+		})
+	})
+
+	Context("when a route in the manifest is an internal route", func() {
+		It("maps the route even if the internal domain is not listed in the foundation", func() {
+			courier.DomainsCall.Returns.Domains = []string{randomDomain}
+
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s.%s`, randomHostName, InternalDomain)
+
+			Expect(routemapper.OnEvent(event)).To(Succeed())
+
+			Expect(courier.MapRouteCall.Received.Domain).To(Equal([]string{InternalDomain}))
+			Expect(courier.MapRouteCall.Received.Hostname).To(Equal([]string{randomHostName}))
+		})
+	})
+
+	Context("when a route has a hostname with a domain that is a subdomain of another domain", func() {
+		It("uses the longest matching domain", func() {
+			courier.DomainsCall.Returns.Domains = []string{randomDomain, "sub." + randomDomain}
+
+			deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s.sub.%s`, randomHostName, randomDomain)
+
+			Expect(routemapper.OnEvent(event)).To(Succeed())
+
+			Expect(courier.MapRouteCall.Received.Domain).To(Equal([]string{"sub." + randomDomain}))
+			Expect(courier.MapRouteCall.Received.Hostname).To(Equal([]string{randomHostName}))
+		})
+	})
+
 	Context("when manifest is bundled with the application", func() {
 		It("reads the manifest file", func() {
 			courier.DomainsCall.Returns.Domains = []string{randomDomain}
@@ -453,4 +613,222 @@ name: hey`)
 			Eventually(logBuffer).Should(Say("no routes to map"))
 		})
 	})
+
+	Context("when the original application exists", func() {
+		var response *Buffer
+
+		BeforeEach(func() {
+			response = NewBuffer()
+
+			event.Data = S.PushEventData{
+				Courier:         courier,
+				TempAppWithUUID: randomTemporaryAppName,
+				FoundationURL:   randomFoundationURL,
+				DeploymentInfo:  deploymentInfo,
+				Response:        response,
+			}
+
+			courier.ExistsCall.Returns.Bool = true
+			courier.DomainsCall.Returns.Domains = []string{randomDomain}
+			courier.RoutesCall.Returns.Routes = map[string][]string{
+				randomAppName: {
+					fmt.Sprintf("%s.%s", randomAppName, randomDomain),
+					fmt.Sprintf("%s.%s", randomHostName, randomDomain),
+					fmt.Sprintf("%s.%s/%s", randomHostName, randomDomain, randomPath),
+				},
+				randomTemporaryAppName: {
+					fmt.Sprintf("%s.%s", randomAppName, randomDomain),
+					fmt.Sprintf("new-%s.%s", randomHostName, randomDomain),
+				},
+			}
+		})
+
+		Context("when a push is finished", func() {
+			It("reports the routes being added and removed", func() {
+				Expect(routemapper.OnEvent(event)).To(Succeed())
+
+				Expect(courier.RoutesCall.Received.AppName).To(Equal([]string{randomAppName, randomTemporaryAppName}))
+
+				Eventually(response).Should(Say(fmt.Sprintf("routes added to %s: new-%s.%s", randomAppName, randomHostName, randomDomain)))
+				Eventually(response).Should(Say(fmt.Sprintf("routes removed from %s: %s.%s, %s.%s/%s", randomAppName, randomHostName, randomDomain, randomHostName, randomDomain, randomPath)))
+			})
+
+			It("does not delete any routes", func() {
+				Expect(routemapper.OnEvent(event)).To(Succeed())
+
+				Expect(courier.DeleteRouteCall.Received.Domain).To(BeEmpty())
+				Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(0))
+			})
+
+			Context("when the routes cannot be found", func() {
+				It("only logs an error", func() {
+					courier.RoutesCall.Returns.Error = errors.New("routes error")
+
+					Expect(routemapper.OnEvent(event)).To(Succeed())
+
+					Eventually(logBuffer).Should(Say("failed to compare routes: routes error"))
+				})
+			})
+		})
+
+		Context("when a push is finishing", func() {
+			var routesPath string
+
+			BeforeEach(func() {
+				event.Type = C.FinishPushEvent
+
+				deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: new-%s.%s`, randomHostName, randomDomain)
+
+				courier.AppGUIDCall.Returns.GUID = "app-guid"
+				routesPath = "/v2/apps/app-guid/routes?inline-relations-depth=1&results-per-page=100"
+				courier.CurlCall.Returns.Output = map[string][]byte{
+					routesPath: []byte(fmt.Sprintf(`{"resources": [
+						{"metadata": {"guid": "route-0"}, "entity": {"host": "%s", "path": "", "port": null, "domain": {"entity": {"name": "%s"}}}},
+						{"metadata": {"guid": "route-1"}, "entity": {"host": "%s", "path": "", "port": null, "domain": {"entity": {"name": "%s"}}}},
+						{"metadata": {"guid": "route-2"}, "entity": {"host": "%s", "path": "/%s", "port": null, "domain": {"entity": {"name": "%s"}}}}
+					]}`, randomAppName, randomDomain, randomHostName, randomDomain, randomHostName, randomPath, randomDomain)),
+					"/v2/routes/route-1/apps": []byte(`{"resources": [{"metadata": {"guid": "app-guid"}}]}`),
+					"/v2/routes/route-2/apps": []byte(`{"resources": [{"metadata": {"guid": "app-guid"}}]}`),
+				}
+			})
+
+			It("deletes the routes of the application that are no longer in the manifest", func() {
+				Expect(routemapper.OnEvent(event)).To(Succeed())
+
+				Expect(courier.DeleteRouteCall.Received.Domain).To(Equal(randomDomain))
+				Expect(courier.DeleteRouteCall.Received.Hostname).To(Equal(randomHostName))
+
+				Expect(courier.DeleteRouteWithPathCall.Received.Domain).To(Equal([]string{randomDomain}))
+				Expect(courier.DeleteRouteWithPathCall.Received.Hostname).To(Equal([]string{randomHostName}))
+				Expect(courier.DeleteRouteWithPathCall.Received.Path).To(Equal([]string{randomPath}))
+
+				Eventually(response).Should(Say(fmt.Sprintf("deleted orphaned route %s.%s", randomHostName, randomDomain)))
+			})
+
+			It("does not map any routes", func() {
+				deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  custom-routes:
+  - route: %s.%s`, randomHostName, randomDomain)
+
+				Expect(routemapper.OnEvent(event)).To(Succeed())
+
+				Expect(courier.MapRouteCall.TimesCalled).To(Equal(0))
+			})
+
+			It("does not delete the routes that are still in the manifest", func() {
+				deploymentInfo.Manifest = fmt.Sprintf(`
+---
+applications:
+- name: example
+  routes:
+  - route: %s.%s/%s`, randomHostName, randomDomain, randomPath)
+
+				Expect(routemapper.OnEvent(event)).To(Succeed())
+
+				Expect(courier.DeleteRouteCall.Received.Hostname).To(Equal(randomHostName))
+				Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(0))
+			})
+
+			It("does not delete a route that is mapped to another application", func() {
+				courier.CurlCall.Returns.Output["/v2/routes/route-1/apps"] = []byte(`{"resources": [{"metadata": {"guid": "app-guid"}}, {"metadata": {"guid": "other-app-guid"}}]}`)
+
+				Expect(routemapper.OnEvent(event)).To(Succeed())
+
+				Expect(courier.CurlCall.Received.Path).To(ContainElement("/v2/routes/route-1/apps"))
+				Expect(courier.DeleteRouteCall.Received.Domain).To(BeEmpty())
+				Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(1))
+
+				Eventually(logBuffer).Should(Say(fmt.Sprintf("not deleting orphaned route %s.%s: it is mapped to other applications", randomHostName, randomDomain)))
+			})
+
+			Context("when there is no manifest", func() {
+				It("does not delete any routes", func() {
+					deploymentInfo.Manifest = ""
+
+					Expect(routemapper.OnEvent(event)).To(Succeed())
+
+					Expect(courier.DeleteRouteCall.Received.Domain).To(BeEmpty())
+					Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(0))
+
+					Eventually(logBuffer).Should(Say("no manifest found"))
+				})
+			})
+
+			Context("when the manifest has no routes", func() {
+				It("does not delete any routes", func() {
+					deploymentInfo.Manifest = "applications:\n- name: example\n"
+
+					Expect(routemapper.OnEvent(event)).To(Succeed())
+
+					Expect(courier.DeleteRouteCall.Received.Domain).To(BeEmpty())
+					Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(0))
+
+					Eventually(logBuffer).Should(Say("no routes in the manifest"))
+				})
+			})
+
+			It("deletes orphaned TCP routes with the port", func() {
+				tcpDomain := "tcp." + randomDomain
+				courier.DomainsCall.Returns.Domains = []string{randomDomain, tcpDomain}
+				courier.RoutesCall.Returns.Routes[randomAppName] = []string{tcpDomain + ":61000"}
+				courier.CurlCall.Returns.Output[routesPath] = []byte(fmt.Sprintf(`{"resources": [
+					{"metadata": {"guid": "route-3"}, "entity": {"host": "", "path": "", "port": 61000, "domain": {"entity": {"name": "%s"}}}}
+				]}`, tcpDomain))
+				courier.CurlCall.Returns.Output["/v2/routes/route-3/apps"] = []byte(`{"resources": [{"metadata": {"guid": "app-guid"}}]}`)
+
+				Expect(routemapper.OnEvent(event)).To(Succeed())
+
+				Expect(courier.DeleteRouteWithPortCall.Received.Domain).To(Equal([]string{tcpDomain}))
+				Expect(courier.DeleteRouteWithPortCall.Received.Port).To(Equal([]int{61000}))
+				Expect(courier.DeleteRouteCall.Received.Domain).To(BeEmpty())
+			})
+
+			Context("when there are no orphaned routes", func() {
+				It("does not delete any routes", func() {
+					courier.RoutesCall.Returns.Routes[randomTemporaryAppName] = courier.RoutesCall.Returns.Routes[randomAppName]
+
+					Expect(routemapper.OnEvent(event)).To(Succeed())
+
+					Expect(courier.DeleteRouteCall.Received.Domain).To(BeEmpty())
+					Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(0))
+
+					Eventually(logBuffer).Should(Say("no orphaned routes"))
+				})
+			})
+
+			Context("when the domain of a route is not in the foundation", func() {
+				It("does not delete the route", func() {
+					courier.DomainsCall.Returns.Domains = []string{"other-" + randomDomain}
+
+					Expect(routemapper.OnEvent(event)).To(Succeed())
+
+					Expect(courier.DeleteRouteCall.Received.Domain).To(BeEmpty())
+					Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(0))
+
+					Eventually(logBuffer).Should(Say("failed to delete orphaned route"))
+				})
+			})
+
+			Context("when deleting a route fails", func() {
+				It("logs an error and continues deleting routes", func() {
+					courier.DeleteRouteCall.Returns.Output = []byte("delete route output")
+					courier.DeleteRouteCall.Returns.Error = errors.New("delete route error")
+
+					Expect(routemapper.OnEvent(event)).To(Succeed())
+
+					Expect(courier.DeleteRouteWithPathCall.TimesCalled).To(Equal(1))
+
+					Eventually(logBuffer).Should(Say(fmt.Sprintf("failed to delete route: %s.%s: delete route output", randomHostName, randomDomain)))
+				})
+			})
+		})
+	})
 })
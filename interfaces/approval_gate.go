@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"io"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// ApprovalGate interface.
+type ApprovalGate interface {
+	Wait(environment S.Environment, deploymentInfo S.DeploymentInfo, response io.Writer) error
+	Approve(uuid, username, password string) error
+	Reject(uuid, username, password, reason string) error
+	Pending() []S.PendingDeployment
+}
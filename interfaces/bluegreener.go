@@ -15,3 +15,9 @@ type BlueGreener interface {
 		response io.ReadWriter,
 	) DeploymentError
 }
+
+// FoundationRecorder is implemented by responses that keep the result of a deployment on
+// every foundation, in addition to its output.
+type FoundationRecorder interface {
+	RecordFoundation(result S.FoundationResult)
+}
@@ -4,5 +4,5 @@ import "net/http"
 
 // Client is an interface for http.Client.
 type Client interface {
-	Get(url string) (*http.Response, error)
+	Do(req *http.Request) (*http.Response, error)
 }
@@ -1,7 +1,7 @@
 package interfaces
 
 import (
-	"bytes"
+	"io"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,11 +16,23 @@ type Deployment struct {
 	Type          DeploymentType
 	Authorization Authorization
 	CFContext     CFContext
+
+	// EmergencyReason is the reason for deploying during a freeze window.
+	EmergencyReason string
+
+	// DryRun is true when the deployment must not make changes to the foundations.
+	DryRun bool
 }
 
 type Authorization struct {
 	Username string
 	Password string
+
+	// Token is the name of the API token the request was authenticated with.
+	Token string
+
+	// Subject is the subject of the JWT the request was authenticated with.
+	Subject string
 }
 
 type CFContext struct {
@@ -32,7 +44,33 @@ type CFContext struct {
 }
 
 type Controller interface {
-	RunDeployment(deployment *Deployment, response *bytes.Buffer) DeployResponse
+	RunDeployment(deployment *Deployment, response io.ReadWriter) DeployResponse
 
 	RunDeploymentViaHttp(g *gin.Context)
+
+	ListEnvironments(g *gin.Context)
+
+	GetEnvironment(g *gin.Context)
+
+	GetApp(g *gin.Context)
+
+	ReconcileApp(g *gin.Context)
+
+	PromoteApp(g *gin.Context)
+
+	ListPendingDeployments(g *gin.Context)
+
+	ApproveDeployment(g *gin.Context)
+
+	RejectDeployment(g *gin.Context)
+
+	Authorize(action string) gin.HandlerFunc
+
+	IssueToken(g *gin.Context)
+
+	ListTokens(g *gin.Context)
+
+	RevokeToken(g *gin.Context)
+
+	OpenAPI(g *gin.Context)
 }
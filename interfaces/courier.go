@@ -8,9 +8,13 @@ type Courier interface {
 	Rename(oldName, newName string) ([]byte, error)
 	MapRoute(appName, domain, hostname string) ([]byte, error)
 	MapRouteWithPath(appName, domain, hostname, path string) ([]byte, error)
+	MapRouteWithPort(appName, domain string, port int) ([]byte, error)
 	UnmapRoute(appName, domain, hostname string) ([]byte, error)
 	UnmapRouteWithPath(appName, domain, hostname, path string) ([]byte, error)
 	DeleteRoute(domain, hostname string) ([]byte, error)
+	DeleteRouteWithPath(domain, hostname, path string) ([]byte, error)
+	DeleteRouteWithPort(domain string, port int) ([]byte, error)
+	Routes(appName string) ([]string, error)
 	CreateService(service, plan, name string) ([]byte, error)
 	BindService(appName, serviceName string) ([]byte, error)
 	UnbindService(appName, serviceName string) ([]byte, error)
@@ -21,5 +25,11 @@ type Courier interface {
 	Cups(appName string, body string) ([]byte, error)
 	Uups(appName string, body string) ([]byte, error)
 	Domains() ([]string, error)
+	SpaceGUID(space string) (string, error)
+	Curl(path string) ([]byte, error)
+	CurlWithBody(method, path, body string) ([]byte, error)
+	AppGUID(appName string) (string, error)
+	SetEnv(appName, name, value string) ([]byte, error)
+	Cancel()
 	CleanUp() error
 }
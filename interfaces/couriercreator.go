@@ -0,0 +1,6 @@
+package interfaces
+
+// CourierCreator interface.
+type CourierCreator interface {
+	CreateCourier() (Courier, error)
+}
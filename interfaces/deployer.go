@@ -11,6 +11,12 @@ type DeployResponse struct {
 	StatusCode     int
 	Error          error
 	DeploymentInfo *structs.DeploymentInfo
+
+	// Foundations is the result of the deployment on every foundation it was pushed to.
+	Foundations []structs.FoundationResult
+
+	// MatchedErrors are the errors the ErrorFinder found in the output of a failed deployment.
+	MatchedErrors []LogMatchedError
 }
 
 // Deployer interface.
@@ -0,0 +1,8 @@
+package interfaces
+
+// Drain interface.
+type Drain interface {
+	Start(uuid string, cancel func()) (func(), error)
+	Draining() bool
+	Running(uuid string) bool
+}
@@ -4,5 +4,6 @@ package interfaces
 type Executor interface {
 	Execute(args ...string) ([]byte, error)
 	ExecuteInDirectory(directory string, args ...string) ([]byte, error)
+	Cancel()
 	CleanUp() error
 }
@@ -0,0 +1,8 @@
+package interfaces
+
+import S "github.com/compozed/deployadactyl/structs"
+
+// History interface.
+type History interface {
+	Latest(environment, org, space, appName string) (S.DeploymentRecord, bool)
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+import S "github.com/compozed/deployadactyl/structs"
+
+// Inventory interface.
+type Inventory interface {
+	Get(environment S.Environment, deploymentInfo S.DeploymentInfo) S.AppInventory
+}
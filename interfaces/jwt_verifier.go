@@ -0,0 +1,8 @@
+package interfaces
+
+import S "github.com/compozed/deployadactyl/structs"
+
+// JWTVerifier interface.
+type JWTVerifier interface {
+	Verify(raw string) (S.Claims, error)
+}
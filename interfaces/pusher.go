@@ -6,5 +6,6 @@ type Pusher interface {
 	Push(appPath, foundationURL string) error
 	FinishPush() error
 	UndoPush() error
+	Cancel()
 	CleanUp() error
 }
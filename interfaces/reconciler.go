@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"io"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Reconciler interface.
+type Reconciler interface {
+	Reconcile(environment S.Environment, deploymentInfo S.DeploymentInfo, response io.ReadWriter) DeployResponse
+}
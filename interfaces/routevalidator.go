@@ -0,0 +1,8 @@
+package interfaces
+
+import S "github.com/compozed/deployadactyl/structs"
+
+// RouteValidator interface.
+type RouteValidator interface {
+	Validate(environment S.Environment, deploymentInfo S.DeploymentInfo) error
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// TokenStore interface.
+type TokenStore interface {
+	Issue(name string, environments []string, ttl time.Duration) (string, S.APIToken, error)
+	Verify(secret string) (S.APIToken, error)
+	Revoke(id string) error
+	List() []S.APIToken
+}
@@ -0,0 +1,83 @@
+package mocks
+
+import (
+	"io"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// ApprovalGate handmade mock for tests.
+type ApprovalGate struct {
+	WaitCall struct {
+		TimesCalled int
+		Received    struct {
+			Environment    S.Environment
+			DeploymentInfo S.DeploymentInfo
+			Response       io.Writer
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	ApproveCall struct {
+		Received struct {
+			UUID     string
+			Username string
+			Password string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	RejectCall struct {
+		Received struct {
+			UUID     string
+			Username string
+			Password string
+			Reason   string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	PendingCall struct {
+		Returns struct {
+			Deployments []S.PendingDeployment
+		}
+	}
+}
+
+// Wait mock method.
+func (a *ApprovalGate) Wait(environment S.Environment, deploymentInfo S.DeploymentInfo, response io.Writer) error {
+	defer func() { a.WaitCall.TimesCalled++ }()
+
+	a.WaitCall.Received.Environment = environment
+	a.WaitCall.Received.DeploymentInfo = deploymentInfo
+	a.WaitCall.Received.Response = response
+
+	return a.WaitCall.Returns.Error
+}
+
+// Approve mock method.
+func (a *ApprovalGate) Approve(uuid, username, password string) error {
+	a.ApproveCall.Received.UUID = uuid
+	a.ApproveCall.Received.Username = username
+	a.ApproveCall.Received.Password = password
+
+	return a.ApproveCall.Returns.Error
+}
+
+// Reject mock method.
+func (a *ApprovalGate) Reject(uuid, username, password, reason string) error {
+	a.RejectCall.Received.UUID = uuid
+	a.RejectCall.Received.Username = username
+	a.RejectCall.Received.Password = password
+	a.RejectCall.Received.Reason = reason
+
+	return a.RejectCall.Returns.Error
+}
+
+// Pending mock method.
+func (a *ApprovalGate) Pending() []S.PendingDeployment {
+	return a.PendingCall.Returns.Deployments
+}
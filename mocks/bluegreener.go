@@ -13,6 +13,7 @@ import (
 type BlueGreener struct {
 	PushCall struct {
 		Write    string
+		Record   []S.FoundationResult
 		Received struct {
 			Environment    S.Environment
 			AppPath        string
@@ -35,5 +36,10 @@ func (b *BlueGreener) Push(environment S.Environment, appPath string, deployment
 	if b.PushCall.Write != "" {
 		bytes.NewBufferString(b.PushCall.Write).WriteTo(out)
 	}
+	if recorder, ok := out.(I.FoundationRecorder); ok {
+		for _, result := range b.PushCall.Record {
+			recorder.RecordFoundation(result)
+		}
+	}
 	return b.PushCall.Returns.Error
 }
@@ -1,10 +1,16 @@
 package mocks
 
-import "net/http"
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
 
 // Client handmade mock for tests.
 type Client struct {
-	GetCall struct {
+	DoCall struct {
+		Delay    time.Duration
 		Received struct {
 			URL string
 		}
@@ -15,9 +21,21 @@ type Client struct {
 	}
 }
 
-// Get mock method.
-func (c *Client) Get(url string) (*http.Response, error) {
-	c.GetCall.Received.URL = url
+// Do mock method. The response is delayed by Delay unless the context of the request is
+// done first, as it is with an http.Client.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	c.DoCall.Received.URL = req.URL.String()
 
-	return &c.GetCall.Returns.Response, c.GetCall.Returns.Error
+	select {
+	case <-time.After(c.DoCall.Delay):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	response := c.DoCall.Returns.Response
+	if response.Body == nil {
+		response.Body = ioutil.NopCloser(strings.NewReader(""))
+	}
+
+	return &response, c.DoCall.Returns.Error
 }
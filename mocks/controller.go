@@ -1,8 +1,8 @@
 package mocks
 
 import (
-	"bytes"
 	"fmt"
+	"io"
 
 	"github.com/gin-gonic/gin"
 
@@ -14,7 +14,7 @@ type Controller struct {
 		Called   bool
 		Received struct {
 			Deployment *I.Deployment
-			Response   *bytes.Buffer
+			Response   io.ReadWriter
 			UUID       string
 		}
 		Write struct {
@@ -28,9 +28,86 @@ type Controller struct {
 			Context *gin.Context
 		}
 	}
+	ListEnvironmentsCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	GetEnvironmentCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	GetAppCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	ReconcileAppCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	PromoteAppCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	ListPendingDeploymentsCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	ApproveDeploymentCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	RejectDeploymentCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	AuthorizeCall struct {
+		Received struct {
+			Actions []string
+		}
+	}
+	IssueTokenCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	ListTokensCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	RevokeTokenCall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
+	OpenAPICall struct {
+		Called   bool
+		Received struct {
+			Context *gin.Context
+		}
+	}
 }
 
-func (c *Controller) RunDeployment(deployment *I.Deployment, response *bytes.Buffer) I.DeployResponse {
+func (c *Controller) RunDeployment(deployment *I.Deployment, response io.ReadWriter) I.DeployResponse {
 	c.RunDeploymentCall.Called = true
 
 	c.RunDeploymentCall.Received.Deployment = deployment
@@ -47,3 +124,81 @@ func (c *Controller) RunDeploymentViaHttp(g *gin.Context) {
 
 	c.RunDeploymentViaHttpCall.Received.Context = g
 }
+
+func (c *Controller) ListEnvironments(g *gin.Context) {
+	c.ListEnvironmentsCall.Called = true
+
+	c.ListEnvironmentsCall.Received.Context = g
+}
+
+func (c *Controller) GetEnvironment(g *gin.Context) {
+	c.GetEnvironmentCall.Called = true
+
+	c.GetEnvironmentCall.Received.Context = g
+}
+
+func (c *Controller) GetApp(g *gin.Context) {
+	c.GetAppCall.Called = true
+
+	c.GetAppCall.Received.Context = g
+}
+
+func (c *Controller) ReconcileApp(g *gin.Context) {
+	c.ReconcileAppCall.Called = true
+
+	c.ReconcileAppCall.Received.Context = g
+}
+
+func (c *Controller) PromoteApp(g *gin.Context) {
+	c.PromoteAppCall.Called = true
+
+	c.PromoteAppCall.Received.Context = g
+}
+
+func (c *Controller) ListPendingDeployments(g *gin.Context) {
+	c.ListPendingDeploymentsCall.Called = true
+
+	c.ListPendingDeploymentsCall.Received.Context = g
+}
+
+func (c *Controller) ApproveDeployment(g *gin.Context) {
+	c.ApproveDeploymentCall.Called = true
+
+	c.ApproveDeploymentCall.Received.Context = g
+}
+
+func (c *Controller) RejectDeployment(g *gin.Context) {
+	c.RejectDeploymentCall.Called = true
+
+	c.RejectDeploymentCall.Received.Context = g
+}
+
+func (c *Controller) Authorize(action string) gin.HandlerFunc {
+	c.AuthorizeCall.Received.Actions = append(c.AuthorizeCall.Received.Actions, action)
+
+	return func(g *gin.Context) { g.Next() }
+}
+
+func (c *Controller) IssueToken(g *gin.Context) {
+	c.IssueTokenCall.Called = true
+
+	c.IssueTokenCall.Received.Context = g
+}
+
+func (c *Controller) ListTokens(g *gin.Context) {
+	c.ListTokensCall.Called = true
+
+	c.ListTokensCall.Received.Context = g
+}
+
+func (c *Controller) RevokeToken(g *gin.Context) {
+	c.RevokeTokenCall.Called = true
+
+	c.RevokeTokenCall.Received.Context = g
+}
+
+func (c *Controller) OpenAPI(g *gin.Context) {
+	c.OpenAPICall.Called = true
+
+	c.OpenAPICall.Received.Context = g
+}
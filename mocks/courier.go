@@ -89,6 +89,19 @@ type Courier struct {
 		}
 	}
 
+	MapRouteWithPortCall struct {
+		TimesCalled int
+		Received    struct {
+			AppName []string
+			Domain  []string
+			Port    []int
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
 	UnmapRouteCall struct {
 		OrderCalled int
 		Received    struct {
@@ -114,6 +127,96 @@ type Courier struct {
 		}
 	}
 
+	DeleteRouteWithPathCall struct {
+		TimesCalled int
+		Received    struct {
+			Domain   []string
+			Hostname []string
+			Path     []string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
+	DeleteRouteWithPortCall struct {
+		TimesCalled int
+		Received    struct {
+			Domain []string
+			Port   []int
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
+	RoutesCall struct {
+		TimesCalled int
+		Received    struct {
+			AppName []string
+		}
+		Returns struct {
+			Routes map[string][]string
+			Error  error
+		}
+	}
+
+	SpaceGUIDCall struct {
+		Received struct {
+			Space string
+		}
+		Returns struct {
+			GUID  string
+			Error error
+		}
+	}
+
+	CurlCall struct {
+		Received struct {
+			Path []string
+		}
+		Returns struct {
+			Output map[string][]byte
+			Error  error
+		}
+	}
+
+	CurlWithBodyCall struct {
+		Received struct {
+			Method []string
+			Path   []string
+			Body   []string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
+	AppGUIDCall struct {
+		Received struct {
+			AppName string
+		}
+		Returns struct {
+			GUID  string
+			Error error
+		}
+	}
+
+	SetEnvCall struct {
+		Received struct {
+			AppName []string
+			Name    []string
+			Value   []string
+		}
+		Returns struct {
+			Output []byte
+			Error  error
+		}
+	}
+
 	CreateServiceCall struct {
 	}
 
@@ -156,6 +259,10 @@ type Courier struct {
 		}
 	}
 
+	CancelCall struct {
+		TimesCalled int
+	}
+
 	CleanUpCall struct {
 		Returns struct {
 			Error error
@@ -239,6 +346,17 @@ func (c *Courier) MapRoute(appName, domain, hostname string) ([]byte, error) {
 	return c.MapRouteCall.Returns.Output[c.MapRouteCall.TimesCalled], c.MapRouteCall.Returns.Error[c.MapRouteCall.TimesCalled]
 }
 
+// MapRouteWithPort mock method.
+func (c *Courier) MapRouteWithPort(appName, domain string, port int) ([]byte, error) {
+	defer func() { c.MapRouteWithPortCall.TimesCalled++ }()
+
+	c.MapRouteWithPortCall.Received.AppName = append(c.MapRouteWithPortCall.Received.AppName, appName)
+	c.MapRouteWithPortCall.Received.Domain = append(c.MapRouteWithPortCall.Received.Domain, domain)
+	c.MapRouteWithPortCall.Received.Port = append(c.MapRouteWithPortCall.Received.Port, port)
+
+	return c.MapRouteWithPortCall.Returns.Output, c.MapRouteWithPortCall.Returns.Error
+}
+
 // UnmapRoute mock method.
 func (c *Courier) UnmapRoute(appName, domain, hostname string) ([]byte, error) {
 	defer func() { c.TimesCourierCalled++ }()
@@ -266,6 +384,36 @@ func (c *Courier) DeleteRoute(domain, hostname string) ([]byte, error) {
 	return c.DeleteRouteCall.Returns.Output, c.DeleteRouteCall.Returns.Error
 }
 
+// DeleteRouteWithPath mock method.
+func (c *Courier) DeleteRouteWithPath(domain, hostname, path string) ([]byte, error) {
+	defer func() { c.DeleteRouteWithPathCall.TimesCalled++ }()
+
+	c.DeleteRouteWithPathCall.Received.Domain = append(c.DeleteRouteWithPathCall.Received.Domain, domain)
+	c.DeleteRouteWithPathCall.Received.Hostname = append(c.DeleteRouteWithPathCall.Received.Hostname, hostname)
+	c.DeleteRouteWithPathCall.Received.Path = append(c.DeleteRouteWithPathCall.Received.Path, path)
+
+	return c.DeleteRouteWithPathCall.Returns.Output, c.DeleteRouteWithPathCall.Returns.Error
+}
+
+// DeleteRouteWithPort mock method.
+func (c *Courier) DeleteRouteWithPort(domain string, port int) ([]byte, error) {
+	defer func() { c.DeleteRouteWithPortCall.TimesCalled++ }()
+
+	c.DeleteRouteWithPortCall.Received.Domain = append(c.DeleteRouteWithPortCall.Received.Domain, domain)
+	c.DeleteRouteWithPortCall.Received.Port = append(c.DeleteRouteWithPortCall.Received.Port, port)
+
+	return c.DeleteRouteWithPortCall.Returns.Output, c.DeleteRouteWithPortCall.Returns.Error
+}
+
+// Routes mock method.
+func (c *Courier) Routes(appName string) ([]string, error) {
+	defer func() { c.RoutesCall.TimesCalled++ }()
+
+	c.RoutesCall.Received.AppName = append(c.RoutesCall.Received.AppName, appName)
+
+	return c.RoutesCall.Returns.Routes[appName], c.RoutesCall.Returns.Error
+}
+
 // Logs mock method.
 func (c *Courier) Logs(appName string) ([]byte, error) {
 	c.LogsCall.Received.AppName = appName
@@ -303,6 +451,45 @@ func (c *Courier) Domains() ([]string, error) {
 	return c.DomainsCall.Returns.Domains, c.DomainsCall.Returns.Error
 }
 
+// SpaceGUID mock method.
+func (c *Courier) SpaceGUID(space string) (string, error) {
+	c.SpaceGUIDCall.Received.Space = space
+
+	return c.SpaceGUIDCall.Returns.GUID, c.SpaceGUIDCall.Returns.Error
+}
+
+// Curl mock method.
+func (c *Courier) Curl(path string) ([]byte, error) {
+	c.CurlCall.Received.Path = append(c.CurlCall.Received.Path, path)
+
+	return c.CurlCall.Returns.Output[path], c.CurlCall.Returns.Error
+}
+
+// CurlWithBody mock method.
+func (c *Courier) CurlWithBody(method, path, body string) ([]byte, error) {
+	c.CurlWithBodyCall.Received.Method = append(c.CurlWithBodyCall.Received.Method, method)
+	c.CurlWithBodyCall.Received.Path = append(c.CurlWithBodyCall.Received.Path, path)
+	c.CurlWithBodyCall.Received.Body = append(c.CurlWithBodyCall.Received.Body, body)
+
+	return c.CurlWithBodyCall.Returns.Output, c.CurlWithBodyCall.Returns.Error
+}
+
+// AppGUID mock method.
+func (c *Courier) AppGUID(appName string) (string, error) {
+	c.AppGUIDCall.Received.AppName = appName
+
+	return c.AppGUIDCall.Returns.GUID, c.AppGUIDCall.Returns.Error
+}
+
+// SetEnv mock method.
+func (c *Courier) SetEnv(appName, name, value string) ([]byte, error) {
+	c.SetEnvCall.Received.AppName = append(c.SetEnvCall.Received.AppName, appName)
+	c.SetEnvCall.Received.Name = append(c.SetEnvCall.Received.Name, name)
+	c.SetEnvCall.Received.Value = append(c.SetEnvCall.Received.Value, value)
+
+	return c.SetEnvCall.Returns.Output, c.SetEnvCall.Returns.Error
+}
+
 func (c *Courier) CreateService(service, plan, name string) ([]byte, error) {
 	panic("Mock not implemented.")
 }
@@ -323,6 +510,11 @@ func (c *Courier) Restage(appName string) ([]byte, error) {
 	panic("Mock not implemented.")
 }
 
+// Cancel mock method.
+func (c *Courier) Cancel() {
+	c.CancelCall.TimesCalled++
+}
+
 // CleanUp mock method.
 func (c *Courier) CleanUp() error {
 	return c.CleanUpCall.Returns.Error
@@ -0,0 +1,25 @@
+package mocks
+
+import I "github.com/compozed/deployadactyl/interfaces"
+
+// CourierCreator handmade mock for tests.
+type CourierCreator struct {
+	CreateCourierCall struct {
+		TimesCalled int
+		Returns     struct {
+			Couriers []I.Courier
+			Error    error
+		}
+	}
+}
+
+// CreateCourier mock method.
+func (c *CourierCreator) CreateCourier() (I.Courier, error) {
+	defer func() { c.CreateCourierCall.TimesCalled++ }()
+
+	if c.CreateCourierCall.Returns.Error != nil {
+		return nil, c.CreateCourierCall.Returns.Error
+	}
+
+	return c.CreateCourierCall.Returns.Couriers[c.CreateCourierCall.TimesCalled], nil
+}
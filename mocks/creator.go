@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"io"
+	"net/http"
 	"os"
 
 	"github.com/compozed/deployadactyl/artifetcher"
@@ -12,6 +13,7 @@ import (
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen"
 	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher"
 	"github.com/compozed/deployadactyl/controller/deployer/error_finder"
+	"github.com/compozed/deployadactyl/controller/policy"
 	"github.com/compozed/deployadactyl/eventmanager"
 	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/logger"
@@ -27,8 +29,41 @@ import (
 // ENDPOINT is used by the handler to define the deployment endpoint.
 const ENDPOINT = "/v2/deploy/:environment/:org/:space/:appName"
 
+// ENVIRONMENTS_ENDPOINT is used by the handler to list the configured environments.
+const ENVIRONMENTS_ENDPOINT = "/v2/environments"
+
+// ENVIRONMENT_ENDPOINT is used by the handler to show a single configured environment.
+const ENVIRONMENT_ENDPOINT = "/v2/environments/:environment"
+
+// APP_ENDPOINT is used by the handler to show an application on every foundation of an environment.
+const APP_ENDPOINT = "/v2/apps/:environment/:org/:space/:appName"
+
+// RECONCILE_ENDPOINT is used by the handler to reconcile an application on every foundation of an environment.
+const RECONCILE_ENDPOINT = "/v2/reconcile/:environment/:org/:space/:appName"
+
+// PROMOTE_ENDPOINT is used by the handler to promote the latest deployment of an application to another environment.
+const PROMOTE_ENDPOINT = "/v2/promote/:fromEnvironment/:toEnvironment/:org/:space/:appName"
+
+// DEPLOYMENTS_ENDPOINT is used by the handler to list the deployments that are pending approval.
+const DEPLOYMENTS_ENDPOINT = "/v2/deployments"
+
+// APPROVE_ENDPOINT is used by the handler to approve a deployment that is pending approval.
+const APPROVE_ENDPOINT = "/v2/deployments/:uuid/approve"
+
+// REJECT_ENDPOINT is used by the handler to reject a deployment that is pending approval.
+const REJECT_ENDPOINT = "/v2/deployments/:uuid/reject"
+
+// TOKENS_ENDPOINT is used by the handler to issue and list API tokens.
+const TOKENS_ENDPOINT = "/v2/tokens"
+
+// TOKEN_ENDPOINT is used by the handler to revoke an API token.
+const TOKEN_ENDPOINT = "/v2/tokens/:id"
+
 // Handmade Creator mock.
 // Uses a mock prechecker to skip verifying the foundations are up and running.
+// Uses a mock route validator to skip validating routes against the foundations.
+// Uses a mock inventory to skip querying the foundations for applications.
+// Uses a mock reconciler to skip reconciling applications on the foundations.
 // Uses a mock Courier and Executor to mock pushing an application.
 // Uses a mock FileSystem to mock writing to the operating system.
 type Creator struct {
@@ -71,7 +106,18 @@ func (c Creator) CreateControllerHandler() *gin.Engine {
 	r.Use(gin.LoggerWithWriter(c.CreateWriter()))
 	r.Use(gin.ErrorLogger())
 
-	r.POST(ENDPOINT, d.RunDeploymentViaHttp)
+	r.POST(ENDPOINT, d.Authorize(policy.DeployAction), d.RunDeploymentViaHttp)
+	r.GET(ENVIRONMENTS_ENDPOINT, d.ListEnvironments)
+	r.GET(ENVIRONMENT_ENDPOINT, d.GetEnvironment)
+	r.GET(APP_ENDPOINT, d.Authorize(policy.ReadAction), d.GetApp)
+	r.POST(RECONCILE_ENDPOINT, d.Authorize(policy.RollbackAction), d.ReconcileApp)
+	r.POST(PROMOTE_ENDPOINT, d.Authorize(policy.PromoteAction), d.PromoteApp)
+	r.GET(DEPLOYMENTS_ENDPOINT, d.ListPendingDeployments)
+	r.POST(APPROVE_ENDPOINT, d.Authorize(policy.ApproveAction), d.ApproveDeployment)
+	r.POST(REJECT_ENDPOINT, d.Authorize(policy.ApproveAction), d.RejectDeployment)
+	r.POST(TOKENS_ENDPOINT, d.IssueToken)
+	r.GET(TOKENS_ENDPOINT, d.ListTokens)
+	r.DELETE(TOKEN_ENDPOINT, d.RevokeToken)
 
 	return r
 }
@@ -81,6 +127,12 @@ func (c Creator) CreateController() controller.Controller {
 		Deployer:       c.CreateDeployer(),
 		SilentDeployer: c.CreateSilentDeployer(),
 		Log:            c.CreateLogger(),
+		Inventory:      c.CreateInventory(),
+		Reconciler:     c.CreateReconciler(),
+		History:        c.CreateHistory(),
+		ApprovalGate:   c.CreateApprovalGate(),
+		Tokens:         c.CreateTokenStore(),
+		Config:         c.CreateConfig(),
 	}
 }
 
@@ -100,12 +152,13 @@ func (c Creator) CreateDeployer() I.Deployer {
 			},
 			Log: c.CreateLogger(),
 		},
-		Prechecker:   c.CreatePrechecker(),
-		EventManager: c.CreateEventManager(),
-		Randomizer:   c.CreateRandomizer(),
-		Log:          c.CreateLogger(),
-		FileSystem:   c.CreateFileSystem(),
-		ErrorFinder:  c.createErrorFinder(),
+		Prechecker:     c.CreatePrechecker(),
+		RouteValidator: c.CreateRouteValidator(),
+		EventManager:   c.CreateEventManager(),
+		Randomizer:     c.CreateRandomizer(),
+		Log:            c.CreateLogger(),
+		FileSystem:     c.CreateFileSystem(),
+		ErrorFinder:    c.createErrorFinder(),
 	}
 }
 
@@ -124,7 +177,7 @@ func (c Creator) createFetcher() I.Fetcher {
 	}
 }
 
-func (c Creator) CreatePusher(deploymentInfo S.DeploymentInfo, response io.ReadWriter) (I.Pusher, error) {
+func (c Creator) CreatePusher(deploymentInfo S.DeploymentInfo, foundation S.Foundation, response io.ReadWriter) (I.Pusher, error) {
 	courier := &Courier{}
 
 	courier.LoginCall.Returns.Output = []byte("logged in\t")
@@ -137,6 +190,7 @@ func (c Creator) CreatePusher(deploymentInfo S.DeploymentInfo, response io.ReadW
 	p := &pusher.Pusher{
 		Courier:        courier,
 		DeploymentInfo: deploymentInfo,
+		Foundation:     foundation,
 		EventManager:   c.CreateEventManager(),
 		Response:       response,
 		Log:            c.CreateLogger(),
@@ -161,6 +215,33 @@ func (c Creator) CreatePrechecker() I.Prechecker {
 	return &Prechecker{}
 }
 
+func (c Creator) CreateRouteValidator() I.RouteValidator {
+	return &RouteValidator{}
+}
+
+func (c Creator) CreateInventory() I.Inventory {
+	return &Inventory{}
+}
+
+func (c Creator) CreateReconciler() I.Reconciler {
+	reconciler := &Reconciler{}
+	reconciler.ReconcileCall.Returns.StatusCode = http.StatusOK
+
+	return reconciler
+}
+
+func (c Creator) CreateApprovalGate() I.ApprovalGate {
+	return &ApprovalGate{}
+}
+
+func (c Creator) CreateHistory() I.History {
+	return &History{}
+}
+
+func (c Creator) CreateTokenStore() I.TokenStore {
+	return &TokenStore{}
+}
+
 func (c Creator) CreateWriter() io.Writer {
 	return c.writer
 }
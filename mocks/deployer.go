@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
 )
 
 // Deployer handmade mock for tests.
@@ -26,8 +27,11 @@ type Deployer struct {
 			Output string
 		}
 		Returns struct {
-			Error      error
-			StatusCode int
+			Error          error
+			StatusCode     int
+			DeploymentInfo *S.DeploymentInfo
+			Foundations    []S.FoundationResult
+			MatchedErrors  []I.LogMatchedError
 		}
 	}
 }
@@ -48,8 +52,11 @@ func (d *Deployer) Deploy(req *http.Request, environment, org, space, appName, u
 	fmt.Fprint(out, d.DeployCall.Write.Output)
 
 	response := I.DeployResponse{
-		StatusCode: d.DeployCall.Returns.StatusCode,
-		Error:      d.DeployCall.Returns.Error,
+		StatusCode:     d.DeployCall.Returns.StatusCode,
+		Error:          d.DeployCall.Returns.Error,
+		DeploymentInfo: d.DeployCall.Returns.DeploymentInfo,
+		Foundations:    d.DeployCall.Returns.Foundations,
+		MatchedErrors:  d.DeployCall.Returns.MatchedErrors,
 	}
 
 	reqChan <- response
@@ -0,0 +1,57 @@
+package mocks
+
+// Drain handmade mock for tests.
+type Drain struct {
+	StartCall struct {
+		TimesCalled int
+		Received    struct {
+			UUID   string
+			Cancel func()
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	FinishCall struct {
+		TimesCalled int
+	}
+	DrainingCall struct {
+		Returns struct {
+			Draining bool
+		}
+	}
+	RunningCall struct {
+		Received struct {
+			UUIDs []string
+		}
+		Returns struct {
+			Running map[string]bool
+		}
+	}
+}
+
+// Start mock method.
+func (d *Drain) Start(uuid string, cancel func()) (func(), error) {
+	defer func() { d.StartCall.TimesCalled++ }()
+
+	d.StartCall.Received.UUID = uuid
+	d.StartCall.Received.Cancel = cancel
+
+	if d.StartCall.Returns.Error != nil {
+		return nil, d.StartCall.Returns.Error
+	}
+
+	return func() { d.FinishCall.TimesCalled++ }, nil
+}
+
+// Draining mock method.
+func (d *Drain) Draining() bool {
+	return d.DrainingCall.Returns.Draining
+}
+
+// Running mock method.
+func (d *Drain) Running(uuid string) bool {
+	d.RunningCall.Received.UUIDs = append(d.RunningCall.Received.UUIDs, uuid)
+
+	return d.RunningCall.Returns.Running[uuid]
+}
@@ -23,6 +23,10 @@ type Executor struct {
 		}
 	}
 
+	CancelCall struct {
+		TimesCalled int
+	}
+
 	CleanUpCall struct {
 		Returns struct {
 			Error error
@@ -45,6 +49,11 @@ func (e *Executor) ExecuteInDirectory(appLocation string, args ...string) ([]byt
 	return e.ExecuteInDirectoryCall.Returns.Output, e.ExecuteInDirectoryCall.Returns.Error
 }
 
+// Cancel mock method.
+func (e *Executor) Cancel() {
+	e.CancelCall.TimesCalled++
+}
+
 // CleanUp mock method.
 func (e *Executor) CleanUp() error {
 	return e.CleanUpCall.Returns.Error
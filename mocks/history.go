@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// History handmade mock for tests.
+type History struct {
+	LatestCall struct {
+		Received struct {
+			Environment string
+			Org         string
+			Space       string
+			AppName     string
+		}
+		Returns struct {
+			Record S.DeploymentRecord
+			Found  bool
+		}
+	}
+}
+
+// Latest mock method.
+func (h *History) Latest(environment, org, space, appName string) (S.DeploymentRecord, bool) {
+	h.LatestCall.Received.Environment = environment
+	h.LatestCall.Received.Org = org
+	h.LatestCall.Received.Space = space
+	h.LatestCall.Received.AppName = appName
+
+	return h.LatestCall.Returns.Record, h.LatestCall.Returns.Found
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Inventory handmade mock for tests.
+type Inventory struct {
+	GetCall struct {
+		TimesCalled int
+		Received    struct {
+			Environment    S.Environment
+			DeploymentInfo S.DeploymentInfo
+		}
+		Returns struct {
+			AppInventory S.AppInventory
+		}
+	}
+}
+
+// Get mock method.
+func (i *Inventory) Get(environment S.Environment, deploymentInfo S.DeploymentInfo) S.AppInventory {
+	defer func() { i.GetCall.TimesCalled++ }()
+
+	i.GetCall.Received.Environment = environment
+	i.GetCall.Received.DeploymentInfo = deploymentInfo
+
+	return i.GetCall.Returns.AppInventory
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import S "github.com/compozed/deployadactyl/structs"
+
+// JWTVerifier handmade mock for tests.
+type JWTVerifier struct {
+	VerifyCall struct {
+		Received struct {
+			Raw string
+		}
+		Returns struct {
+			Claims S.Claims
+			Error  error
+		}
+	}
+}
+
+// Verify mock method.
+func (j *JWTVerifier) Verify(raw string) (S.Claims, error) {
+	j.VerifyCall.Received.Raw = raw
+
+	return j.VerifyCall.Returns.Claims, j.VerifyCall.Returns.Error
+}
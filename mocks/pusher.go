@@ -3,6 +3,7 @@ package mocks
 import (
 	"fmt"
 	"io"
+	"sync"
 )
 
 // Pusher handmade mock for tests.
@@ -52,6 +53,11 @@ type Pusher struct {
 		}
 	}
 
+	CancelCall struct {
+		sync.Mutex
+		TimesCalled int
+	}
+
 	CleanUpCall struct {
 		Returns struct {
 			Error error
@@ -89,6 +95,23 @@ func (p *Pusher) UndoPush() error {
 	return p.UndoPushCall.Returns.Error
 }
 
+// Cancel mock method.
+func (p *Pusher) Cancel() {
+	p.CancelCall.Lock()
+	defer p.CancelCall.Unlock()
+
+	p.CancelCall.TimesCalled++
+}
+
+// CancelTimesCalled returns how many times Cancel was called. It can be called while
+// another goroutine cancels the pusher.
+func (p *Pusher) CancelTimesCalled() int {
+	p.CancelCall.Lock()
+	defer p.CancelCall.Unlock()
+
+	return p.CancelCall.TimesCalled
+}
+
 // CleanUp mock method.
 func (p *Pusher) CleanUp() error {
 	return p.CleanUpCall.Returns.Error
@@ -11,7 +11,10 @@ import (
 type PusherCreator struct {
 	CreatePusherCall struct {
 		TimesCalled int
-		Returns     struct {
+		Received    struct {
+			Foundations []S.Foundation
+		}
+		Returns struct {
 			Pushers []interfaces.Pusher
 			Error   []error
 		}
@@ -19,8 +22,10 @@ type PusherCreator struct {
 }
 
 // CreatePusher mock method.
-func (p *PusherCreator) CreatePusher(deploymentInfo S.DeploymentInfo, response io.ReadWriter) (interfaces.Pusher, error) {
+func (p *PusherCreator) CreatePusher(deploymentInfo S.DeploymentInfo, foundation S.Foundation, response io.ReadWriter) (interfaces.Pusher, error) {
 	defer func() { p.CreatePusherCall.TimesCalled++ }()
 
+	p.CreatePusherCall.Received.Foundations = append(p.CreatePusherCall.Received.Foundations, foundation)
+
 	return p.CreatePusherCall.Returns.Pushers[p.CreatePusherCall.TimesCalled], p.CreatePusherCall.Returns.Error[p.CreatePusherCall.TimesCalled]
 }
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"fmt"
+	"io"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// Reconciler handmade mock for tests.
+type Reconciler struct {
+	ReconcileCall struct {
+		TimesCalled int
+		Received    struct {
+			Environment    S.Environment
+			DeploymentInfo S.DeploymentInfo
+			Response       io.ReadWriter
+		}
+		Write struct {
+			Output string
+		}
+		Returns I.DeployResponse
+	}
+}
+
+// Reconcile mock method.
+func (r *Reconciler) Reconcile(environment S.Environment, deploymentInfo S.DeploymentInfo, response io.ReadWriter) I.DeployResponse {
+	defer func() { r.ReconcileCall.TimesCalled++ }()
+
+	r.ReconcileCall.Received.Environment = environment
+	r.ReconcileCall.Received.DeploymentInfo = deploymentInfo
+	r.ReconcileCall.Received.Response = response
+
+	fmt.Fprint(response, r.ReconcileCall.Write.Output)
+
+	return r.ReconcileCall.Returns
+}
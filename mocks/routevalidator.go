@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// RouteValidator handmade mock for tests.
+type RouteValidator struct {
+	ValidateCall struct {
+		TimesCalled int
+		Received    struct {
+			Environment    S.Environment
+			DeploymentInfo S.DeploymentInfo
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+// Validate mock method.
+func (r *RouteValidator) Validate(environment S.Environment, deploymentInfo S.DeploymentInfo) error {
+	defer func() { r.ValidateCall.TimesCalled++ }()
+
+	r.ValidateCall.Received.Environment = environment
+	r.ValidateCall.Received.DeploymentInfo = deploymentInfo
+
+	return r.ValidateCall.Returns.Error
+}
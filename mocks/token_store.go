@@ -0,0 +1,73 @@
+package mocks
+
+import (
+	"time"
+
+	S "github.com/compozed/deployadactyl/structs"
+)
+
+// TokenStore handmade mock for tests.
+type TokenStore struct {
+	IssueCall struct {
+		Received struct {
+			Name         string
+			Environments []string
+			TTL          time.Duration
+		}
+		Returns struct {
+			Secret string
+			Token  S.APIToken
+			Error  error
+		}
+	}
+	VerifyCall struct {
+		Received struct {
+			Secret string
+		}
+		Returns struct {
+			Token S.APIToken
+			Error error
+		}
+	}
+	RevokeCall struct {
+		Received struct {
+			ID string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	ListCall struct {
+		Returns struct {
+			Tokens []S.APIToken
+		}
+	}
+}
+
+// Issue mock method.
+func (t *TokenStore) Issue(name string, environments []string, ttl time.Duration) (string, S.APIToken, error) {
+	t.IssueCall.Received.Name = name
+	t.IssueCall.Received.Environments = environments
+	t.IssueCall.Received.TTL = ttl
+
+	return t.IssueCall.Returns.Secret, t.IssueCall.Returns.Token, t.IssueCall.Returns.Error
+}
+
+// Verify mock method.
+func (t *TokenStore) Verify(secret string) (S.APIToken, error) {
+	t.VerifyCall.Received.Secret = secret
+
+	return t.VerifyCall.Returns.Token, t.VerifyCall.Returns.Error
+}
+
+// Revoke mock method.
+func (t *TokenStore) Revoke(id string) error {
+	t.RevokeCall.Received.ID = id
+
+	return t.RevokeCall.Returns.Error
+}
+
+// List mock method.
+func (t *TokenStore) List() []S.APIToken {
+	return t.ListCall.Returns.Tokens
+}
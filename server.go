@@ -2,30 +2,58 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/compozed/deployadactyl/client"
+	"github.com/compozed/deployadactyl/config"
 	C "github.com/compozed/deployadactyl/constants"
+	"github.com/compozed/deployadactyl/controller/deployer/bluegreen/pusher/courier/executor"
+	"github.com/compozed/deployadactyl/controller/deployer/drain"
 	"github.com/compozed/deployadactyl/creator"
 	"github.com/compozed/deployadactyl/eventmanager/handlers/envvar"
 	"github.com/compozed/deployadactyl/eventmanager/handlers/healthchecker"
 	"github.com/compozed/deployadactyl/eventmanager/handlers/routemapper"
+	I "github.com/compozed/deployadactyl/interfaces"
 	"github.com/compozed/deployadactyl/logger"
+	"github.com/compozed/deployadactyl/tlsconfig"
 	"github.com/op/go-logging"
+	"github.com/spf13/afero"
 )
 
 const (
 	defaultConfigFilePath = "./config.yml"
 	defaultLogLevel       = "DEBUG"
 	logLevelEnvVarName    = "DEPLOYADACTYL_LOGLEVEL"
+	defaultServerURL      = "http://localhost:8080"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate-config":
+			os.Exit(validateConfig(os.Args[2:], os.Stdout))
+		case "reconcile":
+			os.Exit(reconcile(os.Args[2:], os.Stdout))
+		case "deploy":
+			os.Exit(deploy(os.Args[2:], os.Stdout))
+		}
+	}
+
 	var (
-		config               = flag.String("config", defaultConfigFilePath, "location of the config file")
+		configPath           = flag.String("config", defaultConfigFilePath, "location of the config file")
 		envVarHandlerEnabled = flag.Bool("env", false, "enable environment variable handling")
 		routeMapperEnabled   = flag.Bool("route-mapper", false, "enables route mapper to map additional routes from a manifest")
+		deleteRoutesEnabled  = flag.Bool("delete-orphaned-routes", false, "enables deleting routes of the original application that are no longer in the manifest")
+		shutdownTimeout      = flag.Duration("shutdown-timeout", drain.DefaultTimeout, "how long to wait for deployments in progress to finish when the server is stopped")
 	)
 	flag.Parse()
 
@@ -42,7 +70,7 @@ func main() {
 	log := logger.DefaultLogger(os.Stdout, logLevel, "deployadactyl")
 	log.Infof("log level : %s", level)
 
-	c, err := creator.Custom(level, *config)
+	c, err := creator.Custom(level, *configPath)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -64,23 +92,280 @@ func main() {
 	log.Infof("registering health check handler")
 	em.AddHandler(healthHandler, C.PushFinishedEvent)
 
-	if *routeMapperEnabled {
-		routeMapper := routemapper.RouteMapper{
-			FileSystem: c.CreateFileSystem(),
-			Log:        c.CreateLogger(),
-		}
+	routeMapper := routemapper.RouteMapper{
+		FileSystem: c.CreateFileSystem(),
+		Log:        c.CreateLogger(),
+	}
 
-		log.Infof("registering health check handler")
+	if *routeMapperEnabled {
+		log.Infof("registering route mapper handler")
 		em.AddHandler(routeMapper, C.PushFinishedEvent)
 	}
 
+	if *deleteRoutesEnabled {
+		log.Infof("registering orphaned route handler")
+		em.AddHandler(routeMapper, C.FinishPushEvent)
+	}
+
+	configWatcher := c.CreateConfigWatcher()
+	go configWatcher.Watch(config.DefaultWatchInterval, nil)
+
+	go c.CreateJanitor().Run(c.JanitorConfig, nil)
+
+	tlsReloader := c.CreateTLSReloader()
+	if tlsReloader != nil {
+		go tlsReloader.Watch(config.DefaultWatchInterval, nil)
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			log.Infof("received SIGHUP")
+			configWatcher.Reload()
+			if tlsReloader != nil {
+				tlsReloader.Reload()
+			}
+		}
+	}()
+
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-terminate
+		os.Exit(shutdown(c.CreateDrain(), c.CreateFileSystem(), *shutdownTimeout, log))
+	}()
+
 	l := c.CreateListener()
 	deploy := c.CreateControllerHandler(c.CreateController())
 
-	log.Infof("Listening on Port %d", c.CreateConfig().Port)
+	scheme := "http"
+	if tlsReloader != nil {
+		scheme = "https"
+	}
+	log.Infof("Listening on %s://%s", scheme, l.Addr())
 
 	err = http.Serve(l, deploy)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// shutdown refuses new deployments and waits up to the timeout for the deployments in progress
+// to finish. The deployments that are still running are cancelled and their pushes are undone.
+// The temporary CF_HOME directories of the deployments of this process are then removed.
+//
+// Returns a non-zero exit code when deployments were cancelled.
+func shutdown(d *drain.Drain, fileSystem *afero.Afero, timeout time.Duration, log I.Logger) int {
+	log.Infof("shutting down: waiting up to %s for deployments in progress to finish", timeout)
+
+	cancelled := d.Shutdown(timeout)
+
+	err := executor.CleanUpAll(fileSystem)
+	if err != nil {
+		log.Errorf("cannot remove temporary directories: %s", err)
+	}
+
+	if len(cancelled) != 0 {
+		log.Errorf("shut down after cancelling deployments: %s", strings.Join(cancelled, ", "))
+		return 1
+	}
+
+	log.Info("shut down")
+	return 0
+}
+
+// validateConfig runs the validate-config subcommand, which prints every problem in a
+// config file without starting the server.
+//
+// Returns a non-zero exit code when the config file has errors.
+func validateConfig(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	flags.SetOutput(out)
+	configPath := flags.String("config", defaultConfigFilePath, "location of the config file")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	report, err := config.ValidateFile(os.Getenv, *configPath)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+
+	if len(report.Problems) > 0 {
+		fmt.Fprintln(out, report)
+	}
+
+	if report.HasErrors() {
+		fmt.Fprintf(out, "%s is not valid\n", *configPath)
+		return 1
+	}
+
+	fmt.Fprintf(out, "%s is valid\n", *configPath)
+	return 0
+}
+
+// reconcile runs the reconcile subcommand, which asks a running server to deploy the latest
+// successful deployment of an application again to the foundations that have drifted from it.
+//
+// Returns a non-zero exit code when the application could not be reconciled.
+func reconcile(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	flags.SetOutput(out)
+	serverURL := flags.String("url", defaultServerURL, "url of the deployadactyl server")
+	username := flags.String("username", "", "username used to log into the foundations")
+	password := flags.String("password", "", "password used to log into the foundations")
+	flags.Usage = func() {
+		fmt.Fprintln(out, "usage: deployadactyl reconcile [flags] environment org space app")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 4 {
+		flags.Usage()
+		return 2
+	}
+
+	requestURL := fmt.Sprintf("%s/v2/reconcile/%s", strings.TrimSuffix(*serverURL, "/"), strings.Join(flags.Args(), "/"))
+
+	req, err := http.NewRequest("POST", requestURL, nil)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+
+	if *username != "" {
+		req.SetBasicAuth(*username, *password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	io.Copy(out, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return 1
+	}
+
+	return 0
+}
+
+// deploy runs the deploy subcommand, which asks a running server to deploy an artifact url,
+// a directory or a zip file and prints the output of the deployment.
+//
+// Returns a non-zero exit code when the deployment fails, and a different one when it was
+// rolled back.
+func deploy(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	flags.SetOutput(out)
+	serverURL := flags.String("url", defaultServerURL, "url of the deployadactyl server")
+	username := flags.String("username", "", "username used to log into the foundations")
+	password := flags.String("password", "", "password used to log into the foundations")
+	token := flags.String("token", "", "API token or JWT used instead of a username and password")
+	artifactURL := flags.String("artifact-url", "", "url of the artifact the server downloads")
+	artifactPath := flags.String("path", "", "directory or zip file that is uploaded instead of an artifact url")
+	manifestPath := flags.String("manifest", "", "manifest file used instead of the manifest of the artifact")
+	healthCheckEndpoint := flags.String("health-check-endpoint", "", "endpoint of the application that is checked after it is pushed")
+	emergencyReason := flags.String("emergency-reason", "", "reason for deploying during a freeze window")
+	dryRun := flags.Bool("dry-run", false, "show what the deployment would do without making changes")
+	caCert := flags.String("ca-cert", "", "CA certificate file used to verify the server instead of the CAs of the system")
+	cert := flags.String("cert", "", "client certificate file presented to the server")
+	key := flags.String("key", "", "key file of the client certificate")
+	skipSSL := flags.Bool("skip-ssl", false, "do not verify the certificate of the server")
+	environmentVariables := envFlag{}
+	flags.Var(environmentVariables, "env", "environment variable of the application as KEY=VALUE, can be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(out, "usage: deployadactyl deploy [flags] (-artifact-url url | -path path) environment org space app")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return client.ExitUsage
+	}
+
+	if flags.NArg() != 4 || (*artifactURL == "") == (*artifactPath == "") || (*cert == "") != (*key == "") {
+		flags.Usage()
+		return client.ExitUsage
+	}
+
+	tlsConfig, err := tlsconfig.ClientConfig(*caCert, *cert, *key, *skipSSL, &afero.Afero{Fs: afero.NewOsFs()})
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return client.ExitFailure
+	}
+	httpClient := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}}
+
+	deployment := client.Deployment{
+		ServerURL:            *serverURL,
+		Environment:          flags.Arg(0),
+		Org:                  flags.Arg(1),
+		Space:                flags.Arg(2),
+		AppName:              flags.Arg(3),
+		Username:             *username,
+		Password:             *password,
+		Token:                *token,
+		ArtifactURL:          *artifactURL,
+		Path:                 *artifactPath,
+		EnvironmentVariables: environmentVariables,
+		HealthCheckEndpoint:  *healthCheckEndpoint,
+		EmergencyReason:      *emergencyReason,
+		DryRun:               *dryRun,
+	}
+
+	if *manifestPath != "" {
+		manifest, err := ioutil.ReadFile(*manifestPath)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return client.ExitFailure
+		}
+		deployment.Manifest = string(manifest)
+	}
+
+	req, err := client.NewRequest(deployment)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return client.ExitFailure
+	}
+
+	fmt.Fprintf(out, "deploying %s to %s\n", deployment.AppName, deployment.Environment)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return client.ExitFailure
+	}
+	defer resp.Body.Close()
+
+	return client.ReportResponse(resp, out)
+}
+
+// envFlag is a flag that can be given more than once with a KEY=VALUE environment variable.
+type envFlag map[string]string
+
+func (e envFlag) String() string {
+	return ""
+}
+
+func (e envFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("%s is not KEY=VALUE", value)
+	}
+	e[parts[0]] = parts[1]
+	return nil
+}
@@ -3,10 +3,14 @@ package main_test
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path"
+	"syscall"
 
+	C "github.com/compozed/deployadactyl/constants"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gbytes"
@@ -30,6 +34,15 @@ environments:
   - name: sandbox
 `)
 
+// request is what a test server received. Test servers send it on a channel so specs
+// can read it without racing the handler.
+type request struct {
+	path    string
+	body    string
+	accept  string
+	hasAuth bool
+}
+
 var _ = Describe("Server", func() {
 
 	var (
@@ -115,4 +128,291 @@ var _ = Describe("Server", func() {
 			})
 		})
 	})
+
+	Describe("stopping the server", func() {
+		It("drains the deployments in progress and exits", func() {
+			configLocation := fmt.Sprintf("%s/config.yml", path.Dir(pathToCLI))
+			Expect(ioutil.WriteFile(configLocation, goodConfig, 0777)).To(Succeed())
+
+			os.Setenv("PORT", "0")
+			defer os.Unsetenv("PORT")
+
+			session, err = gexec.Start(exec.Command(pathToCLI, "-config", configLocation, "-shutdown-timeout", "1s"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session.Out).Should(Say("Listening on"))
+
+			session.Signal(syscall.SIGTERM)
+
+			Eventually(session.Out).Should(Say("shutting down: waiting up to 1s"))
+			Eventually(session).Should(gexec.Exit(0))
+		})
+	})
+
+	Describe("reconcile subcommand", func() {
+		var (
+			server     *httptest.Server
+			requests   chan request
+			statusCode int
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			requests = make(chan request, 1)
+		})
+
+		JustBeforeEach(func() {
+			statusCode, requests := statusCode, requests
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _, hasAuth := r.BasicAuth()
+				requests <- request{path: r.Method + " " + r.URL.Path, hasAuth: hasAuth}
+
+				w.WriteHeader(statusCode)
+				fmt.Fprint(w, "reconcile output")
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("asks the server to reconcile the application", func() {
+			session, err = gexec.Start(exec.Command(pathToCLI, "reconcile", "-url", server.URL, "-username", "user", "-password", "pwd", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(Say("reconcile output"))
+
+			var received request
+			Expect(requests).To(Receive(&received))
+			Expect(received.path).To(Equal("POST /v2/reconcile/test/org/space/app"))
+			Expect(received.hasAuth).To(BeTrue())
+		})
+
+		Context("when the application cannot be reconciled", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+			})
+
+			It("exits with an error", func() {
+				session, err = gexec.Start(exec.Command(pathToCLI, "reconcile", "-url", server.URL, "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1))
+
+				var received request
+				Expect(requests).To(Receive(&received))
+				Expect(received.hasAuth).To(BeFalse())
+			})
+		})
+
+		It("prints the usage when the application is not given", func() {
+			session, err = gexec.Start(exec.Command(pathToCLI, "reconcile", "test"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(2))
+			Expect(session.Out).To(Say("usage: deployadactyl reconcile"))
+		})
+	})
+
+	Describe("deploy subcommand", func() {
+		var (
+			server     *httptest.Server
+			requests   chan request
+			statusCode int
+			response   string
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			response = `{"status": "success", "uuid": "uuid", "foundations": [{"foundation_url": "https://api.example.com", "status": "success"}], "logs": "deploy output"}`
+			requests = make(chan request, 1)
+		})
+
+		JustBeforeEach(func() {
+			statusCode, response, requests := statusCode, response, requests
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				requests <- request{path: r.Method + " " + r.URL.RequestURI(), body: string(body)}
+
+				w.WriteHeader(statusCode)
+				fmt.Fprint(w, response)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("deploys the artifact and prints the output", func() {
+			session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-url", server.URL, "-artifact-url", "https://example.com/artifact.jar", "-env", "PORT=8080", "-dry-run", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(Say("deploy output"))
+			Expect(session.Out).To(Say("https://api.example.com: success"))
+			Expect(session.Out).To(Say("deployment uuid succeeded"))
+
+			var received request
+			Expect(requests).To(Receive(&received))
+			Expect(received.path).To(Equal("POST /v2/deploy/test/org/space/app?dry_run=true"))
+			Expect(received.body).To(ContainSubstring(`"artifact_url":"https://example.com/artifact.jar"`))
+			Expect(received.body).To(ContainSubstring(`"environment_variables":{"PORT":"8080"}`))
+		})
+
+		Context("when the deployment fails", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+				response = `{"status": "failure", "uuid": "uuid", "error": "push failed", "foundations": [{"foundation_url": "https://api.example.com", "status": "failure", "error": "push failed"}]}`
+			})
+
+			It("exits with an error", func() {
+				session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-url", server.URL, "-artifact-url", "https://example.com/artifact.jar", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1))
+				Expect(session.Out).To(Say("deployment uuid failed"))
+			})
+		})
+
+		Context("when the deployment was rolled back", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusInternalServerError
+				response = `{"status": "failure", "uuid": "uuid", "error": "push failed", "foundations": [{"foundation_url": "https://api.example.com", "status": "rolled_back"}]}`
+			})
+
+			It("exits with a different error", func() {
+				session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-url", server.URL, "-artifact-url", "https://example.com/artifact.jar", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(3))
+				Expect(session.Out).To(Say("deployment uuid was rolled back"))
+			})
+		})
+
+		It("prints the output of a streamed deployment while it runs", func() {
+			finish := make(chan struct{})
+			defer close(finish)
+
+			streamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests <- request{path: r.Method + " " + r.URL.RequestURI(), accept: r.Header.Get("Accept")}
+
+				w.Header().Set("Content-Type", C.StreamContentType)
+				fmt.Fprintln(w, `{"output": "pushing the application\n"}`)
+				w.(http.Flusher).Flush()
+
+				<-finish
+				fmt.Fprintln(w, `{"result": {"status": "success", "status_code": 200, "uuid": "uuid", "foundations": [{"foundation_url": "https://api.example.com", "status": "success"}]}}`)
+			}))
+			defer streamServer.Close()
+
+			session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-url", streamServer.URL, "-artifact-url", "https://example.com/artifact.jar", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session.Out).Should(Say("pushing the application"))
+			Consistently(session).ShouldNot(gexec.Exit())
+
+			finish <- struct{}{}
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(Say("https://api.example.com: success"))
+			Expect(session.Out).To(Say("deployment uuid succeeded"))
+
+			var received request
+			Expect(requests).To(Receive(&received))
+			Expect(received.accept).To(ContainSubstring(C.StreamContentType))
+		})
+
+		Context("when the server uses TLS", func() {
+			var tlsServer *httptest.Server
+
+			JustBeforeEach(func() {
+				tlsServer = httptest.NewTLSServer(server.Config.Handler)
+			})
+
+			AfterEach(func() {
+				tlsServer.Close()
+			})
+
+			It("fails when the certificate of the server cannot be verified", func() {
+				session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-url", tlsServer.URL, "-artifact-url", "https://example.com/artifact.jar", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1))
+				Expect(session.Out).To(Say("certificate"))
+			})
+
+			It("deploys without verifying the certificate of the server when asked to", func() {
+				session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-url", tlsServer.URL, "-skip-ssl", "-artifact-url", "https://example.com/artifact.jar", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(Say("deployment uuid succeeded"))
+			})
+
+			It("fails when the CA file cannot be read", func() {
+				session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-url", tlsServer.URL, "-ca-cert", "missing.pem", "-artifact-url", "https://example.com/artifact.jar", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1))
+				Expect(session.Out).To(Say("cannot load CA file missing.pem"))
+			})
+		})
+
+		It("prints the usage when a certificate is given without a key", func() {
+			session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-cert", "cert.pem", "-artifact-url", "https://example.com/artifact.jar", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(2))
+			Expect(session.Out).To(Say("usage: deployadactyl deploy"))
+		})
+
+		It("prints the usage when no artifact is given", func() {
+			session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(2))
+			Expect(session.Out).To(Say("usage: deployadactyl deploy"))
+		})
+
+		It("prints the usage when an environment variable is not KEY=VALUE", func() {
+			session, err = gexec.Start(exec.Command(pathToCLI, "deploy", "-artifact-url", "https://example.com/artifact.jar", "-env", "PORT", "test", "org", "space", "app"), GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(2))
+			Expect(session.Out).To(Say("PORT is not KEY=VALUE"))
+		})
+	})
+
+	Describe("validate-config subcommand", func() {
+		Context("when the config is valid", func() {
+			It("exits successfully", func() {
+				configLocation := fmt.Sprintf("%s/config.yml", path.Dir(pathToCLI))
+
+				Expect(ioutil.WriteFile(configLocation, goodConfig, 0777)).To(Succeed())
+
+				session, err = gexec.Start(exec.Command(pathToCLI, "validate-config", "-config", configLocation), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(Say("is valid"))
+			})
+		})
+
+		Context("when the config has errors", func() {
+			It("prints every problem and exits with an error", func() {
+				configLocation := fmt.Sprintf("%s/config.yml", path.Dir(pathToCLI))
+
+				Expect(ioutil.WriteFile(configLocation, badConfig, 0777)).To(Succeed())
+
+				session, err = gexec.Start(exec.Command(pathToCLI, "validate-config", "-config", configLocation), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1))
+				Expect(session.Out).To(Say("line 3: error: environment sandbox has no foundations"))
+				Expect(session.Out).To(Say("is not valid"))
+			})
+		})
+	})
 })
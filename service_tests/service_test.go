@@ -123,4 +123,18 @@ var _ = Describe("Service", func() {
 			})
 		})
 	})
+
+	Context("listing environments", func() {
+		It("returns the environments of the config", func() {
+			resp, err := http.Get(deployadactylServer.URL + "/v2/environments/" + ENVIRONMENTNAME)
+			Expect(err).ToNot(HaveOccurred())
+
+			responseBody, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK), string(responseBody))
+			Expect(string(responseBody)).To(ContainSubstring(`"foundations":["api1.example.com","api2.example.com","api3.example.com","api4.example.com"]`))
+		})
+	})
 })
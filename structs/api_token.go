@@ -0,0 +1,15 @@
+package structs
+
+import "time"
+
+// APIToken is a token issued by Deployadactyl that lets a CI system deploy to an
+// environment with the service account of the environment. Only the hash of the token is kept.
+type APIToken struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Hash         string    `json:"hash,omitempty"`
+	Environments []string  `json:"environments"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Revoked      bool      `json:"revoked"`
+}
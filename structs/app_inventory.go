@@ -0,0 +1,29 @@
+package structs
+
+// AppInventory is the state of an application on every foundation of an environment.
+type AppInventory struct {
+	Environment string          `json:"environment"`
+	Org         string          `json:"org"`
+	Space       string          `json:"space"`
+	AppName     string          `json:"app_name"`
+	Foundations []FoundationApp `json:"foundations"`
+
+	// Drift is true when the foundations do not run the same deployment of the application.
+	Drift        bool     `json:"drift"`
+	DriftReasons []string `json:"drift_reasons"`
+}
+
+// FoundationApp is the state of an application on a single foundation.
+type FoundationApp struct {
+	FoundationURL  string   `json:"foundation_url"`
+	Name           string   `json:"name"`
+	Exists         bool     `json:"exists"`
+	State          string   `json:"state"`
+	Instances      int      `json:"instances"`
+	Routes         []string `json:"routes"`
+	DeploymentUUID string   `json:"deployment_uuid"`
+	ArtifactURL    string   `json:"artifact_url"`
+
+	// Error is set when the foundation could not be queried.
+	Error string `json:"error,omitempty"`
+}
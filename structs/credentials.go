@@ -0,0 +1,7 @@
+package structs
+
+// Credentials is a Cloud Foundry username and password.
+type Credentials struct {
+	Username string
+	Password string
+}
@@ -0,0 +1,59 @@
+package structs
+
+// DeployResult is the body of a deploy response for clients that accept JSON.
+type DeployResult struct {
+	// Status is "success", "dry_run" or "failure".
+	Status     string           `json:"status"`
+	StatusCode int              `json:"status_code"`
+	UUID       string           `json:"uuid"`
+	Error      string           `json:"error,omitempty"`
+	Parameters DeployParameters `json:"parameters"`
+
+	// FieldErrors are the problems with the fields of a request body that is not valid.
+	FieldErrors []FieldError `json:"field_errors,omitempty"`
+
+	Foundations   []FoundationResult `json:"foundations"`
+	MatchedErrors []MatchedError     `json:"matched_errors"`
+
+	// Logs is the output of the deployment, the same as the text response.
+	Logs string `json:"logs"`
+}
+
+// DeployStreamLine is a line of a streamed deploy response. Every line has output of the
+// deployment as it was written, except for the last line, which has the result of the
+// deployment without its logs.
+type DeployStreamLine struct {
+	Output string        `json:"output,omitempty"`
+	Result *DeployResult `json:"result,omitempty"`
+}
+
+// DeployParameters are the parameters a deployment ran with.
+type DeployParameters struct {
+	ArtifactURL      string `json:"artifact_url"`
+	ArtifactChecksum string `json:"artifact_checksum"`
+	Username         string `json:"username"`
+	Environment      string `json:"environment"`
+	Org              string `json:"org"`
+	Space            string `json:"space"`
+	AppName          string `json:"app_name"`
+	Instances        uint16 `json:"instances"`
+	DryRun           bool   `json:"dry_run"`
+}
+
+// FoundationResult is the result of a deployment on a single foundation.
+type FoundationResult struct {
+	FoundationURL string `json:"foundation_url"`
+
+	// Status is "success", "failure", "rolled_back" or "skipped" when nothing was pushed.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Logs   string `json:"logs"`
+}
+
+// MatchedError is an error that was found in the output of a deployment by an error matcher.
+type MatchedError struct {
+	Code        string   `json:"code"`
+	Description string   `json:"description"`
+	Details     []string `json:"details"`
+	Solution    string   `json:"solution"`
+}
@@ -12,6 +12,7 @@ type DeploymentInfo struct {
 	Space                string
 	AppName              string
 	UUID                 string
+	ArtifactChecksum     string `json:"artifact_checksum"`
 	SkipSSL              bool
 	Instances            uint16
 	Domain               string
@@ -20,6 +21,10 @@ type DeploymentInfo struct {
 	HealthCheckEndpoint  string            `json:"health_check_endpoint"`
 	CustomParams         map[string]interface{}
 
+	// DryRun is true when the deployment must not make changes to the foundations. It is set
+	// by the Deployer and cannot be set in the request body.
+	DryRun bool `json:"-"`
+
 	// Generic map used for users to provide their own deployment properties in JSON format.
 	Data map[string]interface{} `json:"data"`
 }
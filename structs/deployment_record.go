@@ -0,0 +1,20 @@
+package structs
+
+import "time"
+
+// DeploymentRecord is a successful deployment of an application to an environment.
+// It has what is needed to deploy the same artifact again.
+type DeploymentRecord struct {
+	UUID                 string            `json:"uuid"`
+	Environment          string            `json:"environment"`
+	Org                  string            `json:"org"`
+	Space                string            `json:"space"`
+	AppName              string            `json:"app_name"`
+	ArtifactURL          string            `json:"artifact_url"`
+	ArtifactChecksum     string            `json:"artifact_checksum"`
+	Manifest             string            `json:"manifest"`
+	HealthCheckEndpoint  string            `json:"health_check_endpoint"`
+	EnvironmentVariables map[string]string `json:"environment_variables"`
+	Username             string            `json:"username"`
+	DeployedAt           time.Time         `json:"deployed_at"`
+}
@@ -2,12 +2,46 @@ package structs
 
 // Environment is representation of a single environment configuration.
 type Environment struct {
-	Name           string
-	Domain         string
-	Foundations    []string `yaml:",flow"`
+	Name   string
+	Domain string
+
+	// Foundations is the list of foundation URLs. It is populated by the config
+	// package because foundations can be given as URLs or as objects.
+	Foundations []string `yaml:"-"`
+
+	// FoundationConfigs holds the settings of foundations that were configured
+	// as objects, keyed by foundation URL.
+	FoundationConfigs map[string]Foundation `yaml:"-"`
+
+	// Credentials is the name of the credentials used to log into the foundations
+	// when a request does not provide basic auth.
+	Credentials string
+
 	Authenticate   bool
 	SkipSSL        bool `yaml:"skip_ssl"`
 	Instances      uint16
 	EnableRollback bool                   `yaml:"rollback_enabled"`
 	CustomParams   map[string]interface{} `yaml:"custom_params"`
+
+	// FreezeWindows are the periods in which deployments to the environment are rejected.
+	FreezeWindows []FreezeWindow `yaml:"freeze_windows"`
+
+	// AllowEmergencyOverride lets a deployment with an emergency reason go ahead during a freeze window.
+	AllowEmergencyOverride bool `yaml:"allow_emergency_override"`
+
+	// Approvers are the users that approve deployments to the environment. Deployments to
+	// an environment with approvers wait for approval for up to ApprovalTimeout.
+	Approvers       []string
+	ApprovalTimeout string `yaml:"approval_timeout"`
+}
+
+// GetFoundation returns the configuration for the foundation with the given URL.
+// Foundations without their own configuration inherit the Environment settings.
+func (e Environment) GetFoundation(foundationURL string) Foundation {
+	foundation, ok := e.FoundationConfigs[foundationURL]
+	if !ok {
+		return Foundation{URL: foundationURL, SkipSSL: e.SkipSSL}
+	}
+
+	return foundation
 }
@@ -0,0 +1,7 @@
+package structs
+
+// FieldError is a problem with a field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,41 @@
+package structs
+
+// Foundation is the configuration of a single Cloud Foundry instance within an Environment.
+// Foundations given as a plain URL in the config only have URL and SkipSSL set.
+type Foundation struct {
+	// URL is the Cloud Controller URL of the foundation.
+	URL string
+
+	// Name is a human readable label used in logs and output.
+	Name string
+
+	// AppsDomain is the shared apps domain of the foundation, eg: "apps.example.com".
+	// When empty it is derived from the foundation URL.
+	AppsDomain string
+
+	// SkipSSL overrides the SkipSSL setting of the Environment.
+	SkipSSL bool
+
+	// Credentials is the name of a credential reference used to log into the foundation.
+	Credentials string
+
+	// ServiceAccount holds the resolved Credentials. It is only set by the Deployer
+	// when a request does not provide basic auth.
+	ServiceAccount Credentials
+
+	// Timeout is the number of seconds to wait on requests made to the foundation
+	// when prechecking and health checking. Zero uses the default timeouts.
+	Timeout int
+
+	// Weight orders the foundations of an Environment. Foundations with a higher
+	// weight are prechecked and deployed to first.
+	Weight int
+}
+
+// Label returns the name of the foundation, or its URL if it has no name.
+func (f Foundation) Label() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return f.URL
+}
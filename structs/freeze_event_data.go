@@ -0,0 +1,21 @@
+package structs
+
+import (
+	"io"
+	"time"
+)
+
+// FreezeEventData has the deployment that was requested during a freeze window, the
+// freeze window and the emergency reason given for the deployment, if any.
+type FreezeEventData struct {
+	Response    io.ReadWriter
+	Environment string
+	Org         string
+	Space       string
+	AppName     string
+	UUID        string
+	Username    string
+	Window      string
+	End         time.Time
+	Reason      string
+}
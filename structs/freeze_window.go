@@ -0,0 +1,13 @@
+package structs
+
+// FreezeWindow is a period in which deployments to an environment are rejected. It is
+// either a date range from Start to End, or it starts every time Cron matches and lasts
+// for Duration. Times are in TimeZone, which defaults to UTC.
+type FreezeWindow struct {
+	Name     string
+	Start    string
+	End      string
+	Cron     string
+	Duration string
+	TimeZone string `yaml:"time_zone"`
+}
@@ -0,0 +1,37 @@
+package structs
+
+import "time"
+
+// Janitor removes the temporary applications and routes that deployments which did not
+// finish left behind in Spaces. Interval and OlderThan are durations, eg: "1h".
+type Janitor struct {
+	Interval  string
+	OlderThan string         `yaml:"older_than"`
+	DryRun    bool           `yaml:"dry_run"`
+	Spaces    []JanitorSpace `yaml:",flow"`
+}
+
+// JanitorSpace is a space that is swept on every foundation of an environment.
+type JanitorSpace struct {
+	Environment string
+	Org         string
+	Space       string
+}
+
+// JanitorReport is what a sweep removed, or would remove in dry-run mode.
+type JanitorReport struct {
+	DryRun  bool          `json:"dry_run"`
+	Removed []JanitorItem `json:"removed"`
+	Errors  []string      `json:"errors"`
+}
+
+// JanitorItem is a temporary application or route found by a sweep.
+type JanitorItem struct {
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Environment string    `json:"environment"`
+	Foundation  string    `json:"foundation"`
+	Org         string    `json:"org"`
+	Space       string    `json:"space"`
+	CreatedAt   time.Time `json:"created_at"`
+}
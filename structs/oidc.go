@@ -0,0 +1,22 @@
+package structs
+
+import "time"
+
+// OIDC is the identity provider whose JWTs are accepted as bearer tokens. JWKS is the
+// URL or file path of the JSON web key set the JWTs are signed with.
+type OIDC struct {
+	Issuer       string
+	Audience     string
+	JWKS         string
+	SubjectClaim string `yaml:"subject_claim"`
+	GroupsClaim  string `yaml:"groups_claim"`
+	ClockSkew    string `yaml:"clock_skew"`
+}
+
+// Claims are the verified claims of a JWT.
+type Claims struct {
+	Subject   string
+	Groups    []string
+	Issuer    string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,17 @@
+package structs
+
+import "time"
+
+// PendingDeployment is a deployment to a protected environment that is waiting for approval.
+type PendingDeployment struct {
+	UUID        string    `json:"uuid"`
+	State       string    `json:"state"`
+	Environment string    `json:"environment"`
+	Org         string    `json:"org"`
+	Space       string    `json:"space"`
+	AppName     string    `json:"app_name"`
+	ArtifactURL string    `json:"artifact_url"`
+	Requester   string    `json:"requester"`
+	Approvers   []string  `json:"approvers"`
+	Expires     time.Time `json:"expires"`
+}
@@ -0,0 +1,28 @@
+package structs
+
+// Policy allows identities to perform actions on the applications of environments, orgs
+// and spaces. Environments, orgs, spaces and apps are glob patterns and match every name
+// when they are empty.
+type Policy struct {
+	Name         string
+	Identities   []string
+	Environments []string
+	Orgs         []string
+	Spaces       []string
+	Apps         []string
+	Actions      []string
+}
+
+// Identity is who made a request. Groups are the groups of a subject.
+type Identity struct {
+	Kind   string
+	Name   string
+	Groups []string
+}
+
+func (i Identity) String() string {
+	if i.Name == "" {
+		return i.Kind
+	}
+	return i.Kind + " " + i.Name
+}
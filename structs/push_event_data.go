@@ -8,6 +8,7 @@ import (
 type PushEventData struct {
 	AppPath         string
 	FoundationURL   string
+	Foundation      Foundation
 	TempAppWithUUID string
 
 	DeploymentInfo *DeploymentInfo
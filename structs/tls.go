@@ -0,0 +1,10 @@
+package structs
+
+// TLS is the certificate the server listens with. When ClientCAFile is set, clients must
+// present a certificate signed by one of its CAs, according to ClientAuth.
+type TLS struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	ClientAuth   string
+}
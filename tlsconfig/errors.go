@@ -0,0 +1,36 @@
+package tlsconfig
+
+import "fmt"
+
+type CertificateError struct {
+	CertFile string
+	Err      error
+}
+
+func (e CertificateError) Error() string {
+	return fmt.Sprintf("cannot load TLS certificate %s: %s", e.CertFile, e.Err)
+}
+
+type ClientCAError struct {
+	ClientCAFile string
+	Err          error
+}
+
+func (e ClientCAError) Error() string {
+	return fmt.Sprintf("cannot load client CA file %s: %s", e.ClientCAFile, e.Err)
+}
+
+type CAError struct {
+	CAFile string
+	Err    error
+}
+
+func (e CAError) Error() string {
+	return fmt.Sprintf("cannot load CA file %s: %s", e.CAFile, e.Err)
+}
+
+type NoCertificatesError struct{}
+
+func (e NoCertificatesError) Error() string {
+	return "no PEM certificates found"
+}
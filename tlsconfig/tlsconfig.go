@@ -0,0 +1,223 @@
+// Package tlsconfig serves the listener certificate and verifies client certificates. It also
+// configures the TLS of clients of a server.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	S "github.com/compozed/deployadactyl/structs"
+	"github.com/spf13/afero"
+)
+
+// Enabled returns true when a certificate is configured.
+func Enabled(config S.TLS) bool {
+	return config.CertFile != ""
+}
+
+// Reloader holds the listener certificate. The certificate and key files are read again when
+// they change, so certificates can be rotated without restarting.
+type Reloader struct {
+	config     S.TLS
+	fileSystem *afero.Afero
+	log        I.Logger
+	mutex      sync.RWMutex
+	cert       *tls.Certificate
+	clientCAs  *x509.CertPool
+	modTime    time.Time
+}
+
+// NewReloader returns a Reloader for the certificate, key and client CA files.
+//
+// Returns an error when the files cannot be read or do not contain a certificate.
+func NewReloader(config S.TLS, fileSystem *afero.Afero, log I.Logger) (*Reloader, error) {
+	r := &Reloader{
+		config:     config,
+		fileSystem: fileSystem,
+		log:        log,
+	}
+
+	err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ClientCAFile != "" {
+		pem, err := fileSystem.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, ClientCAError{config.ClientCAFile, err}
+		}
+
+		r.clientCAs = x509.NewCertPool()
+		if !r.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, ClientCAError{config.ClientCAFile, NoCertificatesError{}}
+		}
+	}
+
+	return r, nil
+}
+
+// Config returns the TLS configuration of the listener. Clients must present a certificate
+// signed by a client CA when a client CA file is configured.
+func (r *Reloader) Config() *tls.Config {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+
+	if r.clientCAs != nil {
+		config.ClientCAs = r.clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		if r.config.ClientAuth == "verify_if_given" {
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	return config
+}
+
+// GetCertificate returns the current certificate for every TLS handshake.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload reads the certificate and key files.
+//
+// Returns a CertificateError and keeps the current certificate if they cannot be read.
+func (r *Reloader) Reload() error {
+	r.log.Infof("reloading TLS certificate %s", r.config.CertFile)
+
+	err := r.load()
+	if err != nil {
+		r.log.Errorf("%s: keeping the current certificate", err)
+		return err
+	}
+
+	r.log.Info("reloaded TLS certificate")
+	return nil
+}
+
+// Watch reloads the certificate whenever the modification time of the certificate or key file
+// changes. It checks every interval until stop is closed.
+func (r *Reloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				r.log.Errorf("cannot stat TLS certificate: %s", err)
+				continue
+			}
+
+			r.mutex.RLock()
+			changed := !modTime.Equal(r.modTime)
+			r.mutex.RUnlock()
+
+			if changed {
+				r.Reload()
+			}
+		}
+	}
+}
+
+// ClientConfig returns the TLS configuration of a client. The certificate of the server must
+// be signed by a CA of the CA file when one is given, or by a CA of the system otherwise. The
+// client presents the certificate and key when they are given. skipSSL skips verifying the
+// certificate of the server.
+//
+// Returns an error when the files cannot be read.
+func ClientConfig(caFile, certFile, keyFile string, skipSSL bool, fileSystem *afero.Afero) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: skipSSL,
+	}
+
+	if caFile != "" {
+		pem, err := fileSystem.ReadFile(caFile)
+		if err != nil {
+			return nil, CAError{caFile, err}
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, CAError{caFile, NoCertificatesError{}}
+		}
+	}
+
+	if certFile != "" {
+		certPEM, err := fileSystem.ReadFile(certFile)
+		if err != nil {
+			return nil, CertificateError{certFile, err}
+		}
+
+		keyPEM, err := fileSystem.ReadFile(keyFile)
+		if err != nil {
+			return nil, CertificateError{certFile, err}
+		}
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, CertificateError{certFile, err}
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+func (r *Reloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return CertificateError{r.config.CertFile, err}
+	}
+
+	certPEM, err := r.fileSystem.ReadFile(r.config.CertFile)
+	if err != nil {
+		return CertificateError{r.config.CertFile, err}
+	}
+
+	keyPEM, err := r.fileSystem.ReadFile(r.config.KeyFile)
+	if err != nil {
+		return CertificateError{r.config.CertFile, err}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return CertificateError{r.config.CertFile, err}
+	}
+
+	r.mutex.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mutex.Unlock()
+
+	return nil
+}
+
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range []string{r.config.CertFile, r.config.KeyFile} {
+		info, err := r.fileSystem.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,380 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"time"
+
+	I "github.com/compozed/deployadactyl/interfaces"
+	"github.com/compozed/deployadactyl/logger"
+	S "github.com/compozed/deployadactyl/structs"
+	. "github.com/compozed/deployadactyl/tlsconfig"
+	logging "github.com/op/go-logging"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// certificate is a PEM encoded certificate and key signed by parent, or self-signed when
+// parent is nil.
+type certificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newCertificate(commonName string, isCA bool, parent *certificate) certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	Expect(err).ToNot(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return certificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c certificate) keyPair() tls.Certificate {
+	pair, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	Expect(err).ToNot(HaveOccurred())
+	return pair
+}
+
+var _ = Describe("TLS config", func() {
+	var (
+		fs       *afero.Afero
+		log      I.Logger
+		ca       certificate
+		server   certificate
+		config   S.TLS
+		reloader *Reloader
+		listener net.Listener
+		rootCAs  *x509.CertPool
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		log = logger.DefaultLogger(GinkgoWriter, logging.DEBUG, "tlsconfig_test")
+
+		ca = newCertificate("ca", true, nil)
+		server = newCertificate("server", false, &ca)
+
+		Expect(fs.WriteFile("/tls/ca.pem", ca.certPEM, 0644)).To(Succeed())
+		Expect(fs.WriteFile("/tls/cert.pem", server.certPEM, 0644)).To(Succeed())
+		Expect(fs.WriteFile("/tls/key.pem", server.keyPEM, 0600)).To(Succeed())
+
+		config = S.TLS{CertFile: "/tls/cert.pem", KeyFile: "/tls/key.pem"}
+
+		rootCAs = x509.NewCertPool()
+		rootCAs.AddCert(ca.cert)
+	})
+
+	AfterEach(func() {
+		if listener != nil {
+			listener.Close()
+			listener = nil
+		}
+	})
+
+	// serve accepts connections and completes their handshakes until the listener is closed.
+	serve := func() string {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		tlsListener := tls.NewListener(listener, reloader.Config())
+		go func() {
+			defer GinkgoRecover()
+			for {
+				conn, err := tlsListener.Accept()
+				if err != nil {
+					return
+				}
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+
+		return listener.Addr().String()
+	}
+
+	dial := func(address string, clientCerts ...tls.Certificate) (*tls.Conn, error) {
+		return tls.Dial("tcp", address, &tls.Config{RootCAs: rootCAs, Certificates: clientCerts})
+	}
+
+	Describe("Enabled", func() {
+		It("returns true when a certificate is configured", func() {
+			Expect(Enabled(config)).To(BeTrue())
+			Expect(Enabled(S.TLS{})).To(BeFalse())
+		})
+	})
+
+	Describe("NewReloader", func() {
+		It("returns an error when the certificate cannot be read", func() {
+			config.CertFile = "/tls/missing.pem"
+
+			_, err := NewReloader(config, fs, log)
+
+			Expect(err).To(BeAssignableToTypeOf(CertificateError{}))
+			Expect(err.Error()).To(ContainSubstring("cannot load TLS certificate /tls/missing.pem"))
+		})
+
+		It("returns an error when the key does not match the certificate", func() {
+			Expect(fs.WriteFile("/tls/key.pem", ca.keyPEM, 0600)).To(Succeed())
+
+			_, err := NewReloader(config, fs, log)
+
+			Expect(err).To(BeAssignableToTypeOf(CertificateError{}))
+		})
+
+		It("returns an error when the client CA file has no certificates", func() {
+			Expect(fs.WriteFile("/tls/ca.pem", []byte("not a certificate"), 0644)).To(Succeed())
+			config.ClientCAFile = "/tls/ca.pem"
+
+			_, err := NewReloader(config, fs, log)
+
+			Expect(err).To(MatchError(ClientCAError{"/tls/ca.pem", NoCertificatesError{}}))
+		})
+	})
+
+	Describe("serving TLS", func() {
+		It("serves the certificate with TLS 1.2 or later", func() {
+			var err error
+			reloader, err = NewReloader(config, fs, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			conn, err := dial(serve())
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+
+			state := conn.ConnectionState()
+			Expect(state.Version).To(BeNumerically(">=", tls.VersionTLS12))
+			Expect(state.PeerCertificates[0].Subject.CommonName).To(Equal("server"))
+		})
+
+		It("does not request a client certificate", func() {
+			var err error
+			reloader, err = NewReloader(config, fs, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(reloader.Config().ClientAuth).To(Equal(tls.NoClientCert))
+		})
+	})
+
+	Describe("verifying client certificates", func() {
+		var address string
+
+		BeforeEach(func() {
+			config.ClientCAFile = "/tls/ca.pem"
+			config.ClientAuth = "require"
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			reloader, err = NewReloader(config, fs, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			address = serve()
+		})
+
+		It("accepts a client certificate signed by the client CA", func() {
+			client := newCertificate("client", false, &ca)
+
+			conn, err := dial(address, client.keyPair())
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+
+			Expect(conn.Handshake()).To(Succeed())
+		})
+
+		It("rejects a client certificate signed by another CA", func() {
+			other := newCertificate("other", true, nil)
+			client := newCertificate("client", false, &other)
+
+			Expect(handshake(dial(address, client.keyPair()))).ToNot(Succeed())
+		})
+
+		It("rejects a client without a certificate", func() {
+			Expect(handshake(dial(address))).ToNot(Succeed())
+		})
+
+		Context("when client certificates are verified if given", func() {
+			BeforeEach(func() {
+				config.ClientAuth = "verify_if_given"
+			})
+
+			It("accepts a client without a certificate", func() {
+				Expect(reloader.Config().ClientAuth).To(Equal(tls.VerifyClientCertIfGiven))
+				Expect(handshake(dial(address))).To(Succeed())
+			})
+		})
+	})
+
+	Describe("ClientConfig", func() {
+		BeforeEach(func() {
+			config.ClientCAFile = "/tls/ca.pem"
+
+			var err error
+			reloader, err = NewReloader(config, fs, log)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("verifies the server with the CA file and presents the client certificate", func() {
+			client := newCertificate("client", false, &ca)
+			Expect(fs.WriteFile("/tls/client-cert.pem", client.certPEM, 0644)).To(Succeed())
+			Expect(fs.WriteFile("/tls/client-key.pem", client.keyPEM, 0600)).To(Succeed())
+
+			clientConfig, err := ClientConfig("/tls/ca.pem", "/tls/client-cert.pem", "/tls/client-key.pem", false, fs)
+			Expect(err).ToNot(HaveOccurred())
+
+			conn, err := tls.Dial("tcp", serve(), clientConfig)
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+
+			Expect(conn.Handshake()).To(Succeed())
+		})
+
+		It("does not verify a server signed by another CA without the CA file", func() {
+			clientConfig, err := ClientConfig("", "", "", false, fs)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(handshake(tls.Dial("tcp", serve(), clientConfig))).ToNot(Succeed())
+		})
+
+		It("skips verifying the server", func() {
+			clientConfig, err := ClientConfig("", "", "", true, fs)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(clientConfig.InsecureSkipVerify).To(BeTrue())
+		})
+
+		It("returns an error when the CA file has no certificates", func() {
+			Expect(fs.WriteFile("/tls/bad-ca.pem", []byte("not a certificate"), 0644)).To(Succeed())
+
+			_, err := ClientConfig("/tls/bad-ca.pem", "", "", false, fs)
+
+			Expect(err).To(MatchError(CAError{"/tls/bad-ca.pem", NoCertificatesError{}}))
+		})
+
+		It("returns an error when the client key cannot be read", func() {
+			_, err := ClientConfig("", "/tls/cert.pem", "/tls/missing.pem", false, fs)
+
+			Expect(err).To(BeAssignableToTypeOf(CertificateError{}))
+		})
+	})
+
+	Describe("reloading the certificate", func() {
+		var address string
+
+		BeforeEach(func() {
+			var err error
+			reloader, err = NewReloader(config, fs, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			address = serve()
+		})
+
+		It("serves the new certificate after a reload", func() {
+			renewed := newCertificate("renewed", false, &ca)
+			Expect(fs.WriteFile("/tls/cert.pem", renewed.certPEM, 0644)).To(Succeed())
+			Expect(fs.WriteFile("/tls/key.pem", renewed.keyPEM, 0600)).To(Succeed())
+
+			Expect(reloader.Reload()).To(Succeed())
+
+			conn, err := dial(address)
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+			Expect(conn.ConnectionState().PeerCertificates[0].Subject.CommonName).To(Equal("renewed"))
+		})
+
+		It("keeps the current certificate when the new one cannot be loaded", func() {
+			Expect(fs.WriteFile("/tls/cert.pem", []byte("not a certificate"), 0644)).To(Succeed())
+
+			Expect(reloader.Reload()).To(BeAssignableToTypeOf(CertificateError{}))
+
+			conn, err := dial(address)
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+			Expect(conn.ConnectionState().PeerCertificates[0].Subject.CommonName).To(Equal("server"))
+		})
+
+		It("reloads the certificate when the files change", func() {
+			stop := make(chan struct{})
+			defer close(stop)
+			go reloader.Watch(10*time.Millisecond, stop)
+
+			renewed := newCertificate("renewed", false, &ca)
+			Expect(fs.WriteFile("/tls/cert.pem", renewed.certPEM, 0644)).To(Succeed())
+			Expect(fs.WriteFile("/tls/key.pem", renewed.keyPEM, 0600)).To(Succeed())
+			later := time.Now().Add(time.Minute)
+			Expect(fs.Chtimes("/tls/key.pem", later, later)).To(Succeed())
+
+			Eventually(func() string {
+				cert, _ := reloader.GetCertificate(nil)
+				parsed, _ := x509.ParseCertificate(cert.Certificate[0])
+				return parsed.Subject.CommonName
+			}).Should(Equal("renewed"))
+		})
+	})
+})
+
+// handshake completes the handshake of a connection and reads from it, so that a client
+// certificate rejected by the server surfaces as an error.
+func handshake(conn *tls.Conn, err error) error {
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = conn.Handshake()
+	if err != nil {
+		return err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}